@@ -26,4 +26,6 @@ type Account struct {
 	LastUsedSlyWallet string
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
+	TokensRevokedAt   *time.Time
+	DeletedAt         *time.Time
 }
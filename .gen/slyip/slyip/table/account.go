@@ -30,6 +30,8 @@ type accountTable struct {
 	LastUsedSlyWallet postgres.ColumnString
 	CreatedAt         postgres.ColumnTimestampz
 	UpdatedAt         postgres.ColumnTimestampz
+	TokensRevokedAt   postgres.ColumnTimestampz
+	DeletedAt         postgres.ColumnTimestampz
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -83,8 +85,10 @@ func newAccountTableImpl(schemaName, tableName, alias string) accountTable {
 		LastUsedSlyWalletColumn = postgres.StringColumn("last_used_sly_wallet")
 		CreatedAtColumn         = postgres.TimestampzColumn("created_at")
 		UpdatedAtColumn         = postgres.TimestampzColumn("updated_at")
-		allColumns              = postgres.ColumnList{IDColumn, FirstNameColumn, LastNameColumn, PhoneColumn, EmailColumn, IsEmailVerifiedColumn, IsPhoneVerifiedColumn, PasswordHashedColumn, InvitationCodeColumn, RoleColumn, LastUsedSlyWalletColumn, CreatedAtColumn, UpdatedAtColumn}
-		mutableColumns          = postgres.ColumnList{FirstNameColumn, LastNameColumn, PhoneColumn, EmailColumn, IsEmailVerifiedColumn, IsPhoneVerifiedColumn, PasswordHashedColumn, InvitationCodeColumn, RoleColumn, LastUsedSlyWalletColumn, CreatedAtColumn, UpdatedAtColumn}
+		TokensRevokedAtColumn   = postgres.TimestampzColumn("tokens_revoked_at")
+		DeletedAtColumn         = postgres.TimestampzColumn("deleted_at")
+		allColumns              = postgres.ColumnList{IDColumn, FirstNameColumn, LastNameColumn, PhoneColumn, EmailColumn, IsEmailVerifiedColumn, IsPhoneVerifiedColumn, PasswordHashedColumn, InvitationCodeColumn, RoleColumn, LastUsedSlyWalletColumn, CreatedAtColumn, UpdatedAtColumn, TokensRevokedAtColumn, DeletedAtColumn}
+		mutableColumns          = postgres.ColumnList{FirstNameColumn, LastNameColumn, PhoneColumn, EmailColumn, IsEmailVerifiedColumn, IsPhoneVerifiedColumn, PasswordHashedColumn, InvitationCodeColumn, RoleColumn, LastUsedSlyWalletColumn, CreatedAtColumn, UpdatedAtColumn, TokensRevokedAtColumn, DeletedAtColumn}
 	)
 
 	return accountTable{
@@ -104,6 +108,8 @@ func newAccountTableImpl(schemaName, tableName, alias string) accountTable {
 		LastUsedSlyWallet: LastUsedSlyWalletColumn,
 		CreatedAt:         CreatedAtColumn,
 		UpdatedAt:         UpdatedAtColumn,
+		TokensRevokedAt:   TokensRevokedAtColumn,
+		DeletedAt:         DeletedAtColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
@@ -5,10 +5,12 @@ import (
 	"github.com/spf13/cobra"
 	"log"
 	"yip/pkg"
+	"yip/src/api/auth/verifier"
 	"yip/src/api/services/dto"
 )
 
 func init() {
+	loginCmd.Flags().String("mfa-code", "", "TOTP or recovery code if a second factor is required")
 	rootCmd.AddCommand(loginCmd)
 }
 
@@ -26,18 +28,45 @@ var loginCmd = &cobra.Command{
 
 		c := pkg.NewApiClient(fmt.Sprintf("%s/%s", location, "api/v1"))
 
-		_, token, err := c.SignIn(dto.SignInRequest{
+		token := signIn(cmd, &c, dto.SignInRequest{
 			Email:    email,
 			Password: userPassword,
 			Audiences: []string{
 				audience,
 			},
 		})
-
-		if err != nil {
-			log.Fatalln(err)
-		}
 		fmt.Println(token.IdToken)
 		fmt.Println(token.RefreshToken)
 	},
 }
+
+// signIn signs in with the request and verifies the challenge of the second
+// factor with the --mfa-code flag if one is required
+func signIn(cmd *cobra.Command, c *pkg.ApiClient, request dto.SignInRequest) *verifier.Token {
+	_, response, err := c.SignIn(request)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if response.MFA == nil {
+		return response.Token
+	}
+	if response.MFA.EnrollmentRequired {
+		log.Fatalln("a second factor is required, enroll it with the challenge first")
+	}
+
+	code, _ := cmd.Flags().GetString("mfa-code")
+	if code == "" {
+		log.Fatalln("a second factor is required, pass --mfa-code")
+	}
+	verify := dto.MFAVerifyDTO{Challenge: response.MFA.Challenge}
+	if len(code) == 6 {
+		verify.Code = code
+	} else {
+		verify.RecoveryCode = code
+	}
+	_, verified, err := c.VerifyMFAChallenge(verify)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return verified.Token
+}
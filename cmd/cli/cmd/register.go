@@ -9,6 +9,7 @@ import (
 )
 
 func init() {
+	registerCmd.Flags().String("mfa-code", "", "TOTP or recovery code if a second factor is required")
 	rootCmd.AddCommand(registerCmd)
 }
 
@@ -26,7 +27,7 @@ var registerCmd = &cobra.Command{
 
 		c := pkg.NewApiClient(fmt.Sprintf("%s/%s", location, "api/v1"))
 
-		_, token, err := c.SignIn(dto.SignInRequest{
+		token := signIn(cmd, &c, dto.SignInRequest{
 			Email:    "leonard.schellenberg@gmail.com",
 			Password: password,
 			Audiences: []string{
@@ -34,10 +35,6 @@ var registerCmd = &cobra.Command{
 			},
 		})
 
-		if err != nil {
-			log.Fatalln(err)
-		}
-
 		c.SetToken(token.IdToken)
 
 		_, user, err := c.RegisterUser(dto.RegisterRequest{
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"yip/pkg/session"
+	"yip/src/config"
+	"yip/src/cryptox"
+)
+
+func init() {
+	rootCmd.AddCommand(sessionLoginCmd)
+}
+
+var sessionLoginCmd = &cobra.Command{
+	Use:   "session-login",
+	Short: "Sign in to a session as wallet",
+	Args:  cobra.ExactArgs(3),
+	Long:  `Signs in to the session of the QR content with a keystore wallet: session-login <wallet file> <password file> <qr content>`,
+	Run: func(cmd *cobra.Command, args []string) {
+		location := "https://auth.singularry.xyz"
+
+		password, err := cryptox.ReadPasswordFile(args[1])
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		key, err := cryptox.KeyFromWalletAndPasswordFile(args[0], password)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		qr, err := session.ParseQRContent(args[2])
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		c := session.NewClient(fmt.Sprintf("%s/%s", location, "api/v1"))
+
+		claims, err := c.VerifyQRContent(qr)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("Sign in to %s (%s)\n", claims.Label, claims.Domain)
+
+		w := c.NewWallet(key, "")
+		w.ConfirmCode = func(mode string, choices []string) (string, error) {
+			var answer string
+			if mode == config.NumberMatchingConfirm {
+				fmt.Printf("Does the dApp show %s? [y/N] ", choices[0])
+				if _, err := fmt.Scanln(&answer); err != nil || answer != "y" {
+					return "", fmt.Errorf("code not confirmed")
+				}
+				return choices[0], nil
+			}
+
+			fmt.Printf("Pick the code shown by the dApp %v: ", choices)
+			_, err := fmt.Scanln(&answer)
+			return answer, err
+		}
+
+		result, err := w.Login(qr)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println(result.RecoveredAddress)
+	},
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	rootCmd.AddCommand(sessionsCmd)
+	sessionsCmd.AddCommand(sessionsCloseCmd)
+
+	sessionsCmd.Flags().String("type", "", "session type, e.g. auth_session")
+	sessionsCmd.Flags().String("client", "", "client id")
+	sessionsCmd.Flags().String("state", "", "state, e.g. connected")
+	sessionsCmd.Flags().String("eoa", "", "address of the wallet")
+	sessionsCmd.Flags().Int64("min-age", 0, "minimum age in seconds")
+	sessionsCmd.Flags().Bool("all", false, "list ended and closed sessions as well")
+}
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Lists the live sessions",
+	Args:  cobra.ExactArgs(0),
+	Long:  `Lists the sessions held by YIP, the oldest first. Only active sessions are listed unless --all is set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		q := url.Values{}
+		for flag, param := range map[string]string{"type": "type", "client": "clientId", "state": "state", "eoa": "eoa"} {
+			if v, _ := cmd.Flags().GetString(flag); v != "" {
+				q.Set(param, v)
+			}
+		}
+		if v, _ := cmd.Flags().GetInt64("min-age"); v > 0 {
+			q.Set("minAge", fmt.Sprintf("%d", v))
+		}
+		if v, _ := cmd.Flags().GetBool("all"); v {
+			q.Set("all", "true")
+		}
+
+		cc, err := PrepareApiClient()
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		_, infos, err := cc.apiClient.AdminSessions(q)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		for _, i := range infos {
+			fmt.Printf("%s %-22s %-10s %6ds client=%s eoa=%s parties=%s\n",
+				ConsoleInBlue(i.SessionId), i.SessionType, i.State, i.AgeInSec, i.ClientId, i.EOA, strings.Join(i.Parties, ","))
+		}
+		fmt.Printf("%d sessions\n", len(infos))
+	},
+}
+
+var sessionsCloseCmd = &cobra.Command{
+	Use:   "close",
+	Short: "Force-closes a session",
+	Args:  cobra.ExactArgs(2),
+	Long:  `Closes a session, an open flow is cancelled: sessions close <session id> <reason>`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cc, err := PrepareApiClient()
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		_, i, err := cc.apiClient.AdminCloseSession(args[0], args[1])
+		if err != nil {
+			log.Fatalln(err)
+		}
+		ColoredPrintln("Session", i.SessionId)
+		ColoredPrintln("State", i.State)
+	},
+}
@@ -1,11 +1,14 @@
 package e2e
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 	"yip/e2e/samples"
+	sessionclient "yip/pkg/session"
 	"yip/src/api/auth/session"
 	"yip/src/api/services/dto"
 	"yip/src/cryptox"
@@ -131,3 +134,36 @@ func printJson(o interface{}) {
 
 	fmt.Println(string(b))
 }
+
+func TestSessionClient(t *testing.T) {
+	c := sessionclient.NewClient(url)
+
+	dApp, err := c.CreateAuthSession(clientId)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	qr, err := sessionclient.ParseQRContent(dApp.QRCodeContent)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = c.NewWallet(userWallet, "").Login(qr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := dApp.AwaitToken(ctx)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.NotEmpty(t, token.IdToken)
+	assert.NoError(t, dApp.Close())
+}
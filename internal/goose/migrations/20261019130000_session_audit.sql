@@ -0,0 +1,19 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+create table slyip.session_audit
+(
+    id           uuid primary key         not null default gen_random_uuid(),
+    session_id   uuid                     not null,
+    session_type varchar(255)             not null,
+    outcome      varchar(255)             not null,
+    party        varchar(255)             not null default '',
+    reason       text                     not null default '',
+    eoa          varchar(255)             not null default '',
+    created_at   timestamp with time zone not null default now()
+);
+
+create index idx_session_audit_session_id on slyip.session_audit (session_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.session_audit;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+create table slyip.push_token
+(
+    token         varchar(1024)            primary key not null,
+    ecdsa_address varchar(255)             not null,
+    account_id    uuid                     not null,
+    platform      varchar(255)             not null,
+    constraint FK_ecdsa foreign key (ecdsa_address) references slyip.ecdsa (address),
+    constraint FK_acc foreign key (account_id) references slyip.account (id),
+    created_at    timestamp with time zone not null default now(),
+    updated_at    timestamp with time zone not null default now()
+);
+
+create index idx_push_token_account_id on slyip.push_token (account_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.push_token;
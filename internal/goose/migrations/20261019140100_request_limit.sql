@@ -0,0 +1,13 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+create table slyip.request_limit
+(
+    subject      varchar(255)             not null,
+    window_start timestamp with time zone not null,
+    requests     integer                  not null default 0,
+    primary key (subject, window_start)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.request_limit;
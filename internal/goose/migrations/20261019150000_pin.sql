@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+create table slyip.pin
+(
+    pin_hash      varchar(255) primary key not null,
+    account_id    uuid                     not null,
+    email         varchar(255)             not null,
+    ecdsa_pub_key varchar(255)             not null,
+    constraint FK_acc foreign key (account_id) references slyip.account (id),
+    expires_at    timestamp with time zone not null,
+    created_at    timestamp with time zone not null default now()
+);
+
+create index idx_pin_expires_at on slyip.pin (expires_at);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.pin;
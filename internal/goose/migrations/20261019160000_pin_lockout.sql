@@ -0,0 +1,21 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+alter table slyip.pin
+    add column failed_attempts integer not null default 0;
+
+create index idx_pin_ecdsa_pub_key on slyip.pin (ecdsa_pub_key);
+
+create table slyip.pin_lockout
+(
+    subject         varchar(255) primary key not null,
+    failed_attempts integer                  not null default 0,
+    locked_until    timestamp with time zone,
+    updated_at      timestamp with time zone not null default now()
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.pin_lockout;
+drop index slyip.idx_pin_ecdsa_pub_key;
+alter table slyip.pin
+    drop column failed_attempts;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+alter table slyip.pin
+    add column channel varchar(16) not null default 'email',
+    add column phone   varchar(32) not null default '';
+
+-- a phone pin signs in to the account that verified the phone, so a verified
+-- phone belongs to one account at most
+create unique index idx_account_verified_phone on slyip.account (phone) where is_phone_verified and phone <> '';
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop index slyip.idx_account_verified_phone;
+
+alter table slyip.pin
+    drop column channel,
+    drop column phone;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+alter table slyip.pin
+    add column link_hash varchar(255) not null default '';
+
+create index idx_pin_link_hash on slyip.pin (link_hash) where link_hash <> '';
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop index slyip.idx_pin_link_hash;
+alter table slyip.pin
+    drop column link_hash;
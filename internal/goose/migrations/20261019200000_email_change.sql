@@ -0,0 +1,24 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+create table slyip.email_change
+(
+    id              uuid primary key         not null default gen_random_uuid(),
+    account_id      uuid                     not null,
+    old_email       varchar(255),
+    new_email       varchar(255)             not null,
+    code_hash       varchar(64),
+    token_hash      varchar(64),
+    failed_attempts integer                  not null default 0,
+    expires_at      timestamp with time zone not null,
+    confirmed_at    timestamp with time zone,
+    changed_by      varchar(255),
+    created_at      timestamp with time zone not null default now(),
+    constraint FK_acc foreign key (account_id) references slyip.account (id) on delete cascade
+);
+
+create index idx_email_change_account_id on slyip.email_change (account_id);
+create unique index idx_email_change_token_hash on slyip.email_change (token_hash) where token_hash is not null;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.email_change;
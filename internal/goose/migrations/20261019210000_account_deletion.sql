@@ -0,0 +1,25 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+alter table slyip.account
+    add column tokens_revoked_at timestamp with time zone,
+    add column deleted_at        timestamp with time zone;
+
+-- the audit trail of a deletion, it outlives the account and holds no personal data
+create table slyip.account_deletion
+(
+    id           uuid primary key         not null default gen_random_uuid(),
+    account_id   uuid                     not null unique,
+    requested_by varchar(255)             not null default '',
+    requested_at timestamp with time zone not null default now(),
+    purge_after  timestamp with time zone not null,
+    purged_at    timestamp with time zone
+);
+
+create index idx_account_deletion_purge_after on slyip.account_deletion (purge_after) where purged_at is null;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.account_deletion;
+alter table slyip.account
+    drop column tokens_revoked_at,
+    drop column deleted_at;
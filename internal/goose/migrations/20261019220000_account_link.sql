@@ -0,0 +1,26 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- a link between two accounts of one person, account_id started it with a code
+-- linked_account_id confirmed. The accounts are not referenced, merged accounts are purged.
+create table slyip.account_link
+(
+    id                uuid primary key         not null default gen_random_uuid(),
+    account_id        uuid                     not null,
+    linked_account_id uuid,
+    code_hash         varchar(255)             not null unique,
+    status            varchar(16)              not null default 'pending',
+    expires_at        timestamp with time zone not null,
+    confirmed_at      timestamp with time zone,
+    merged_into       uuid,
+    merged_by         varchar(255)             not null default '',
+    merged_at         timestamp with time zone,
+    created_at        timestamp with time zone not null default now()
+);
+
+create index idx_account_link_account_id on slyip.account_link (account_id);
+create index idx_account_link_linked_account_id on slyip.account_link (linked_account_id);
+create index idx_account_link_status on slyip.account_link (status);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.account_link;
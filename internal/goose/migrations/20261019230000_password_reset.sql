@@ -0,0 +1,20 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+create table slyip.password_reset
+(
+    id           uuid primary key         not null default gen_random_uuid(),
+    account_id   uuid                     not null,
+    token_hash   varchar(64)              not null,
+    expires_at   timestamp with time zone not null,
+    used_at      timestamp with time zone,
+    requested_ip varchar(64),
+    created_at   timestamp with time zone not null default now(),
+    constraint FK_acc foreign key (account_id) references slyip.account (id) on delete cascade
+);
+
+create index idx_password_reset_account_id on slyip.password_reset (account_id);
+create unique index idx_password_reset_token_hash on slyip.password_reset (token_hash);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.password_reset;
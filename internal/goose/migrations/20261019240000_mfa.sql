@@ -0,0 +1,45 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- subject is the id of an account or of the YIP admin, the admin has no account row
+create table slyip.mfa_totp
+(
+    subject        varchar(255) primary key not null,
+    secret         text                     not null,
+    confirmed_at   timestamp with time zone,
+    last_used_step bigint                   not null default 0,
+    created_at     timestamp with time zone not null default now(),
+    updated_at     timestamp with time zone not null default now()
+);
+
+create table slyip.mfa_recovery_code
+(
+    id         uuid primary key         not null default gen_random_uuid(),
+    subject    varchar(255)             not null,
+    code_hash  varchar(64)              not null,
+    used_at    timestamp with time zone,
+    created_at timestamp with time zone not null default now()
+);
+
+create unique index idx_mfa_recovery_code_subject_code_hash on slyip.mfa_recovery_code (subject, code_hash);
+
+create table slyip.mfa_challenge
+(
+    id              uuid primary key         not null default gen_random_uuid(),
+    subject         varchar(255)             not null,
+    token_hash      varchar(64)              not null,
+    role            varchar(64)              not null,
+    audiences       text                     not null,
+    failed_attempts integer                  not null default 0,
+    expires_at      timestamp with time zone not null,
+    used_at         timestamp with time zone,
+    created_at      timestamp with time zone not null default now()
+);
+
+create unique index idx_mfa_challenge_token_hash on slyip.mfa_challenge (token_hash);
+create index idx_mfa_challenge_subject on slyip.mfa_challenge (subject);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.mfa_challenge;
+drop table slyip.mfa_recovery_code;
+drop table slyip.mfa_totp;
@@ -0,0 +1,41 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- credential_id and public_key (COSE) are base64url encoded without padding
+create table slyip.webauthn_credential
+(
+    id            uuid primary key         not null default gen_random_uuid(),
+    account_id    uuid                     not null,
+    credential_id varchar(1024)            not null,
+    public_key    text                     not null,
+    algorithm     integer                  not null,
+    sign_count    bigint                   not null default 0,
+    aaguid        varchar(36)              not null,
+    label         varchar(255)             not null,
+    created_at    timestamp with time zone not null default now(),
+    last_used_at  timestamp with time zone,
+    constraint FK_acc foreign key (account_id) references slyip.account (id) on delete cascade
+);
+
+create unique index idx_webauthn_credential_credential_id on slyip.webauthn_credential (credential_id);
+create index idx_webauthn_credential_account_id on slyip.webauthn_credential (account_id);
+
+-- account_id is null for login challenges, the account is known from the credential
+create table slyip.webauthn_challenge
+(
+    id             uuid primary key         not null default gen_random_uuid(),
+    challenge_hash varchar(64)              not null,
+    account_id     uuid,
+    purpose        varchar(32)              not null,
+    audiences      text                     not null,
+    expires_at     timestamp with time zone not null,
+    used_at        timestamp with time zone,
+    created_at     timestamp with time zone not null default now(),
+    constraint FK_acc foreign key (account_id) references slyip.account (id) on delete cascade
+);
+
+create unique index idx_webauthn_challenge_challenge_hash on slyip.webauthn_challenge (challenge_hash);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.webauthn_challenge;
+drop table slyip.webauthn_credential;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- account_id is null for pins of an email resp. phone without account, the
+-- account is created when the pin is redeemed
+alter table slyip.pin
+    alter column account_id drop not null;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+delete from slyip.pin where account_id is null;
+alter table slyip.pin
+    alter column account_id set not null;
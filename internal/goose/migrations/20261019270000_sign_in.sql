@@ -0,0 +1,19 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- sign-ins with pin, SIWE, password and passkey, QR sessions are recorded in
+-- slyip.session_audit
+create table slyip.sign_in
+(
+    id         uuid primary key         not null default gen_random_uuid(),
+    account_id uuid                     not null,
+    method     varchar(16)              not null,
+    eoa        varchar(255)             not null default '',
+    created_at timestamp with time zone not null default now(),
+    constraint FK_acc foreign key (account_id) references slyip.account (id) on delete cascade
+);
+
+create index idx_sign_in_account_id on slyip.sign_in (account_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+drop table slyip.sign_in;
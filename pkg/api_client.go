@@ -2,7 +2,9 @@ package pkg
 
 import (
 	"fmt"
+	"net/url"
 	info2 "yip/src/api/admin/info"
+	"yip/src/api/admin/sessions"
 	"yip/src/api/auth/pin"
 	"yip/src/api/auth/session"
 	"yip/src/api/auth/verifier"
@@ -84,12 +86,101 @@ func (c *ApiClient) GetCodes() (statusCode int, response *repo.PaginatedResponse
 	statusCode, err = c.httpClient.Get(response, c.token, "admin/info/codes")
 	return
 }
-func (c *ApiClient) SignIn(body dto.SignInRequest) (statusCode int, response *verifier.Token, err error) {
-	response = &verifier.Token{}
+
+func (c *ApiClient) AdminSessions(query url.Values) (statusCode int, response []session.SessionInfo, err error) {
+	statusCode, err = c.httpClient.Get(&response, c.token, "admin/sessions?"+query.Encode())
+	return
+}
+
+func (c *ApiClient) AdminCloseSession(sessionId string, reason string) (statusCode int, response *session.SessionInfo, err error) {
+	response = &session.SessionInfo{}
+	statusCode, err = c.httpClient.Post(sessions.CloseSessionRequestDTO{Reason: reason}, response, c.token, fmt.Sprintf("admin/sessions/%s/close", sessionId))
+	return
+}
+
+func (c *ApiClient) SignIn(body dto.SignInRequest) (statusCode int, response *dto.SignInResponse, err error) {
+	response = &dto.SignInResponse{}
 	statusCode, err = c.httpClient.Post(body, response, c.token, "admin/accounts/token")
 	return
 }
 
+func (c *ApiClient) EnrollMFAChallenge(body dto.MFAChallengeDTO) (statusCode int, response *dto.TOTPEnrollmentResponse, err error) {
+	response = &dto.TOTPEnrollmentResponse{}
+	statusCode, err = c.httpClient.Post(body, response, "", "auth/mfa/challenge/enroll")
+	return
+}
+
+func (c *ApiClient) VerifyMFAChallenge(body dto.MFAVerifyDTO) (statusCode int, response *dto.MFAVerifyResponse, err error) {
+	response = &dto.MFAVerifyResponse{}
+	statusCode, err = c.httpClient.Post(body, response, "", "auth/mfa/challenge/verify")
+	return
+}
+
+func (c *ApiClient) GetMFAStatus() (statusCode int, response *dto.MFAStatusResponse, err error) {
+	response = &dto.MFAStatusResponse{}
+	statusCode, err = c.httpClient.Get(response, c.token, "auth/mfa")
+	return
+}
+
+func (c *ApiClient) StartTOTPEnrollment() (statusCode int, response *dto.TOTPEnrollmentResponse, err error) {
+	response = &dto.TOTPEnrollmentResponse{}
+	statusCode, err = c.httpClient.Post(struct{}{}, response, c.token, "auth/mfa/totp")
+	return
+}
+
+func (c *ApiClient) ConfirmTOTPEnrollment(body dto.TOTPCodeDTO) (statusCode int, response *dto.RecoveryCodesResponse, err error) {
+	response = &dto.RecoveryCodesResponse{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/mfa/totp/confirm")
+	return
+}
+
+func (c *ApiClient) DisableMFA(body dto.MFACodeDTO) (statusCode int, err error) {
+	return c.httpClient.Post(body, nil, c.token, "auth/mfa/totp/disable")
+}
+
+func (c *ApiClient) RegenerateRecoveryCodes(body dto.TOTPCodeDTO) (statusCode int, response *dto.RecoveryCodesResponse, err error) {
+	response = &dto.RecoveryCodesResponse{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/mfa/recovery-codes")
+	return
+}
+
+func (c *ApiClient) ResetMFA(accountId string) (statusCode int, err error) {
+	return c.httpClient.Delete(nil, c.token, fmt.Sprintf("admin/accounts/%s/mfa", accountId))
+}
+
+func (c *ApiClient) BeginWebAuthnLogin(body dto.WebAuthnLoginBeginDTO) (statusCode int, response *dto.WebAuthnRequestOptions, err error) {
+	response = &dto.WebAuthnRequestOptions{}
+	statusCode, err = c.httpClient.Post(body, response, "", "auth/webauthn/login/begin")
+	return
+}
+
+func (c *ApiClient) FinishWebAuthnLogin(body dto.WebAuthnLoginDTO) (statusCode int, response *verifier.Token, err error) {
+	response = &verifier.Token{}
+	statusCode, err = c.httpClient.Post(body, response, "", "auth/webauthn/login/finish")
+	return
+}
+
+func (c *ApiClient) BeginWebAuthnRegistration() (statusCode int, response *dto.WebAuthnCreationOptions, err error) {
+	response = &dto.WebAuthnCreationOptions{}
+	statusCode, err = c.httpClient.Post(struct{}{}, response, c.token, "auth/webauthn/register/begin")
+	return
+}
+
+func (c *ApiClient) FinishWebAuthnRegistration(body dto.WebAuthnRegistrationDTO) (statusCode int, response *dto.WebAuthnCredentialResponse, err error) {
+	response = &dto.WebAuthnCredentialResponse{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/webauthn/register/finish")
+	return
+}
+
+func (c *ApiClient) GetWebAuthnCredentials() (statusCode int, response []dto.WebAuthnCredentialResponse, err error) {
+	statusCode, err = c.httpClient.Get(&response, c.token, "auth/webauthn/credentials")
+	return
+}
+
+func (c *ApiClient) DeleteWebAuthnCredential(id string) (statusCode int, err error) {
+	return c.httpClient.Delete(nil, c.token, fmt.Sprintf("auth/webauthn/credentials/%s", id))
+}
+
 func (c *ApiClient) RegisterUser(body dto.RegisterRequest) (statusCode int, response *repo.AccountModel, err error) {
 	response = &repo.AccountModel{}
 	statusCode, err = c.httpClient.Post(body, response, c.token, "admin/accounts/register")
@@ -114,12 +205,130 @@ func (c *ApiClient) GetUser(accountId string) (statusCode int, response *reposit
 	return
 }
 
-func (c *ApiClient) SetEmail(body dto.SetEmailRequest) (statusCode int, response *repositories.UserAccount, err error) {
-	response = &repositories.UserAccount{}
+func (c *ApiClient) SetEmail(body dto.SetEmailRequest) (statusCode int, response *repo.AccountModel, err error) {
+	response = &repo.AccountModel{}
 	statusCode, err = c.httpClient.Put(body, response, c.token, "admin/accounts/email")
 	return
 }
 
+func (c *ApiClient) GetEmailChanges(accountId string) (statusCode int, response []repo.EmailChangeModel, err error) {
+	response = []repo.EmailChangeModel{}
+	statusCode, err = c.httpClient.Get(&response, c.token, fmt.Sprintf("admin/accounts/%s/email/changes", accountId))
+	return
+}
+
+func (c *ApiClient) RequestEmailChange(body dto.EmailChangeRequestDTO) (statusCode int, response *dto.EmailChangeResponse, err error) {
+	response = &dto.EmailChangeResponse{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/email/change")
+	return
+}
+
+func (c *ApiClient) ConfirmEmailChange(body dto.EmailChangeConfirmDTO) (statusCode int, response *repo.AccountModel, err error) {
+	response = &repo.AccountModel{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/email/change/confirm")
+	return
+}
+
+func (c *ApiClient) ConfirmEmailChangeLink(body dto.EmailChangeConfirmLinkDTO) (statusCode int, response *repo.AccountModel, err error) {
+	response = &repo.AccountModel{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/email/change/confirm-link")
+	return
+}
+
+func (c *ApiClient) ForgotPassword(body dto.PasswordForgotDTO) (statusCode int, response *dto.PasswordForgotResponse, err error) {
+	response = &dto.PasswordForgotResponse{}
+	statusCode, err = c.httpClient.Post(body, response, "", "auth/password/forgot")
+	return
+}
+
+func (c *ApiClient) ResetPassword(body dto.PasswordResetDTO) (statusCode int, err error) {
+	return c.httpClient.Post(body, nil, "", "auth/password/reset")
+}
+
+func (c *ApiClient) ChangePassword(body dto.PasswordChangeDTO) (statusCode int, response *verifier.Token, err error) {
+	response = &verifier.Token{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/password/change")
+	return
+}
+
+func (c *ApiClient) GetMe() (statusCode int, response *repo.AccountModel, err error) {
+	response = &repo.AccountModel{}
+	statusCode, err = c.httpClient.Get(response, c.token, "me")
+	return
+}
+
+func (c *ApiClient) PatchMe(body dto.ProfilePatchDTO) (statusCode int, response *repo.AccountModel, err error) {
+	response = &repo.AccountModel{}
+	statusCode, err = c.httpClient.Patch(body, response, c.token, "me")
+	return
+}
+
+func (c *ApiClient) GetMyDevices() (statusCode int, response []repo.EcdsaModel, err error) {
+	response = []repo.EcdsaModel{}
+	statusCode, err = c.httpClient.Get(&response, c.token, "me/devices")
+	return
+}
+
+func (c *ApiClient) RemoveMyDevice(address string) (statusCode int, err error) {
+	return c.httpClient.Delete(nil, c.token, fmt.Sprintf("me/devices/%s", address))
+}
+
+func (c *ApiClient) GetMyWallets() (statusCode int, response []repo.SlyWalletModel, err error) {
+	response = []repo.SlyWalletModel{}
+	statusCode, err = c.httpClient.Get(&response, c.token, "me/wallets")
+	return
+}
+
+func (c *ApiClient) RemoveMyWallet(address string) (statusCode int, err error) {
+	return c.httpClient.Delete(nil, c.token, fmt.Sprintf("me/wallets/%s", address))
+}
+
+func (c *ApiClient) ExportMe() (statusCode int, response *dto.AccountExport, err error) {
+	response = &dto.AccountExport{}
+	statusCode, err = c.httpClient.Get(response, c.token, "me/export")
+	return
+}
+
+func (c *ApiClient) DeleteMe() (statusCode int, response *repo.AccountDeletionModel, err error) {
+	response = &repo.AccountDeletionModel{}
+	statusCode, err = c.httpClient.Delete(response, c.token, "me")
+	return
+}
+
+func (c *ApiClient) CreateAccountLink() (statusCode int, response *dto.AccountLinkResponse, err error) {
+	response = &dto.AccountLinkResponse{}
+	statusCode, err = c.httpClient.Post(struct{}{}, response, c.token, "me/links")
+	return
+}
+
+func (c *ApiClient) ConfirmAccountLink(body dto.AccountLinkConfirmDTO) (statusCode int, response *repo.AccountLinkModel, err error) {
+	response = &repo.AccountLinkModel{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, "me/links/confirm")
+	return
+}
+
+func (c *ApiClient) GetMyAccountLinks() (statusCode int, response []repo.AccountLinkModel, err error) {
+	response = []repo.AccountLinkModel{}
+	statusCode, err = c.httpClient.Get(&response, c.token, "me/links")
+	return
+}
+
+func (c *ApiClient) CancelAccountLink(linkId string) (statusCode int, err error) {
+	return c.httpClient.Delete(nil, c.token, fmt.Sprintf("me/links/%s", linkId))
+}
+
+func (c *ApiClient) GetAccountLinks(status string) (statusCode int, response []repo.AccountLinkModel, err error) {
+	response = []repo.AccountLinkModel{}
+	statusCode, err = c.httpClient.Get(&response, c.token, fmt.Sprintf("admin/accounts/links?status=%s", url.QueryEscape(status)))
+	return
+}
+
+func (c *ApiClient) MergeAccounts(linkId string, body dto.AccountMergeDTO) (statusCode int, response *repo.AccountModel, err error) {
+	response = &repo.AccountModel{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, fmt.Sprintf("admin/accounts/links/%s/merge", linkId))
+	return
+}
+
 func (c *ApiClient) RequestPin(body pin.PinRequestDTO) (statusCode int, response *pin.PinRequestResponse, err error) {
 	response = &pin.PinRequestResponse{}
 	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/pin")
@@ -132,12 +341,41 @@ func (c *ApiClient) RedeemPin(body pin.PinRedeemDTO) (statusCode int, response *
 	return
 }
 
+func (c *ApiClient) RedeemPinLink(body pin.PinLinkRedeemDTO) (statusCode int, response *verifier.Token, err error) {
+	response = &verifier.Token{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/pin/link/redeem")
+	return
+}
+
+func (c *ApiClient) RequestPhonePin(body pin.PhonePinRequestDTO) (statusCode int, response *pin.PhonePinRequestResponse, err error) {
+	response = &pin.PhonePinRequestResponse{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/pin/phone")
+	return
+}
+
+func (c *ApiClient) RedeemPhonePin(body pin.PhonePinRedeemDTO) (statusCode int, response *verifier.Token, err error) {
+	response = &verifier.Token{}
+	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/pin/phone/redeem")
+	return
+}
+
 func (c *ApiClient) ListPins() (statusCode int, response []pin.Pin, err error) {
 	response = []pin.Pin{}
 	statusCode, err = c.httpClient.Get(response, c.token, "admin/pins")
 	return
 }
 
+func (c *ApiClient) ListPinLockouts() (statusCode int, response []pin.Lockout, err error) {
+	response = []pin.Lockout{}
+	statusCode, err = c.httpClient.Get(&response, c.token, "admin/accounts/pins/lockouts")
+	return
+}
+
+func (c *ApiClient) ClearPinLockout(subject string) (statusCode int, err error) {
+	statusCode, err = c.httpClient.Post(pin.ClearLockoutDTO{Subject: subject}, &EmptyBody{}, c.token, "admin/accounts/pins/lockouts/clear")
+	return
+}
+
 // //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 //
 //	Auth Endpoints
@@ -160,6 +398,11 @@ func (c *ApiClient) VerifyToken(idToken string) (statusCode int, response *verif
 	return
 }
 
+func (c *ApiClient) RegisterPushToken(body dto.PushTokenRequestDTO) (statusCode int, err error) {
+	statusCode, err = c.httpClient.Post(body, &EmptyBody{}, c.token, "auth/push/token")
+	return
+}
+
 func (c *ApiClient) Session(body *session.WebsocketMessage) (statusCode int, response *session.WebsocketMessage, err error) {
 	response = &session.WebsocketMessage{}
 	statusCode, err = c.httpClient.Post(body, response, c.token, "auth/session")
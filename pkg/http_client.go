@@ -69,6 +69,10 @@ func (c *HttpClient) Post(body interface{}, response interface{}, token string,
 		return res.StatusCode, fmt.Errorf("%s", b)
 	}
 
+	if res.StatusCode == http.StatusNoContent {
+		return res.StatusCode, nil
+	}
+
 	err = json.Unmarshal(b, response)
 
 	if err != nil {
@@ -159,6 +163,88 @@ func (c *HttpClient) Get(response interface{}, token string, path string) (int,
 	return res.StatusCode, nil
 }
 
+func (c *HttpClient) Patch(body interface{}, response interface{}, token string, path string) (int, error) {
+	buffer, err := json.Marshal(body)
+
+	if err != nil {
+		return -1, err
+	}
+
+	req, err := http.NewRequest("PATCH", c.getUrl(path), bytes.NewBuffer(buffer))
+
+	if err != nil {
+		return -1, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %v", token))
+	}
+
+	res, err := c.Client.Do(req)
+	if err != nil {
+		return -1, err
+	}
+
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return -1, err
+	}
+
+	if res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("%s", b)
+	}
+
+	err = json.Unmarshal(b, response)
+
+	if err != nil {
+		return -1, err
+	}
+
+	return res.StatusCode, nil
+}
+
+func (c *HttpClient) Delete(response interface{}, token string, path string) (int, error) {
+	req, err := http.NewRequest("DELETE", c.getUrl(path), nil)
+
+	if err != nil {
+		return -1, err
+	}
+
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %v", token))
+	}
+
+	res, err := c.Client.Do(req)
+	if err != nil {
+		return -1, err
+	}
+
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return -1, err
+	}
+
+	if res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("%s", b)
+	}
+
+	if response != nil && res.StatusCode != http.StatusNoContent {
+		err = json.Unmarshal(b, response)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return res.StatusCode, nil
+}
+
 func (he *HttpError) read(body []byte) error {
 	return json.Unmarshal(body, he)
 }
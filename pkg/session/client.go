@@ -0,0 +1,97 @@
+// Package session is a client for the session protocol of YIP. A DApp creates
+// a session and waits for the token, a Wallet scans the QR content and signs in.
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"yip/pkg"
+	protocol "yip/src/api/auth/session"
+)
+
+const defaultPollInterval = 2 * time.Second
+
+// Error is a session_error returned by YIP
+type Error struct {
+	Code    string
+	Message string
+	Details string
+}
+
+func (e *Error) Error() string {
+	if e.Details == "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s (%s)", e.Code, e.Message, e.Details)
+}
+
+// EndedError is returned while waiting for the token if a party rejected or
+// cancelled the session
+type EndedError struct {
+	State  string
+	Party  string
+	Reason string
+}
+
+func (e *EndedError) Error() string {
+	return fmt.Sprintf("session %s by %s: %s", e.State, e.Party, e.Reason)
+}
+
+type Client struct {
+	api     pkg.ApiClient
+	baseUrl string
+	// stream has no timeout, the events stream stays open until the flow ends
+	stream       *http.Client
+	PollInterval time.Duration
+}
+
+// NewClient creates a client for the YIP api, e.g. http://localhost:8080/api/v1
+func NewClient(baseUrl string) *Client {
+	if !strings.Contains(baseUrl, "http") {
+		baseUrl = fmt.Sprintf("https://%s", baseUrl)
+	}
+
+	return &Client{
+		api:          pkg.NewApiClient(baseUrl),
+		baseUrl:      baseUrl,
+		stream:       &http.Client{},
+		PollInterval: defaultPollInterval,
+	}
+}
+
+// send posts a message and returns the reply. A session_error is returned as
+// *Error, a reply of none of the expected types as error.
+func (c *Client) send(msg *protocol.WebsocketMessage, expected ...string) (*protocol.WebsocketMessage, error) {
+	status, response, err := c.api.Session(msg)
+	if err != nil {
+		// throttled requests carry the session_error as body of a 429
+		wm := &protocol.WebsocketMessage{}
+		if status >= 300 && json.Unmarshal([]byte(err.Error()), wm) == nil && wm.MessageType == protocol.MessageTypeSessionError {
+			return nil, sessionError(wm)
+		}
+		return nil, err
+	}
+
+	if response.MessageType == protocol.MessageTypeSessionError {
+		return nil, sessionError(response)
+	}
+
+	for _, e := range expected {
+		if response.MessageType == e {
+			return response, nil
+		}
+	}
+
+	return nil, fmt.Errorf("expected %s, got %s", strings.Join(expected, " or "), response.MessageType)
+}
+
+func sessionError(wm *protocol.WebsocketMessage) error {
+	e, err := wm.ParseSessionError()
+	if err != nil {
+		return err
+	}
+	return &Error{Code: e.Code, Message: e.Message, Details: e.Details}
+}
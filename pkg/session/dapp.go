@@ -0,0 +1,164 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	protocol "yip/src/api/auth/session"
+	"yip/src/api/auth/verifier"
+)
+
+// DApp is the party that creates a session, shows its QR content and waits
+// for the wallet to sign in
+type DApp struct {
+	client        *Client
+	ClientId      string
+	SessionId     string
+	QRCodeContent string
+	DeepLink      string
+	// OnMatchCode is called with the code to show the user if the client
+	// requires number matching
+	OnMatchCode func(code string)
+	matchCode   string
+}
+
+func (d *DApp) showMatchCode(code string) {
+	if code == "" || code == d.matchCode || d.OnMatchCode == nil {
+		return
+	}
+	d.matchCode = code
+	d.OnMatchCode(code)
+}
+
+// CreateAuthSession creates an auth_session for the given client id
+func (c *Client) CreateAuthSession(clientId string) (*DApp, error) {
+	return c.createAuthSession(protocol.CreateSessionMessage(clientId, protocol.SessionTypeAuth))
+}
+
+// PushAuthSession creates an auth_session and sends a push notification to
+// the devices of the account of address. YIP doesn't tell whether a device
+// was notified, the dApp shows the QR content and the match code as well.
+func (c *Client) PushAuthSession(clientId string, address string) (*DApp, error) {
+	msg := protocol.CreateSessionMessage(clientId, protocol.SessionTypeAuth)
+	payload := msg.Payload.(protocol.PayloadCreateSessionRequest)
+	payload.PushTo = address
+	msg.Payload = payload
+
+	return c.createAuthSession(msg)
+}
+
+func (c *Client) createAuthSession(msg *protocol.WebsocketMessage) (*DApp, error) {
+	wm, err := c.send(msg, protocol.MessageTypeSessionCreatedResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := wm.ParseSessionCreated()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DApp{
+		client:        c,
+		ClientId:      created.ClientId,
+		SessionId:     created.SessionId,
+		QRCodeContent: created.QRCodeContent,
+		DeepLink:      created.DeepLink,
+	}, nil
+}
+
+// AwaitToken waits for the token on the session events of the websocket. It
+// falls back to the server-sent events and then to polling if a transport is
+// not available.
+func (d *DApp) AwaitToken(ctx context.Context) (*verifier.Token, error) {
+	for _, stream := range []eventStream{d.client.websocketEvents, d.client.streamEvents} {
+		token, err := d.streamToken(ctx, stream)
+		if !errors.Is(err, errStreamUnavailable) {
+			return token, err
+		}
+	}
+	return d.PollToken(ctx)
+}
+
+// PollToken pings the session every PollInterval until the wallet signed in
+func (d *DApp) PollToken(ctx context.Context) (*verifier.Token, error) {
+	ticker := time.NewTicker(d.client.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		wm, err := d.client.send(protocol.CreatePingRequest(d.SessionId), protocol.MessageTypePingTokenResponse)
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := wm.ParsePingResponse()
+		if err != nil {
+			return nil, err
+		}
+		d.showMatchCode(p.MatchCode)
+		switch p.AuthState {
+		case protocol.FlowStateSuccess:
+			return p.Token, nil
+		case protocol.FlowStateFailed:
+			return nil, &EndedError{State: p.State, Party: p.Party, Reason: p.Reason}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *DApp) streamToken(ctx context.Context, stream eventStream) (*verifier.Token, error) {
+	var token *verifier.Token
+
+	err := stream(ctx, d.SessionId, func(wm *protocol.WebsocketMessage) (bool, error) {
+		switch wm.MessageType {
+		case protocol.MessageTypePingTokenResponse:
+			p, err := wm.ParsePingResponse()
+			if err != nil {
+				return false, err
+			}
+			token = p.Token
+			return false, nil
+		case protocol.MessageTypeSessionState:
+			p, err := wm.ParseSessionState()
+			if err != nil {
+				return false, err
+			}
+			d.showMatchCode(p.MatchCode)
+			switch p.State {
+			case protocol.AuthFlowStateNameClosed:
+				return false, fmt.Errorf("session closed")
+			case protocol.AuthFlowStateNameRejected, protocol.AuthFlowStateNameCancelled:
+				return false, &EndedError{State: p.State, Party: p.Party, Reason: p.Reason}
+			}
+		case protocol.MessageTypeSessionError:
+			return false, sessionError(wm)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil {
+		return nil, errStreamUnavailable
+	}
+	return token, nil
+}
+
+// Cancel cancels the session, a waiting wallet is told the reason
+func (d *DApp) Cancel(reason string) error {
+	_, err := d.client.send(protocol.CreateSessionCancelled(d.SessionId, reason), protocol.MessageTypePingTokenResponse)
+	return err
+}
+
+// Close closes the session
+func (d *DApp) Close() error {
+	_, err := d.client.send(protocol.CreateCloseSessionRequest(d.SessionId), protocol.MessageTypeCloseSessionResponse)
+	return err
+}
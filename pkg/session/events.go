@@ -0,0 +1,128 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"io"
+	"net/http"
+	"strings"
+	protocol "yip/src/api/auth/session"
+)
+
+// errStreamUnavailable is returned if the events stream cannot be used and
+// the caller should try the next transport
+var errStreamUnavailable = errors.New("session events not available")
+
+// eventStream reads the session events of a transport until handle returns
+// false
+type eventStream func(ctx context.Context, sessionId string, handle func(wm *protocol.WebsocketMessage) (bool, error)) error
+
+// websocketEvents reads the session events of the websocket until handle
+// returns false, the server closes the connection or the context is done
+func (c *Client) websocketEvents(ctx context.Context, sessionId string, handle func(wm *protocol.WebsocketMessage) (bool, error)) error {
+	u := fmt.Sprintf("%s/auth/session/%s/ws", c.baseUrl, sessionId)
+	u = "ws" + strings.TrimPrefix(u, "http")
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, u, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return errStreamUnavailable
+	}
+	defer conn.Close()
+
+	// unblock the read once the context is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		wm := &protocol.WebsocketMessage{}
+		if err := conn.ReadJSON(wm); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return nil
+			}
+			return err
+		}
+
+		next, err := handle(wm)
+		if err != nil || !next {
+			return err
+		}
+	}
+}
+
+// streamEvents reads the session events until handle returns false, the
+// stream ends or the context is done
+func (c *Client) streamEvents(ctx context.Context, sessionId string, handle func(wm *protocol.WebsocketMessage) (bool, error)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/auth/session/%s/events", c.baseUrl, sessionId), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	res, err := c.stream.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return errStreamUnavailable
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errStreamUnavailable
+	}
+
+	err = readEvents(res.Body, handle)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// readEvents parses a text/event-stream. Only the data lines are used, every
+// event carries a complete message.
+func readEvents(r io.Reader, handle func(wm *protocol.WebsocketMessage) (bool, error)) error {
+	scanner := bufio.NewScanner(r)
+	data := strings.Builder{}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			if data.Len() == 0 {
+				continue
+			}
+			wm := &protocol.WebsocketMessage{}
+			if err := json.Unmarshal([]byte(data.String()), wm); err != nil {
+				return err
+			}
+			data.Reset()
+
+			next, err := handle(wm)
+			if err != nil || !next {
+				return err
+			}
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"gopkg.in/square/go-jose.v2"
+	"net/http"
+	"strings"
+	"yip/src/api/auth/verifier"
+)
+
+// issuer is the YIP the client talks to, the JWKS is served below it
+func (c *Client) issuer() string {
+	return strings.TrimSuffix(strings.TrimSuffix(c.baseUrl, "/"), "/api/v1")
+}
+
+// VerifyQRContent verifies the signed handoff token of the QR content with the
+// JWKS of YIP. The returned claims hold the label and domain of the client the
+// wallet should show the user.
+func (c *Client) VerifyQRContent(qr *QRContent) (*verifier.HandoffClaims, error) {
+	if qr.HandoffToken == "" {
+		return nil, fmt.Errorf("qr content is not signed")
+	}
+
+	key, err := c.signingKey()
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := verifier.ParseHandoff(qr.HandoffToken, key, c.issuer())
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.SessionId != qr.SessionId || claims.ClientId != qr.ClientId || claims.SessionType != qr.SessionType || claims.E2EPublicKey != qr.E2EPublicKey {
+		return nil, fmt.Errorf("qr content does not match its signature")
+	}
+	return claims, nil
+}
+
+// signingKey fetches the token signing key from the JWKS of YIP
+func (c *Client) signingKey() (*rsa.PublicKey, error) {
+	res, err := c.stream.Get(fmt.Sprintf("%s/.well-known/jwks", c.issuer()))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks not available: %s", res.Status)
+	}
+
+	keys := jose.JSONWebKeySet{}
+	if err = json.NewDecoder(res.Body).Decode(&keys); err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys.Keys {
+		if key, ok := k.Key.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no rsa key in jwks")
+}
+
+// handoffClaims reads the claims of a deep link without verifying them, they
+// are verified by VerifyQRContent
+func handoffClaims(token string) (*verifier.HandoffClaims, error) {
+	claims := &verifier.HandoffClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return nil, fmt.Errorf("invalid handoff token: %w", err)
+	}
+	return claims, nil
+}
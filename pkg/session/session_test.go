@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	protocol "yip/src/api/auth/session"
+	"yip/src/api/auth/verifier"
+)
+
+func TestParseQRContent(t *testing.T) {
+	qr, err := ParseQRContent("https://yip.net/api/v1/auth/session?sid=5f0e&cid=d798&flow=auth_session&chainId=31337&pk=abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://yip.net/api/v1/auth/session", qr.Url)
+	assert.Equal(t, "5f0e", qr.SessionId)
+	assert.Equal(t, "d798", qr.ClientId)
+	assert.Equal(t, protocol.SessionTypeAuth, qr.SessionType)
+	assert.Equal(t, "31337", qr.ChainId)
+	assert.Equal(t, "abc", qr.E2EPublicKey)
+
+	_, err = ParseQRContent("https://yip.net/api/v1/auth/session?cid=d798")
+	assert.Error(t, err)
+
+	// a deep link only carries the handoff token
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &verifier.HandoffClaims{SessionId: "5f0e", SessionType: protocol.SessionTypeAuth}).SignedString([]byte("k"))
+	assert.NoError(t, err)
+	qr, err = ParseQRContent("https://wallet.yip.net/session?t=" + token)
+	assert.NoError(t, err)
+	assert.Equal(t, "5f0e", qr.SessionId)
+	assert.Equal(t, token, qr.HandoffToken)
+}
+
+func TestReadEvents(t *testing.T) {
+	stream := ": keep-alive\n\n" +
+		"id: 1\nevent: session_state\ndata: {\"messageType\":\"session_state\",\"payload\":{\"state\":\"created\"}}\n\n" +
+		"id: 2\nevent: session_state\ndata: {\"messageType\":\"session_state\",\"payload\":{\"state\":\"verified\"}}\n\n" +
+		"id: 2\nevent: ping_token_response\ndata: {\"messageType\":\"ping_token_response\",\"payload\":{\"authState\":\"success\",\"token\":{\"token\":\"t\"}}}\n\n"
+
+	var types []string
+	err := readEvents(strings.NewReader(stream), func(wm *protocol.WebsocketMessage) (bool, error) {
+		types = append(types, wm.MessageType)
+		return wm.MessageType != protocol.MessageTypePingTokenResponse, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"session_state", "session_state", "ping_token_response"}, types)
+}
+
+func TestWebsocketEvents(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/auth/session/5f0e/ws", r.URL.Path)
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.WriteJSON(protocol.WebsocketMessage{MessageType: protocol.MessageTypeSessionState, Payload: protocol.PayloadSessionState{State: protocol.AuthFlowStateNameVerified}})
+		_ = conn.WriteJSON(protocol.WebsocketMessage{MessageType: protocol.MessageTypePingTokenResponse, Payload: protocol.PayloadPingTokenResponse{AuthState: protocol.FlowStateSuccess, Token: &verifier.Token{IdToken: "t"}}})
+	}))
+	defer server.Close()
+
+	d := &DApp{client: NewClient(server.URL + "/api/v1"), SessionId: "5f0e"}
+	token, err := d.streamToken(context.Background(), d.client.websocketEvents)
+	assert.NoError(t, err)
+	assert.Equal(t, "t", token.IdToken)
+
+	// a server without the websocket lets the client fall back
+	d.client = NewClient("http://127.0.0.1:1/api/v1")
+	_, err = d.streamToken(context.Background(), d.client.websocketEvents)
+	assert.ErrorIs(t, err, errStreamUnavailable)
+}
@@ -0,0 +1,151 @@
+package session
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"net/url"
+	protocol "yip/src/api/auth/session"
+	"yip/src/api/services/dto"
+	"yip/src/cryptox"
+)
+
+// QRContent is the content of the QR code shown by the dApp or of its deep link
+type QRContent struct {
+	Url          string
+	SessionId    string
+	ClientId     string
+	SessionType  string
+	ChainId      string
+	E2EPublicKey string
+	// E2ESecret is added by the dApp, the wallet authenticates its handshake
+	// with it, see cryptox.E2EHandshakeMAC
+	E2ESecret string
+	// HandoffToken is signed by YIP, see Client.VerifyQRContent
+	HandoffToken string
+}
+
+// ParseQRContent parses the QR code content or the deep link of a session. A
+// deep link only carries the handoff token, its fields are taken from the
+// unverified claims.
+func ParseQRContent(content string) (*QRContent, error) {
+	u, err := url.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid qr content: %w", err)
+	}
+
+	q := u.Query()
+	qr := &QRContent{
+		SessionId:    q.Get("sid"),
+		ClientId:     q.Get("cid"),
+		SessionType:  q.Get("flow"),
+		ChainId:      q.Get("chainId"),
+		E2EPublicKey: q.Get("pk"),
+		E2ESecret:    q.Get("hs"),
+		HandoffToken: q.Get("t"),
+	}
+
+	if qr.SessionId == "" && qr.HandoffToken != "" {
+		claims, err := handoffClaims(qr.HandoffToken)
+		if err != nil {
+			return nil, err
+		}
+		qr.SessionId = claims.SessionId
+		qr.ClientId = claims.ClientId
+		qr.SessionType = claims.SessionType
+		qr.ChainId = claims.ChainId
+		qr.E2EPublicKey = claims.E2EPublicKey
+	}
+
+	if qr.SessionId == "" || qr.SessionType == "" {
+		return nil, fmt.Errorf("invalid qr content: session id or flow missing")
+	}
+
+	u.RawQuery = ""
+	qr.Url = u.String()
+
+	return qr, nil
+}
+
+// Wallet is the party that scans the QR content and signs in with its key
+type Wallet struct {
+	client           *Client
+	key              *keystore.Key
+	SLYWalletAddress string
+	// ConfirmCode is asked for the code shown by the dApp if the client
+	// requires number matching. In choose mode the user picks one of choices,
+	// in confirm mode the only choice has to be confirmed.
+	ConfirmCode func(mode string, choices []string) (string, error)
+}
+
+// NewWallet creates the wallet role. slyWalletAddress is optional, if set the
+// key has to be a controller key of the SLYWallet.
+func (c *Client) NewWallet(key *keystore.Key, slyWalletAddress string) *Wallet {
+	return &Wallet{
+		client:           c,
+		key:              key,
+		SLYWalletAddress: slyWalletAddress,
+	}
+}
+
+// Login verifies the signed QR content, connects the account to its session,
+// signs the SIWE challenge and submits the signature
+func (w *Wallet) Login(qr *QRContent) (*dto.VerifyResponse, error) {
+	if qr.SessionType != protocol.SessionTypeAuth {
+		return nil, fmt.Errorf("not an auth session: %s", qr.SessionType)
+	}
+	if _, err := w.client.VerifyQRContent(qr); err != nil {
+		return nil, err
+	}
+
+	wm, err := w.client.send(protocol.CreateAccountResponse(qr.SessionId, w.key.Address.Hex(), w.SLYWalletAddress, qr.ChainId), protocol.MessageTypeSignatureRequest, protocol.MessageTypeNumberMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	if wm.MessageType == protocol.MessageTypeNumberMatch {
+		wm, err = w.matchNumber(qr, wm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	challenge, err := wm.ParseChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	sm, err := cryptox.Sign(challenge.Challenge, w.key, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
+	if err != nil {
+		return nil, err
+	}
+
+	wm, err = w.client.send(protocol.CreateSubmitSignature(qr.SessionId, sm.Message, sm.Signature), protocol.MessageTypeVerificationResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return wm.ParseVerificationResponse()
+}
+
+func (w *Wallet) matchNumber(qr *QRContent, wm *protocol.WebsocketMessage) (*protocol.WebsocketMessage, error) {
+	match, err := wm.ParseNumberMatch()
+	if err != nil {
+		return nil, err
+	}
+	if w.ConfirmCode == nil {
+		return nil, fmt.Errorf("number matching required, ConfirmCode not set")
+	}
+
+	code, err := w.ConfirmCode(match.Mode, match.Choices)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.client.send(protocol.CreateNumberMatchResponse(qr.SessionId, code), protocol.MessageTypeSignatureRequest)
+}
+
+// Reject declines the sign in, the waiting dApp is told the reason
+func (w *Wallet) Reject(qr *QRContent, reason string) error {
+	_, err := w.client.send(protocol.CreateSessionRejected(qr.SessionId, reason), protocol.MessageTypePingTokenResponse)
+	return err
+}
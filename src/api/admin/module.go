@@ -3,7 +3,9 @@ package admin
 import (
 	"github.com/go-chi/chi/v5"
 	"yip/src/api/admin/info"
+	"yip/src/api/admin/sessions"
 	"yip/src/api/admin/user"
+	"yip/src/api/auth/session"
 	"yip/src/api/auth/verifier"
 	"yip/src/api/services"
 	"yip/src/config"
@@ -11,8 +13,9 @@ import (
 )
 
 type AdminModule struct {
-	UserController user.Controller
-	InfoController info.Controller
+	UserController     user.Controller
+	InfoController     info.Controller
+	SessionsController sessions.Controller
 }
 
 func NewAdminModule(
@@ -20,10 +23,12 @@ func NewAdminModule(
 	services *services.Services,
 	middleware *verifier.TokenVerifierMiddleware,
 	ethProvider *providers.EthProvider,
+	connector *session.MConnector,
 ) AdminModule {
 	return AdminModule{
-		UserController: user.NewController(&services.UserService, &services.PinService, middleware),
-		InfoController: info.NewController(config, &services.InvitationCodeService, ethProvider, middleware),
+		UserController:     user.NewController(&services.UserService, &services.PinService, &services.EmailChangeService, &services.AccountLinkService, &services.MFAService, middleware),
+		InfoController:     info.NewController(config, &services.InvitationCodeService, ethProvider, middleware),
+		SessionsController: sessions.NewController(connector, &services.SessionAuditService, middleware),
 	}
 }
 
@@ -31,5 +36,6 @@ func (a AdminModule) Routes() func(r chi.Router) {
 	return func(r chi.Router) {
 		r.Route("/accounts", a.UserController.Routes())
 		r.Route("/info", a.InfoController.Routes())
+		r.Route("/sessions", a.SessionsController.Routes())
 	}
 }
@@ -0,0 +1,168 @@
+package sessions
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"yip/src/api/admin/info"
+	"yip/src/api/auth/session"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services"
+	"yip/src/common"
+	"yip/src/httpx"
+	"yip/src/repositories/repo"
+)
+
+type Controller struct {
+	connector          *session.MConnector
+	auditService       *services.SessionAuditService
+	yipAdminMiddleware *verifier.TokenVerifierMiddleware
+}
+
+func NewController(
+	connector *session.MConnector,
+	auditService *services.SessionAuditService,
+	tokenMiddleware *verifier.TokenVerifierMiddleware) Controller {
+	return Controller{
+		connector:          connector,
+		auditService:       auditService,
+		yipAdminMiddleware: tokenMiddleware,
+	}
+}
+
+func (c Controller) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(c.yipAdminMiddleware.PrincipalCtx)
+			r.Use(info.AdminCtx)
+			r.Get("/", c.ListSessions)
+			r.Get("/{sessionId}", c.GetSession)
+			r.Post("/{sessionId}/close", c.CloseSession)
+		})
+	}
+}
+
+// swagger:parameters listSessions
+type listSessions struct {
+	// in:query
+	Type string `json:"type"`
+	// in:query
+	ClientId string `json:"clientId"`
+	// in:query
+	State string `json:"state"`
+	// in:query
+	EOA string `json:"eoa"`
+	// in:query
+	MinAge int64 `json:"minAge"`
+	// lists ended and closed sessions as well
+	// in:query
+	All bool `json:"all"`
+}
+
+// swagger:route GET /admin/sessions Sessions listSessions
+// Lists the live sessions, the oldest first
+//
+// Security:
+//   - Bearer: []
+//
+// Responses:
+//
+//	200: []SessionInfo
+func (c Controller) ListSessions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := session.SessionFilter{
+		SessionType: q.Get("type"),
+		ClientId:    q.Get("clientId"),
+		State:       q.Get("state"),
+		EOA:         q.Get("eoa"),
+		All:         q.Get("all") == "true",
+	}
+
+	if minAge := q.Get("minAge"); minAge != "" {
+		v, err := strconv.ParseInt(minAge, 10, 64)
+		if err != nil {
+			httpx.RespondWithJSON(w, httpx.BadRequest("minAge is not a number"))
+			return
+		}
+		filter.MinAgeInSec = v
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(c.connector.ListSessions(filter)))
+}
+
+// swagger:route GET /admin/sessions/{sessionId} Sessions getSession
+// Returns a session
+//
+// Security:
+//   - Bearer: []
+//
+// Responses:
+//
+//	200: SessionInfo
+func (c Controller) GetSession(w http.ResponseWriter, r *http.Request) {
+	sid, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest("sessionId not uuid"))
+		return
+	}
+
+	i, err := c.connector.GetSessionInfo(sid)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.NotFound(err.Error()))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(i))
+}
+
+// swagger:parameters closeSession
+type closeSession struct {
+	// in:body
+	Body CloseSessionRequestDTO
+}
+
+// swagger:route POST /admin/sessions/{sessionId}/close Sessions closeSession
+// Force-closes a session, an open flow is cancelled with the given reason
+//
+// Security:
+//   - Bearer: []
+//
+// Responses:
+//
+//	200: SessionInfo
+func (c Controller) CloseSession(w http.ResponseWriter, r *http.Request) {
+	sid, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest("sessionId not uuid"))
+		return
+	}
+
+	data := &CloseSessionRequestDTO{}
+	if err = common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	if _, err = c.connector.GetSessionInfo(sid); err != nil {
+		httpx.RespondWithJSON(w, httpx.NotFound(err.Error()))
+		return
+	}
+
+	i, err := c.connector.ForceClose(sid, data.Reason)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.Conflict(err.Error()))
+		return
+	}
+
+	c.auditService.Record(r.Context(), &repo.SessionAuditModel{
+		SessionID:   sid,
+		SessionType: i.SessionType,
+		Outcome:     session.AuthFlowStateNameClosed,
+		Party:       session.PartyAdmin,
+		Reason:      data.Reason,
+		EOA:         i.EOA,
+	})
+
+	httpx.RespondWithJSON(w, httpx.OK(i))
+}
@@ -0,0 +1,16 @@
+package sessions
+
+import (
+	"yip/src/slyerrors"
+)
+
+// swagger:model CloseSessionRequest
+type CloseSessionRequestDTO struct {
+	Reason string `json:"reason"`
+}
+
+func (a *CloseSessionRequestDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("reason", a.Reason).
+		Error()
+}
@@ -12,19 +12,26 @@ import (
 	"yip/src/api/services/dto"
 	"yip/src/common"
 	"yip/src/httpx"
+	"yip/src/repositories/repo"
 	"yip/src/slyerrors"
 )
 
 type Controller struct {
 	service            *services.UserService
 	pinService         *pin.Service
+	emailChangeService *services.EmailChangeService
+	accountLinkService *services.AccountLinkService
+	mfaService         *services.MFAService
 	yipAdminMiddleware *verifier.TokenVerifierMiddleware
 }
 
-func NewController(service *services.UserService, pinService *pin.Service, tokenMiddleware *verifier.TokenVerifierMiddleware) Controller {
+func NewController(service *services.UserService, pinService *pin.Service, emailChangeService *services.EmailChangeService, accountLinkService *services.AccountLinkService, mfaService *services.MFAService, tokenMiddleware *verifier.TokenVerifierMiddleware) Controller {
 	return Controller{
 		service:            service,
 		pinService:         pinService,
+		emailChangeService: emailChangeService,
+		accountLinkService: accountLinkService,
+		mfaService:         mfaService,
 		yipAdminMiddleware: tokenMiddleware,
 	}
 }
@@ -40,10 +47,16 @@ func (c Controller) Routes() func(r chi.Router) {
 			r.Post("/register", c.RegisterUser)
 			r.Put("/email", c.SetEmail)
 			r.Get("/pins", c.GetPins)
+			r.Get("/pins/lockouts", c.GetPinLockouts)
+			r.Post("/pins/lockouts/clear", c.ClearPinLockout)
+			r.Get("/links", c.GetAccountLinks)
+			r.Post("/links/{linkId}/merge", c.MergeAccounts)
 
 			r.Route("/{accountId}", func(r chi.Router) {
 				r.Use(c.AccountCtx)
 				r.Get("/", c.GetAccount)
+				r.Get("/email/changes", c.GetEmailChanges)
+				r.Delete("/mfa", c.ResetMFA)
 			})
 		})
 	}
@@ -107,13 +120,14 @@ type signin struct {
 // swagger:route POST /admin/accounts/token OIDC signin
 // Signs In A User
 //
-// Responds with token when email credentials are given
+// Responds with token when email credentials are given, or with the challenge
+// of the second factor if one is required, see POST /auth/mfa/challenge/verify
 // Security:
 //   - Bearer: []
 //
 // Responses:
 //
-//	200: Token
+//	200: SignInResponse
 func (c Controller) SignInUser(w http.ResponseWriter, r *http.Request) {
 	data := &dto.SignInRequest{}
 	if err := data.ReadAndValidate(r); err != nil {
@@ -268,6 +282,9 @@ type setemail struct {
 // swagger:route PUT /admin/accounts/email admin setemail
 // Sets An Email Of A Given User
 //
+// The email is set without confirmation, the change records the acting admin
+// and the old email is notified.
+//
 // Security:
 //   - Bearer: []
 //
@@ -277,7 +294,7 @@ type setemail struct {
 func (c Controller) SetEmail(w http.ResponseWriter, r *http.Request) {
 	data := &dto.SetEmailRequest{}
 	if err := data.ReadAndValidate(r); err != nil {
-		http.Error(w, err.Error(), 400)
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
 		return
 	}
 
@@ -299,16 +316,52 @@ func (c Controller) SetEmail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := c.service.SetEmail(r.Context(), uu, data.Email)
+	result, err := c.emailChangeService.AdminSetEmail(r.Context(), &user, uu, data.Email, httpx.ClientIP(r))
 
 	if err != nil {
-		httpx.RespondWithJSON(w, httpx.MapServiceError(slyerrors.MapJetError(err, "")))
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
 		return
 	}
 
 	httpx.RespondWithJSON(w, httpx.OK(result))
 }
 
+// swagger:route GET /admin/accounts/{accountId}/email/changes admin getEmailChanges
+// Returns the email changes of an account, the latest first
+//
+// Security:
+//   - Bearer: []
+//
+// Responses:
+//
+//	200: []EmailChangeModel
+func (c Controller) GetEmailChanges(w http.ResponseWriter, r *http.Request) {
+	user, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	if !user.IsAdmin() {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(slyerrors.Forbidden("403", "access forbidden")))
+		return
+	}
+
+	uu, err := uuid.Parse(getAccountFromCtx(r).ID)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest("account id is no uuid"))
+		return
+	}
+
+	changes, err := c.emailChangeService.ListChanges(r.Context(), uu)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(changes))
+}
+
 // swagger:route GET /admin/accounts/pins users
 // Returns all pins
 //
@@ -327,3 +380,195 @@ func (c Controller) GetPins(w http.ResponseWriter, r *http.Request) {
 
 	httpx.RespondWithJSON(w, httpx.OK(pins))
 }
+
+// swagger:route GET /admin/accounts/pins/lockouts users
+// Returns the ips and accounts with failed pin redemptions, locked ones have lockedUntil set
+//
+// Security:
+//   - Bearer: []
+//
+// Responses:
+//
+//	200: []Lockout
+func (c Controller) GetPinLockouts(w http.ResponseWriter, r *http.Request) {
+	user, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	if !user.IsAdmin() {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(slyerrors.Forbidden("403", "access forbidden")))
+		return
+	}
+
+	lockouts, err := c.pinService.ListLockouts(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(lockouts))
+}
+
+// swagger:parameters clearPinLockout
+type clearPinLockout struct {
+	// in:body
+	Body pin.ClearLockoutDTO
+}
+
+// swagger:route POST /admin/accounts/pins/lockouts/clear users clearPinLockout
+// Clears the failed pin redemptions of an ip or account
+//
+// Security:
+//   - Bearer: []
+//
+// Responses:
+//
+//	204:
+func (c Controller) ClearPinLockout(w http.ResponseWriter, r *http.Request) {
+	data := &pin.ClearLockoutDTO{}
+	if err := data.ReadAndValidate(r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	user, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	if !user.IsAdmin() {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(slyerrors.Forbidden("403", "access forbidden")))
+		return
+	}
+
+	if err = c.pinService.ClearLockout(r.Context(), data.Subject); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.NoContent())
+}
+
+// swagger:route GET /admin/accounts/links admin getAccountLinks
+// Returns the account links with the status of the query parameter status, the confirmed ones by default
+//
+// Security:
+//   - Bearer: []
+//
+// Responses:
+//
+//	200: []AccountLinkModel
+func (c Controller) GetAccountLinks(w http.ResponseWriter, r *http.Request) {
+	user, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	if !user.IsAdmin() {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(slyerrors.Forbidden("403", "access forbidden")))
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = repo.AccountLinkConfirmed
+	}
+
+	links, err := c.accountLinkService.ListLinksByStatus(r.Context(), status)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(links))
+}
+
+// swagger:parameters mergeAccounts
+type mergeAccounts struct {
+	// in:body
+	Body dto.AccountMergeDTO
+}
+
+// swagger:route POST /admin/accounts/links/{linkId}/merge admin mergeAccounts
+// Merges the accounts of a confirmed link into the target account
+//
+// The keys, wallets and invitation code of the other account are moved to the
+// target, the other account is anonymized and purged.
+//
+// Security:
+//   - Bearer: []
+//
+// Responses:
+//
+//	200: AccountModel
+func (c Controller) MergeAccounts(w http.ResponseWriter, r *http.Request) {
+	user, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	if !user.IsAdmin() {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(slyerrors.Forbidden("403", "access forbidden")))
+		return
+	}
+
+	linkId, err := uuid.Parse(chi.URLParam(r, "linkId"))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest("link id is no uuid"))
+		return
+	}
+
+	data := &dto.AccountMergeDTO{}
+	if err = common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	account, err := c.accountLinkService.Merge(r.Context(), &user, linkId, data)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(account))
+}
+
+// swagger:route DELETE /admin/accounts/{accountId}/mfa admin resetMFA
+// Removes the second factor and recovery codes of an account, e.g. when both are lost
+//
+// Security:
+//   - Bearer: []
+//
+// Responses:
+//
+//	204: noContent
+func (c Controller) ResetMFA(w http.ResponseWriter, r *http.Request) {
+	user, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	if !user.IsAdmin() {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(slyerrors.Forbidden("403", "access forbidden")))
+		return
+	}
+
+	uu, err := uuid.Parse(getAccountFromCtx(r).ID)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest("account id is no uuid"))
+		return
+	}
+
+	if err = c.mfaService.Reset(r.Context(), uu); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.NoContent())
+}
@@ -9,6 +9,7 @@ import (
 	"yip/src/api/admin"
 	"yip/src/api/auth"
 	"yip/src/api/auth/verifier"
+	"yip/src/api/me"
 	"yip/src/api/services"
 	"yip/src/api/slywallet"
 	"yip/src/app"
@@ -26,6 +27,7 @@ type Modules struct {
 	AuthModule      auth.Module
 	AdminModule     admin.AdminModule
 	SLYWalletModule slywallet.Module
+	MeModule        me.Module
 }
 
 func NewApi(app *app.App) Api {
@@ -37,11 +39,14 @@ func NewApi(app *app.App) Api {
 
 	apiServices := services.GenerateApiServices(app)
 
-	tokenMiddleware := initMiddleware(app.Verifier)
+	tokenMiddleware := initMiddleware(&apiServices.TokenService)
 
 	api.Modules.AuthModule = auth.NewAuthModule(app.Config, &apiServices, &tokenMiddleware)
-	api.Modules.AdminModule = admin.NewAdminModule(app.Config, &apiServices, &tokenMiddleware, app.EthProvider)
+	api.Modules.AdminModule = admin.NewAdminModule(app.Config, &apiServices, &tokenMiddleware, app.EthProvider, &api.Modules.AuthModule.SessionController.MConnector)
 	api.Modules.SLYWalletModule = slywallet.NewModule(&apiServices, &tokenMiddleware)
+	api.Modules.MeModule = me.NewModule(&apiServices, &tokenMiddleware)
+
+	go apiServices.PrivacyService.RunPurger(context.Background())
 
 	api.Router = newRouter(&api)
 	return api
@@ -59,6 +64,9 @@ func newRouter(api *Api) *chi.Mux {
 	// TODO make sure to delete this
 	origins = []string{"*"}
 
+	if api.App.Config.API.TrustProxy {
+		r.Use(middleware.RealIP)
+	}
 	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
@@ -96,11 +104,12 @@ func (api Api) defineRoutes(r *chi.Mux) {
 	r.Route("/auth", api.Modules.AuthModule.Routes())
 	r.Route("/admin", api.Modules.AdminModule.Routes())
 	r.Route("/sly", api.Modules.SLYWalletModule.Routes())
+	r.Route("/me", api.Modules.MeModule.Routes())
 }
 
-func initMiddleware(verf *verifier.Verifier) verifier.TokenVerifierMiddleware {
+func initMiddleware(tokenService *services.TokenService) verifier.TokenVerifierMiddleware {
 	v := func(token string) (*verifier.Principal, error) {
-		return verf.VerifyToken(context.Background(), token)
+		return tokenService.VerifyToken(context.Background(), token)
 	}
 
 	return verifier.NewTokenVerifierMiddleware(v)
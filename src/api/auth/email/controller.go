@@ -0,0 +1,131 @@
+package email
+
+import (
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services"
+	"yip/src/api/services/dto"
+	"yip/src/common"
+	"yip/src/httpx"
+)
+
+type Controller struct {
+	emailChangeService *services.EmailChangeService
+	tokenMiddleware    verifier.TokenVerifierMiddleware
+}
+
+func NewController(service *services.EmailChangeService, tokenMiddleware *verifier.TokenVerifierMiddleware) Controller {
+	return Controller{
+		emailChangeService: service,
+		tokenMiddleware:    *tokenMiddleware,
+	}
+}
+
+func (c Controller) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Post("/change/confirm-link", c.ConfirmChangeLink)
+
+		r.Group(func(r chi.Router) {
+			r.Use(c.tokenMiddleware.PrincipalCtx)
+			r.Post("/change", c.RequestChange)
+			r.Post("/change/confirm", c.ConfirmChange)
+		})
+	}
+}
+
+// swagger:parameters requestEmailChange
+type requestEmailChange struct {
+	// in:body
+	Body dto.EmailChangeRequestDTO
+}
+
+// swagger:route POST /auth/email/change Email requestEmailChange
+// Requests a change of the email, a code is sent to the new email and a notice to the old one
+//
+// Responses:
+//
+//	200: EmailChangeResponse
+func (a Controller) RequestChange(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	data := &dto.EmailChangeRequestDTO{}
+	if err = common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	response, err := a.emailChangeService.RequestChange(r.Context(), &principal, data, httpx.ClientIP(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(response))
+}
+
+// swagger:parameters confirmEmailChange
+type confirmEmailChange struct {
+	// in:body
+	Body dto.EmailChangeConfirmDTO
+}
+
+// swagger:route POST /auth/email/change/confirm Email confirmEmailChange
+// Confirms the pending email change with the code sent to the new email
+//
+// Responses:
+//
+//	200: AccountModel
+func (a Controller) ConfirmChange(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	data := &dto.EmailChangeConfirmDTO{}
+	if err = common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	account, err := a.emailChangeService.ConfirmChange(r.Context(), &principal, data, httpx.ClientIP(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(account))
+}
+
+// swagger:parameters confirmEmailChangeLink
+type confirmEmailChangeLink struct {
+	// in:body
+	Body dto.EmailChangeConfirmLinkDTO
+}
+
+// swagger:route POST /auth/email/change/confirm-link Email confirmEmailChangeLink
+// Confirms an email change with the token of the link sent to the new email
+//
+// Responses:
+//
+//	200: AccountModel
+func (a Controller) ConfirmChangeLink(w http.ResponseWriter, r *http.Request) {
+	data := &dto.EmailChangeConfirmLinkDTO{}
+	if err := common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	account, err := a.emailChangeService.ConfirmChangeLink(r.Context(), data, httpx.ClientIP(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(account))
+}
@@ -0,0 +1,234 @@
+package mfa
+
+import (
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services"
+	"yip/src/api/services/dto"
+	"yip/src/common"
+	"yip/src/httpx"
+)
+
+type Controller struct {
+	mfaService      *services.MFAService
+	tokenMiddleware verifier.TokenVerifierMiddleware
+}
+
+func NewController(service *services.MFAService, tokenMiddleware *verifier.TokenVerifierMiddleware) Controller {
+	return Controller{
+		mfaService:      service,
+		tokenMiddleware: *tokenMiddleware,
+	}
+}
+
+func (c Controller) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Post("/challenge/enroll", c.EnrollChallenge)
+		r.Post("/challenge/verify", c.VerifyChallenge)
+
+		r.Group(func(r chi.Router) {
+			r.Use(c.tokenMiddleware.PrincipalCtx)
+			r.Get("/", c.GetStatus)
+			r.Post("/totp", c.StartEnrollment)
+			r.Post("/totp/confirm", c.ConfirmEnrollment)
+			r.Post("/totp/disable", c.Disable)
+			r.Post("/recovery-codes", c.RegenerateRecoveryCodes)
+		})
+	}
+}
+
+// swagger:parameters enrollMFAChallenge
+type enrollMFAChallenge struct {
+	// in:body
+	Body dto.MFAChallengeDTO
+}
+
+// swagger:route POST /auth/mfa/challenge/enroll MFA enrollMFAChallenge
+// Starts the TOTP enrollment of a sign-in whose role requires a second factor, the challenge is verified with a code of the new secret
+//
+// Responses:
+//
+//	200: TOTPEnrollmentResponse
+func (a Controller) EnrollChallenge(w http.ResponseWriter, r *http.Request) {
+	data := &dto.MFAChallengeDTO{}
+	if err := common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	response, err := a.mfaService.EnrollChallenge(r.Context(), data)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(response))
+}
+
+// swagger:parameters verifyMFAChallenge
+type verifyMFAChallenge struct {
+	// in:body
+	Body dto.MFAVerifyDTO
+}
+
+// swagger:route POST /auth/mfa/challenge/verify MFA verifyMFAChallenge
+// Verifies the challenge of a sign-in with a TOTP or recovery code and responds with the token
+//
+// Responses:
+//
+//	200: MFAVerifyResponse
+func (a Controller) VerifyChallenge(w http.ResponseWriter, r *http.Request) {
+	data := &dto.MFAVerifyDTO{}
+	if err := common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	response, err := a.mfaService.VerifyChallenge(r.Context(), data)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(response))
+}
+
+// swagger:route GET /auth/mfa MFA getMFAStatus
+// Returns whether a second factor is enrolled and required
+//
+// Responses:
+//
+//	200: MFAStatusResponse
+func (a Controller) GetStatus(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	status, err := a.mfaService.Status(r.Context(), &principal)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(status))
+}
+
+// swagger:route POST /auth/mfa/totp MFA startTOTPEnrollment
+// Creates a new TOTP secret, it counts once confirmed with a code
+//
+// Responses:
+//
+//	200: TOTPEnrollmentResponse
+func (a Controller) StartEnrollment(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	response, err := a.mfaService.StartEnrollment(r.Context(), &principal)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(response))
+}
+
+// swagger:parameters confirmTOTPEnrollment regenerateRecoveryCodes
+type totpCode struct {
+	// in:body
+	Body dto.TOTPCodeDTO
+}
+
+// swagger:route POST /auth/mfa/totp/confirm MFA confirmTOTPEnrollment
+// Confirms the TOTP secret with a code, the response carries the recovery codes
+//
+// Responses:
+//
+//	200: RecoveryCodesResponse
+func (a Controller) ConfirmEnrollment(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	data := &dto.TOTPCodeDTO{}
+	if err = common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	response, err := a.mfaService.ConfirmEnrollment(r.Context(), &principal, data)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(response))
+}
+
+// swagger:parameters disableMFA
+type disableMFA struct {
+	// in:body
+	Body dto.MFACodeDTO
+}
+
+// swagger:route POST /auth/mfa/totp/disable MFA disableMFA
+// Removes the second factor, proven with a TOTP or recovery code. Not allowed for roles requiring a second factor.
+//
+// Responses:
+//
+//	204: noContent
+func (a Controller) Disable(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	data := &dto.MFACodeDTO{}
+	if err = common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	if err = a.mfaService.Disable(r.Context(), &principal, data); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.NoContent())
+}
+
+// swagger:route POST /auth/mfa/recovery-codes MFA regenerateRecoveryCodes
+// Replaces the recovery codes, proven with a TOTP code
+//
+// Responses:
+//
+//	200: RecoveryCodesResponse
+func (a Controller) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	data := &dto.TOTPCodeDTO{}
+	if err = common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	response, err := a.mfaService.RegenerateRecoveryCodes(r.Context(), &principal, data)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(response))
+}
@@ -2,20 +2,30 @@ package auth
 
 import (
 	"github.com/go-chi/chi/v5"
+	"yip/src/api/auth/email"
+	"yip/src/api/auth/mfa"
+	"yip/src/api/auth/password"
 	"yip/src/api/auth/pin"
+	"yip/src/api/auth/push"
 	"yip/src/api/auth/session"
 	"yip/src/api/auth/siwe"
 	"yip/src/api/auth/token"
 	"yip/src/api/auth/verifier"
+	"yip/src/api/auth/webauthn"
 	"yip/src/api/services"
 	"yip/src/config"
 )
 
 type Module struct {
-	TokenController   token.Controller
-	SIWEController    siwe.Controller
-	PinController     pin.Controller
-	SessionController session.Controller
+	TokenController    token.Controller
+	SIWEController     siwe.Controller
+	PinController      pin.Controller
+	SessionController  session.Controller
+	PushController     push.Controller
+	EmailController    email.Controller
+	PasswordController password.Controller
+	MFAController      mfa.Controller
+	WebAuthnController webauthn.Controller
 }
 
 func NewAuthModule(
@@ -24,10 +34,15 @@ func NewAuthModule(
 	middleware *verifier.TokenVerifierMiddleware,
 ) Module {
 	return Module{
-		TokenController:   token.NewController(&services.TokenService, &services.UserService, middleware),
-		SIWEController:    siwe.NewController(config, &services.SIWEService, &services.UserService),
-		PinController:     pin.NewController(&services.PinService),
-		SessionController: session.NewController(config, &services.SIWEService, &services.UserService),
+		TokenController:    token.NewController(&services.TokenService, &services.UserService, middleware),
+		SIWEController:     siwe.NewController(config, &services.SIWEService, &services.UserService, &services.SessionAuditService),
+		PinController:      pin.NewController(&services.PinService),
+		SessionController:  session.NewController(config, &services.SIWEService, &services.UserService, services.SLYWalletService, &services.SessionAuditService, &services.PushService),
+		PushController:     push.NewController(&services.PushService, middleware),
+		EmailController:    email.NewController(&services.EmailChangeService, middleware),
+		PasswordController: password.NewController(&services.PasswordService, middleware),
+		MFAController:      mfa.NewController(&services.MFAService, middleware),
+		WebAuthnController: webauthn.NewController(&services.WebAuthnService, middleware),
 	}
 }
 
@@ -37,5 +52,10 @@ func (a Module) Routes() func(r chi.Router) {
 		r.Route("/siwe", a.SIWEController.Routes())
 		r.Route("/pin", a.PinController.Routes())
 		r.Route("/session", a.SessionController.Routes())
+		r.Route("/push", a.PushController.Routes())
+		r.Route("/email", a.EmailController.Routes())
+		r.Route("/password", a.PasswordController.Routes())
+		r.Route("/mfa", a.MFAController.Routes())
+		r.Route("/webauthn", a.WebAuthnController.Routes())
 	}
 }
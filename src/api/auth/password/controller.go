@@ -0,0 +1,124 @@
+package password
+
+import (
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services"
+	"yip/src/api/services/dto"
+	"yip/src/common"
+	"yip/src/httpx"
+)
+
+type Controller struct {
+	passwordService *services.PasswordService
+	tokenMiddleware verifier.TokenVerifierMiddleware
+}
+
+func NewController(service *services.PasswordService, tokenMiddleware *verifier.TokenVerifierMiddleware) Controller {
+	return Controller{
+		passwordService: service,
+		tokenMiddleware: *tokenMiddleware,
+	}
+}
+
+func (c Controller) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Post("/forgot", c.ForgotPassword)
+		r.Post("/reset", c.ResetPassword)
+
+		r.Group(func(r chi.Router) {
+			r.Use(c.tokenMiddleware.PrincipalCtx)
+			r.Post("/change", c.ChangePassword)
+		})
+	}
+}
+
+// swagger:parameters forgotPassword
+type forgotPassword struct {
+	// in:body
+	Body dto.PasswordForgotDTO
+}
+
+// swagger:route POST /auth/password/forgot Password forgotPassword
+// Mails a reset token to the email if it belongs to an account with a password, the response is the same otherwise
+//
+// Responses:
+//
+//	200: PasswordForgotResponse
+func (a Controller) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	data := &dto.PasswordForgotDTO{}
+	if err := common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	response, err := a.passwordService.ForgotPassword(r.Context(), data, httpx.ClientIP(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(response))
+}
+
+// swagger:parameters resetPassword
+type resetPassword struct {
+	// in:body
+	Body dto.PasswordResetDTO
+}
+
+// swagger:route POST /auth/password/reset Password resetPassword
+// Sets the new password with the token of the reset mail, the tokens of the account are revoked
+//
+// Responses:
+//
+//	204: noContent
+func (a Controller) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	data := &dto.PasswordResetDTO{}
+	if err := common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	if err := a.passwordService.ResetPassword(r.Context(), data, httpx.ClientIP(r)); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.NoContent())
+}
+
+// swagger:parameters changePassword
+type changePassword struct {
+	// in:body
+	Body dto.PasswordChangeDTO
+}
+
+// swagger:route POST /auth/password/change Password changePassword
+// Changes the password of the account, the current password is required. The other tokens of the account are revoked, the response carries a new one.
+//
+// Responses:
+//
+//	200: Token
+func (a Controller) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	data := &dto.PasswordChangeDTO{}
+	if err = common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	token, err := a.passwordService.ChangePassword(r.Context(), &principal, data, httpx.ClientIP(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(token))
+}
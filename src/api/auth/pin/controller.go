@@ -21,6 +21,9 @@ func (c Controller) Routes() func(r chi.Router) {
 	return func(r chi.Router) {
 		r.Post("/", c.RequestPin)
 		r.Post("/redeem", c.Redeem)
+		r.Post("/link/redeem", c.RedeemLink)
+		r.Post("/phone", c.RequestPhonePin)
+		r.Post("/phone/redeem", c.RedeemPhonePin)
 
 	}
 }
@@ -47,10 +50,10 @@ func (a Controller) RequestPin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pin, err := a.service.RequestPin(r.Context(), data)
+	pin, err := a.service.RequestPin(r.Context(), data, httpx.ClientIP(r))
 
 	if err != nil {
-		httpx.RespondWithJSON(w, httpx.InternalError(err.Error()))
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
 		return
 	}
 
@@ -77,7 +80,7 @@ func (a Controller) Redeem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := a.service.Redeem(r.Context(), data)
+	token, err := a.service.Redeem(r.Context(), data, httpx.ClientIP(r))
 
 	if err != nil {
 		fmt.Println(err.Error())
@@ -87,3 +90,90 @@ func (a Controller) Redeem(w http.ResponseWriter, r *http.Request) {
 
 	httpx.RespondWithJSON(w, httpx.OK(token))
 }
+
+// swagger:parameters redeemPinLink
+type redeemPinLink struct {
+	// in:body
+	Body PinLinkRedeemDTO
+}
+
+// swagger:route POST /auth/pin/link/redeem Pin redeemPinLink
+// Redeems the magic link of a pin mail, consuming its pin too
+//
+// Responses:
+//
+//	200: Principal
+func (a Controller) RedeemLink(w http.ResponseWriter, r *http.Request) {
+	data := &PinLinkRedeemDTO{}
+
+	if err := data.ReadAndValidate(r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	token, err := a.service.RedeemLink(r.Context(), data, httpx.ClientIP(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(token))
+}
+
+// swagger:parameters requestPhonePin
+type requestPhonePin struct {
+	// in:body
+	Body PhonePinRequestDTO
+}
+
+// swagger:route POST /auth/pin/phone Pin requestPhonePin
+// Requests a pin sent by SMS
+//
+// Responses:
+//
+//	200: PhonePinRequestResponse
+func (a Controller) RequestPhonePin(w http.ResponseWriter, r *http.Request) {
+	data := &PhonePinRequestDTO{}
+
+	if err := data.ReadAndValidate(r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	pin, err := a.service.RequestPhonePin(r.Context(), data, httpx.ClientIP(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(pin))
+}
+
+// swagger:parameters redeemPhonePin
+type redeemPhonePin struct {
+	// in:body
+	Body PhonePinRedeemDTO
+}
+
+// swagger:route POST /auth/pin/phone/redeem Pin redeemPhonePin
+// Redeems a pin sent by SMS and marks the phone verified
+//
+// Responses:
+//
+//	200: Principal
+func (a Controller) RedeemPhonePin(w http.ResponseWriter, r *http.Request) {
+	data := &PhonePinRedeemDTO{}
+
+	if err := data.ReadAndValidate(r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	token, err := a.service.RedeemPhonePin(r.Context(), data, httpx.ClientIP(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(token))
+}
@@ -0,0 +1,206 @@
+package pin
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+	"yip/src/cryptox"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+)
+
+// DBStore keeps the pins in slyip.pin, so they survive deploys and are shared
+// by all replicas
+type DBStore struct {
+	repo *repo.PinRepository
+}
+
+func NewDBStore(pinRepo *repo.PinRepository) *DBStore {
+	return &DBStore{
+		repo: pinRepo,
+	}
+}
+
+func (s *DBStore) Create(ctx context.Context, pinHash string, pin Pin) error {
+	// the account of a new email resp. phone is created on redemption
+	var accountId *uuid.UUID
+	if pin.AccountId != "" {
+		uu, err := uuid.Parse(pin.AccountId)
+		if err != nil {
+			return slyerrors.BadRequest(slyerrors.ErrCodeParsingUUID, err.Error())
+		}
+		accountId = &uu
+	}
+
+	err := s.repo.Create(ctx, &repo.PinModel{
+		PinHash:     pinHash,
+		AccountID:   accountId,
+		Email:       pin.Email,
+		EcdsaPubKey: pin.ECDSAPubKey,
+		ExpiresAt:   pin.Expiration,
+		Channel:     pin.Channel,
+		Phone:       pin.Phone,
+		LinkHash:    pin.linkHash,
+	})
+
+	if slyerrors.IsUniqueViolation(err) {
+		return cryptox.ErrCodeTaken
+	}
+	return err
+}
+
+func (s *DBStore) GetByLink(ctx context.Context, linkHash string) (*Pin, error) {
+	p, err := s.repo.GetByLinkHash(ctx, linkHash)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodePinNotExistent, "pin not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pin := mapPin(*p)
+	pin.hash = p.PinHash
+	return &pin, nil
+}
+
+func (s *DBStore) ListByKey(ctx context.Context, ecdsaPubKey string) ([]Pin, error) {
+	pins, err := s.repo.ListByEcdsaPubKey(ctx, ecdsaPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Pin, len(pins))
+	for i, p := range pins {
+		result[i] = mapPin(p)
+		result[i].hash = p.PinHash
+	}
+	return result, nil
+}
+
+func (s *DBStore) Fail(ctx context.Context, pinHash string) (int, error) {
+	n, err := s.repo.IncrementFailedAttempts(ctx, pinHash)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return 0, slyerrors.BadRequest(slyerrors.ErrCodePinNotExistent, "pin not found")
+	}
+	return n, err
+}
+
+func (s *DBStore) Consume(ctx context.Context, pinHash string) error {
+	deleted, err := s.repo.DeleteByHash(ctx, pinHash)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return slyerrors.BadRequest(slyerrors.ErrCodePinNotExistent, "pin not found")
+	}
+	return nil
+}
+
+func (s *DBStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := s.repo.DeleteExpired(ctx, now)
+	return err
+}
+
+func (s *DBStore) List(ctx context.Context) ([]Pin, error) {
+	pins, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Pin, len(pins))
+	for i, p := range pins {
+		result[i] = mapPin(p)
+	}
+	return result, nil
+}
+
+func mapPin(p repo.PinModel) Pin {
+	var accountId string
+	if p.AccountID != nil {
+		accountId = p.AccountID.String()
+	}
+	return Pin{
+		AccountId:      accountId,
+		Email:          p.Email,
+		ECDSAPubKey:    p.EcdsaPubKey,
+		Expiration:     p.ExpiresAt,
+		FailedAttempts: p.FailedAttempts,
+		Channel:        p.Channel,
+		Phone:          p.Phone,
+	}
+}
+
+// DBLockoutStore keeps the lockouts in slyip.pin_lockout, so all replicas
+// count the failures together
+type DBLockoutStore struct {
+	repo *repo.PinLockoutRepository
+}
+
+func NewDBLockoutStore(lockoutRepo *repo.PinLockoutRepository) *DBLockoutStore {
+	return &DBLockoutStore{
+		repo: lockoutRepo,
+	}
+}
+
+func (s *DBLockoutStore) Get(ctx context.Context, subject string) (*Lockout, error) {
+	l, err := s.repo.Get(ctx, subject)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lockout := Lockout(*l)
+	return &lockout, nil
+}
+
+func (s *DBLockoutStore) Fail(ctx context.Context, subject string) (*Lockout, error) {
+	l, err := s.repo.IncrementFailedAttempts(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	lockout := Lockout(*l)
+	return &lockout, nil
+}
+
+func (s *DBLockoutStore) Lock(ctx context.Context, subject string, until time.Time) error {
+	return s.repo.Lock(ctx, subject, until)
+}
+
+func (s *DBLockoutStore) Reset(ctx context.Context, subject string) (bool, error) {
+	return s.repo.Delete(ctx, subject)
+}
+
+func (s *DBLockoutStore) List(ctx context.Context) ([]Lockout, error) {
+	lockouts, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Lockout, len(lockouts))
+	for i, l := range lockouts {
+		result[i] = Lockout(l)
+	}
+	return result, nil
+}
+
+// DBRateLimitStore counts the pin requests in slyip.request_limit, so all
+// replicas apply the limits together
+type DBRateLimitStore struct {
+	repo *repo.RequestLimitRepository
+}
+
+func NewDBRateLimitStore(limitRepo *repo.RequestLimitRepository) *DBRateLimitStore {
+	return &DBRateLimitStore{
+		repo: limitRepo,
+	}
+}
+
+func (s *DBRateLimitStore) Hit(ctx context.Context, subject string, windowStart time.Time) (int, error) {
+	return s.repo.Increment(ctx, subject, windowStart)
+}
+
+func (s *DBRateLimitStore) DeleteBefore(ctx context.Context, windowStart time.Time) error {
+	return s.repo.DeleteBefore(ctx, windowStart)
+}
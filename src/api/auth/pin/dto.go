@@ -10,6 +10,8 @@ import (
 type PinRequestDTO struct {
 	Email       string `json:"email"`
 	ECDSAPubKey string `json:"ecdsaPubKey"`
+	// Language of the pin mail, e.g. "de", the default language if empty
+	Language string `json:"language,omitempty"`
 }
 
 func (p *PinRequestDTO) ReadAndValidate(r *http.Request) error {
@@ -25,6 +27,8 @@ func (p *PinRequestDTO) ReadAndValidate(r *http.Request) error {
 }
 
 type PinRedeemDTO struct {
+	// Email optionally binds the redemption to the email the pin was sent to
+	Email        string   `json:"email,omitempty"`
 	Pin          string   `json:"pin"`
 	PinSignature string   `json:"pinSignature"`
 	Audiences    []string `json:"audiences"`
@@ -43,11 +47,106 @@ func (p *PinRedeemDTO) ReadAndValidate(r *http.Request) error {
 		Error()
 }
 
+// PinLinkRedeemDTO redeems the magic link of a pin mail. Token is the t
+// parameter of the link, TokenSignature its signature by the device key the
+// pin was requested with.
+type PinLinkRedeemDTO struct {
+	Token          string   `json:"token"`
+	TokenSignature string   `json:"tokenSignature"`
+	Audiences      []string `json:"audiences"`
+}
+
+func (p *PinLinkRedeemDTO) ReadAndValidate(r *http.Request) error {
+	err := json.NewDecoder(r.Body).Decode(p)
+
+	if err != nil {
+		return slyerrors.NewValidation("400").Add("json is not readable", slyerrors.ValidationCodeCannotValidate, err.Error()).Error()
+	}
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("token", p.Token).
+		ValidateNotEmpty("tokenSignature", p.TokenSignature).
+		ValidateAtLeastOneElement("audiences", p.Audiences).
+		Error()
+}
+
+type PhonePinRequestDTO struct {
+	// Phone in E.164 format, e.g. +4915112345678
+	Phone       string `json:"phone"`
+	ECDSAPubKey string `json:"ecdsaPubKey"`
+	// Language of the SMS, e.g. "de", english if empty
+	Language string `json:"language,omitempty"`
+}
+
+func (p *PhonePinRequestDTO) ReadAndValidate(r *http.Request) error {
+	err := json.NewDecoder(r.Body).Decode(p)
+
+	if err != nil {
+		return slyerrors.NewValidation("400").Add("json is not readable", slyerrors.ValidationCodeCannotValidate, err.Error()).Error()
+	}
+	return slyerrors.NewValidation("400").
+		ValidatePhone("phone", p.Phone).
+		ValidateNotEmpty("ecdsaPubKey", p.ECDSAPubKey).
+		Error()
+}
+
+type PhonePinRedeemDTO struct {
+	// Phone optionally binds the redemption to the phone the pin was sent to
+	Phone        string   `json:"phone,omitempty"`
+	Pin          string   `json:"pin"`
+	PinSignature string   `json:"pinSignature"`
+	Audiences    []string `json:"audiences"`
+}
+
+func (p *PhonePinRedeemDTO) ReadAndValidate(r *http.Request) error {
+	err := json.NewDecoder(r.Body).Decode(p)
+
+	if err != nil {
+		return slyerrors.NewValidation("400").Add("json is not readable", slyerrors.ValidationCodeCannotValidate, err.Error()).Error()
+	}
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("pin", p.Pin).
+		ValidateNotEmpty("pinSignature", p.PinSignature).
+		ValidateAtLeastOneElement("audiences", p.Audiences).
+		Error()
+}
+
+type ClearLockoutDTO struct {
+	// Subject e.g. "ip:203.0.113.7" or "account:0000-0000-...:key:0xab..."
+	Subject string `json:"subject"`
+}
+
+func (p *ClearLockoutDTO) ReadAndValidate(r *http.Request) error {
+	err := json.NewDecoder(r.Body).Decode(p)
+
+	if err != nil {
+		return slyerrors.NewValidation("400").Add("json is not readable", slyerrors.ValidationCodeCannotValidate, err.Error()).Error()
+	}
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("subject", p.Subject).
+		Error()
+}
+
 // swagger : model PinRequestResponse
 type PinRequestResponse struct {
-	AccountId   string    `json:"accountId"`
+	// AccountId is empty until the pin of a new account is redeemed
+	AccountId   string    `json:"accountId,omitempty"`
 	Email       string    `json:"email"`
 	ECDSAPubKey string    `json:"ecdsaPubKey"`
 	Expiration  time.Time `json:"expiration"`
 	Pin         string    `json:"pin"`
+	Link        string    `json:"link,omitempty"`
+	// ResendAfter is the end of the resend cooldown, until then requests
+	// return this pin instead of sending a new one
+	ResendAfter time.Time `json:"resendAfter"`
+}
+
+// swagger : model PhonePinRequestResponse
+type PhonePinRequestResponse struct {
+	// AccountId is empty until the pin of a new account is redeemed
+	AccountId   string    `json:"accountId,omitempty"`
+	Phone       string    `json:"phone"`
+	ECDSAPubKey string    `json:"ecdsaPubKey"`
+	Expiration  time.Time `json:"expiration"`
+	Pin         string    `json:"pin,omitempty"`
+	ResendAfter time.Time `json:"resendAfter"`
 }
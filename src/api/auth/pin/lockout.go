@@ -0,0 +1,181 @@
+package pin
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"yip/src/slyerrors"
+)
+
+const (
+	defaultMaxAttempts     = 5
+	defaultLockoutInSec    = 60
+	defaultMaxLockoutInSec = 24 * 60 * 60
+)
+
+// Lockout counts the failed redemptions of a subject. Subjects are the ip of
+// the caller ("ip:...") and the accounts the pins were requested for, per
+// device key that redeems them ("account:...:key:..."). Keying accounts by
+// device key keeps a guesser from locking the owner out of their own pins.
+type Lockout struct {
+	Subject        string     `json:"subject"`
+	FailedAttempts int        `json:"failedAttempts"`
+	LockedUntil    *time.Time `json:"lockedUntil,omitempty"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+func (l *Lockout) isLocked(now time.Time) bool {
+	return l != nil && l.LockedUntil != nil && l.LockedUntil.After(now)
+}
+
+// LockoutStore keeps the lockouts
+type LockoutStore interface {
+	// Get returns nil if the subject has no failures
+	Get(ctx context.Context, subject string) (*Lockout, error)
+	// Fail counts a failed redemption of the subject
+	Fail(ctx context.Context, subject string) (*Lockout, error)
+	Lock(ctx context.Context, subject string, until time.Time) error
+	// Reset forgets the failures of the subject, it returns false if there
+	// were none
+	Reset(ctx context.Context, subject string) (bool, error)
+	List(ctx context.Context) ([]Lockout, error)
+}
+
+func ipSubject(ip string) string {
+	return "ip:" + ip
+}
+
+func accountSubject(accountId string, key string) string {
+	return "account:" + accountId + ":key:" + key
+}
+
+// checkLocked returns ErrCodePinLocked while the subject is locked
+func (s *Service) checkLocked(ctx context.Context, subject string, now time.Time) error {
+	l, err := s.lockouts.Get(ctx, subject)
+	if err != nil {
+		return err
+	}
+	if l.isLocked(now) {
+		return slyerrors.TooManyRequests(slyerrors.ErrCodePinLocked, "too many failed attempts, locked until %s", l.LockedUntil.Format(time.RFC3339)).
+			WithRetryAfter(l.LockedUntil.Sub(now))
+	}
+	return nil
+}
+
+// fail counts a failed redemption of the subject. Every maxAttempts failures
+// lock the subject, each lock twice as long as the one before. Failures are
+// forgotten after maxLockout without failures.
+func (s *Service) fail(ctx context.Context, subject string, now time.Time) {
+	l, err := s.lockouts.Get(ctx, subject)
+	if err == nil && l != nil && now.Sub(l.UpdatedAt) > s.maxLockout {
+		_, err = s.lockouts.Reset(ctx, subject)
+	}
+	if err == nil {
+		l, err = s.lockouts.Fail(ctx, subject)
+	}
+	if err == nil && l.FailedAttempts%s.maxAttempts == 0 {
+		until := now.Add(s.lockoutDuration(l.FailedAttempts))
+		log.Printf("pin redemption of %s locked until %s after %d failed attempts", subject, until.Format(time.RFC3339), l.FailedAttempts)
+		err = s.lockouts.Lock(ctx, subject, until)
+	}
+	if err != nil {
+		log.Printf("could not count failed pin redemption of %s: %s", subject, err)
+	}
+}
+
+func (s *Service) lockoutDuration(failedAttempts int) time.Duration {
+	d := s.lockout
+	for i := 2 * s.maxAttempts; i <= failedAttempts && d < s.maxLockout; i += s.maxAttempts {
+		d *= 2
+	}
+	if d > s.maxLockout {
+		d = s.maxLockout
+	}
+	return d
+}
+
+// ListLockouts returns the subjects with failed redemptions
+func (s *Service) ListLockouts(ctx context.Context) ([]Lockout, error) {
+	return s.lockouts.List(ctx)
+}
+
+// ClearLockout forgets the failures of a subject, e.g. after an admin checked
+// that the user locked themselves out
+func (s *Service) ClearLockout(ctx context.Context, subject string) error {
+	cleared, err := s.lockouts.Reset(ctx, subject)
+	if err != nil {
+		return err
+	}
+	if !cleared {
+		return slyerrors.NotFound(slyerrors.ErrCodeUnknown, "no lockout for %s", subject)
+	}
+	return nil
+}
+
+// LockoutPool is the in-memory LockoutStore, it is used by tests
+type LockoutPool struct {
+	lockouts map[string]Lockout
+	mutex    *sync.Mutex
+}
+
+func NewLockoutPool() LockoutPool {
+	return LockoutPool{
+		lockouts: make(map[string]Lockout),
+		mutex:    &sync.Mutex{},
+	}
+}
+
+func (p *LockoutPool) Get(_ context.Context, subject string) (*Lockout, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	l, ok := p.lockouts[subject]
+	if !ok {
+		return nil, nil
+	}
+	return &l, nil
+}
+
+func (p *LockoutPool) Fail(_ context.Context, subject string) (*Lockout, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	l := p.lockouts[subject]
+	l.Subject = subject
+	l.FailedAttempts++
+	l.UpdatedAt = time.Now()
+	p.lockouts[subject] = l
+	return &l, nil
+}
+
+func (p *LockoutPool) Lock(_ context.Context, subject string, until time.Time) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if l, ok := p.lockouts[subject]; ok {
+		l.LockedUntil = &until
+		p.lockouts[subject] = l
+	}
+	return nil
+}
+
+func (p *LockoutPool) Reset(_ context.Context, subject string) (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	_, ok := p.lockouts[subject]
+	delete(p.lockouts, subject)
+	return ok, nil
+}
+
+func (p *LockoutPool) List(_ context.Context) ([]Lockout, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	lockouts := make([]Lockout, 0)
+	for _, l := range p.lockouts {
+		lockouts = append(lockouts, l)
+	}
+	return lockouts, nil
+}
@@ -0,0 +1,100 @@
+package pin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+	"yip/src/api/auth/verifier"
+	"yip/src/cryptox"
+	"yip/src/slyerrors"
+)
+
+const defaultMagicLinkExpirationInMin = 15
+
+// newMagicLinkNonce returns the nonce of a magic link, only its hash is
+// stored with the pin
+func newMagicLinkNonce() (string, error) {
+	return cryptox.RandomToken(32)
+}
+
+// magicLink signs the link of a pin, it expires with the pin at the latest
+func (s *Service) magicLink(pin *Pin, nonce string) (string, error) {
+	expiration := s.magicLinkExpiration
+	if until := time.Until(pin.Expiration); until < expiration {
+		expiration = until
+	}
+
+	token, err := s.verifier.SignMagicLink(&verifier.MagicLinkClaims{
+		Nonce:       nonce,
+		ECDSAPubKey: pin.ECDSAPubKey,
+	}, int64(expiration.Seconds()))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s?t=%s", s.magicLinkUrl, url.QueryEscape(token)), nil
+}
+
+// redeemLink consumes the pin of a verified magic link. The signature of the
+// token proves that the caller holds the device key the pin was requested
+// for.
+func (s *Service) redeemLink(ctx context.Context, claims *verifier.MagicLinkClaims, token string, signature string, ip string) (*Pin, error) {
+	now := time.Now()
+
+	if err := s.checkLocked(ctx, ipSubject(ip), now); err != nil {
+		return nil, err
+	}
+
+	address, err := cryptox.Recover(token, signature, cryptox.SignMethodEthereumPrefix, false)
+	if err != nil || address.String() != claims.ECDSAPubKey {
+		s.fail(ctx, ipSubject(ip), now)
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongSignature, "invalid signature")
+	}
+
+	// the pin is gone if the link or the pin was redeemed before
+	pin, err := s.store.GetByLink(ctx, hashPin(s.hashSecret, claims.Nonce))
+	if err != nil {
+		return nil, err
+	}
+	if pin.ECDSAPubKey != claims.ECDSAPubKey {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongSignature, "invalid signature")
+	}
+
+	if err = s.checkLocked(ctx, accountSubject(pin.AccountId, pin.ECDSAPubKey), now); err != nil {
+		return nil, err
+	}
+	if pin.Expiration.Before(now) {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodePinExpired, "pin expired")
+	}
+
+	if err = s.store.Consume(ctx, pin.hash); err != nil {
+		return nil, err
+	}
+
+	if _, err = s.lockouts.Reset(ctx, accountSubject(pin.AccountId, pin.ECDSAPubKey)); err != nil {
+		log.Println("could not reset pin lockout:", err)
+	}
+
+	return pin, nil
+}
+
+// RedeemLink redeems the magic link of a pin mail instead of the pin
+func (s *Service) RedeemLink(ctx context.Context, body *PinLinkRedeemDTO, ip string) (*verifier.Token, error) {
+	if !s.config.VerifyAudiencesExist(body.Audiences) {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeAudienceDoesntExist, "audience(s) dont exist")
+	}
+
+	claims, err := s.verifier.VerifyMagicLink(body.Token)
+	if err != nil {
+		s.fail(ctx, ipSubject(ip), time.Now())
+		return nil, err
+	}
+
+	pin, err := s.redeemLink(ctx, claims, body.Token, body.TokenSignature, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.signIn(ctx, pin, body.Audiences)
+}
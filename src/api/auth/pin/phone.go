@@ -0,0 +1,132 @@
+package pin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yip/src/api/auth/verifier"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+)
+
+// smsTexts by language, the pin is the only argument
+var smsTexts = map[string]string{
+	"en": "Your PIN is %s. Do not share it with anyone.",
+	"de": "Deine PIN lautet %s. Gib sie an niemanden weiter.",
+}
+
+func smsText(lang string, pin string) string {
+	text, ok := smsTexts[strings.ToLower(lang)]
+	if !ok {
+		text = smsTexts["en"]
+	}
+	return fmt.Sprintf(text, pin)
+}
+
+// RequestPhonePin sends a pin by SMS. The pin signs in to the account that
+// verified the phone, or verifies it for the account of the requesting device
+// key, or creates a new account when it is redeemed. It is rate limited like
+// RequestPin.
+func (s *Service) RequestPhonePin(ctx context.Context, body *PhonePinRequestDTO, ip string) (*PhonePinRequestResponse, error) {
+	now := time.Now()
+	request := Pin{Phone: body.Phone, ECDSAPubKey: body.ECDSAPubKey, Channel: ChannelPhone}
+	active, err := s.activePin(ctx, request, now)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return &PhonePinRequestResponse{
+			AccountId:   active.AccountId,
+			Phone:       active.Phone,
+			ECDSAPubKey: active.ECDSAPubKey,
+			Expiration:  active.Expiration,
+			ResendAfter: s.resendAfter(active),
+		}, nil
+	}
+	if err = s.throttleRequest(ctx, request, ip, now); err != nil {
+		return nil, err
+	}
+
+	account, err := s.phoneAccount(ctx, body.Phone, body.ECDSAPubKey)
+	if err != nil {
+		return nil, err
+	}
+	var accountId string
+	if account != nil {
+		accountId = account.ID.String()
+	}
+
+	pin, err := s.create(ctx, Pin{
+		AccountId:   accountId,
+		Phone:       body.Phone,
+		ECDSAPubKey: body.ECDSAPubKey,
+		Channel:     ChannelPhone,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PhonePinRequestResponse{
+		AccountId:   accountId,
+		Phone:       pin.Phone,
+		ECDSAPubKey: pin.ECDSAPubKey,
+		Expiration:  pin.Expiration,
+		ResendAfter: s.resendAfter(pin),
+	}
+
+	if s.config.Test.On {
+		response.Pin = pin.Pin
+		return response, nil
+	}
+
+	if err = s.sms.Send(ctx, pin.Phone, smsText(body.Language, pin.Pin)); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// phoneAccount resolves the account a phone pin is for, it returns nil if
+// there is none yet. An unverified phone never signs in to an account, anyone
+// can enter any phone in a profile. It only gets verified for the account of
+// the device key, whose signature redeems the pin.
+func (s *Service) phoneAccount(ctx context.Context, phone string, ecdsaPubKey string) (*repo.AccountModel, error) {
+	account, err := s.repos.AccountRepo.GetByVerifiedPhone(ctx, phone)
+	if err == nil {
+		return account, nil
+	}
+	if !errors.Is(err, repo.DBItemNotFound) {
+		return nil, err
+	}
+
+	device, err := s.repos.EcdsaRepo.GetByAddress(ctx, ecdsaPubKey)
+	if err != nil && !errors.Is(err, repo.DBItemNotFound) {
+		return nil, err
+	}
+	if device != nil {
+		account, err = s.repos.AccountRepo.GetByID(ctx, device.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if account.Phone == phone {
+			return account, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// RedeemPhonePin redeems a pin sent by SMS and marks the phone verified
+func (s *Service) RedeemPhonePin(ctx context.Context, body *PhonePinRedeemDTO, ip string) (*verifier.Token, error) {
+	if !s.config.VerifyAudiencesExist(body.Audiences) {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeAudienceDoesntExist, "audience(s) dont exist")
+	}
+
+	pin, err := s.redeem(ctx, ChannelPhone, body.Pin, body.PinSignature, body.Phone, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.signIn(ctx, pin, body.Audiences)
+}
@@ -1,114 +1,141 @@
 package pin
 
 import (
+	"context"
 	"sync"
 	"time"
 	"yip/src/cryptox"
 	"yip/src/slyerrors"
 )
 
+const (
+	ChannelEmail = "email"
+	ChannelPhone = "phone"
+)
+
 type Pin struct {
-	AccountId   string    `json:"accountId"`
+	// AccountId is empty for an email resp. phone without account, the account
+	// is created when the pin is redeemed
+	AccountId   string    `json:"accountId,omitempty"`
 	Email       string    `json:"email"`
 	ECDSAPubKey string    `json:"ecdsaPubKey"`
 	Pin         string    `json:"pin,omitempty"`
 	Expiration  time.Time `json:"expirationDate"`
+	// Channel the pin was sent by, ChannelEmail or ChannelPhone
+	Channel string `json:"channel"`
+	Phone   string `json:"phone,omitempty"`
+	// FailedAttempts counts the failed redemptions of the pin
+	FailedAttempts int `json:"failedAttempts"`
+	hash           string
+	// linkHash is the hash of the nonce of the magic link, if one was sent
+	linkHash string
+}
+
+// recipient is the email resp. phone the pin was sent to
+func (p *Pin) recipient() string {
+	if p.Channel == ChannelPhone {
+		return p.Phone
+	}
+	return p.Email
 }
 
+// PinPool is the in-memory Store, it is used by tests
 type PinPool struct {
-	pool            map[string]Pin
-	mutex           *sync.Mutex
-	ExpirationInMin time.Duration
+	pool  map[string]Pin
+	mutex *sync.Mutex
 }
 
-func NewPool(expirationDurationInMin time.Duration) PinPool {
+func NewPool() PinPool {
 	return PinPool{
-		pool:            make(map[string]Pin),
-		mutex:           &sync.Mutex{},
-		ExpirationInMin: expirationDurationInMin,
+		pool:  make(map[string]Pin),
+		mutex: &sync.Mutex{},
 	}
 }
 
-func (p *PinPool) request(accountId string, email string, ecdsaPubKey string) Pin {
+func (p *PinPool) Create(_ context.Context, pinHash string, pin Pin) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	pin := cryptox.GeneratePassword(6, false, false, true)
-	for {
-		_, ok := p.pool[pin]
-		if !ok {
-			pin = cryptox.GeneratePassword(6, false, false, true)
-			break
-		}
+	if _, ok := p.pool[pinHash]; ok {
+		return cryptox.ErrCodeTaken
 	}
 
-	pp := Pin{
-		AccountId:   accountId,
-		Email:       email,
-		ECDSAPubKey: ecdsaPubKey,
-		Pin:         pin,
-		Expiration:  time.Now().Add(p.ExpirationInMin * time.Minute),
-	}
-
-	p.pool[pin] = pp
-
-	p.cleanPool()
-
-	return pp
+	// like the database only the hash is kept
+	pin.Pin = ""
+	p.pool[pinHash] = pin
+	return nil
 }
 
-func (p *PinPool) redeem(pin string, pinSignature string) (Pin, error) {
+func (p *PinPool) GetByLink(_ context.Context, linkHash string) (*Pin, error) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	pp, ok := p.pool[pin]
-	if !ok {
-		return pp, slyerrors.BadRequest(slyerrors.ErrCodePinNotExistent, "pin not found")
-	}
-
-	if pp.Expiration.Before(time.Now()) {
-		return pp, slyerrors.Unauthorized(slyerrors.ErrCodePinExpired, "pin expired")
+	for k, v := range p.pool {
+		if linkHash != "" && v.linkHash == linkHash {
+			v.hash = k
+			return &v, nil
+		}
 	}
+	return nil, slyerrors.BadRequest(slyerrors.ErrCodePinNotExistent, "pin not found")
+}
 
-	address, err := cryptox.Recover(pin, pinSignature, cryptox.SignMethodEthereumPrefix, false)
-	if err != nil {
-		return pp, err
-	}
+func (p *PinPool) ListByKey(_ context.Context, ecdsaPubKey string) ([]Pin, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 
-	if address.String() != pp.ECDSAPubKey {
-		return pp, slyerrors.Unauthorized(slyerrors.ErrCodeWrongSignature, "invalid signature")
+	pins := make([]Pin, 0)
+	for k, v := range p.pool {
+		if v.ECDSAPubKey == ecdsaPubKey {
+			v.hash = k
+			pins = append(pins, v)
+		}
 	}
-
-	p.remove(pin)
-
-	return pp, nil
+	return pins, nil
 }
 
-func (p *PinPool) remove(pin string) {
-	delete(p.pool, pin)
+func (p *PinPool) Fail(_ context.Context, pinHash string) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	pp, ok := p.pool[pinHash]
+	if !ok {
+		return 0, slyerrors.BadRequest(slyerrors.ErrCodePinNotExistent, "pin not found")
+	}
+	pp.FailedAttempts++
+	p.pool[pinHash] = pp
+	return pp.FailedAttempts, nil
 }
 
-func (p *PinPool) list() []Pin {
+func (p *PinPool) Consume(_ context.Context, pinHash string) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	pins := make([]Pin, 0)
-	for _, v := range p.pool {
-		pins = append(pins, v)
+	if _, ok := p.pool[pinHash]; !ok {
+		return slyerrors.BadRequest(slyerrors.ErrCodePinNotExistent, "pin not found")
 	}
-	return pins
+	delete(p.pool, pinHash)
+	return nil
 }
 
-func (p *PinPool) cleanPool() {
-	removePin := make([]string, 0)
-	now := time.Now()
-	for _, v := range p.pool {
+func (p *PinPool) DeleteExpired(_ context.Context, now time.Time) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for k, v := range p.pool {
 		if v.Expiration.Before(now) {
-			removePin = append(removePin, v.Pin)
+			delete(p.pool, k)
 		}
 	}
+	return nil
+}
+
+func (p *PinPool) List(_ context.Context) ([]Pin, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 
-	for _, v := range removePin {
-		p.remove(v)
+	pins := make([]Pin, 0)
+	for _, v := range p.pool {
+		pins = append(pins, v)
 	}
+	return pins, nil
 }
@@ -1,30 +1,365 @@
 package pin
 
 import (
+	"context"
 	"fmt"
+	"github.com/google/uuid"
 	"testing"
 	"time"
+	"yip/src/api/auth/verifier"
 	"yip/src/cryptox"
+	"yip/src/slyerrors"
 )
 
 const (
 	test_email = "some@email.com"
+	test_ip    = "203.0.113.7"
 )
 
+func testService() *Service {
+	pool := NewPool()
+	lockouts := NewLockoutPool()
+	limits := NewRateLimitPool()
+	return &Service{
+		store:       &pool,
+		hashSecret:  []byte("secret"),
+		expiration:  time.Minute,
+		lockouts:    &lockouts,
+		maxAttempts: 3,
+		lockout:     time.Minute,
+		maxLockout:  time.Hour,
+		limits:      &limits,
+
+		requestWindow:           time.Hour,
+		maxRequests:             100,
+		maxRequestsPerIp:        3,
+		maxRequestsPerRecipient: 2,
+		resendCooldown:          time.Minute,
+	}
+}
+
 func TestPinWrongSignature(t *testing.T) {
-	pool := NewPool(time.Minute)
+	s := testService()
+	ctx := context.Background()
 
 	key, _ := cryptox.GenerateNewKey()
-	pin := pool.request("", test_email, key.Address.String())
+	pin, err := s.create(ctx, Pin{Email: test_email, ECDSAPubKey: key.Address.String(), Channel: ChannelEmail})
+	if err != nil {
+		t.Error(err)
+		return
+	}
 	signature, err := cryptox.Sign(pin.Pin, key, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-	calcPin, err := pool.redeem(pin.Pin, signature.Signature)
+	calcPin, err := s.redeem(ctx, ChannelEmail, pin.Pin, signature.Signature, test_email, test_ip)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 	fmt.Println(calcPin)
 }
+
+func TestPinSingleUse(t *testing.T) {
+	s := testService()
+	ctx := context.Background()
+
+	key, _ := cryptox.GenerateNewKey()
+	pin, err := s.create(ctx, Pin{Email: test_email, ECDSAPubKey: key.Address.String(), Channel: ChannelEmail})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := cryptox.Sign(pin.Pin, key, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.redeem(ctx, ChannelEmail, pin.Pin, signature.Signature, test_email, test_ip); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.redeem(ctx, ChannelEmail, pin.Pin, signature.Signature, test_email, test_ip); err == nil {
+		t.Error("pin redeemed twice")
+	}
+}
+
+func TestPinExpired(t *testing.T) {
+	s := testService()
+	s.expiration = -time.Second
+	ctx := context.Background()
+
+	key, _ := cryptox.GenerateNewKey()
+	pin, err := s.create(ctx, Pin{Email: test_email, ECDSAPubKey: key.Address.String(), Channel: ChannelEmail})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := cryptox.Sign(pin.Pin, key, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.redeem(ctx, ChannelEmail, pin.Pin, signature.Signature, test_email, test_ip); err == nil {
+		t.Error("expired pin redeemed")
+	}
+}
+
+func TestPinLockout(t *testing.T) {
+	s := testService()
+	ctx := context.Background()
+
+	key, _ := cryptox.GenerateNewKey()
+	accountId := uuid.NewString()
+	pin, err := s.create(ctx, Pin{AccountId: accountId, Email: test_email, ECDSAPubKey: key.Address.String(), Channel: ChannelEmail})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	guess := func(code string) error {
+		signature, err := cryptox.Sign(code, key, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.redeem(ctx, ChannelEmail, code, signature.Signature, "", test_ip)
+		return err
+	}
+
+	wrong := "000000"
+	if pin.Pin == wrong {
+		wrong = "000001"
+	}
+	for i := 0; i < s.maxAttempts; i++ {
+		if err = guess(wrong); slyerrors.Cause(err).Code != slyerrors.ErrCodePinNotExistent {
+			t.Fatalf("guess %d: %v", i, err)
+		}
+	}
+
+	// the pin is invalidated and the ip locked
+	if err = guess(pin.Pin); slyerrors.Cause(err).Code != slyerrors.ErrCodePinLocked {
+		t.Fatalf("expected lockout, got %v", err)
+	}
+	if pins, _ := s.ListPins(ctx); len(pins) != 0 {
+		t.Errorf("pin not invalidated: %v", pins)
+	}
+
+	lockouts, _ := s.ListLockouts(ctx)
+	if len(lockouts) != 2 {
+		t.Errorf("expected ip and account lockout, got %v", lockouts)
+	}
+	if err = s.ClearLockout(ctx, ipSubject(test_ip)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new pin of the account is locked as well
+	pin, err = s.create(ctx, Pin{AccountId: accountId, Email: test_email, ECDSAPubKey: key.Address.String(), Channel: ChannelEmail})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = guess(pin.Pin); slyerrors.Cause(err).Code != slyerrors.ErrCodePinLocked {
+		t.Errorf("expected account lockout, got %v", err)
+	}
+
+	// the owner redeems the pins of another device key
+	ownerKey, _ := cryptox.GenerateNewKey()
+	pin, err = s.create(ctx, Pin{AccountId: accountId, Email: test_email, ECDSAPubKey: ownerKey.Address.String(), Channel: ChannelEmail})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := cryptox.Sign(pin.Pin, ownerKey, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.redeem(ctx, ChannelEmail, pin.Pin, signature.Signature, "", "198.51.100.1"); err != nil {
+		t.Errorf("owner locked out by another key: %v", err)
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	s := testService()
+
+	for failures, expected := range map[int]time.Duration{
+		3:   time.Minute,
+		6:   2 * time.Minute,
+		9:   4 * time.Minute,
+		300: time.Hour,
+	} {
+		if d := s.lockoutDuration(failures); d != expected {
+			t.Errorf("%d failures: expected %s, got %s", failures, expected, d)
+		}
+	}
+}
+
+func TestPhonePin(t *testing.T) {
+	s := testService()
+	ctx := context.Background()
+
+	key, _ := cryptox.GenerateNewKey()
+	pin, err := s.create(ctx, Pin{Phone: "+4915112345678", ECDSAPubKey: key.Address.String(), Channel: ChannelPhone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := cryptox.Sign(pin.Pin, key, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a phone pin does not verify an email
+	if _, err = s.redeem(ctx, ChannelEmail, pin.Pin, signature.Signature, "", test_ip); err == nil {
+		t.Error("phone pin redeemed as email pin")
+	}
+	if _, err = s.redeem(ctx, ChannelPhone, pin.Pin, signature.Signature, "+4915187654321", test_ip); err == nil {
+		t.Error("phone pin redeemed for another phone")
+	}
+
+	redeemed, err := s.redeem(ctx, ChannelPhone, pin.Pin, signature.Signature, "+4915112345678", test_ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redeemed.Channel != ChannelPhone || redeemed.Phone != "+4915112345678" {
+		t.Errorf("unexpected pin %v", redeemed)
+	}
+}
+
+func TestMagicLink(t *testing.T) {
+	s := testService()
+	ctx := context.Background()
+
+	key, _ := cryptox.GenerateNewKey()
+	other, _ := cryptox.GenerateNewKey()
+	nonce, err := newMagicLinkNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pin, err := s.create(ctx, Pin{Email: test_email, ECDSAPubKey: key.Address.String(), Channel: ChannelEmail, linkHash: hashPin(s.hashSecret, nonce)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &verifier.MagicLinkClaims{Nonce: nonce, ECDSAPubKey: key.Address.String()}
+	token := "signed.magic.link"
+
+	// only the device key the pin was requested with may redeem the link
+	wrong, err := cryptox.Sign(token, other, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.redeemLink(ctx, claims, token, wrong.Signature, test_ip); err == nil {
+		t.Fatal("link redeemed with a foreign key")
+	}
+
+	signature, err := cryptox.Sign(token, key, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.redeemLink(ctx, claims, token, signature.Signature, test_ip); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.redeemLink(ctx, claims, token, signature.Signature, test_ip); err == nil {
+		t.Error("link redeemed twice")
+	}
+
+	// the link consumed the pin
+	pinSignature, err := cryptox.Sign(pin.Pin, key, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.redeem(ctx, ChannelEmail, pin.Pin, pinSignature.Signature, test_email, test_ip); err == nil {
+		t.Error("pin redeemed after its link")
+	}
+}
+
+func TestMagicLinkConsumedByPin(t *testing.T) {
+	s := testService()
+	ctx := context.Background()
+
+	key, _ := cryptox.GenerateNewKey()
+	nonce, err := newMagicLinkNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pin, err := s.create(ctx, Pin{Email: test_email, ECDSAPubKey: key.Address.String(), Channel: ChannelEmail, linkHash: hashPin(s.hashSecret, nonce)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinSignature, err := cryptox.Sign(pin.Pin, key, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.redeem(ctx, ChannelEmail, pin.Pin, pinSignature.Signature, test_email, test_ip); err != nil {
+		t.Fatal(err)
+	}
+
+	token := "signed.magic.link"
+	signature, err := cryptox.Sign(token, key, cryptox.SignMethodEthereumPrefix, cryptox.SignTypeWeb3JS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &verifier.MagicLinkClaims{Nonce: nonce, ECDSAPubKey: key.Address.String()}
+	if _, err = s.redeemLink(ctx, claims, token, signature.Signature, test_ip); err == nil {
+		t.Error("link redeemed after its pin")
+	}
+}
+
+func TestRequestRateLimit(t *testing.T) {
+	s := testService()
+	ctx := context.Background()
+	now := time.Now()
+
+	request := Pin{Email: test_email, Channel: ChannelEmail}
+	for i := 0; i < s.maxRequestsPerRecipient; i++ {
+		if err := s.throttleRequest(ctx, request, test_ip, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := s.throttleRequest(ctx, request, test_ip, now)
+	if err == nil {
+		t.Fatal("recipient not limited")
+	}
+	e := slyerrors.Cause(err)
+	if e.Code != slyerrors.ErrCodePinRateLimited || e.RetryAfter <= 0 {
+		t.Errorf("unexpected error %v, retry after %d", e, e.RetryAfter)
+	}
+
+	// the ip is limited across recipients
+	if err = s.throttleRequest(ctx, Pin{Email: "other@email.com", Channel: ChannelEmail}, test_ip, now); err == nil {
+		t.Error("ip not limited")
+	}
+	if err = s.throttleRequest(ctx, Pin{Email: "other@email.com", Channel: ChannelEmail}, "198.51.100.1", now); err != nil {
+		t.Error(err)
+	}
+
+	// the next window starts over
+	if err = s.throttleRequest(ctx, request, test_ip, now.Add(s.requestWindow)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestResendCooldown(t *testing.T) {
+	s := testService()
+	ctx := context.Background()
+
+	key, _ := cryptox.GenerateNewKey()
+	request := Pin{Email: test_email, ECDSAPubKey: key.Address.String(), Channel: ChannelEmail}
+	pin, err := s.create(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	active, err := s.activePin(ctx, request, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active == nil || active.Expiration != pin.Expiration {
+		t.Fatalf("active pin not reused: %v", active)
+	}
+
+	// another channel or device key gets a pin of its own
+	if active, _ = s.activePin(ctx, Pin{Phone: "+4915112345678", ECDSAPubKey: key.Address.String(), Channel: ChannelPhone}, now); active != nil {
+		t.Error("pin reused for another channel")
+	}
+	other, _ := cryptox.GenerateNewKey()
+	if active, _ = s.activePin(ctx, Pin{Email: test_email, ECDSAPubKey: other.Address.String(), Channel: ChannelEmail}, now); active != nil {
+		t.Error("pin reused for another device key")
+	}
+
+	if active, _ = s.activePin(ctx, request, now.Add(s.resendCooldown)); active != nil {
+		t.Error("pin reused after the cooldown")
+	}
+}
@@ -0,0 +1,141 @@
+package pin
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"yip/src/slyerrors"
+)
+
+const (
+	defaultResendCooldownInSec     = 60
+	defaultRequestWindowInSec      = 60 * 60
+	defaultMaxRequestsPerRecipient = 5
+	defaultMaxRequestsPerIp        = 20
+	defaultMaxRequests             = 1000
+)
+
+// globalSubject counts all pin requests
+const globalSubject = "global"
+
+// RateLimitStore counts the pin requests of a subject per fixed window.
+// Subjects are the ip of the caller ("ip:..."), the email resp. phone the pin
+// is sent to ("email:...", "phone:...") and all requests ("global").
+type RateLimitStore interface {
+	// Hit counts a request of the subject in the window starting at
+	// windowStart and returns the requests of the window
+	Hit(ctx context.Context, subject string, windowStart time.Time) (int, error)
+	// DeleteBefore forgets the windows starting before windowStart
+	DeleteBefore(ctx context.Context, windowStart time.Time) error
+}
+
+func recipientSubject(pin Pin) string {
+	return pin.Channel + ":" + strings.ToLower(pin.recipient())
+}
+
+// throttle counts a request of the subject and returns ErrCodePinRateLimited
+// if the subject made more than max requests in the current window. A max of
+// zero or less disables the limit.
+func (s *Service) throttle(ctx context.Context, subject string, max int, now time.Time) error {
+	if max <= 0 {
+		return nil
+	}
+
+	windowStart := now.Truncate(s.requestWindow)
+	requests, err := s.limits.Hit(ctx, subject, windowStart)
+	if err != nil {
+		return err
+	}
+	if requests > max {
+		return slyerrors.TooManyRequests(slyerrors.ErrCodePinRateLimited, "too many pin requests, retry after %s", windowStart.Add(s.requestWindow).Format(time.RFC3339)).
+			WithRetryAfter(windowStart.Add(s.requestWindow).Sub(now))
+	}
+	return nil
+}
+
+// throttleRequest applies the global, ip and recipient limits to a pin
+// request. The recipient is limited last, so requests rejected by the other
+// limits do not use up the quota of someone else's inbox.
+func (s *Service) throttleRequest(ctx context.Context, pin Pin, ip string, now time.Time) error {
+	if err := s.limits.DeleteBefore(ctx, now.Truncate(s.requestWindow)); err != nil {
+		log.Println("could not delete pin request limits:", err)
+	}
+
+	if err := s.throttle(ctx, globalSubject, s.maxRequests, now); err != nil {
+		return err
+	}
+	if err := s.throttle(ctx, ipSubject(ip), s.maxRequestsPerIp, now); err != nil {
+		return err
+	}
+	return s.throttle(ctx, recipientSubject(pin), s.maxRequestsPerRecipient, now)
+}
+
+// activePin returns the latest pin requested for the recipient and device key
+// of pin within the resend cooldown, or nil if there is none. Such a request
+// reuses the active pin instead of sending a new one.
+func (s *Service) activePin(ctx context.Context, pin Pin, now time.Time) (*Pin, error) {
+	pins, err := s.store.ListByKey(ctx, pin.ECDSAPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var active *Pin
+	for i := range pins {
+		p := &pins[i]
+		if p.Channel != pin.Channel || !strings.EqualFold(p.recipient(), pin.recipient()) || !p.Expiration.After(now) {
+			continue
+		}
+		if s.resendAfter(p).After(now) && (active == nil || p.Expiration.After(active.Expiration)) {
+			active = p
+		}
+	}
+	return active, nil
+}
+
+// resendAfter is the time a new pin may be sent instead of pin
+func (s *Service) resendAfter(pin *Pin) time.Time {
+	return pin.Expiration.Add(-s.expiration).Add(s.resendCooldown)
+}
+
+// RateLimitPool is the in-memory RateLimitStore, it is used by tests
+type RateLimitPool struct {
+	windows map[string]map[time.Time]int
+	mutex   *sync.Mutex
+}
+
+func NewRateLimitPool() RateLimitPool {
+	return RateLimitPool{
+		windows: make(map[string]map[time.Time]int),
+		mutex:   &sync.Mutex{},
+	}
+}
+
+func (p *RateLimitPool) Hit(_ context.Context, subject string, windowStart time.Time) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.windows[subject] == nil {
+		p.windows[subject] = make(map[time.Time]int)
+	}
+	p.windows[subject][windowStart]++
+	return p.windows[subject][windowStart], nil
+}
+
+func (p *RateLimitPool) DeleteBefore(_ context.Context, windowStart time.Time) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for subject, windows := range p.windows {
+		for start := range windows {
+			if start.Before(windowStart) {
+				delete(windows, start)
+			}
+		}
+		if len(windows) == 0 {
+			delete(p.windows, subject)
+		}
+	}
+	return nil
+}
@@ -2,24 +2,53 @@ package pin
 
 import (
 	"context"
-	"fmt"
+	"crypto/hmac"
+	"errors"
 	"github.com/google/uuid"
+	"log"
+	"strings"
 	"time"
 	"yip/src/api/auth/verifier"
 	"yip/src/config"
+	"yip/src/cryptox"
 	"yip/src/providers"
 	"yip/src/repositories"
 	"yip/src/repositories/repo"
 	"yip/src/slyerrors"
 )
 
+const (
+	defaultExpirationInMin = 60
+	// attempts to find a pin no outstanding pin has
+	maxCreateAttempts = 5
+)
+
 type Service struct {
-	config   *config.Config
-	verifier *verifier.Verifier
-	pool     PinPool
-	database repositories.Database
-	ep       *providers.EmailProvider
-	repos    *repo.Repositories
+	config     *config.Config
+	verifier   *verifier.Verifier
+	store      Store
+	hashSecret []byte
+	expiration time.Duration
+	lockouts   LockoutStore
+	// failed redemptions of a pin before it is invalidated, resp. of a
+	// subject before it is locked for lockout, twice as long with each lock
+	maxAttempts int
+	lockout     time.Duration
+	maxLockout  time.Duration
+	// magic links are sent along the pin mail if magicLinkUrl is set
+	magicLinkUrl        string
+	magicLinkExpiration time.Duration
+	limits              RateLimitStore
+	// pin requests per requestWindow, a limit of zero or less is disabled
+	requestWindow           time.Duration
+	maxRequests             int
+	maxRequestsPerIp        int
+	maxRequestsPerRecipient int
+	resendCooldown          time.Duration
+	database                repositories.Database
+	ep                      *providers.EmailProvider
+	sms                     providers.SMSProvider
+	repos                   *repo.Repositories
 }
 
 func NewService(
@@ -27,40 +56,251 @@ func NewService(
 	verifier *verifier.Verifier,
 	useDB repositories.Database,
 	ep *providers.EmailProvider,
+	sms providers.SMSProvider,
 	repos *repo.Repositories,
 ) Service {
+	expirationInMin := config.Pin.ExpirationInMin
+	if expirationInMin <= 0 {
+		expirationInMin = defaultExpirationInMin
+	}
+	maxAttempts := config.Pin.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	lockoutInSec := config.Pin.LockoutInSec
+	if lockoutInSec <= 0 {
+		lockoutInSec = defaultLockoutInSec
+	}
+	maxLockoutInSec := config.Pin.MaxLockoutInSec
+	if maxLockoutInSec <= 0 {
+		maxLockoutInSec = defaultMaxLockoutInSec
+	}
+	magicLinkExpirationInMin := config.Pin.MagicLinkExpirationInMin
+	if magicLinkExpirationInMin <= 0 {
+		magicLinkExpirationInMin = defaultMagicLinkExpirationInMin
+	}
+	resendCooldownInSec := config.Pin.ResendCooldownInSec
+	if resendCooldownInSec == 0 {
+		resendCooldownInSec = defaultResendCooldownInSec
+	}
+	requestWindowInSec := config.Pin.RequestWindowInSec
+	if requestWindowInSec <= 0 {
+		requestWindowInSec = defaultRequestWindowInSec
+	}
+	maxRequests := config.Pin.MaxRequests
+	if maxRequests == 0 {
+		maxRequests = defaultMaxRequests
+	}
+	maxRequestsPerIp := config.Pin.MaxRequestsPerIp
+	if maxRequestsPerIp == 0 {
+		maxRequestsPerIp = defaultMaxRequestsPerIp
+	}
+	maxRequestsPerRecipient := config.Pin.MaxRequestsPerRecipient
+	if maxRequestsPerRecipient == 0 {
+		maxRequestsPerRecipient = defaultMaxRequestsPerRecipient
+	}
 	return Service{
-		config:   config,
-		verifier: verifier,
-		pool:     NewPool(60 * time.Minute),
-		database: useDB,
-		ep:       ep,
-		repos:    repos,
+		config:                  config,
+		verifier:                verifier,
+		store:                   NewDBStore(repos.PinRepo),
+		hashSecret:              []byte(config.Pin.HashSecret),
+		expiration:              time.Duration(expirationInMin) * time.Minute,
+		lockouts:                NewDBLockoutStore(repos.PinLockoutRepo),
+		maxAttempts:             maxAttempts,
+		lockout:                 time.Duration(lockoutInSec) * time.Second,
+		maxLockout:              time.Duration(maxLockoutInSec) * time.Second,
+		magicLinkUrl:            config.Pin.MagicLinkUrl,
+		magicLinkExpiration:     time.Duration(magicLinkExpirationInMin) * time.Minute,
+		limits:                  NewDBRateLimitStore(repos.RequestLimitRepo),
+		requestWindow:           time.Duration(requestWindowInSec) * time.Second,
+		maxRequests:             maxRequests,
+		maxRequestsPerIp:        maxRequestsPerIp,
+		maxRequestsPerRecipient: maxRequestsPerRecipient,
+		resendCooldown:          time.Duration(resendCooldownInSec) * time.Second,
+		database:                useDB,
+		ep:                      ep,
+		sms:                     sms,
+		repos:                   repos,
+	}
+}
+
+// create stores a new pin of the account, channel and device key of pin. A pin
+// is only handed out once while it is outstanding.
+func (s *Service) create(ctx context.Context, pin Pin) (*Pin, error) {
+	if err := s.store.DeleteExpired(ctx, time.Now()); err != nil {
+		log.Println("could not delete expired pins:", err)
 	}
+
+	pin.Expiration = time.Now().Add(s.expiration)
+
+	code, err := cryptox.Allocate(cryptox.PinCodes, maxCreateAttempts, func(code string) error {
+		return s.store.Create(ctx, hashPin(s.hashSecret, code), pin)
+	})
+	if err != nil {
+		return nil, slyerrors.Unexpected(slyerrors.ErrCodeUnknown, err.Error())
+	}
+
+	pin.Pin = code
+	return &pin, nil
 }
 
-func (s *Service) RequestPin(ctx context.Context, body *PinRequestDTO) (*PinRequestResponse, error) {
-	account, err := s.database.GetAccountByEmail(ctx, body.Email)
+// redeem checks the pin and consumes it. The pin is looked up among the pins
+// of the device key that signed it, so a guess only hits the pins requested
+// for one key and channel. If recipient is given, it has to be the email resp.
+// phone the pin was sent to. Failures are counted per pin, account and ip.
+func (s *Service) redeem(ctx context.Context, channel string, code string, pinSignature string, recipient string, ip string) (*Pin, error) {
+	now := time.Now()
+
+	if err := s.checkLocked(ctx, ipSubject(ip), now); err != nil {
+		return nil, err
+	}
+
+	address, err := cryptox.Recover(code, pinSignature, cryptox.SignMethodEthereumPrefix, false)
 	if err != nil {
-		fmt.Println("trying to get account by email but failed: ", err.Error())
-		account, err = s.database.RegisterAccountWithEmail(ctx, body.Email)
-		if err != nil {
-			fmt.Println("trying to register account by email but failed: ", err.Error())
+		s.fail(ctx, ipSubject(ip), now)
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongSignature, "invalid signature")
+	}
+
+	keyPins, err := s.store.ListByKey(ctx, address.String())
+	if err != nil {
+		return nil, err
+	}
+	pins := make([]Pin, 0, len(keyPins))
+	for _, p := range keyPins {
+		if p.Channel == channel {
+			pins = append(pins, p)
+		}
+	}
+
+	accounts := make(map[string]bool)
+	for _, p := range pins {
+		if p.AccountId != "" {
+			accounts[p.AccountId] = true
+		}
+	}
+	for accountId := range accounts {
+		if err = s.checkLocked(ctx, accountSubject(accountId, address.String()), now); err != nil {
+			return nil, err
+		}
+	}
+
+	pinHash := hashPin(s.hashSecret, code)
+	var pin *Pin
+	for i := range pins {
+		if hmac.Equal([]byte(pins[i].hash), []byte(pinHash)) && (recipient == "" || strings.EqualFold(pins[i].recipient(), recipient)) {
+			pin = &pins[i]
+			break
+		}
+	}
+
+	if pin == nil {
+		s.fail(ctx, ipSubject(ip), now)
+		for accountId := range accounts {
+			s.fail(ctx, accountSubject(accountId, address.String()), now)
+		}
+		for _, p := range pins {
+			s.failPin(ctx, p.hash)
+		}
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodePinNotExistent, "pin not found")
+	}
+
+	if pin.Expiration.Before(now) {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodePinExpired, "pin expired")
+	}
+
+	// single use, a concurrent redemption of the same pin fails here
+	if err = s.store.Consume(ctx, pin.hash); err != nil {
+		return nil, err
+	}
+
+	if pin.AccountId != "" {
+		if _, err = s.lockouts.Reset(ctx, accountSubject(pin.AccountId, pin.ECDSAPubKey)); err != nil {
+			log.Println("could not reset pin lockout:", err)
+		}
+	}
+
+	return pin, nil
+}
+
+// failPin counts a failed redemption of a pin, after maxAttempts the pin is
+// invalidated
+func (s *Service) failPin(ctx context.Context, pinHash string) {
+	n, err := s.store.Fail(ctx, pinHash)
+	if err == nil && n >= s.maxAttempts {
+		err = s.store.Consume(ctx, pinHash)
+	}
+	if err != nil {
+		log.Println("could not count failed pin redemption:", err)
+	}
+}
+
+// RequestPin sends a pin to an email. Requests are rate limited, and within
+// the resend cooldown the active pin is reused. The account of an unknown
+// email is created when the pin is redeemed.
+func (s *Service) RequestPin(ctx context.Context, body *PinRequestDTO, ip string) (*PinRequestResponse, error) {
+	now := time.Now()
+	request := Pin{Email: body.Email, ECDSAPubKey: body.ECDSAPubKey, Channel: ChannelEmail}
+	active, err := s.activePin(ctx, request, now)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return &PinRequestResponse{
+			AccountId:   active.AccountId,
+			Email:       active.Email,
+			ECDSAPubKey: active.ECDSAPubKey,
+			Expiration:  active.Expiration,
+			ResendAfter: s.resendAfter(active),
+		}, nil
+	}
+	if err = s.throttleRequest(ctx, request, ip, now); err != nil {
+		return nil, err
+	}
+
+	var accountId string
+	account, err := s.repos.AccountRepo.GetByEmail(ctx, body.Email)
+	if err == nil {
+		accountId = account.ID.String()
+	} else if !errors.Is(err, repo.DBItemNotFound) {
+		return nil, err
+	}
+
+	template := Pin{
+		AccountId:   accountId,
+		Email:       body.Email,
+		ECDSAPubKey: body.ECDSAPubKey,
+		Channel:     ChannelEmail,
+	}
+	// the link is stored with the pin, redeeming either consumes both
+	var nonce string
+	if s.magicLinkUrl != "" {
+		if nonce, err = newMagicLinkNonce(); err != nil {
 			return nil, err
 		}
+		template.linkHash = hashPin(s.hashSecret, nonce)
 	}
 
-	pin := s.pool.request(account.ID, account.Email, body.ECDSAPubKey)
+	pin, err := s.create(ctx, template)
+	if err != nil {
+		return nil, err
+	}
+	var link string
+	if nonce != "" {
+		if link, err = s.magicLink(pin, nonce); err != nil {
+			return nil, err
+		}
+	}
 	response := &PinRequestResponse{
-		AccountId:   account.ID,
+		AccountId:   accountId,
 		Email:       pin.Email,
 		ECDSAPubKey: pin.ECDSAPubKey,
 		Expiration:  pin.Expiration,
+		ResendAfter: s.resendAfter(pin),
 	}
 
 	// test
 	if !s.config.Test.On {
-		err = s.ep.SendPinMail(pin.Email, pin.Pin)
+		err = s.ep.SendPinMail(ctx, pin.Email, body.Language, providers.PinMail{Pin: pin.Pin, Link: link, ExpiresAt: pin.Expiration})
 		if err != nil {
 			return nil, err
 		}
@@ -68,54 +308,109 @@ func (s *Service) RequestPin(ctx context.Context, body *PinRequestDTO) (*PinRequ
 
 	if s.config.Test.On {
 		response.Pin = pin.Pin
+		response.Link = link
 	}
 
 	return response, nil
 }
 
-func (s *Service) Redeem(ctx context.Context, body *PinRedeemDTO) (*verifier.Token, error) {
+func (s *Service) Redeem(ctx context.Context, body *PinRedeemDTO, ip string) (*verifier.Token, error) {
 	if !s.config.VerifyAudiencesExist(body.Audiences) {
 		return nil, slyerrors.BadRequest(slyerrors.ErrCodeAudienceDoesntExist, "audience(s) dont exist")
 	}
-	pin, err := s.pool.redeem(body.Pin, body.PinSignature)
+	pin, err := s.redeem(ctx, ChannelEmail, body.Pin, body.PinSignature, body.Email, ip)
 	if err != nil {
 		return nil, err
 	}
 
+	return s.signIn(ctx, pin, body.Audiences)
+}
+
+// signIn registers the device key of a redeemed pin with its account, marks the
+// email resp. phone verified and issues the token. The account of a new email
+// resp. phone is created here, so unredeemed pins leave no accounts behind.
+func (s *Service) signIn(ctx context.Context, pin *Pin, audiences []string) (*verifier.Token, error) {
+	if pin.AccountId == "" {
+		accountId, err := s.createAccount(ctx, pin)
+		if err != nil {
+			return nil, err
+		}
+		pin.AccountId = accountId
+	}
+
 	uu, err := uuid.Parse(pin.AccountId)
 	if err != nil {
 		return nil, slyerrors.Unexpected(slyerrors.ErrCodeUnknown, err.Error())
 	}
 
-	devices, err := s.database.GetDevices(ctx, uu)
-	if err != nil {
+	// a key of another account is not moved silently, the accounts have to be
+	// linked and merged instead
+	device, err := s.repos.EcdsaRepo.GetByAddress(ctx, pin.ECDSAPubKey)
+	if err != nil && !errors.Is(err, repo.DBItemNotFound) {
 		return nil, err
 	}
-
-	deviceIsRegistered := false
-
-	for _, d := range devices {
-		if d.Address == pin.ECDSAPubKey {
-			deviceIsRegistered = true
-			break
-		}
+	if device != nil && device.AccountID != uu {
+		return nil, slyerrors.Conflict(slyerrors.ErrCodeKeyOfOtherAccount, "the key belongs to another account, link the accounts to use it")
 	}
 
-	if !deviceIsRegistered {
+	if device == nil {
 		_, err = s.database.AddDevice(ctx, uu, pin.ECDSAPubKey)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	account, err := s.repos.AccountRepo.SetEmailVerified(ctx, uu)
+	var account *repo.AccountModel
+	if pin.Channel == ChannelPhone {
+		account, err = s.repos.AccountRepo.SetPhoneVerified(ctx, uu, pin.Phone)
+	} else {
+		account, err = s.repos.AccountRepo.SetEmailVerified(ctx, uu)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.verifier.CreateToken(audiences, account.ID.String(), pin.ECDSAPubKey, account.LastUsedSlyWallet, verifier.RoleBasic)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.verifier.CreateToken(body.Audiences, account.ID.String(), pin.ECDSAPubKey, account.LastUsedSlyWallet, verifier.RoleBasic)
+	// a failing record does not fail the sign-in
+	_, err = s.repos.SignInRepo.Create(ctx, &repo.SignInModel{AccountID: account.ID, Method: repo.SignInMethodPin, EOA: pin.ECDSAPubKey})
+	if err != nil {
+		log.Println("could not record pin sign-in:", err)
+	}
+	return token, nil
+}
+
+// createAccount resolves the account of a redeemed pin requested without one,
+// another pin may have created it in the meantime, or creates it
+func (s *Service) createAccount(ctx context.Context, pin *Pin) (string, error) {
+	if pin.Channel != ChannelPhone {
+		account, err := s.repos.AccountRepo.GetByEmail(ctx, pin.Email)
+		if err == nil {
+			return account.ID.String(), nil
+		}
+		if !errors.Is(err, repo.DBItemNotFound) {
+			return "", err
+		}
+		created, err := s.database.RegisterAccountWithEmail(ctx, pin.Email)
+		if err != nil {
+			return "", err
+		}
+		return created.ID, nil
+	}
+
+	account, err := s.phoneAccount(ctx, pin.Phone, pin.ECDSAPubKey)
+	if err == nil && account == nil {
+		account, err = s.repos.AccountRepo.CreateWithPhone(ctx, pin.Phone)
+	}
+	if err != nil {
+		return "", err
+	}
+	return account.ID.String(), nil
 }
 
 func (s *Service) ListPins(ctx context.Context) ([]Pin, error) {
-	return s.pool.list(), nil
+	return s.store.List(ctx)
 }
@@ -0,0 +1,33 @@
+package pin
+
+import (
+	"context"
+	"time"
+	"yip/src/cryptox"
+)
+
+// Store keeps the outstanding pins. Pins are keyed by their hash, a store
+// never sees the pin itself.
+type Store interface {
+	// Create returns cryptox.ErrCodeTaken if an outstanding pin has the hash
+	Create(ctx context.Context, pinHash string, pin Pin) error
+	// GetByLink returns the pin of a magic link with its hash, or
+	// ErrCodePinNotExistent
+	GetByLink(ctx context.Context, linkHash string) (*Pin, error)
+	// ListByKey returns the pins requested for the device key, with their hash
+	ListByKey(ctx context.Context, ecdsaPubKey string) ([]Pin, error)
+	// Fail counts a failed redemption of the pin and returns the failed
+	// attempts so far
+	Fail(ctx context.Context, pinHash string) (int, error)
+	// Consume removes the pin. Of concurrent calls only one succeeds, the
+	// others get ErrCodePinNotExistent.
+	Consume(ctx context.Context, pinHash string) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+	List(ctx context.Context) ([]Pin, error)
+}
+
+// hashPin keys the hash with secret, a 6 digit pin could be recovered from a
+// plain hash by trying all pins
+func hashPin(secret []byte, pin string) string {
+	return cryptox.HashCode(secret, pin)
+}
@@ -0,0 +1,99 @@
+package push
+
+import (
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services"
+	"yip/src/api/services/dto"
+	"yip/src/common"
+	"yip/src/httpx"
+)
+
+type Controller struct {
+	pushService     *services.PushService
+	tokenMiddleware verifier.TokenVerifierMiddleware
+}
+
+func NewController(service *services.PushService, tokenMiddleware *verifier.TokenVerifierMiddleware) Controller {
+	return Controller{
+		pushService:     service,
+		tokenMiddleware: *tokenMiddleware,
+	}
+}
+
+func (c Controller) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(c.tokenMiddleware.PrincipalCtx)
+			r.Post("/token", c.RegisterToken)
+			r.Delete("/token", c.UnregisterToken)
+		})
+	}
+}
+
+// swagger:parameters registerPushToken
+type registerPushToken struct {
+	// in:body
+	Body dto.PushTokenRequestDTO
+}
+
+// swagger:route POST /auth/push/token Push registerPushToken
+// Registers the push token of the device key the token was issued for
+//
+// Responses:
+//
+//	204: noContent
+func (a Controller) RegisterToken(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	data := &dto.PushTokenRequestDTO{}
+	if err = common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	if err = a.pushService.RegisterToken(r.Context(), &principal, data); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.NoContent())
+}
+
+// swagger:parameters unregisterPushToken
+type unregisterPushToken struct {
+	// in:body
+	Body dto.PushTokenRequestDTO
+}
+
+// swagger:route DELETE /auth/push/token Push unregisterPushToken
+// Removes the push token of the device key the token was issued for
+//
+// Responses:
+//
+//	204: noContent
+func (a Controller) UnregisterToken(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	data := &dto.PushTokenRequestDTO{}
+	if err = common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	if err = a.pushService.UnregisterToken(r.Context(), &principal, data.Token); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.NoContent())
+}
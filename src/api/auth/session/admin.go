@@ -0,0 +1,171 @@
+package session
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"sort"
+	"time"
+)
+
+// PartyAdmin ends a session that was force-closed by an admin
+const PartyAdmin = "admin"
+
+// SessionInfo is the admin view of a session held by the MConnector
+type SessionInfo struct {
+	SessionId   string    `json:"sessionId"`
+	SessionType string    `json:"sessionType"`
+	ClientId    string    `json:"clientId"`
+	State       string    `json:"state"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"createdAt"`
+	AgeInSec    int64     `json:"ageInSec"`
+	EOA         string    `json:"eoa,omitempty"`
+	// Parties that took part in the session so far
+	Parties          []string `json:"parties"`
+	WebsocketClients int      `json:"websocketClients"`
+	Reason           string   `json:"reason,omitempty"`
+}
+
+// SessionFilter selects sessions, empty fields match all. Only active
+// sessions are listed unless All is set.
+type SessionFilter struct {
+	SessionType string
+	ClientId    string
+	State       string
+	EOA         string
+	MinAgeInSec int64
+	All         bool
+}
+
+func (f SessionFilter) matches(i *SessionInfo) bool {
+	return (f.All || i.Active) &&
+		(f.SessionType == "" || f.SessionType == i.SessionType) &&
+		(f.ClientId == "" || f.ClientId == i.ClientId) &&
+		(f.State == "" || f.State == i.State) &&
+		(f.EOA == "" || f.EOA == i.EOA) &&
+		i.AgeInSec >= f.MinAgeInSec
+}
+
+func (s *Session) info() *SessionInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := &SessionInfo{
+		SessionId:   s.SessionId.String(),
+		SessionType: s.SessionType,
+		ClientId:    s.clientId,
+		CreatedAt:   s.createdAt,
+		AgeInSec:    int64(time.Since(s.createdAt).Seconds()),
+		Parties:     []string{PartyDApp},
+	}
+
+	final := false
+	switch {
+	case s.AuthFlow != nil:
+		i.State = s.AuthFlow.StateName()
+		i.EOA = s.AuthFlow.eoa
+		i.Reason = s.AuthFlow.reason
+		final = s.AuthFlow.isVerified() || s.AuthFlow.isEnded()
+	case s.SignRequestFlow != nil:
+		i.State = s.SignRequestFlow.state
+		i.EOA = s.SignRequestFlow.eoa
+		i.Reason = s.SignRequestFlow.reason
+		final = s.SignRequestFlow.isFinal() || s.SignRequestFlow.state == SignRequestStateExpired
+	case s.LinkDeviceFlow != nil:
+		i.State = s.LinkDeviceFlow.state
+		i.EOA = s.LinkDeviceFlow.approver
+		i.Reason = s.LinkDeviceFlow.reason
+		final = s.LinkDeviceFlow.isFinal()
+	}
+	if i.EOA != "" {
+		i.Parties = append(i.Parties, PartyWallet)
+	}
+	if s.isClosed {
+		i.State = AuthFlowStateNameClosed
+	}
+	i.Active = !s.isClosed && !final
+
+	for c := range s.clients {
+		if c.CommunicationType == CommunicationTypeWebsocket {
+			i.WebsocketClients++
+		}
+	}
+
+	return i
+}
+
+// forceClose ends an open flow on behalf of an admin and closes the session,
+// waiting parties are notified by the session events
+func (s *Session) forceClose(reason string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case s.AuthFlow != nil:
+		if s.AuthFlow.end(AuthFlowStateCancelled, PartyAdmin, reason) == nil {
+			s.publishAuthStateLocked()
+		}
+	case s.SignRequestFlow != nil:
+		if !s.SignRequestFlow.isFinal() && s.SignRequestFlow.state != SignRequestStateExpired {
+			s.SignRequestFlow.setFailed(reason)
+			s.publishSignRequestStateLocked()
+		}
+	case s.LinkDeviceFlow != nil:
+		// an approval in flight is finished by its transaction
+		if s.LinkDeviceFlow.state == LinkDeviceStateCreated && !s.LinkDeviceFlow.approving {
+			s.LinkDeviceFlow.state = LinkDeviceStateFailed
+			s.LinkDeviceFlow.reason = reason
+			s.publishLinkDeviceStateLocked()
+		}
+	}
+
+	s.isClosed = true
+	s.publishAuthStateLocked()
+}
+
+// ListSessions returns the sessions matching filter, the oldest first
+func (mc *MConnector) ListSessions(filter SessionFilter) []SessionInfo {
+	mc.lock.Lock()
+	sessions := make([]*Session, 0, len(mc.sessions))
+	for _, s := range mc.sessions {
+		sessions = append(sessions, s)
+	}
+	mc.lock.Unlock()
+
+	infos := []SessionInfo{}
+	for _, s := range sessions {
+		if i := s.info(); filter.matches(i) {
+			infos = append(infos, *i)
+		}
+	}
+
+	sort.Slice(infos, func(a, b int) bool {
+		return infos[a].CreatedAt.Before(infos[b].CreatedAt)
+	})
+	return infos
+}
+
+// GetSessionInfo returns the admin view of a session
+func (mc *MConnector) GetSessionInfo(sessionId uuid.UUID) (*SessionInfo, error) {
+	s, err := mc.getSession(sessionId)
+	if err != nil {
+		return nil, err
+	}
+	return s.info(), nil
+}
+
+// ForceClose closes a session on behalf of an admin
+func (mc *MConnector) ForceClose(sessionId uuid.UUID, reason string) (*SessionInfo, error) {
+	s, err := mc.getSession(sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	i := s.info()
+	if !i.Active {
+		return nil, fmt.Errorf("session already %s", i.State)
+	}
+
+	s.forceClose(reason)
+	return s.info(), nil
+}
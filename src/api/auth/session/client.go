@@ -170,6 +170,9 @@ func (c *SessionClient) handleSessionRequest(wm *WebsocketMessage) error {
 	if err != nil {
 		return err
 	}
+	if s.verifyNotClosedYet() != nil {
+		return fmt.Errorf("session closed")
+	}
 
 	c.session = s
 	return nil
@@ -7,7 +7,10 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"log"
 	"net/http"
+	"time"
 	"yip/src/api/auth/verifier"
 	"yip/src/api/services"
 	"yip/src/api/services/dto"
@@ -17,28 +20,39 @@ import (
 )
 
 type Controller struct {
-	siweService *services.SIWEService
-	userService *services.UserService
-	MConnector  MConnector
-	config      *config.Config
+	siweService      *services.SIWEService
+	userService      *services.UserService
+	slyWalletService *services.SLYWalletService
+	auditService     *services.SessionAuditService
+	pushService      *services.PushService
+	MConnector       MConnector
+	config           *config.Config
 }
 
 func NewController(
 	c *config.Config,
 	service *services.SIWEService,
 	userService *services.UserService,
+	slyWalletService *services.SLYWalletService,
+	auditService *services.SessionAuditService,
+	pushService *services.PushService,
 ) Controller {
 	return Controller{
-		siweService: service,
-		userService: userService,
-		config:      c,
-		MConnector:  InitMConnector(),
+		siweService:      service,
+		userService:      userService,
+		slyWalletService: slyWalletService,
+		auditService:     auditService,
+		pushService:      pushService,
+		config:           c,
+		MConnector:       InitMConnector(),
 	}
 }
 
 func (c Controller) Routes() func(r chi.Router) {
 	return func(r chi.Router) {
 		r.Post("/", c.SessionChannel)
+		r.Get("/{id}/events", c.Events)
+		r.Get("/{id}/ws", c.EventsWebsocket)
 	}
 }
 
@@ -51,35 +65,79 @@ func (a Controller) SessionChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := negotiateVersion(msg.Version)
+	if err != nil {
+		httpx.RespondWithJSON(w, flowErrorResponse(err, msg.SessionId))
+		return
+	}
+
+	resp := a.dispatch(r.Context(), msg, version, httpx.ClientIP(r))
+	if wm, ok := resp.Payload.(*WebsocketMessage); ok {
+		wm.stampReply(msg, version)
+	}
+	httpx.RespondWithJSON(w, resp)
+}
+
+func (a Controller) dispatch(ctx context.Context, msg *WebsocketMessage, version int, ip string) *httpx.Response {
+	if err := msg.decodePayload(version); err != nil {
+		switch e := slyerrors.Cause(err); e.Code {
+		case slyerrors.ErrCodeSessionMessageTypeUnknown:
+			return jsonErrorResponse(200, e.Code, "unknown message type", e.Details, msg.SessionId)
+		case slyerrors.ErrCodeSessionUnsupportedVersion:
+			return jsonErrorResponse(200, e.Code, e.Details, "", msg.SessionId)
+		}
+		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", msg.SessionId)
+	}
+
 	switch msg.MessageType {
 	case MessageTypeCreateSessionRequest:
-		httpx.RespondWithJSON(w, a.CreateSession(msg))
+		return a.CreateSession(ctx, msg, ip)
 	case MessageTypeConnectWithAccount:
-		httpx.RespondWithJSON(w, a.SetAccount(msg))
+		return a.SetAccount(msg)
 	case MessageTypeSubmitSignature:
-		httpx.RespondWithJSON(w, a.SubmitSIWE(r.Context(), msg))
+		return a.SubmitSIWE(ctx, msg)
 	case MessageTypePingToken:
-		httpx.RespondWithJSON(w, a.PingResult(msg))
+		return a.PingResult(msg)
 	case MessageTypeCloseSession:
-		httpx.RespondWithJSON(w, a.CloseSession(msg))
+		return a.CloseSession(msg)
+	case MessageTypeFetchSignRequest:
+		return a.FetchSignRequest(ctx, msg)
+	case MessageTypeSubmitSignRequest:
+		return a.SubmitSignRequest(ctx, msg)
+	case MessageTypeRejectSignRequest:
+		return a.RejectSignRequest(msg)
+	case MessageTypeSignRequestStatus:
+		return a.SignRequestStatus(msg)
+	case MessageTypeFetchLinkDevice:
+		return a.FetchLinkDevice(msg)
+	case MessageTypeProveLinkDevice:
+		return a.ProveLinkDevice(msg)
+	case MessageTypeApproveLinkDevice:
+		return a.ApproveLinkDevice(ctx, msg)
+	case MessageTypeLinkDeviceStatus:
+		return a.LinkDeviceStatus(msg)
+	case MessageTypeE2EHandshake:
+		return a.E2EHandshake(msg)
+	case MessageTypeE2EMessage:
+		return a.RelaySealed(msg)
+	case MessageTypeSessionRejected:
+		return a.EndSession(ctx, msg, AuthFlowStateRejected)
+	case MessageTypeSessionCancelled:
+		return a.EndSession(ctx, msg, AuthFlowStateCancelled)
+	case MessageTypeNumberMatchResponse:
+		return a.ConfirmNumberMatch(ctx, msg)
 	default:
-		httpx.RespondWithJSON(w, jsonErrorResponse(200, slyerrors.ErrCodeSessionMessageTypeUnknown, "unknown message type", fmt.Sprintf("type %s is not known", msg.MessageType), msg.SessionId))
+		return jsonErrorResponse(200, slyerrors.ErrCodeSessionMessageTypeUnknown, "unknown message type", fmt.Sprintf("type %s is not known", msg.MessageType), msg.SessionId)
 	}
 }
 
-func (a Controller) CreateSession(wm *WebsocketMessage) *httpx.Response {
+func (a Controller) CreateSession(ctx context.Context, wm *WebsocketMessage, ip string) *httpx.Response {
 	payload, err := wm.ParseCreateSessionRequest()
 	if err != nil {
 		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", "")
 	}
 
-	var cl *config.Client
-	for _, c := range a.config.Clients {
-		if c.ID == payload.ClientId {
-			cl = &c
-		}
-	}
-
+	cl := a.config.ClientById(payload.ClientId)
 	if cl == nil {
 		return jsonErrorResponse(200, slyerrors.ErrCodeUnknownClient, "client id does not exist", "", "")
 	}
@@ -90,31 +148,80 @@ func (a Controller) CreateSession(wm *WebsocketMessage) *httpx.Response {
 		return jsonErrorResponse(200, slyerrors.ErrCodeAudienceDoesntExist, "no audiences found for client", "", "")
 	}
 
-	clients := map[*SessionClient]*SessionClient{}
-	clients[newSessionClient(payload.ClientId, &a.MConnector, nil)] = nil
+	if payload.PushTo != "" {
+		if err = a.pushService.ThrottleLogin(ctx, payload.PushTo, ip); err != nil {
+			return flowErrorResponse(err, "")
+		}
+	}
 
 	var s *Session
 	if payload.SessionType == SessionTypeAuth {
-		s = newAuthSession(&a.MConnector)
-		s.clients = clients
+		s = newAuthSession(&a.MConnector, payload.ClientId)
 		s.AuthFlow.domain = cl.Domain
 		s.AuthFlow.audiences = audiences
+		s.AuthFlow.numberMatching = cl.NumberMatching
+		// a pushed sign in is approved by picking the code the dApp shows, a
+		// careless tap on an unsolicited push can't approve it
+		if payload.PushTo != "" {
+			s.AuthFlow.numberMatching = config.NumberMatchingChoose
+		}
+	} else if payload.SessionType == SessionTypeSignRequest {
+		flow, err := NewSignRequestFlow(payload.SignRequest)
+		if err != nil {
+			return jsonErrorResponse(200, slyerrors.ErrCodeSignRequestInvalid, err.Error(), "", "")
+		}
+		s = newSignRequestSession(&a.MConnector, payload.ClientId, flow)
+	} else if payload.SessionType == SessionTypeLinkDevice {
+		s = newLinkDeviceSession(&a.MConnector, payload.ClientId, NewLinkDeviceFlow(payload.LinkDevice))
 	} else {
 		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, "session type does not exist", "", "")
 	}
 
-	return httpx.OK(WebsocketMessage{
+	if payload.E2EPublicKey != "" {
+		s.enableE2E(payload.E2EPublicKey)
+	}
+
+	handoff, err := a.siweService.SignHandoff(&verifier.HandoffClaims{
+		SessionId:    s.SessionId.String(),
+		ClientId:     cl.ID,
+		SessionType:  s.SessionType,
+		ChainId:      a.config.EthConfig.Chain.ID,
+		E2EPublicKey: payload.E2EPublicKey,
+		Label:        cl.Label,
+		Domain:       cl.Domain,
+	}, a.handoffExpirationInSec())
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeUnknown, "cant sign session handoff", err.Error(), s.SessionId.String())
+	}
+
+	created := PayloadSessionCreatedResponse{
+		SessionId:     s.SessionId.String(),
+		SessionType:   s.SessionType,
+		ClientId:      payload.ClientId,
+		QRCodeContent: getQRCodeContent(a.config.JWT.Issuer, s.SessionId.String(), payload.ClientId, payload.SessionType, a.config.EthConfig.Chain.ID, payload.E2EPublicKey, handoff),
+		DeepLink:      getDeepLink(a.config.API.UniversalLink, handoff),
+	}
+
+	if payload.PushTo != "" {
+		if _, err = a.pushService.NotifyLogin(ctx, payload.PushTo, loginPush(cl, &created)); err != nil {
+			log.Printf("session %s: push to %s failed: %v", s.SessionId, payload.PushTo, err)
+		}
+	}
+
+	return httpx.OK(&WebsocketMessage{
 		MessageType: MessageTypeSessionCreatedResponse,
 		SessionId:   s.SessionId.String(),
-		Payload: PayloadSessionCreatedResponse{
-			SessionId:     s.SessionId.String(),
-			SessionType:   s.SessionType,
-			ClientId:      payload.ClientId,
-			QRCodeContent: getQRCodeContent(a.config.JWT.Issuer, s.SessionId.String(), payload.ClientId, payload.SessionType, a.config.EthConfig.Chain.ID),
-		},
+		Payload:     created,
 	})
 }
 
+func (a Controller) handoffExpirationInSec() int64 {
+	if a.config.JWT.HandoffExpirationInSec > 0 {
+		return a.config.JWT.HandoffExpirationInSec
+	}
+	return defaultHandoffExpirationInSec
+}
+
 func (a Controller) SetAccount(wm *WebsocketMessage) *httpx.Response {
 	session, response := a.MConnector.getSessionFromMessageAndVerifyStatus(wm)
 	if response != nil {
@@ -125,10 +232,6 @@ func (a Controller) SetAccount(wm *WebsocketMessage) *httpx.Response {
 		return jsonErrorResponse(200, slyerrors.ErrCodeSessionWrongSessionType, "flow not initiated by party", "", session.SessionId.String())
 	}
 
-	if !session.AuthFlow.isCreatedState() {
-		return jsonErrorResponse(200, slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "not expecting this message", "", session.SessionId.String())
-	}
-
 	payload, err := wm.ParseAccountsResponse()
 	if err != nil {
 		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", session.SessionId.String())
@@ -138,21 +241,41 @@ func (a Controller) SetAccount(wm *WebsocketMessage) *httpx.Response {
 		return jsonErrorResponse(200, slyerrors.ErrCodeWrongChainId, "wrong chain id", "", session.SessionId.String())
 	}
 
-	session.AuthFlow.setPayload(payload)
+	match, err := session.connectAccount(payload)
+	if err != nil {
+		if slyerrors.Cause(err).Code == slyerrors.ErrCodeSessionEnded {
+			return sessionEndedResponse(session)
+		}
+		return flowErrorResponse(err, session.SessionId.String())
+	}
+
+	// the SIWE message is sent once the wallet user matched the code
+	if match != nil {
+		return httpx.OK(&WebsocketMessage{
+			MessageType: MessageTypeNumberMatch,
+			SessionId:   session.SessionId.String(),
+			Payload:     *match,
+		})
+	}
+
+	return a.challenge(session)
+}
 
+func (a Controller) challenge(session *Session) *httpx.Response {
 	r, err := a.siweService.Challenge(&dto.ChallengeRequestDTO{
 		Address: session.AuthFlow.eoa,
-		ChainId: payload.ChainID,
+		ChainId: a.config.EthConfig.Chain.ID,
 		Domain:  session.AuthFlow.domain,
 	})
 	if err != nil {
 		return jsonErrorResponse(200, slyerrors.ErrCodeSessionCantCreateSIWEMessage, err.Error(), "", session.SessionId.String())
 	}
 
-	wmResponse := wm.response()
-	wmResponse.Payload = r
-
-	return httpx.OK(wmResponse)
+	return httpx.OK(&WebsocketMessage{
+		MessageType: MessageTypeSignatureRequest,
+		SessionId:   session.SessionId.String(),
+		Payload:     r,
+	})
 }
 
 func (a Controller) SubmitSIWE(ctx context.Context, wm *WebsocketMessage) *httpx.Response {
@@ -165,10 +288,18 @@ func (a Controller) SubmitSIWE(ctx context.Context, wm *WebsocketMessage) *httpx
 		return jsonErrorResponse(200, slyerrors.ErrCodeSessionWrongSessionType, "flow not initiated by party", "", session.SessionId.String())
 	}
 
+	if session.AuthFlow.isEnded() {
+		return sessionEndedResponse(session)
+	}
+
 	if !session.AuthFlow.isConnectedState() {
 		return jsonErrorResponse(200, slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "not expecting this message", "", session.SessionId.String())
 	}
 
+	if session.AuthFlow.needsNumberMatch() {
+		return jsonErrorResponse(200, slyerrors.ErrCodeSessionNumberMatchRequired, "number match not confirmed", "", session.SessionId.String())
+	}
+
 	payload, err := wm.ParseSubmitRequest()
 	if err != nil {
 		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", session.SessionId.String())
@@ -210,7 +341,10 @@ func (a Controller) SubmitSIWE(ctx context.Context, wm *WebsocketMessage) *httpx
 		return jsonErrorResponse(200, slyerrors.ErrCodeCantCreateOrGetAccount, "error parsing retrieving account", err.Error(), session.SessionId.String())
 	}
 
-	session.AuthFlow.setVerified(account.ID)
+	if err = session.verifyAuthFlow(account.ID); err != nil {
+		return flowErrorResponse(err, session.SessionId.String())
+	}
+	a.audit(ctx, session)
 
 	return httpx.OK(CreateVerificationResponse(session.SessionId.String(), verificationResult))
 }
@@ -225,12 +359,26 @@ func (a Controller) PingResult(wm *WebsocketMessage) *httpx.Response {
 		return jsonErrorResponse(200, slyerrors.ErrCodeSessionWrongSessionType, "flow not initiated by party", "", session.SessionId.String())
 	}
 
+	if session.AuthFlow.isEnded() {
+		return httpx.OK(session.pingEndedResponse())
+	}
+
 	if !session.AuthFlow.isVerified() {
-		return httpx.OK(CreatePingResponse(session.SessionId.String(), FlowStatePending, nil))
+		return httpx.OK(&WebsocketMessage{
+			MessageType: MessageTypePingTokenResponse,
+			SessionId:   session.SessionId.String(),
+			Payload: PayloadPingTokenResponse{
+				AuthState: FlowStatePending,
+				MatchCode: session.AuthFlow.matchCode(),
+			},
+		})
 	}
 
-	info := session.AuthFlow
-	token, err := a.siweService.CreateToken(info.audiences, info.accountId, info.eoa, info.slyWalletAddress, verifier.RoleBasic)
+	if err := session.claimToken(); err != nil {
+		return flowErrorResponse(err, session.SessionId.String())
+	}
+
+	token, err := a.createToken(session)
 	if err != nil {
 		return jsonErrorResponse(200, slyerrors.ErrCodeCantCreateToken, "cant create token", err.Error(), session.SessionId.String())
 	}
@@ -238,6 +386,173 @@ func (a Controller) PingResult(wm *WebsocketMessage) *httpx.Response {
 	return httpx.OK(CreatePingResponse(session.SessionId.String(), FlowStateSuccess, token))
 }
 
+func (a Controller) createToken(session *Session) (*verifier.Token, error) {
+	info := session.AuthFlow
+	return a.siweService.CreateToken(info.audiences, info.accountId, info.eoa, info.slyWalletAddress, verifier.RoleBasic)
+}
+
+// Events streams the state transitions of a session as server-sent events.
+// For auth sessions the stream ends with a ping_token_response carrying the
+// token once the flow is verified, or with the closed state. The token is
+// delivered once per session, over this stream, the websocket or ping_token,
+// whichever asks first. For sign request and link device sessions it ends with
+// the final state. Reconnecting clients send Last-Event-ID and receive all
+// events they missed.
+func (a Controller) Events(w http.ResponseWriter, r *http.Request) {
+	session, response := a.getEventsSession(r)
+	if response != nil {
+		httpx.RespondWithJSON(w, response)
+		return
+	}
+
+	lastEventId, err := parseLastEventId(r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		httpx.RespondWithJSON(w, jsonErrorResponse(http.StatusBadRequest, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", session.SessionId.String()))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httpx.RespondWithJSON(w, jsonErrorResponse(http.StatusInternalServerError, slyerrors.ErrCodeUnknown, "streaming not supported", "", session.SessionId.String()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	a.streamEvents(r.Context(), session, lastEventId, &sseWriter{w: w, flusher: flusher})
+}
+
+// EventsWebsocket streams the same events as Events over a websocket, one
+// message per frame. A new connection receives all events of the session.
+func (a Controller) EventsWebsocket(w http.ResponseWriter, r *http.Request) {
+	session, response := a.getEventsSession(r)
+	if response != nil {
+		httpx.RespondWithJSON(w, response)
+		return
+	}
+
+	conn, err := a.MConnector.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer conn.Close()
+
+	// the client sends nothing, reading handles pongs and notices a close
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	a.streamEvents(ctx, session, 0, &websocketWriter{conn: conn})
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeWait))
+}
+
+func (a Controller) getEventsSession(r *http.Request) (*Session, *httpx.Response) {
+	uu, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, jsonErrorResponse(http.StatusBadRequest, slyerrors.ErrCodeSessionWrongSessionId, err.Error(), "", "")
+	}
+
+	session, err := a.MConnector.getSession(uu)
+	if err != nil {
+		return nil, jsonErrorResponse(http.StatusNotFound, slyerrors.ErrCodeSessionNotFound, err.Error(), "", uu.String())
+	}
+
+	if !session.hasFlow() {
+		return nil, jsonErrorResponse(http.StatusBadRequest, slyerrors.ErrCodeSessionWrongSessionType, "session has no flow", "", uu.String())
+	}
+	return session, nil
+}
+
+// streamEvents writes the events after lastEventId and then the live events
+// until a terminal state was written or ctx is done
+func (a Controller) streamEvents(ctx context.Context, session *Session, lastEventId int, w eventWriter) {
+	replay, ch := session.subscribe(lastEventId)
+	defer session.unsubscribe(ch)
+
+	// send returns false once the stream is finished
+	send := func(e *SessionEvent) bool {
+		if err := w.write(e.ID, e.Message); err != nil {
+			return false
+		}
+		if isTerminalState(e) {
+			a.finishEventStream(w, session, e)
+			return false
+		}
+		return true
+	}
+
+	for _, e := range replay {
+		if !send(e) {
+			return
+		}
+	}
+
+	// the client already received the terminal event but reconnected for the token
+	if len(replay) == 0 && lastEventId > 0 {
+		session.mutex.Lock()
+		last := session.events.last()
+		session.mutex.Unlock()
+		if last != nil && last.ID == lastEventId && isTerminalState(last) {
+			a.finishEventStream(w, session, last)
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlivePeriod)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-ch:
+			if !send(e) {
+				return
+			}
+		case <-keepAlive.C:
+			if err := w.keepAlive(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// finishEventStream sends the token after the verified state. It shares the
+// id of the verified event. A reconnect after the token was delivered receives
+// an error instead.
+func (a Controller) finishEventStream(w eventWriter, session *Session, e *SessionEvent) {
+	p, ok := e.Message.Payload.(PayloadSessionState)
+	if !ok || p.State != AuthFlowStateNameVerified {
+		return
+	}
+
+	var msg *WebsocketMessage
+	if err := session.claimToken(); err != nil {
+		msg = createErrorResponse(slyerrors.ErrCodeSessionTokenIssued, "token already issued", "", session.SessionId.String())
+	} else if token, err := a.createToken(session); err != nil {
+		msg = createErrorResponse(slyerrors.ErrCodeCantCreateToken, "cant create token", err.Error(), session.SessionId.String())
+	} else {
+		msg = CreatePingResponse(session.SessionId.String(), FlowStateSuccess, token)
+	}
+
+	if err := w.write(e.ID, msg); err != nil {
+		log.Println(err)
+	}
+}
+
 func (a Controller) CloseSession(wm *WebsocketMessage) *httpx.Response {
 	session, response := a.MConnector.getSessionFromMessageAndVerifyStatus(wm)
 	if response != nil {
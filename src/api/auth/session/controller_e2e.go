@@ -0,0 +1,53 @@
+package session
+
+import (
+	"yip/src/httpx"
+	"yip/src/slyerrors"
+)
+
+// E2EHandshake is sent by the wallet with its public key after scanning the QR
+// code, which carries the public key and the handshake secret of the dApp
+func (a Controller) E2EHandshake(wm *WebsocketMessage) *httpx.Response {
+	session, response := a.MConnector.getSessionFromMessageAndVerifyStatus(wm)
+	if response != nil {
+		return response
+	}
+	sid := session.SessionId.String()
+
+	payload, err := wm.ParseE2EHandshake()
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", sid)
+	}
+
+	if err = session.addWalletE2EKey(payload); err != nil {
+		return flowErrorResponse(err, sid)
+	}
+
+	return httpx.OK(CreateE2EHandshake(sid, payload.PublicKey, payload.Mac))
+}
+
+// RelaySealed passes a sealed payload to the other party through the session
+// events. YIP only sees the message type and the session id.
+func (a Controller) RelaySealed(wm *WebsocketMessage) *httpx.Response {
+	session, response := a.MConnector.getSessionFromMessageAndVerifyStatus(wm)
+	if response != nil {
+		return response
+	}
+	sid := session.SessionId.String()
+
+	payload, err := wm.ParseSealed()
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", sid)
+	}
+
+	e, err := session.relaySealed(payload)
+	if err != nil {
+		return flowErrorResponse(err, sid)
+	}
+
+	return httpx.OK(&WebsocketMessage{
+		MessageType: MessageTypeE2EMessageAccepted,
+		SessionId:   sid,
+		Payload:     PayloadE2EMessageAccepted{EventId: e.ID},
+	})
+}
@@ -0,0 +1,169 @@
+package session
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"log"
+	"yip/src/cryptox"
+	"yip/src/httpx"
+	"yip/src/slyerrors"
+)
+
+// FetchLinkDevice is sent by the approving device after scanning the QR code
+// shown by the new device, and by the new device for the message to prove its
+// key with
+func (a Controller) FetchLinkDevice(wm *WebsocketMessage) *httpx.Response {
+	session, response := a.getLinkDeviceSession(wm)
+	if response != nil {
+		return response
+	}
+
+	return httpx.OK(&WebsocketMessage{
+		MessageType: MessageTypeLinkDevice,
+		SessionId:   session.SessionId.String(),
+		Payload:     session.linkDevice(),
+	})
+}
+
+// ProveLinkDevice verifies the new device's signature of the link device
+// message, which binds its key to the session. The answer carries the code the
+// new device shows to the approver.
+func (a Controller) ProveLinkDevice(wm *WebsocketMessage) *httpx.Response {
+	session, response := a.getLinkDeviceSession(wm)
+	if response != nil {
+		return response
+	}
+	sid := session.SessionId.String()
+
+	payload, err := wm.ParseProveLinkDevice()
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", sid)
+	}
+
+	request := session.linkDevice()
+	recovered, err := cryptox.Recover(request.Message, payload.Signature, cryptox.SignMethodEthereumPrefix, false)
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeWrongSignature, err.Error(), "", sid)
+	}
+	if *recovered != common.HexToAddress(request.EOA) {
+		return jsonErrorResponse(200, slyerrors.ErrCodeWrongSignature, "invalid signature", "signer is not the new key", sid)
+	}
+
+	var code string
+	err = session.updateLinkDevice(func(f *LinkDeviceFlow) error {
+		code, err = f.setProven()
+		return err
+	})
+	if err != nil {
+		return flowErrorResponse(err, sid)
+	}
+
+	state := session.linkDeviceState()
+	state.MatchCode = code
+	return httpx.OK(CreateLinkDeviceStateMessage(sid, state))
+}
+
+// ApproveLinkDevice verifies the approver's signature, role and match code,
+// relays AddKey and registers the new key once the transaction is mined
+func (a Controller) ApproveLinkDevice(ctx context.Context, wm *WebsocketMessage) *httpx.Response {
+	session, response := a.getLinkDeviceSession(wm)
+	if response != nil {
+		return response
+	}
+	sid := session.SessionId.String()
+
+	payload, err := wm.ParseApproveLinkDevice()
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", sid)
+	}
+
+	request := session.linkDevice()
+	approver := common.HexToAddress(payload.Approver)
+
+	recovered, err := cryptox.Recover(request.Message, payload.Signature, cryptox.SignMethodEthereumPrefix, false)
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeWrongSignature, err.Error(), "", sid)
+	}
+	if *recovered != approver {
+		return jsonErrorResponse(200, slyerrors.ErrCodeWrongSignature, "invalid signature", "signer is not the approver", sid)
+	}
+
+	slyWallet := common.HexToAddress(request.SLYWalletAddress)
+	approverRole, err := a.slyWalletService.GetKeyRole(ctx, slyWallet, approver)
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeGetSLYAuthentication, err.Error(), "", sid)
+	}
+
+	role := session.LinkDeviceFlow.request.role()
+	if !canApprove(approverRole, role) {
+		return jsonErrorResponse(200, slyerrors.ErrCodeNotAControllerKey, "approver is not allowed to add the key", approver.Hex(), sid)
+	}
+
+	newKey := common.HexToAddress(request.EOA)
+	if err = a.slyWalletService.CheckControllerKey(ctx, slyWallet, newKey); err != nil {
+		return flowErrorResponse(err, sid)
+	}
+
+	// make sure the request is still open before spending gas
+	err = session.updateLinkDevice(func(f *LinkDeviceFlow) error {
+		return f.reserve(payload.Code)
+	})
+	if err != nil {
+		return flowErrorResponse(err, sid)
+	}
+
+	ticket, err := a.slyWalletService.AddControllerKey(ctx, slyWallet, newKey, role)
+	if err != nil {
+		_ = session.updateLinkDevice(func(f *LinkDeviceFlow) error {
+			f.approving = false
+			return nil
+		})
+		return jsonErrorResponse(200, slyerrors.ErrCodeTransact, "cant add key", err.Error(), sid)
+	}
+
+	err = session.updateLinkDevice(func(f *LinkDeviceFlow) error {
+		return f.setApproved(approver.Hex(), ticket.TransactionHash)
+	})
+	if err != nil {
+		return flowErrorResponse(err, sid)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), linkDeviceMiningTimeout)
+		defer cancel()
+
+		status, err := a.slyWalletService.WaitForControllerKey(ctx, ticket.TransactionHash, slyWallet, newKey, role)
+		if err != nil {
+			log.Println("link device:", err.Error())
+		}
+		_ = session.updateLinkDevice(func(f *LinkDeviceFlow) error {
+			f.setMined(status, err)
+			return nil
+		})
+	}()
+
+	return httpx.OK(CreateLinkDeviceStateMessage(sid, session.linkDeviceState()))
+}
+
+// LinkDeviceStatus is polled by the new device, alternatively to the session events
+func (a Controller) LinkDeviceStatus(wm *WebsocketMessage) *httpx.Response {
+	session, response := a.getLinkDeviceSession(wm)
+	if response != nil {
+		return response
+	}
+
+	return httpx.OK(CreateLinkDeviceStateMessage(session.SessionId.String(), session.linkDeviceState()))
+}
+
+func (a Controller) getLinkDeviceSession(wm *WebsocketMessage) (*Session, *httpx.Response) {
+	session, response := a.MConnector.getSessionFromMessageAndVerifyStatus(wm)
+	if response != nil {
+		return nil, response
+	}
+
+	if session.LinkDeviceFlow == nil {
+		return nil, jsonErrorResponse(200, slyerrors.ErrCodeSessionWrongSessionType, "not a link device session", "", session.SessionId.String())
+	}
+
+	return session, nil
+}
@@ -0,0 +1,38 @@
+package session
+
+import (
+	"context"
+	"yip/src/httpx"
+	"yip/src/slyerrors"
+)
+
+// ConfirmNumberMatch handles the code the wallet user confirmed or picked.
+// The SIWE message is sent once the code matches, a wrong code rejects the flow.
+func (a Controller) ConfirmNumberMatch(ctx context.Context, wm *WebsocketMessage) *httpx.Response {
+	session, response := a.MConnector.getSessionFromMessageAndVerifyStatus(wm)
+	if response != nil {
+		return response
+	}
+	sid := session.SessionId.String()
+
+	if session.AuthFlow == nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeSessionWrongSessionType, "flow not initiated by party", "", sid)
+	}
+	if session.AuthFlow.isEnded() {
+		return sessionEndedResponse(session)
+	}
+
+	payload, err := wm.ParseNumberMatchResponse()
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", sid)
+	}
+
+	if err = session.confirmNumberMatch(payload.Code); err != nil {
+		if session.AuthFlow.isEnded() {
+			a.audit(ctx, session)
+		}
+		return flowErrorResponse(err, sid)
+	}
+
+	return a.challenge(session)
+}
@@ -0,0 +1,73 @@
+package session
+
+import (
+	"context"
+	"yip/src/httpx"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+)
+
+// EndSession handles session_rejected of the wallet and session_cancelled of
+// the dApp. The party is taken from the message type, not from the client.
+// The auth flow ends in a failed state, which is reported to the other party
+// by ping_token, the session events and websocket.
+func (a Controller) EndSession(ctx context.Context, wm *WebsocketMessage, state int) *httpx.Response {
+	session, response := a.MConnector.getSessionFromMessageAndVerifyStatus(wm)
+	if response != nil {
+		return response
+	}
+	sid := session.SessionId.String()
+
+	if session.AuthFlow == nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeSessionWrongSessionType, "flow not initiated by party", "", sid)
+	}
+
+	payload, err := wm.ParseSessionEnd()
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", sid)
+	}
+
+	party := PartyDApp
+	if state == AuthFlowStateRejected {
+		party = PartyWallet
+	}
+
+	if err = session.endAuthFlow(state, party, payload.Reason); err != nil {
+		return flowErrorResponse(err, sid)
+	}
+
+	a.audit(ctx, session)
+
+	return httpx.OK(session.pingEndedResponse())
+}
+
+func sessionEndedResponse(session *Session) *httpx.Response {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	return jsonErrorResponse(200, slyerrors.ErrCodeSessionEnded, "session "+session.AuthFlow.StateName(), session.AuthFlow.reason, session.SessionId.String())
+}
+
+// audit records the outcome of an auth session. A verified flow is recorded
+// as outcome of the wallet, an ended one of the party that ended it.
+func (a Controller) audit(ctx context.Context, session *Session) {
+	if a.auditService == nil {
+		return
+	}
+
+	session.mutex.Lock()
+	record := &repo.SessionAuditModel{
+		SessionID:   session.SessionId,
+		SessionType: session.SessionType,
+		Outcome:     session.AuthFlow.StateName(),
+		Party:       PartyWallet,
+		Reason:      session.AuthFlow.reason,
+		EOA:         session.AuthFlow.eoa,
+	}
+	if session.AuthFlow.isEnded() {
+		record.Party = session.AuthFlow.endedBy
+	}
+	session.mutex.Unlock()
+
+	a.auditService.Record(ctx, record)
+}
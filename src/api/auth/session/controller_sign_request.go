@@ -0,0 +1,180 @@
+package session
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"yip/src/api/services/dto"
+	"yip/src/contracts"
+	"yip/src/cryptox"
+	"yip/src/httpx"
+	"yip/src/slyerrors"
+)
+
+// FetchSignRequest is sent by the wallet after scanning the QR code. Only the
+// signer named by the dApp can fetch the request. For an Execute call, the
+// call is bound to the signer's current nonce.
+func (a Controller) FetchSignRequest(ctx context.Context, wm *WebsocketMessage) *httpx.Response {
+	session, response := a.getSignRequestSession(wm)
+	if response != nil {
+		return response
+	}
+	sid := session.SessionId.String()
+
+	payload, err := wm.ParseFetchSignRequest()
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", sid)
+	}
+
+	request, _ := session.signRequest()
+	eoa := common.HexToAddress(payload.EOA)
+	if eoa != common.HexToAddress(request.Request.Signer) {
+		return jsonErrorResponse(200, slyerrors.ErrCodeSignRequestWrongSigner, "not the signer of the request", eoa.Hex(), sid)
+	}
+
+	var prepared *dto.PreparedExecute
+	if request.Request.Kind == SignRequestKindExecute {
+		slyWallet := common.HexToAddress(request.Request.Execute.SLYWalletAddress)
+		role, err := a.slyWalletService.GetKeyRole(ctx, slyWallet, eoa)
+		if err != nil {
+			return jsonErrorResponse(200, slyerrors.ErrCodeGetSLYAuthentication, err.Error(), "", sid)
+		}
+		if role == contracts.RoleNone {
+			return jsonErrorResponse(200, slyerrors.ErrCodeNotAControllerKey, "not a controller key", eoa.Hex(), sid)
+		}
+
+		prepared, err = a.slyWalletService.PrepareExecute(ctx, request.Request.Execute, eoa)
+		if err != nil {
+			return jsonErrorResponse(200, slyerrors.ErrCodeSignRequestInvalid, "cant prepare execute call", err.Error(), sid)
+		}
+	}
+
+	err = session.updateSignRequest(func(f *SignRequestFlow) error {
+		return f.setFetched(eoa.Hex(), prepared)
+	})
+	if err != nil {
+		return flowErrorResponse(err, sid)
+	}
+
+	request, _ = session.signRequest()
+	return httpx.OK(&WebsocketMessage{
+		MessageType: MessageTypeSignRequest,
+		SessionId:   sid,
+		Payload:     request,
+	})
+}
+
+// SubmitSignRequest receives the wallet's signature of the digest. The signature
+// is verified and, if requested, relayed as meta-transaction. Relays count
+// against the limit of the dApp's client.
+func (a Controller) SubmitSignRequest(ctx context.Context, wm *WebsocketMessage) *httpx.Response {
+	session, response := a.getSignRequestSession(wm)
+	if response != nil {
+		return response
+	}
+	sid := session.SessionId.String()
+
+	payload, err := wm.ParseSubmitSignRequest()
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", sid)
+	}
+
+	request, eoa := session.signRequest()
+	digest, err := hexutil.Decode(request.Digest)
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "sign request not fetched yet", "", sid)
+	}
+
+	if request.Request.Relay {
+		if err := a.slyWalletService.ThrottleRelay(ctx, session.clientId); err != nil {
+			return flowErrorResponse(err, sid)
+		}
+	}
+
+	recovered, err := cryptox.RecoverHash(digest, payload.Signature)
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeWrongSignature, err.Error(), "", sid)
+	}
+	if *recovered != common.HexToAddress(eoa) {
+		return jsonErrorResponse(200, slyerrors.ErrCodeWrongSignature, "invalid signature", "signer is not the fetching key", sid)
+	}
+
+	err = session.updateSignRequest(func(f *SignRequestFlow) error {
+		return f.setSigned(payload.Signature)
+	})
+	if err != nil {
+		return flowErrorResponse(err, sid)
+	}
+
+	if request.Request.Relay {
+		signature := common.FromHex(payload.Signature)
+		// the contract expects V as 27|28
+		if signature[64] < 27 {
+			signature[64] += 27
+		}
+
+		ticket, err := a.slyWalletService.RelayExecute(ctx, request.Prepared, signature)
+		if err != nil {
+			_ = session.updateSignRequest(func(f *SignRequestFlow) error {
+				f.setFailed(err.Error())
+				return nil
+			})
+			return jsonErrorResponse(200, slyerrors.ErrCodeSignRequestRelayFailed, "relaying the transaction failed", err.Error(), sid)
+		}
+
+		err = session.updateSignRequest(func(f *SignRequestFlow) error {
+			return f.setRelayed(ticket.TransactionHash)
+		})
+		if err != nil {
+			return flowErrorResponse(err, sid)
+		}
+	}
+
+	return httpx.OK(CreateSignRequestStateMessage(sid, session.signRequestState()))
+}
+
+// RejectSignRequest is sent by the wallet if the user declines to sign
+func (a Controller) RejectSignRequest(wm *WebsocketMessage) *httpx.Response {
+	session, response := a.getSignRequestSession(wm)
+	if response != nil {
+		return response
+	}
+	sid := session.SessionId.String()
+
+	payload, err := wm.ParseRejectSignRequest()
+	if err != nil {
+		return jsonErrorResponse(200, slyerrors.ErrCodeBadSessionRequest, err.Error(), "", sid)
+	}
+
+	err = session.updateSignRequest(func(f *SignRequestFlow) error {
+		return f.setRejected(payload.Reason)
+	})
+	if err != nil {
+		return flowErrorResponse(err, sid)
+	}
+
+	return httpx.OK(CreateSignRequestStateMessage(sid, session.signRequestState()))
+}
+
+// SignRequestStatus is polled by the dApp, alternatively to the session events
+func (a Controller) SignRequestStatus(wm *WebsocketMessage) *httpx.Response {
+	session, response := a.getSignRequestSession(wm)
+	if response != nil {
+		return response
+	}
+
+	return httpx.OK(CreateSignRequestStateMessage(session.SessionId.String(), session.signRequestState()))
+}
+
+func (a Controller) getSignRequestSession(wm *WebsocketMessage) (*Session, *httpx.Response) {
+	session, response := a.MConnector.getSessionFromMessageAndVerifyStatus(wm)
+	if response != nil {
+		return nil, response
+	}
+
+	if session.SignRequestFlow == nil {
+		return nil, jsonErrorResponse(200, slyerrors.ErrCodeSessionWrongSessionType, "not a sign request session", "", session.SessionId.String())
+	}
+
+	return session, nil
+}
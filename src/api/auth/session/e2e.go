@@ -0,0 +1,86 @@
+package session
+
+import (
+	"yip/src/slyerrors"
+)
+
+const (
+	// e2eMaxHandshakes bounds the handshakes of a session. YIP can't verify
+	// their MAC, the dApp picks the first one that verifies.
+	e2eMaxHandshakes = 3
+	// e2eMaxCiphertextLength is the maximum length of a base64url ciphertext
+	e2eMaxCiphertextLength = 64 * 1024
+	// e2eMaxMessages bounds the sealed payloads relayed per session
+	e2eMaxMessages = 100
+)
+
+// e2eChannel holds the X25519 public keys of both parties. YIP only relays
+// sealed payloads, it never holds a private key.
+type e2eChannel struct {
+	dAppPublicKey string
+	handshakes    int
+	messages      int
+}
+
+// E2EAssociatedData is authenticated with every sealed payload. It binds the
+// ciphertext to the session and the sender, so YIP cannot replay a payload to
+// another session or reflect it to its sender.
+func E2EAssociatedData(sessionId string, from string) []byte {
+	return []byte(sessionId + ":" + from)
+}
+
+func (s *Session) enableE2E(dAppPublicKey string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.e2e = &e2eChannel{dAppPublicKey: dAppPublicKey}
+}
+
+// addWalletE2EKey publishes a handshake of the wallet, so the dApp learns the
+// wallet key from the event stream. The dApp only accepts a key whose MAC
+// verifies with the secret of its QR code, a handshake of someone who merely
+// knows the session id is ignored instead of locking out the wallet.
+func (s *Session) addWalletE2EKey(handshake *PayloadE2EHandshake) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.e2e == nil {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionE2ENotEnabled, "session is not end-to-end encrypted")
+	}
+	if s.e2e.handshakes >= e2eMaxHandshakes {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionE2ELimitReached, "too many handshakes")
+	}
+
+	s.e2e.handshakes++
+	s.events.publish(&WebsocketMessage{
+		MessageType: MessageTypeE2EHandshake,
+		SessionId:   s.SessionId.String(),
+		Payload:     *handshake,
+	})
+	return nil
+}
+
+// relaySealed publishes a sealed payload to the event stream of the session,
+// at most e2eMaxMessages per session
+func (s *Session) relaySealed(sealed *PayloadSealed) (*SessionEvent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.e2e == nil {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeSessionE2ENotEnabled, "session is not end-to-end encrypted")
+	}
+	if s.e2e.handshakes == 0 {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "keys not exchanged yet")
+	}
+	if s.e2e.messages >= e2eMaxMessages {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeSessionE2ELimitReached, "too many messages")
+	}
+
+	s.e2e.messages++
+
+	return s.events.publish(&WebsocketMessage{
+		MessageType: MessageTypeE2EMessage,
+		SessionId:   s.SessionId.String(),
+		Payload:     *sealed,
+	}), nil
+}
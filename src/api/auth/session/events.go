@@ -0,0 +1,220 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// comment lines resp. pings keep proxies from closing idle streams
+	sseKeepAlivePeriod = 15 * time.Second
+
+	// buffer per subscriber; a subscriber that falls behind misses live events
+	// but can reconnect with Last-Event-ID to replay them
+	eventSubscriberBuffer = 16
+)
+
+// SessionEvent is a single entry of the event log of a session. The ID is
+// strictly increasing per session and is used as SSE event id.
+type SessionEvent struct {
+	ID      int
+	Message *WebsocketMessage
+}
+
+type eventLog struct {
+	events      []*SessionEvent
+	subscribers map[chan *SessionEvent]struct{}
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{
+		events:      make([]*SessionEvent, 0),
+		subscribers: make(map[chan *SessionEvent]struct{}),
+	}
+}
+
+// publish must be called while holding the session mutex
+func (l *eventLog) publish(msg *WebsocketMessage) *SessionEvent {
+	e := &SessionEvent{
+		ID:      len(l.events) + 1,
+		Message: msg,
+	}
+	l.events = append(l.events, e)
+
+	for ch := range l.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+	return e
+}
+
+// since must be called while holding the session mutex
+func (l *eventLog) since(lastEventId int) []*SessionEvent {
+	if lastEventId < 0 || lastEventId >= len(l.events) {
+		return []*SessionEvent{}
+	}
+	return append([]*SessionEvent{}, l.events[lastEventId:]...)
+}
+
+func (l *eventLog) last() *SessionEvent {
+	if len(l.events) == 0 {
+		return nil
+	}
+	return l.events[len(l.events)-1]
+}
+
+// lastOf returns the latest event of the given message type. State events are
+// interleaved with relayed messages, so transitions are detected per type.
+func (l *eventLog) lastOf(messageType string) *SessionEvent {
+	for i := len(l.events) - 1; i >= 0; i-- {
+		if l.events[i].Message.MessageType == messageType {
+			return l.events[i]
+		}
+	}
+	return nil
+}
+
+// subscribe returns all events after lastEventId together with a channel for
+// upcoming events. Both are taken under the session lock, so no event is lost
+// or delivered twice between replay and live stream.
+func (s *Session) subscribe(lastEventId int) ([]*SessionEvent, chan *SessionEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ch := make(chan *SessionEvent, eventSubscriberBuffer)
+	s.events.subscribers[ch] = struct{}{}
+
+	return s.events.since(lastEventId), ch
+}
+
+func (s *Session) unsubscribe(ch chan *SessionEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.events.subscribers, ch)
+}
+
+func (s *Session) publishAuthState() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.publishAuthStateLocked()
+}
+
+func (s *Session) publishAuthStateLocked() {
+	if s.AuthFlow == nil {
+		return
+	}
+
+	state := s.AuthFlow.StateName()
+	if s.isClosed {
+		state = AuthFlowStateNameClosed
+	}
+
+	// only transitions are published
+	if l := s.events.lastOf(MessageTypeSessionState); l != nil {
+		if p, ok := l.Message.Payload.(PayloadSessionState); ok && p.State == state {
+			return
+		}
+	}
+
+	msg := &WebsocketMessage{
+		MessageType: MessageTypeSessionState,
+		SessionId:   s.SessionId.String(),
+		Payload:     s.AuthFlow.statePayload(state),
+	}
+	s.events.publish(msg)
+
+	if s.AuthFlow.isEnded() {
+		s.notifyLocked(msg)
+	}
+}
+
+func parseLastEventId(header string) (int, error) {
+	if header == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(header)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid Last-Event-ID: %s", header)
+	}
+	return id, nil
+}
+
+func isTerminalState(e *SessionEvent) bool {
+	switch p := e.Message.Payload.(type) {
+	case PayloadSessionState:
+		switch p.State {
+		case AuthFlowStateNameVerified, AuthFlowStateNameClosed, AuthFlowStateNameRejected, AuthFlowStateNameCancelled:
+			return true
+		default:
+			return false
+		}
+	case PayloadSignRequestState:
+		return p.Final
+	case PayloadLinkDeviceState:
+		return p.Final
+	default:
+		return false
+	}
+}
+
+// eventWriter is a transport of the session events
+type eventWriter interface {
+	write(id int, msg *WebsocketMessage) error
+	keepAlive() error
+}
+
+type sseWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (s *sseWriter) write(id int, msg *WebsocketMessage) error {
+	if err := writeSSEEvent(s.w, id, msg); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// keepAlive writes a comment line
+func (s *sseWriter) keepAlive() error {
+	if _, err := fmt.Fprint(s.w, ": keep-alive\n\n"); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// websocketWriter sends every message as JSON text frame, the event id is not
+// sent
+type websocketWriter struct {
+	conn *websocket.Conn
+}
+
+func (s *websocketWriter) write(_ int, msg *WebsocketMessage) error {
+	s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return s.conn.WriteJSON(msg)
+}
+
+func (s *websocketWriter) keepAlive() error {
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+}
+
+// writeSSEEvent writes a message in the text/event-stream format
+func writeSSEEvent(w io.Writer, id int, msg *WebsocketMessage) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, msg.MessageType, b)
+	return err
+}
@@ -0,0 +1,178 @@
+package session
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"yip/src/config"
+	"yip/src/slyerrors"
+)
+
+func TestSessionEventsReplay(t *testing.T) {
+	mc := InitMConnector()
+	s := newAuthSession(&mc, "client")
+
+	s.AuthFlow.setPayload(&PayloadAccountsResponse{EOA: "0x0", ChainID: "1"})
+	s.publishAuthState()
+	// publishing the same state twice is not a transition
+	s.publishAuthState()
+	s.AuthFlow.setVerified("account")
+	s.publishAuthState()
+
+	replay, ch := s.subscribe(0)
+	defer s.unsubscribe(ch)
+
+	assert.Len(t, replay, 3)
+	assert.Equal(t, AuthFlowStateNameCreated, replay[0].Message.Payload.(PayloadSessionState).State)
+	assert.Equal(t, AuthFlowStateNameConnected, replay[1].Message.Payload.(PayloadSessionState).State)
+	assert.Equal(t, AuthFlowStateNameVerified, replay[2].Message.Payload.(PayloadSessionState).State)
+	assert.True(t, isTerminalState(replay[2]))
+
+	replay, ch2 := s.subscribe(2)
+	defer s.unsubscribe(ch2)
+	assert.Len(t, replay, 1)
+	assert.Equal(t, 3, replay[0].ID)
+
+	s.close()
+	e := <-ch
+	assert.Equal(t, 4, e.ID)
+	assert.Equal(t, AuthFlowStateNameClosed, e.Message.Payload.(PayloadSessionState).State)
+}
+
+func TestSessionRejected(t *testing.T) {
+	mc := InitMConnector()
+	s := newAuthSession(&mc, "client")
+
+	assert.NoError(t, s.endAuthFlow(AuthFlowStateRejected, PartyWallet, "user declined"))
+	// an ended flow cannot be ended again
+	assert.Error(t, s.endAuthFlow(AuthFlowStateCancelled, PartyDApp, ""))
+
+	replay, ch := s.subscribe(0)
+	defer s.unsubscribe(ch)
+
+	assert.Len(t, replay, 2)
+	p := replay[1].Message.Payload.(PayloadSessionState)
+	assert.Equal(t, AuthFlowStateNameRejected, p.State)
+	assert.Equal(t, FlowStateFailed, p.AuthState)
+	assert.Equal(t, PartyWallet, p.Party)
+	assert.Equal(t, "user declined", p.Reason)
+	assert.True(t, isTerminalState(replay[1]))
+}
+
+func TestNumberMatch(t *testing.T) {
+	m, err := newNumberMatch(config.NumberMatchingChoose)
+	assert.NoError(t, err)
+	assert.Len(t, m.choices, numberMatchChoices)
+	assert.Contains(t, m.choices, m.code)
+
+	mc := InitMConnector()
+	s := newAuthSession(&mc, "client")
+	s.AuthFlow.numberMatching = config.NumberMatchingConfirm
+	match, err := s.connectAccount(&PayloadAccountsResponse{EOA: "0x0", ChainID: "1"})
+	assert.NoError(t, err)
+	assert.True(t, s.AuthFlow.needsNumberMatch())
+	assert.Equal(t, []string{s.AuthFlow.matchCode()}, match.Choices)
+	// only one wallet can connect
+	_, err = s.connectAccount(&PayloadAccountsResponse{EOA: "0x1", ChainID: "1"})
+	assert.Equal(t, slyerrors.ErrCodeSessionDifferentMessageTypeExpected, slyerrors.Cause(err).Code)
+	assert.Equal(t, "0x0", s.AuthFlow.eoa)
+
+	assert.NoError(t, s.confirmNumberMatch(s.AuthFlow.matchCode()))
+	assert.False(t, s.AuthFlow.needsNumberMatch())
+
+	// a wrong code rejects the flow
+	s2 := newAuthSession(&mc, "client")
+	s2.AuthFlow.numberMatching = config.NumberMatchingConfirm
+	s2.AuthFlow.setPayload(&PayloadAccountsResponse{EOA: "0x0", ChainID: "1"})
+	assert.NoError(t, s2.AuthFlow.startNumberMatch())
+	err = s2.confirmNumberMatch("0")
+	assert.Equal(t, slyerrors.ErrCodeSessionNumberMismatch, slyerrors.Cause(err).Code)
+	assert.Equal(t, AuthFlowStateNameRejected, s2.AuthFlow.StateName())
+}
+
+func TestForceClose(t *testing.T) {
+	mc := InitMConnector()
+	s := newAuthSession(&mc, "client")
+	s.AuthFlow.setPayload(&PayloadAccountsResponse{EOA: "0x0", ChainID: "1"})
+	newAuthSession(&mc, "client").close()
+
+	infos := mc.ListSessions(SessionFilter{ClientId: "client"})
+	assert.Len(t, infos, 1)
+	assert.Equal(t, AuthFlowStateNameConnected, infos[0].State)
+	assert.Equal(t, []string{PartyDApp, PartyWallet}, infos[0].Parties)
+	assert.Len(t, mc.ListSessions(SessionFilter{}), 1)
+	assert.Len(t, mc.ListSessions(SessionFilter{All: true}), 2)
+
+	i, err := mc.ForceClose(s.SessionId, "stuck")
+	assert.NoError(t, err)
+	assert.False(t, i.Active)
+	assert.Equal(t, AuthFlowStateNameClosed, i.State)
+	assert.Equal(t, PartyAdmin, s.AuthFlow.endedBy)
+	// a force-closed session accepts no message and can't be verified anymore
+	assert.NotNil(t, s.verifyNotClosedYet())
+	assert.Equal(t, slyerrors.ErrCodeSessionClosed, slyerrors.Cause(s.verifyAuthFlow("account")).Code)
+
+	_, err = mc.ForceClose(s.SessionId, "stuck")
+	assert.Error(t, err)
+	assert.Empty(t, mc.ListSessions(SessionFilter{}))
+}
+
+func TestClaimToken(t *testing.T) {
+	mc := InitMConnector()
+	s := newAuthSession(&mc, "client")
+	s.AuthFlow.setPayload(&PayloadAccountsResponse{EOA: "0x0", ChainID: "1"})
+	assert.NoError(t, s.verifyAuthFlow("account"))
+
+	assert.NoError(t, s.claimToken())
+	// a second ping or reconnect gets no token
+	assert.Equal(t, slyerrors.ErrCodeSessionTokenIssued, slyerrors.Cause(s.claimToken()).Code)
+}
+
+func TestE2ELimits(t *testing.T) {
+	mc := InitMConnector()
+	s := newAuthSession(&mc, "client")
+	sealed := &PayloadSealed{From: PartyDApp, Nonce: "n", Ciphertext: "c"}
+
+	_, err := s.relaySealed(sealed)
+	assert.Equal(t, slyerrors.ErrCodeSessionE2ENotEnabled, slyerrors.Cause(err).Code)
+
+	s.enableE2E("key")
+	_, err = s.relaySealed(sealed)
+	assert.Error(t, err)
+
+	// a handshake of someone else does not lock out the wallet
+	for i := 0; i < e2eMaxHandshakes; i++ {
+		assert.NoError(t, s.addWalletE2EKey(&PayloadE2EHandshake{PublicKey: "key", Mac: "mac"}))
+	}
+	err = s.addWalletE2EKey(&PayloadE2EHandshake{PublicKey: "key", Mac: "mac"})
+	assert.Equal(t, slyerrors.ErrCodeSessionE2ELimitReached, slyerrors.Cause(err).Code)
+
+	for i := 0; i < e2eMaxMessages; i++ {
+		_, err = s.relaySealed(sealed)
+		assert.NoError(t, err)
+	}
+	_, err = s.relaySealed(sealed)
+	assert.Equal(t, slyerrors.ErrCodeSessionE2ELimitReached, slyerrors.Cause(err).Code)
+}
+
+func TestParseLastEventId(t *testing.T) {
+	id, err := parseLastEventId("")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, id)
+
+	id, err = parseLastEventId("3")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, id)
+
+	_, err = parseLastEventId("abc")
+	assert.Error(t, err)
+	_, err = parseLastEventId("-1")
+	assert.Error(t, err)
+}
+
+func TestWriteSSEEvent(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := writeSSEEvent(buf, 7, CreateSessionStateMessage("sid", AuthFlowStateNameCreated, FlowStatePending))
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 7\nevent: session_state\ndata: {\"messageType\":\"session_state\",\"sessionId\":\"sid\",\"payload\":{\"state\":\"created\",\"authState\":\"pending\"}}\n\n", buf.String())
+}
@@ -1,14 +1,25 @@
 package session
 
+import "yip/src/slyerrors"
+
 const (
 	AuthFlowStateNone      = 0
 	AuthFlowStateCreated   = 1
 	AuthFlowStateConnected = 2
 	AuthFlowStateVerified  = 3
+	AuthFlowStateRejected  = 4
+	AuthFlowStateCancelled = 5
 
 	FlowStatePending = "pending"
 	FlowStateSuccess = "success"
 	FlowStateFailed  = "failed"
+
+	AuthFlowStateNameCreated   = "created"
+	AuthFlowStateNameConnected = "connected"
+	AuthFlowStateNameVerified  = "verified"
+	AuthFlowStateNameClosed    = "closed"
+	AuthFlowStateNameRejected  = "rejected"
+	AuthFlowStateNameCancelled = "cancelled"
 )
 
 type AuthFlow struct {
@@ -18,6 +29,14 @@ type AuthFlow struct {
 	audiences        []string
 	domain           string
 	state            int
+	// party and reason of a rejected or cancelled flow
+	endedBy string
+	reason  string
+	// number matching mode of the client, see config.Client
+	numberMatching string
+	match          *numberMatch
+	// the token of a verified flow is handed out once
+	tokenIssued bool
 }
 
 func NewAuthFlow() *AuthFlow {
@@ -35,6 +54,9 @@ func (a *AuthFlow) isConnectedState() bool {
 func (a *AuthFlow) isVerified() bool {
 	return a.state == AuthFlowStateVerified
 }
+func (a *AuthFlow) isEnded() bool {
+	return a.state == AuthFlowStateRejected || a.state == AuthFlowStateCancelled
+}
 func (a *AuthFlow) SessionState() string {
 
 	switch a.state {
@@ -51,6 +73,33 @@ func (a *AuthFlow) SessionState() string {
 	}
 }
 
+// StateName returns the fine-grained state of the flow, as streamed by the
+// session events endpoint
+func (a *AuthFlow) StateName() string {
+	switch a.state {
+	case AuthFlowStateConnected:
+		return AuthFlowStateNameConnected
+	case AuthFlowStateVerified:
+		return AuthFlowStateNameVerified
+	case AuthFlowStateRejected:
+		return AuthFlowStateNameRejected
+	case AuthFlowStateCancelled:
+		return AuthFlowStateNameCancelled
+	default:
+		return AuthFlowStateNameCreated
+	}
+}
+
+func (a *AuthFlow) statePayload(state string) PayloadSessionState {
+	return PayloadSessionState{
+		State:     state,
+		AuthState: a.SessionState(),
+		Party:     a.endedBy,
+		Reason:    a.reason,
+		MatchCode: a.matchCode(),
+	}
+}
+
 func (a *AuthFlow) setPayload(payload *PayloadAccountsResponse) {
 	a.eoa = payload.EOA
 	a.slyWalletAddress = payload.SLYWalletAddress
@@ -61,3 +110,15 @@ func (a *AuthFlow) setVerified(accountId string) {
 	a.state = AuthFlowStateVerified
 	a.accountId = accountId
 }
+
+// end moves the flow into the rejected or cancelled state. A verified or
+// already ended flow cannot be ended anymore.
+func (a *AuthFlow) end(state int, party string, reason string) error {
+	if a.isVerified() || a.isEnded() {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "session already %s", a.StateName())
+	}
+	a.state = state
+	a.endedBy = party
+	a.reason = reason
+	return nil
+}
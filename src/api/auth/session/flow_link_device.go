@@ -0,0 +1,256 @@
+package session
+
+import (
+	"fmt"
+	"time"
+	"yip/src/config"
+	"yip/src/contracts"
+	"yip/src/providers"
+	"yip/src/slyerrors"
+)
+
+const (
+	LinkDeviceStateCreated  = "created"
+	LinkDeviceStateApproved = "approved"
+	LinkDeviceStateLinked   = "linked"
+	LinkDeviceStateExpired  = "expired"
+	LinkDeviceStateFailed   = "failed"
+
+	linkDeviceTimeout = 10 * time.Minute
+	// time YIP waits for the AddKey transaction to be mined
+	linkDeviceMiningTimeout = 10 * time.Minute
+)
+
+// LinkDeviceRequest is submitted by the new device. Role is the role the new
+// key asks for, it defaults to authenticator.
+type LinkDeviceRequest struct {
+	EOA              string `json:"eoa"`
+	SLYWalletAddress string `json:"slyWalletAddress"`
+	Role             int    `json:"role"`
+}
+
+func (r *LinkDeviceRequest) validate() error {
+	v := slyerrors.NewValidation("400").
+		ValidateEthAddress("linkDevice.eoa", r.EOA).
+		ValidateEthAddress("linkDevice.slyWalletAddress", r.SLYWalletAddress)
+
+	if r.Role < 0 || r.Role >= int(contracts.RoleRoleCount) {
+		v.Add("linkDevice.role", slyerrors.ValidationCodeNumberOutOfRange, "between %d and %d", contracts.RoleOwner, contracts.RoleAuthenticator)
+	}
+
+	return v.Error()
+}
+
+func (r *LinkDeviceRequest) role() contracts.Role {
+	if r.Role == 0 {
+		return contracts.RoleAuthenticator
+	}
+	return contracts.Role(r.Role)
+}
+
+type LinkDeviceFlow struct {
+	request   *LinkDeviceRequest
+	message   string
+	state     string
+	approver  string
+	txHash    string
+	reason    string
+	expiresAt time.Time
+	// set once the new device signed the message with its key, the approver
+	// has to pick the code the new device shows
+	proven bool
+	match  *numberMatch
+	// set while the AddKey transaction of an approval is being sent
+	approving bool
+}
+
+func NewLinkDeviceFlow(request *LinkDeviceRequest) *LinkDeviceFlow {
+	return &LinkDeviceFlow{
+		request:   request,
+		state:     LinkDeviceStateCreated,
+		expiresAt: time.Now().Add(linkDeviceTimeout),
+	}
+}
+
+// linkDeviceMessage is the message the approving device signs with the Ethereum prefix
+func linkDeviceMessage(sessionId string, request *LinkDeviceRequest) string {
+	return fmt.Sprintf("Link device %s to SLYWallet %s with role %d.\nSession: %s", request.EOA, request.SLYWalletAddress, request.role(), sessionId)
+}
+
+// canApprove returns whether a key with the approver role may add a key with the given role.
+// Owners may add any role, admins may add admins and authenticators.
+func canApprove(approver contracts.Role, role contracts.Role) bool {
+	switch approver {
+	case contracts.RoleOwner:
+		return true
+	case contracts.RoleAdmin:
+		return role == contracts.RoleAdmin || role == contracts.RoleAuthenticator
+	default:
+		return false
+	}
+}
+
+func (f *LinkDeviceFlow) isFinal() bool {
+	switch f.state {
+	case LinkDeviceStateLinked, LinkDeviceStateExpired, LinkDeviceStateFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *LinkDeviceFlow) checkCreated() error {
+	if f.state == LinkDeviceStateCreated && time.Now().After(f.expiresAt) {
+		f.state = LinkDeviceStateExpired
+	}
+	switch f.state {
+	case LinkDeviceStateCreated:
+		return nil
+	case LinkDeviceStateExpired:
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionExpired, "link device request expired")
+	default:
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "link device request already %s", f.state)
+	}
+}
+
+// setProven marks the key of the new device as proven and returns the code the
+// new device shows
+func (f *LinkDeviceFlow) setProven() (string, error) {
+	if err := f.checkCreated(); err != nil {
+		return "", err
+	}
+	if f.proven {
+		return "", slyerrors.BadRequest(slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "key already proven")
+	}
+
+	m, err := newNumberMatch(config.NumberMatchingChoose)
+	if err != nil {
+		return "", err
+	}
+	f.match = m
+	f.proven = true
+	return m.code, nil
+}
+
+// reserve guards against concurrent approvals sending AddKey twice. A wrong
+// code fails the flow, the approver may have scanned the QR code of an attacker.
+func (f *LinkDeviceFlow) reserve(code string) error {
+	if err := f.checkCreated(); err != nil {
+		return err
+	}
+	if !f.proven {
+		return slyerrors.BadRequest(slyerrors.ErrCodeLinkDeviceNotProven, "new device has not proven its key")
+	}
+	if f.approving {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "link device request is being approved")
+	}
+	if code != f.match.code {
+		f.reason = "number match failed"
+		f.state = LinkDeviceStateFailed
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionNumberMismatch, "code does not match")
+	}
+	f.approving = true
+	return nil
+}
+
+func (f *LinkDeviceFlow) setApproved(approver string, txHash string) error {
+	if err := f.checkCreated(); err != nil {
+		return err
+	}
+	f.approving = false
+	f.approver = approver
+	f.txHash = txHash
+	f.state = LinkDeviceStateApproved
+	return nil
+}
+
+func (f *LinkDeviceFlow) setMined(status string, err error) {
+	switch {
+	case err != nil:
+		f.reason = err.Error()
+		f.state = LinkDeviceStateFailed
+	case status == providers.TransactionStatusFailed:
+		f.reason = "transaction failed"
+		f.state = LinkDeviceStateFailed
+	default:
+		f.state = LinkDeviceStateLinked
+	}
+}
+
+func (f *LinkDeviceFlow) expire() error {
+	if f.state != LinkDeviceStateCreated {
+		return fmt.Errorf("link device request already %s", f.state)
+	}
+	f.state = LinkDeviceStateExpired
+	return nil
+}
+
+func (f *LinkDeviceFlow) statePayload() PayloadLinkDeviceState {
+	return PayloadLinkDeviceState{
+		State:           f.state,
+		Final:           f.isFinal(),
+		Approver:        f.approver,
+		TransactionHash: f.txHash,
+		Reason:          f.reason,
+		ExpiresAt:       f.expiresAt,
+	}
+}
+
+func (f *LinkDeviceFlow) requestPayload() PayloadLinkDevice {
+	p := PayloadLinkDevice{
+		EOA:              f.request.EOA,
+		SLYWalletAddress: f.request.SLYWalletAddress,
+		Role:             int(f.request.role()),
+		Message:          f.message,
+		Proven:           f.proven,
+		ExpiresAt:        f.expiresAt,
+	}
+	if f.match != nil {
+		p.MatchChoices = f.match.choices
+	}
+	return p
+}
+
+func (s *Session) updateLinkDevice(update func(f *LinkDeviceFlow) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := update(s.LinkDeviceFlow)
+	s.publishLinkDeviceStateLocked()
+	return err
+}
+
+func (s *Session) linkDeviceState() PayloadLinkDeviceState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.LinkDeviceFlow.state == LinkDeviceStateCreated && time.Now().After(s.LinkDeviceFlow.expiresAt) {
+		s.LinkDeviceFlow.state = LinkDeviceStateExpired
+		s.publishLinkDeviceStateLocked()
+	}
+	return s.LinkDeviceFlow.statePayload()
+}
+
+func (s *Session) linkDevice() PayloadLinkDevice {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.LinkDeviceFlow.requestPayload()
+}
+
+func (s *Session) publishLinkDeviceStateLocked() {
+	if s.LinkDeviceFlow == nil {
+		return
+	}
+
+	p := s.LinkDeviceFlow.statePayload()
+
+	// only transitions are published
+	if l := s.events.lastOf(MessageTypeLinkDeviceState); l != nil {
+		if lp, ok := l.Message.Payload.(PayloadLinkDeviceState); ok && lp.State == p.State {
+			return
+		}
+	}
+
+	s.events.publish(CreateLinkDeviceStateMessage(s.SessionId.String(), p))
+}
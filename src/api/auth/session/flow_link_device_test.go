@@ -0,0 +1,70 @@
+package session
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"yip/src/contracts"
+	"yip/src/providers"
+	"yip/src/slyerrors"
+)
+
+func TestLinkDeviceCanApprove(t *testing.T) {
+	assert.True(t, canApprove(contracts.RoleOwner, contracts.RoleOwner))
+	assert.True(t, canApprove(contracts.RoleAdmin, contracts.RoleAuthenticator))
+	assert.False(t, canApprove(contracts.RoleAdmin, contracts.RoleOwner))
+	assert.False(t, canApprove(contracts.RoleAuthenticator, contracts.RoleAuthenticator))
+	assert.False(t, canApprove(contracts.RoleNone, contracts.RoleAuthenticator))
+}
+
+func TestLinkDeviceFlow(t *testing.T) {
+	request := &LinkDeviceRequest{
+		EOA:              "0x0000000000000000000000000000000000000001",
+		SLYWalletAddress: "0x0000000000000000000000000000000000000002",
+	}
+	assert.NoError(t, request.validate())
+	assert.Equal(t, contracts.RoleAuthenticator, request.role())
+	assert.Error(t, (&LinkDeviceRequest{EOA: request.EOA, SLYWalletAddress: request.SLYWalletAddress, Role: 4}).validate())
+
+	flow := NewLinkDeviceFlow(request)
+	// the new device has to prove its key first
+	assert.Equal(t, slyerrors.ErrCodeLinkDeviceNotProven, slyerrors.Cause(flow.reserve("")).Code)
+
+	code, err := flow.setProven()
+	assert.NoError(t, err)
+	assert.Contains(t, flow.requestPayload().MatchChoices, code)
+	_, err = flow.setProven()
+	assert.Error(t, err)
+
+	assert.NoError(t, flow.reserve(code))
+	// a second approval must not send AddKey again
+	assert.Error(t, flow.reserve(code))
+
+	assert.NoError(t, flow.setApproved("0x0000000000000000000000000000000000000003", "0xhash"))
+	assert.False(t, flow.isFinal())
+	assert.Error(t, flow.expire())
+
+	flow.setMined(providers.TransactionStatusSuccess, nil)
+	assert.Equal(t, LinkDeviceStateLinked, flow.state)
+	assert.True(t, flow.isFinal())
+}
+
+func TestLinkDeviceExpired(t *testing.T) {
+	flow := NewLinkDeviceFlow(&LinkDeviceRequest{})
+	flow.expiresAt = time.Now().Add(-time.Second)
+
+	assert.Error(t, flow.reserve(""))
+	assert.Equal(t, LinkDeviceStateExpired, flow.state)
+}
+
+func TestLinkDeviceNumberMismatch(t *testing.T) {
+	flow := NewLinkDeviceFlow(&LinkDeviceRequest{})
+	code, err := flow.setProven()
+	assert.NoError(t, err)
+
+	err = flow.reserve(code + "0")
+	assert.Equal(t, slyerrors.ErrCodeSessionNumberMismatch, slyerrors.Cause(err).Code)
+	assert.Equal(t, LinkDeviceStateFailed, flow.state)
+	assert.True(t, flow.isFinal())
+	assert.Error(t, flow.reserve(code))
+}
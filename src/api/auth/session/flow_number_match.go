@@ -0,0 +1,129 @@
+package session
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"yip/src/config"
+	"yip/src/slyerrors"
+)
+
+const numberMatchChoices = 3
+
+// numberMatch is the confirmation step between connect_with_account and the
+// SIWE signature. The dApp shows code, the wallet user has to confirm or pick it.
+type numberMatch struct {
+	mode      string
+	code      string
+	choices   []string
+	confirmed bool
+}
+
+// randomMatchCode returns a two digit code
+func randomMatchCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(90))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d", n.Int64()+10), nil
+}
+
+func newNumberMatch(mode string) (*numberMatch, error) {
+	code, err := randomMatchCode()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &numberMatch{
+		mode:    mode,
+		code:    code,
+		choices: []string{code},
+	}
+	if mode != config.NumberMatchingChoose {
+		return m, nil
+	}
+
+	for len(m.choices) < numberMatchChoices {
+		c, err := randomMatchCode()
+		if err != nil {
+			return nil, err
+		}
+		if !contains(m.choices, c) {
+			m.choices = append(m.choices, c)
+		}
+	}
+
+	// the position of the code must not give it away
+	for i := len(m.choices) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, err
+		}
+		m.choices[i], m.choices[j.Int64()] = m.choices[j.Int64()], m.choices[i]
+	}
+
+	return m, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, l := range list {
+		if l == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *AuthFlow) startNumberMatch() error {
+	m, err := newNumberMatch(a.numberMatching)
+	if err != nil {
+		return err
+	}
+	a.match = m
+	return nil
+}
+
+// needsNumberMatch is true while the wallet has not confirmed the code yet
+func (a *AuthFlow) needsNumberMatch() bool {
+	return a.match != nil && !a.match.confirmed
+}
+
+// matchCode is the code the dApp shows while the flow is waiting for the wallet
+func (a *AuthFlow) matchCode() string {
+	if !a.isConnectedState() || a.match == nil {
+		return ""
+	}
+	return a.match.code
+}
+
+func (a *AuthFlow) confirmNumberMatch(code string) error {
+	if !a.isConnectedState() || !a.needsNumberMatch() {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "not expecting this message")
+	}
+	if code != a.match.code {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionNumberMismatch, "code does not match")
+	}
+	a.match.confirmed = true
+	return nil
+}
+
+func (a *AuthFlow) numberMatchPayload() PayloadNumberMatch {
+	return PayloadNumberMatch{
+		Mode:    a.match.mode,
+		Choices: a.match.choices,
+	}
+}
+
+// confirmNumberMatch checks the code picked by the wallet. A wrong code rejects
+// the flow, the wallet user may have scanned the QR code of an attacker.
+func (s *Session) confirmNumberMatch(code string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.AuthFlow.confirmNumberMatch(code)
+	if err != nil && slyerrors.Cause(err).Code == slyerrors.ErrCodeSessionNumberMismatch {
+		_ = s.AuthFlow.end(AuthFlowStateRejected, PartyWallet, "number match failed")
+		s.publishAuthStateLocked()
+	}
+	return err
+}
@@ -0,0 +1,276 @@
+package session
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"time"
+	"yip/src/api/services/dto"
+	"yip/src/slyerrors"
+)
+
+const (
+	SignRequestKindTypedData = "typed_data"
+	SignRequestKindExecute   = "execute"
+
+	SignRequestStateCreated  = "created"
+	SignRequestStateFetched  = "fetched"
+	SignRequestStateSigned   = "signed"
+	SignRequestStateRelayed  = "relayed"
+	SignRequestStateRejected = "rejected"
+	SignRequestStateExpired  = "expired"
+	SignRequestStateFailed   = "failed"
+
+	signRequestDefaultTimeout = 5 * time.Minute
+	signRequestMaxTimeout     = 30 * time.Minute
+)
+
+var signRequestKinds = []string{SignRequestKindTypedData, SignRequestKindExecute}
+
+// SignRequest is submitted by the dApp. Either TypedData (EIP-712) or Execute
+// (a SLYWallet call) is set, according to Kind. Relay is only possible for
+// Execute: YIP then submits the signed call via ExecuteWithSignature. Only the
+// Signer key can fetch and sign the request.
+type SignRequest struct {
+	Kind         string              `json:"kind"`
+	Signer       string              `json:"signer"`
+	TypedData    *apitypes.TypedData `json:"typedData,omitempty"`
+	Execute      *dto.ExecuteCall    `json:"execute,omitempty"`
+	Relay        bool                `json:"relay"`
+	TimeoutInSec int64               `json:"timeoutInSec,omitempty"`
+}
+
+func (r *SignRequest) validate() error {
+	v := slyerrors.NewValidation("400").
+		ValidateInList("signRequest.kind", r.Kind, signRequestKinds).
+		ValidateEthAddress("signRequest.signer", r.Signer)
+
+	switch r.Kind {
+	case SignRequestKindTypedData:
+		if r.TypedData == nil {
+			v.Add("signRequest.typedData", slyerrors.ValidationCodeStringEmpty, "")
+		} else if _, _, err := apitypes.TypedDataAndHash(*r.TypedData); err != nil {
+			v.Add("signRequest.typedData", slyerrors.ValidationCodeCannotValidate, err.Error())
+		}
+		if r.Relay {
+			v.Add("signRequest.relay", slyerrors.ValidationCodeUnexpectedValue, "typed data cannot be relayed")
+		}
+	case SignRequestKindExecute:
+		if r.Execute == nil {
+			v.Add("signRequest.execute", slyerrors.ValidationCodeStringEmpty, "")
+		} else {
+			v.Merge(r.Execute.Validate(), "signRequest.execute")
+		}
+	}
+
+	if r.TimeoutInSec < 0 || time.Duration(r.TimeoutInSec)*time.Second > signRequestMaxTimeout {
+		v.Add("signRequest.timeoutInSec", slyerrors.ValidationCodeNumberOutOfRange, "between 0 and %d", int64(signRequestMaxTimeout.Seconds()))
+	}
+
+	return v.Error()
+}
+
+func (r *SignRequest) timeout() time.Duration {
+	if r.TimeoutInSec == 0 {
+		return signRequestDefaultTimeout
+	}
+	return time.Duration(r.TimeoutInSec) * time.Second
+}
+
+type SignRequestFlow struct {
+	request *SignRequest
+	// signer is the expected key, eoa the key that fetched the request
+	signer    string
+	state     string
+	eoa       string
+	prepared  *dto.PreparedExecute
+	digest    []byte
+	signature string
+	txHash    string
+	reason    string
+	expiresAt time.Time
+}
+
+func NewSignRequestFlow(request *SignRequest) (*SignRequestFlow, error) {
+	f := &SignRequestFlow{
+		request:   request,
+		signer:    common.HexToAddress(request.Signer).Hex(),
+		state:     SignRequestStateCreated,
+		expiresAt: time.Now().Add(request.timeout()),
+	}
+
+	if request.Kind == SignRequestKindTypedData {
+		digest, _, err := apitypes.TypedDataAndHash(*request.TypedData)
+		if err != nil {
+			return nil, err
+		}
+		f.digest = digest
+	}
+
+	return f, nil
+}
+
+// isFinal is true if the flow will not change its state anymore
+func (f *SignRequestFlow) isFinal() bool {
+	switch f.state {
+	case SignRequestStateSigned:
+		return !f.request.Relay
+	case SignRequestStateRelayed, SignRequestStateRejected, SignRequestStateExpired, SignRequestStateFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *SignRequestFlow) isExpired() bool {
+	return f.state == SignRequestStateExpired || (!f.isFinal() && time.Now().After(f.expiresAt))
+}
+
+// expect returns an error if the flow is not in the given state. An overdue
+// flow is moved to the expired state.
+func (f *SignRequestFlow) expect(state string) error {
+	if f.isExpired() {
+		f.state = SignRequestStateExpired
+		return slyerrors.BadRequest(slyerrors.ErrCodeSignRequestExpired, "sign request expired")
+	}
+	if f.state == SignRequestStateRejected {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSignRequestRejected, "sign request rejected")
+	}
+	if f.state != state {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "not expecting this message in state %s", f.state)
+	}
+	return nil
+}
+
+func (f *SignRequestFlow) setFetched(eoa string, prepared *dto.PreparedExecute) error {
+	if err := f.expect(SignRequestStateCreated); err != nil {
+		return err
+	}
+	if eoa != f.signer {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSignRequestWrongSigner, "sign request is for %s", f.signer)
+	}
+
+	if prepared != nil {
+		digest, err := hexutil.Decode(prepared.Digest)
+		if err != nil {
+			return fmt.Errorf("invalid digest: %w", err)
+		}
+		f.digest = digest
+		f.prepared = prepared
+	}
+	f.eoa = eoa
+	f.state = SignRequestStateFetched
+	return nil
+}
+
+func (f *SignRequestFlow) setSigned(signature string) error {
+	if err := f.expect(SignRequestStateFetched); err != nil {
+		return err
+	}
+	f.signature = signature
+	f.state = SignRequestStateSigned
+	return nil
+}
+
+func (f *SignRequestFlow) setRelayed(txHash string) error {
+	if err := f.expect(SignRequestStateSigned); err != nil {
+		return err
+	}
+	f.txHash = txHash
+	f.state = SignRequestStateRelayed
+	return nil
+}
+
+func (f *SignRequestFlow) setFailed(reason string) {
+	f.reason = reason
+	f.state = SignRequestStateFailed
+}
+
+func (f *SignRequestFlow) setRejected(reason string) error {
+	if f.isFinal() {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "sign request already %s", f.state)
+	}
+	if f.isExpired() {
+		f.state = SignRequestStateExpired
+		return slyerrors.BadRequest(slyerrors.ErrCodeSignRequestExpired, "sign request expired")
+	}
+	f.reason = reason
+	f.state = SignRequestStateRejected
+	return nil
+}
+
+func (f *SignRequestFlow) expire() error {
+	if f.isFinal() {
+		return fmt.Errorf("sign request already %s", f.state)
+	}
+	f.state = SignRequestStateExpired
+	return nil
+}
+
+func (f *SignRequestFlow) statePayload() PayloadSignRequestState {
+	return PayloadSignRequestState{
+		State:           f.state,
+		Final:           f.isFinal(),
+		EOA:             f.eoa,
+		Signature:       f.signature,
+		TransactionHash: f.txHash,
+		Reason:          f.reason,
+		ExpiresAt:       f.expiresAt,
+	}
+}
+
+func (f *SignRequestFlow) requestPayload() PayloadSignRequest {
+	return PayloadSignRequest{
+		Request:   f.request,
+		Prepared:  f.prepared,
+		Digest:    hexutil.Encode(f.digest),
+		ExpiresAt: f.expiresAt,
+	}
+}
+
+// updateSignRequest applies an update to the sign request flow under the session
+// lock and publishes the resulting state
+func (s *Session) updateSignRequest(update func(f *SignRequestFlow) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := update(s.SignRequestFlow)
+	s.publishSignRequestStateLocked()
+	return err
+}
+
+func (s *Session) signRequestState() PayloadSignRequestState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.SignRequestFlow.isExpired() {
+		s.SignRequestFlow.state = SignRequestStateExpired
+		s.publishSignRequestStateLocked()
+	}
+	return s.SignRequestFlow.statePayload()
+}
+
+func (s *Session) signRequest() (PayloadSignRequest, string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.SignRequestFlow.requestPayload(), s.SignRequestFlow.eoa
+}
+
+func (s *Session) publishSignRequestStateLocked() {
+	if s.SignRequestFlow == nil {
+		return
+	}
+
+	p := s.SignRequestFlow.statePayload()
+
+	// only transitions are published
+	if l := s.events.lastOf(MessageTypeSignRequestState); l != nil {
+		if lp, ok := l.Message.Payload.(PayloadSignRequestState); ok && lp.State == p.State {
+			return
+		}
+	}
+
+	s.events.publish(CreateSignRequestStateMessage(s.SessionId.String(), p))
+}
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"yip/src/cryptox"
+	"yip/src/slyerrors"
+)
+
+const testSigner = "0x0000000000000000000000000000000000000002"
+
+func testTypedData() *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"Mail": {
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Mail",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "YIP",
+			ChainId: math.NewHexOrDecimal256(31337),
+		},
+		Message: apitypes.TypedDataMessage{
+			"contents": "hello",
+		},
+	}
+}
+
+func TestSignRequestTypedData(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	eoa := crypto.PubkeyToAddress(key.PublicKey)
+
+	request := &SignRequest{Kind: SignRequestKindTypedData, Signer: eoa.Hex(), TypedData: testTypedData()}
+	assert.NoError(t, request.validate())
+
+	flow, err := NewSignRequestFlow(request)
+	assert.NoError(t, err)
+
+	// only the signer named by the dApp can fetch the request
+	err = flow.setFetched("0x0000000000000000000000000000000000000001", nil)
+	assert.Equal(t, slyerrors.ErrCodeSignRequestWrongSigner, slyerrors.Cause(err).Code)
+	assert.NoError(t, flow.setFetched(eoa.Hex(), nil))
+
+	sig, err := crypto.Sign(flow.digest, key)
+	assert.NoError(t, err)
+	recovered, err := cryptox.RecoverHash(flow.digest, hexutil.Encode(sig))
+	assert.NoError(t, err)
+	assert.Equal(t, eoa, *recovered)
+
+	assert.NoError(t, flow.setSigned(hexutil.Encode(sig)))
+	assert.True(t, flow.isFinal())
+
+	// a final flow cannot be rejected anymore
+	assert.Error(t, flow.setRejected("too late"))
+}
+
+func TestSignRequestValidation(t *testing.T) {
+	assert.Error(t, (&SignRequest{Kind: "unknown"}).validate())
+	assert.Error(t, (&SignRequest{Kind: SignRequestKindTypedData, TypedData: testTypedData()}).validate())
+	assert.Error(t, (&SignRequest{Kind: SignRequestKindTypedData}).validate())
+	assert.Error(t, (&SignRequest{Kind: SignRequestKindTypedData, Signer: testSigner, TypedData: testTypedData(), Relay: true}).validate())
+	assert.Error(t, (&SignRequest{Kind: SignRequestKindExecute}).validate())
+	assert.Error(t, (&SignRequest{Kind: SignRequestKindTypedData, Signer: testSigner, TypedData: testTypedData(), TimeoutInSec: 3600}).validate())
+}
+
+func TestSignRequestExpiredAndRejected(t *testing.T) {
+	flow, err := NewSignRequestFlow(&SignRequest{Kind: SignRequestKindTypedData, Signer: testSigner, TypedData: testTypedData()})
+	assert.NoError(t, err)
+	flow.expiresAt = time.Now().Add(-time.Second)
+
+	err = flow.setFetched(testSigner, nil)
+	assert.Equal(t, slyerrors.ErrCodeSignRequestExpired, slyerrors.Cause(err).Code)
+	assert.Equal(t, SignRequestStateExpired, flow.state)
+
+	flow, _ = NewSignRequestFlow(&SignRequest{Kind: SignRequestKindTypedData, Signer: testSigner, TypedData: testTypedData()})
+	assert.NoError(t, flow.setRejected("user declined"))
+	assert.True(t, flow.isFinal())
+
+	err = flow.setFetched(testSigner, nil)
+	assert.Equal(t, slyerrors.ErrCodeSignRequestRejected, slyerrors.Cause(err).Code)
+}
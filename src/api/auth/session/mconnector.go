@@ -25,6 +25,8 @@ func InitMConnector() MConnector {
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
+			// dApps of any origin use the session api, like the CORS policy
+			CheckOrigin: func(r *http.Request) bool { return true },
 		},
 		sessions:          make(map[uuid.UUID]*Session),
 		register:          make(chan *SessionClient),
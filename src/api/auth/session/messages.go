@@ -1,12 +1,12 @@
 package session
 
 import (
-	"encoding/json"
 	"fmt"
+	"time"
 	"yip/src/api/auth/verifier"
 	"yip/src/api/services/dto"
+	"yip/src/cryptox"
 	"yip/src/slyerrors"
-	"yip/src/utils"
 )
 
 const (
@@ -22,9 +22,35 @@ const (
 	MessageTypePingTokenResponse      = "ping_token_response"
 	MessageTypeCloseSession           = "session_close"
 	MessageTypeCloseSessionResponse   = "session_close_response"
+	MessageTypeSessionState           = "session_state"
+	MessageTypeFetchSignRequest       = "sign_request_fetch"
+	MessageTypeSignRequest            = "sign_request"
+	MessageTypeSubmitSignRequest      = "sign_request_response"
+	MessageTypeRejectSignRequest      = "sign_request_reject"
+	MessageTypeSignRequestStatus      = "sign_request_status"
+	MessageTypeSignRequestState       = "sign_request_state"
+	MessageTypeFetchLinkDevice        = "link_device_fetch"
+	MessageTypeLinkDevice             = "link_device"
+	MessageTypeProveLinkDevice        = "link_device_prove"
+	MessageTypeApproveLinkDevice      = "link_device_approve"
+	MessageTypeLinkDeviceStatus       = "link_device_status"
+	MessageTypeLinkDeviceState        = "link_device_state"
+	MessageTypeE2EHandshake           = "e2e_handshake"
+	MessageTypeE2EMessage             = "e2e_message"
+	MessageTypeE2EMessageAccepted     = "e2e_message_accepted"
+	MessageTypeSessionRejected        = "session_rejected"
+	MessageTypeSessionCancelled       = "session_cancelled"
+	MessageTypeNumberMatch            = "number_match"
+	MessageTypeNumberMatchResponse    = "number_match_response"
 )
 
+// WebsocketMessage is the envelope of all session messages. Version, Id and
+// ReplyTo are set from protocol version 2 on; a message without version is
+// treated as version 1.
 type WebsocketMessage struct {
+	Version     int         `json:"v,omitempty"`
+	Id          string      `json:"id,omitempty"`
+	ReplyTo     string      `json:"replyTo,omitempty"`
 	MessageType string      `json:"messageType"`
 	SessionId   string      `json:"sessionId"`
 	Payload     interface{} `json:"payload"`
@@ -52,44 +78,75 @@ func (wm *WebsocketMessage) IsSessionIdEmpty() bool {
 }
 
 type PayloadCreateSessionRequest struct {
-	ClientId    string `json:"clientId"`
-	SessionType string `json:"sessionType"`
+	ClientId    string             `json:"clientId"`
+	SessionType string             `json:"sessionType"`
+	SignRequest *SignRequest       `json:"signRequest,omitempty"`
+	LinkDevice  *LinkDeviceRequest `json:"linkDevice,omitempty"`
+	// E2EPublicKey enables the end-to-end encrypted channel, see PayloadSealed
+	E2EPublicKey string `json:"e2ePublicKey,omitempty"`
+	// PushTo is a device key address of a returning user. The devices of its
+	// account get a push notification with the QR code content.
+	PushTo string `json:"pushTo,omitempty"`
 }
 
 func CreateSessionMessage(clientId string, sessionType string) *WebsocketMessage {
-	return &WebsocketMessage{
-		MessageType: MessageTypeCreateSessionRequest,
-		SessionId:   "",
-		Payload: PayloadCreateSessionRequest{
-			ClientId:    clientId,
-			SessionType: sessionType,
-		},
-	}
+	return newMessage(MessageTypeCreateSessionRequest, "", PayloadCreateSessionRequest{
+		ClientId:    clientId,
+		SessionType: sessionType,
+	})
 }
 
-func (wm *WebsocketMessage) ParseCreateSessionRequest() (*PayloadCreateSessionRequest, error) {
-	payload := PayloadCreateSessionRequest{}
+func (p *PayloadCreateSessionRequest) validate() error {
+	err := slyerrors.NewValidation("400").
+		ValidateNotEmpty("clientId", p.ClientId).
+		ValidateInList("sessionType", p.SessionType, sessionTypes).
+		Error()
 
-	b, err := json.Marshal(wm.Payload)
 	if err != nil {
-		return nil, fmt.Errorf("payload is not according to message type")
+		return err
 	}
 
-	err = json.Unmarshal(b, &payload)
-	if err != nil {
-		return nil, fmt.Errorf("payload is not according to message type")
+	if p.E2EPublicKey != "" {
+		if _, err = cryptox.ParseE2EPublicKey(p.E2EPublicKey); err != nil {
+			return slyerrors.NewValidation("400").Add("e2ePublicKey", slyerrors.ValidationCodeCannotValidate, err.Error()).Error()
+		}
 	}
 
-	err = slyerrors.NewValidation("400").
-		ValidateNotEmpty("clientId", payload.ClientId).
-		ValidateInList("sessionType", payload.SessionType, sessionTypes).
-		Error()
+	if p.PushTo != "" {
+		if p.SessionType != SessionTypeAuth {
+			return slyerrors.NewValidation("400").Add("pushTo", slyerrors.ValidationCodeCannotValidate, "only auth sessions can be pushed").Error()
+		}
+		if err = slyerrors.NewValidation("400").ValidateEthAddress("pushTo", p.PushTo).Error(); err != nil {
+			return err
+		}
+	}
 
-	if err != nil {
-		return nil, err
+	switch p.SessionType {
+	case SessionTypeSignRequest:
+		if p.SignRequest == nil {
+			return slyerrors.NewValidation("400").ValidateNotEmpty("signRequest", "").Error()
+		}
+		return p.SignRequest.validate()
+	case SessionTypeLinkDevice:
+		if p.LinkDevice == nil {
+			return slyerrors.NewValidation("400").ValidateNotEmpty("linkDevice", "").Error()
+		}
+		return p.LinkDevice.validate()
 	}
 
-	return &payload, nil
+	return nil
+}
+
+func (wm *WebsocketMessage) ParseCreateSessionRequest() (*PayloadCreateSessionRequest, error) {
+	return parsePayload[PayloadCreateSessionRequest](wm)
+}
+
+func CreateSignRequestSessionMessage(clientId string, request *SignRequest) *WebsocketMessage {
+	return newMessage(MessageTypeCreateSessionRequest, "", PayloadCreateSessionRequest{
+		ClientId:    clientId,
+		SessionType: SessionTypeSignRequest,
+		SignRequest: request,
+	})
 }
 
 type PayloadSessionCreatedResponse struct {
@@ -97,6 +154,12 @@ type PayloadSessionCreatedResponse struct {
 	SessionType   string `json:"sessionType"`
 	ClientId      string `json:"clientId"`
 	QRCodeContent string `json:"qrCodeContent"`
+	// DeepLink opens the wallet app directly, set if a universal link is configured
+	DeepLink string `json:"deepLink,omitempty"`
+}
+
+func (wm *WebsocketMessage) ParseSessionCreated() (*PayloadSessionCreatedResponse, error) {
+	return parsePayload[PayloadSessionCreatedResponse](wm)
 }
 
 type PayloadSignatureRequest struct {
@@ -104,41 +167,40 @@ type PayloadSignatureRequest struct {
 	Message string `json:"message"`
 }
 
-type PayloadSignatureResponse = dto.SubmitRequestDTO
+// PayloadChallenge is the SIWE message the wallet receives in reply to
+// connect_with_account
+type PayloadChallenge = dto.ChallengeResponse
 
-func CreateSubmitSignature(sessionId string, message string, signature string) *WebsocketMessage {
-	return &WebsocketMessage{
-		MessageType: MessageTypeSubmitSignature,
-		SessionId:   sessionId,
-		Payload: PayloadSignatureResponse{
-			Message:   message,
-			Signature: signature,
-		},
-	}
+func (wm *WebsocketMessage) ParseChallenge() (*PayloadChallenge, error) {
+	return parsePayload[PayloadChallenge](wm)
 }
 
-func (wm *WebsocketMessage) ParseSubmitRequest() (*PayloadSignatureResponse, error) {
-	payload := PayloadSignatureResponse{}
+type PayloadSignatureResponse = dto.SubmitRequestDTO
 
-	err := utils.MapToStruct(wm.Payload, &payload)
-	if err != nil {
-		return nil, fmt.Errorf("payload is not according to message type")
-	}
+func CreateSubmitSignature(sessionId string, message string, signature string) *WebsocketMessage {
+	return newMessage(MessageTypeSubmitSignature, sessionId, PayloadSignatureResponse{
+		Message:   message,
+		Signature: signature,
+	})
+}
 
-	err = slyerrors.NewValidation("400").
-		ValidateNotEmpty("message", payload.Message).
-		ValidateNotEmpty("signature", payload.Signature).
+func validateSignatureResponse(p *PayloadSignatureResponse) error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("message", p.Message).
+		ValidateNotEmpty("signature", p.Signature).
 		Error()
+}
 
-	if err != nil {
-		return nil, err
-	}
-
-	return &payload, nil
+func (wm *WebsocketMessage) ParseSubmitRequest() (*PayloadSignatureResponse, error) {
+	return parsePayload[PayloadSignatureResponse](wm)
 }
 
 type PayloadVerificationResponse = dto.VerifyResponse
 
+func (wm *WebsocketMessage) ParseVerificationResponse() (*PayloadVerificationResponse, error) {
+	return parsePayload[PayloadVerificationResponse](wm)
+}
+
 func CreateVerificationResponse(sessionId string, response *dto.VerifyResponse) *WebsocketMessage {
 	return &WebsocketMessage{
 		MessageType: MessageTypeVerificationResponse,
@@ -158,39 +220,22 @@ type PayloadAccountsResponse struct {
 }
 
 func CreateAccountResponse(sessionId string, eoa string, slyWalletAddress string, chainId string) *WebsocketMessage {
-	return &WebsocketMessage{
-		MessageType: MessageTypeConnectWithAccount,
-		SessionId:   sessionId,
-		Payload: PayloadAccountsResponse{
-			EOA:              eoa,
-			SLYWalletAddress: slyWalletAddress,
-			ChainID:          chainId,
-		},
-	}
+	return newMessage(MessageTypeConnectWithAccount, sessionId, PayloadAccountsResponse{
+		EOA:              eoa,
+		SLYWalletAddress: slyWalletAddress,
+		ChainID:          chainId,
+	})
 }
 
-func (wm *WebsocketMessage) ParseAccountsResponse() (*PayloadAccountsResponse, error) {
-	payload := PayloadAccountsResponse{}
-
-	b, err := json.Marshal(wm.Payload)
-	if err != nil {
-		return nil, fmt.Errorf("payload is not according to message type")
-	}
-	err = json.Unmarshal(b, &payload)
-	if err != nil {
-		return nil, fmt.Errorf("payload is not according to message type")
-	}
-
-	err = slyerrors.NewValidation("400").
-		ValidateEthAddress("eoa", payload.EOA).
-		ValidateNotEmpty("chainId", payload.ChainID).
+func (p *PayloadAccountsResponse) validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateEthAddress("eoa", p.EOA).
+		ValidateNotEmpty("chainId", p.ChainID).
 		Error()
+}
 
-	if err != nil {
-		return nil, err
-	}
-
-	return &payload, nil
+func (wm *WebsocketMessage) ParseAccountsResponse() (*PayloadAccountsResponse, error) {
+	return parsePayload[PayloadAccountsResponse](wm)
 }
 
 type PayloadSessionError struct {
@@ -200,18 +245,7 @@ type PayloadSessionError struct {
 }
 
 func (wm *WebsocketMessage) ParseSessionError() (*PayloadSessionError, error) {
-	payload := PayloadSessionError{}
-
-	err := utils.MapToStruct(wm.Payload, &payload)
-	if err != nil {
-		return nil, fmt.Errorf("payload is not according to message type")
-	}
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &payload, nil
+	return parsePayload[PayloadSessionError](wm)
 }
 
 type PayloadPingTokenRequest struct {
@@ -220,14 +254,16 @@ type PayloadPingTokenRequest struct {
 type PayloadPingTokenResponse struct {
 	AuthState string          `json:"authState"`
 	Token     *verifier.Token `json:"token"`
+	// set if the flow was rejected or cancelled
+	State  string `json:"state,omitempty"`
+	Party  string `json:"party,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// code the dApp shows while the wallet confirms the number match
+	MatchCode string `json:"matchCode,omitempty"`
 }
 
 func CreatePingRequest(sessionId string) *WebsocketMessage {
-	return &WebsocketMessage{
-		MessageType: MessageTypePingToken,
-		SessionId:   sessionId,
-		Payload:     PayloadPingTokenRequest{},
-	}
+	return newMessage(MessageTypePingToken, sessionId, PayloadPingTokenRequest{})
 }
 
 func CreatePingResponse(sessionId string, state string, token *verifier.Token) *WebsocketMessage {
@@ -241,6 +277,93 @@ func CreatePingResponse(sessionId string, state string, token *verifier.Token) *
 	}
 }
 
+// CreatePingEndedResponse reports a rejected or cancelled flow to a polling dApp
+func CreatePingEndedResponse(sessionId string, flow *AuthFlow) *WebsocketMessage {
+	return &WebsocketMessage{
+		MessageType: MessageTypePingTokenResponse,
+		SessionId:   sessionId,
+		Payload: PayloadPingTokenResponse{
+			AuthState: flow.SessionState(),
+			State:     flow.StateName(),
+			Party:     flow.endedBy,
+			Reason:    flow.reason,
+		},
+	}
+}
+
+type PayloadSessionState struct {
+	State     string `json:"state"`
+	AuthState string `json:"authState"`
+	Party     string `json:"party,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	// code the dApp shows while the wallet confirms the number match
+	MatchCode string `json:"matchCode,omitempty"`
+}
+
+func CreateSessionStateMessage(sessionId string, state string, authState string) *WebsocketMessage {
+	return &WebsocketMessage{
+		MessageType: MessageTypeSessionState,
+		SessionId:   sessionId,
+		Payload: PayloadSessionState{
+			State:     state,
+			AuthState: authState,
+		},
+	}
+}
+
+func (wm *WebsocketMessage) ParseSessionState() (*PayloadSessionState, error) {
+	return parsePayload[PayloadSessionState](wm)
+}
+
+// PayloadNumberMatch is sent to the wallet instead of the SIWE message if the
+// client requires number matching. Choices holds the code only in confirm mode.
+type PayloadNumberMatch struct {
+	Mode    string   `json:"mode"`
+	Choices []string `json:"choices"`
+}
+
+func (wm *WebsocketMessage) ParseNumberMatch() (*PayloadNumberMatch, error) {
+	return parsePayload[PayloadNumberMatch](wm)
+}
+
+type PayloadNumberMatchResponse struct {
+	Code string `json:"code"`
+}
+
+func CreateNumberMatchResponse(sessionId string, code string) *WebsocketMessage {
+	return newMessage(MessageTypeNumberMatchResponse, sessionId, PayloadNumberMatchResponse{Code: code})
+}
+
+func (p *PayloadNumberMatchResponse) validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("code", p.Code).
+		Error()
+}
+
+func (wm *WebsocketMessage) ParseNumberMatchResponse() (*PayloadNumberMatchResponse, error) {
+	return parsePayload[PayloadNumberMatchResponse](wm)
+}
+
+// PayloadSessionEnd is sent by either party with session_rejected or
+// session_cancelled
+// PayloadSessionEnd ends an auth flow. session_rejected is sent by the
+// wallet, session_cancelled by the dApp.
+type PayloadSessionEnd struct {
+	Reason string `json:"reason"`
+}
+
+func CreateSessionRejected(sessionId string, reason string) *WebsocketMessage {
+	return newMessage(MessageTypeSessionRejected, sessionId, PayloadSessionEnd{Reason: reason})
+}
+
+func CreateSessionCancelled(sessionId string, reason string) *WebsocketMessage {
+	return newMessage(MessageTypeSessionCancelled, sessionId, PayloadSessionEnd{Reason: reason})
+}
+
+func (wm *WebsocketMessage) ParseSessionEnd() (*PayloadSessionEnd, error) {
+	return parsePayload[PayloadSessionEnd](wm)
+}
+
 type PayloadSessionClosed struct {
 }
 
@@ -256,24 +379,280 @@ type PayloadSessionClose struct {
 }
 
 func CreateCloseSessionRequest(sessionId string) *WebsocketMessage {
+	return newMessage(MessageTypeCloseSession, sessionId, PayloadSessionClose{})
+}
+
+func (wm *WebsocketMessage) ParsePingResponse() (*PayloadPingTokenResponse, error) {
+	return parsePayload[PayloadPingTokenResponse](wm)
+}
+
+type PayloadFetchSignRequest struct {
+	EOA string `json:"eoa"`
+}
+
+func CreateFetchSignRequest(sessionId string, eoa string) *WebsocketMessage {
+	return newMessage(MessageTypeFetchSignRequest, sessionId, PayloadFetchSignRequest{EOA: eoa})
+}
+
+func (p *PayloadFetchSignRequest) validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateEthAddress("eoa", p.EOA).
+		Error()
+}
+
+func (wm *WebsocketMessage) ParseFetchSignRequest() (*PayloadFetchSignRequest, error) {
+	return parsePayload[PayloadFetchSignRequest](wm)
+}
+
+// PayloadSignRequest is sent to the wallet. Digest is the hash to sign: the
+// EIP-712 hash of the typed data or of the prepared Execute call.
+type PayloadSignRequest struct {
+	Request   *SignRequest         `json:"request"`
+	Prepared  *dto.PreparedExecute `json:"prepared,omitempty"`
+	Digest    string               `json:"digest"`
+	ExpiresAt time.Time            `json:"expiresAt"`
+}
+
+func (wm *WebsocketMessage) ParseSignRequest() (*PayloadSignRequest, error) {
+	return parsePayload[PayloadSignRequest](wm)
+}
+
+type PayloadSubmitSignRequest struct {
+	Signature string `json:"signature"`
+}
+
+func CreateSubmitSignRequest(sessionId string, signature string) *WebsocketMessage {
+	return newMessage(MessageTypeSubmitSignRequest, sessionId, PayloadSubmitSignRequest{Signature: signature})
+}
+
+func (p *PayloadSubmitSignRequest) validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("signature", p.Signature).
+		Error()
+}
+
+func (wm *WebsocketMessage) ParseSubmitSignRequest() (*PayloadSubmitSignRequest, error) {
+	return parsePayload[PayloadSubmitSignRequest](wm)
+}
+
+type PayloadRejectSignRequest struct {
+	Reason string `json:"reason"`
+}
+
+func CreateRejectSignRequest(sessionId string, reason string) *WebsocketMessage {
+	return newMessage(MessageTypeRejectSignRequest, sessionId, PayloadRejectSignRequest{Reason: reason})
+}
+
+func (wm *WebsocketMessage) ParseRejectSignRequest() (*PayloadRejectSignRequest, error) {
+	return parsePayload[PayloadRejectSignRequest](wm)
+}
+
+// PayloadStatusRequest asks for the state of a sign request or link device session
+type PayloadStatusRequest struct {
+}
+
+func CreateSignRequestStatusRequest(sessionId string) *WebsocketMessage {
+	return newMessage(MessageTypeSignRequestStatus, sessionId, PayloadStatusRequest{})
+}
+
+// PayloadSignRequestState describes the state of a sign request. Final is set
+// once the state does not change anymore.
+type PayloadSignRequestState struct {
+	State           string    `json:"state"`
+	Final           bool      `json:"final"`
+	EOA             string    `json:"eoa,omitempty"`
+	Signature       string    `json:"signature,omitempty"`
+	TransactionHash string    `json:"transactionHash,omitempty"`
+	Reason          string    `json:"reason,omitempty"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+func CreateSignRequestStateMessage(sessionId string, state PayloadSignRequestState) *WebsocketMessage {
 	return &WebsocketMessage{
-		MessageType: MessageTypeCloseSession,
+		MessageType: MessageTypeSignRequestState,
 		SessionId:   sessionId,
-		Payload:     PayloadSessionClose{},
+		Payload:     state,
 	}
 }
 
-func (wm *WebsocketMessage) ParsePingResponse() (*PayloadPingTokenResponse, error) {
-	payload := &PayloadPingTokenResponse{}
+func (wm *WebsocketMessage) ParseSignRequestState() (*PayloadSignRequestState, error) {
+	return parsePayload[PayloadSignRequestState](wm)
+}
 
-	err := utils.MapToStruct(wm.Payload, payload)
-	if err != nil {
-		return nil, fmt.Errorf("payload is not according to message type")
+func CreateLinkDeviceSessionMessage(clientId string, request *LinkDeviceRequest) *WebsocketMessage {
+	return newMessage(MessageTypeCreateSessionRequest, "", PayloadCreateSessionRequest{
+		ClientId:    clientId,
+		SessionType: SessionTypeLinkDevice,
+		LinkDevice:  request,
+	})
+}
+
+type PayloadFetchLinkDevice struct {
+}
+
+func CreateFetchLinkDevice(sessionId string) *WebsocketMessage {
+	return newMessage(MessageTypeFetchLinkDevice, sessionId, PayloadFetchLinkDevice{})
+}
+
+// PayloadLinkDevice is sent to the approving device. Message has to be signed
+// with the Ethereum prefix by an owner or admin key of the SLYWallet, after the
+// new device proved its key by signing it as well. The user then picks the code
+// the new device shows of MatchChoices.
+type PayloadLinkDevice struct {
+	EOA              string    `json:"eoa"`
+	SLYWalletAddress string    `json:"slyWalletAddress"`
+	Role             int       `json:"role"`
+	Message          string    `json:"message"`
+	Proven           bool      `json:"proven"`
+	MatchChoices     []string  `json:"matchChoices,omitempty"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+}
+
+func (wm *WebsocketMessage) ParseLinkDevice() (*PayloadLinkDevice, error) {
+	return parsePayload[PayloadLinkDevice](wm)
+}
+
+// PayloadProveLinkDevice is sent by the new device with the signature of the
+// link device message by its key
+type PayloadProveLinkDevice struct {
+	Signature string `json:"signature"`
+}
+
+func CreateProveLinkDevice(sessionId string, signature string) *WebsocketMessage {
+	return newMessage(MessageTypeProveLinkDevice, sessionId, PayloadProveLinkDevice{Signature: signature})
+}
+
+func (p *PayloadProveLinkDevice) validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("signature", p.Signature).
+		Error()
+}
+
+func (wm *WebsocketMessage) ParseProveLinkDevice() (*PayloadProveLinkDevice, error) {
+	return parsePayload[PayloadProveLinkDevice](wm)
+}
+
+type PayloadApproveLinkDevice struct {
+	Approver  string `json:"approver"`
+	Signature string `json:"signature"`
+	Code      string `json:"code"`
+}
+
+func CreateApproveLinkDevice(sessionId string, approver string, signature string, code string) *WebsocketMessage {
+	return newMessage(MessageTypeApproveLinkDevice, sessionId, PayloadApproveLinkDevice{
+		Approver:  approver,
+		Signature: signature,
+		Code:      code,
+	})
+}
+
+func (p *PayloadApproveLinkDevice) validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateEthAddress("approver", p.Approver).
+		ValidateNotEmpty("signature", p.Signature).
+		ValidateNotEmpty("code", p.Code).
+		Error()
+}
+
+func (wm *WebsocketMessage) ParseApproveLinkDevice() (*PayloadApproveLinkDevice, error) {
+	return parsePayload[PayloadApproveLinkDevice](wm)
+}
+
+func CreateLinkDeviceStatusRequest(sessionId string) *WebsocketMessage {
+	return newMessage(MessageTypeLinkDeviceStatus, sessionId, PayloadStatusRequest{})
+}
+
+// PayloadLinkDeviceState is the state of a link device flow. MatchCode is only
+// sent in the answer to link_device_prove, the new device shows it.
+type PayloadLinkDeviceState struct {
+	State           string    `json:"state"`
+	Final           bool      `json:"final"`
+	Approver        string    `json:"approver,omitempty"`
+	TransactionHash string    `json:"transactionHash,omitempty"`
+	Reason          string    `json:"reason,omitempty"`
+	MatchCode       string    `json:"matchCode,omitempty"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+func CreateLinkDeviceStateMessage(sessionId string, state PayloadLinkDeviceState) *WebsocketMessage {
+	return &WebsocketMessage{
+		MessageType: MessageTypeLinkDeviceState,
+		SessionId:   sessionId,
+		Payload:     state,
 	}
+}
+
+func (wm *WebsocketMessage) ParseLinkDeviceState() (*PayloadLinkDeviceState, error) {
+	return parsePayload[PayloadLinkDeviceState](wm)
+}
 
-	return payload, nil
+// PayloadE2EHandshake carries the wallet's public key and its MAC with the
+// secret of the QR code, see cryptox.E2EHandshakeMAC
+type PayloadE2EHandshake struct {
+	PublicKey string `json:"publicKey"`
+	Mac       string `json:"mac"`
 }
 
-func getQRCodeContent(uri string, sessionId string, clientId string, sessionType string, chainId string) string {
-	return fmt.Sprintf("%s/%s?sid=%s&cid=%s&flow=%s&chainId=%s", uri, "api/v1/auth/session", sessionId, clientId, sessionType, chainId)
+func CreateE2EHandshake(sessionId string, publicKey string, mac string) *WebsocketMessage {
+	return newMessage(MessageTypeE2EHandshake, sessionId, PayloadE2EHandshake{PublicKey: publicKey, Mac: mac})
+}
+
+func (p *PayloadE2EHandshake) validate() error {
+	v := slyerrors.NewValidation("400").
+		ValidateNotEmpty("mac", p.Mac)
+	if _, err := cryptox.ParseE2EPublicKey(p.PublicKey); err != nil {
+		v.Add("publicKey", slyerrors.ValidationCodeCannotValidate, err.Error())
+	}
+	return v.Error()
+}
+
+func (wm *WebsocketMessage) ParseE2EHandshake() (*PayloadE2EHandshake, error) {
+	return parsePayload[PayloadE2EHandshake](wm)
+}
+
+// PayloadSealed is a payload relayed between dApp and wallet. Nonce and
+// Ciphertext are base64url encoded, the ciphertext is sealed with the shared
+// key and E2EAssociatedData of the sender.
+type PayloadSealed struct {
+	From       string `json:"from"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+func CreateSealedMessage(sessionId string, sealed PayloadSealed) *WebsocketMessage {
+	return newMessage(MessageTypeE2EMessage, sessionId, sealed)
+}
+
+func (p *PayloadSealed) validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateInList("from", p.From, sessionParties).
+		ValidateNotEmpty("nonce", p.Nonce).
+		ValidateNotEmpty("ciphertext", p.Ciphertext).
+		ValidateMaxLength("ciphertext", p.Ciphertext, e2eMaxCiphertextLength).
+		Error()
+}
+
+func (wm *WebsocketMessage) ParseSealed() (*PayloadSealed, error) {
+	return parsePayload[PayloadSealed](wm)
+}
+
+type PayloadE2EMessageAccepted struct {
+	EventId int `json:"eventId"`
+}
+
+// getQRCodeContent keeps the plain parameters for wallets that do not verify
+// the signed handoff token t yet
+func getQRCodeContent(uri string, sessionId string, clientId string, sessionType string, chainId string, e2ePublicKey string, handoffToken string) string {
+	content := fmt.Sprintf("%s/%s?sid=%s&cid=%s&flow=%s&chainId=%s", uri, "api/v1/auth/session", sessionId, clientId, sessionType, chainId)
+	if e2ePublicKey != "" {
+		content += "&pk=" + e2ePublicKey
+	}
+	return content + "&t=" + handoffToken
+}
+
+func getDeepLink(universalLink string, handoffToken string) string {
+	if universalLink == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s?t=%s", universalLink, handoffToken)
 }
@@ -0,0 +1,178 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"yip/src/slyerrors"
+)
+
+const (
+	// ProtocolVersion1 is the original protocol. Its messages carry no version,
+	// id or replyTo.
+	ProtocolVersion1 = 1
+	// ProtocolVersion2 adds the message id and replyTo to the envelope, the
+	// sign request and link device messages and the e2e channel.
+	ProtocolVersion2 = 2
+
+	ProtocolVersionMin    = ProtocolVersion1
+	ProtocolVersionLatest = ProtocolVersion2
+)
+
+// messageSpec describes a message type: the protocol version it was introduced
+// with and how its payload is decoded and validated
+type messageSpec struct {
+	minVersion int
+	decode     func(raw interface{}) (interface{}, error)
+}
+
+// payloadSpec creates the spec of a message with payload T. validate may be nil.
+func payloadSpec[T any](minVersion int, validate func(p *T) error) messageSpec {
+	return messageSpec{
+		minVersion: minVersion,
+		decode: func(raw interface{}) (interface{}, error) {
+			payload := new(T)
+
+			switch p := raw.(type) {
+			case *T:
+				payload = p
+			case T:
+				*payload = p
+			default:
+				b, err := json.Marshal(raw)
+				if err != nil {
+					return nil, fmt.Errorf("payload is not according to message type")
+				}
+				if err = json.Unmarshal(b, payload); err != nil {
+					return nil, fmt.Errorf("payload is not according to message type")
+				}
+			}
+
+			if validate != nil {
+				if err := validate(payload); err != nil {
+					return nil, err
+				}
+			}
+			return payload, nil
+		},
+	}
+}
+
+var messageRegistry = map[string]messageSpec{
+	MessageTypeCreateSessionRequest:   payloadSpec[PayloadCreateSessionRequest](ProtocolVersion1, (*PayloadCreateSessionRequest).validate),
+	MessageTypeSessionCreatedResponse: payloadSpec[PayloadSessionCreatedResponse](ProtocolVersion1, nil),
+	MessageTypeSessionError:           payloadSpec[PayloadSessionError](ProtocolVersion1, nil),
+	MessageTypeSignatureRequest:       payloadSpec[PayloadChallenge](ProtocolVersion1, nil),
+	MessageTypeSubmitSignature:        payloadSpec[PayloadSignatureResponse](ProtocolVersion1, validateSignatureResponse),
+	MessageTypeVerificationResponse:   payloadSpec[PayloadVerificationResponse](ProtocolVersion1, nil),
+	MessageTypeAccountsRequest:        payloadSpec[PayloadAccountsRequest](ProtocolVersion1, nil),
+	MessageTypeConnectWithAccount:     payloadSpec[PayloadAccountsResponse](ProtocolVersion1, (*PayloadAccountsResponse).validate),
+	MessageTypePingToken:              payloadSpec[PayloadPingTokenRequest](ProtocolVersion1, nil),
+	MessageTypePingTokenResponse:      payloadSpec[PayloadPingTokenResponse](ProtocolVersion1, nil),
+	MessageTypeCloseSession:           payloadSpec[PayloadSessionClose](ProtocolVersion1, nil),
+	MessageTypeCloseSessionResponse:   payloadSpec[PayloadSessionClosed](ProtocolVersion1, nil),
+	MessageTypeSessionState:           payloadSpec[PayloadSessionState](ProtocolVersion1, nil),
+	MessageTypeFetchSignRequest:       payloadSpec[PayloadFetchSignRequest](ProtocolVersion2, (*PayloadFetchSignRequest).validate),
+	MessageTypeSignRequest:            payloadSpec[PayloadSignRequest](ProtocolVersion2, nil),
+	MessageTypeSubmitSignRequest:      payloadSpec[PayloadSubmitSignRequest](ProtocolVersion2, (*PayloadSubmitSignRequest).validate),
+	MessageTypeRejectSignRequest:      payloadSpec[PayloadRejectSignRequest](ProtocolVersion2, nil),
+	MessageTypeSignRequestStatus:      payloadSpec[PayloadStatusRequest](ProtocolVersion2, nil),
+	MessageTypeSignRequestState:       payloadSpec[PayloadSignRequestState](ProtocolVersion2, nil),
+	MessageTypeFetchLinkDevice:        payloadSpec[PayloadFetchLinkDevice](ProtocolVersion2, nil),
+	MessageTypeLinkDevice:             payloadSpec[PayloadLinkDevice](ProtocolVersion2, nil),
+	MessageTypeProveLinkDevice:        payloadSpec[PayloadProveLinkDevice](ProtocolVersion2, (*PayloadProveLinkDevice).validate),
+	MessageTypeApproveLinkDevice:      payloadSpec[PayloadApproveLinkDevice](ProtocolVersion2, (*PayloadApproveLinkDevice).validate),
+	MessageTypeLinkDeviceStatus:       payloadSpec[PayloadStatusRequest](ProtocolVersion2, nil),
+	MessageTypeLinkDeviceState:        payloadSpec[PayloadLinkDeviceState](ProtocolVersion2, nil),
+	MessageTypeE2EHandshake:           payloadSpec[PayloadE2EHandshake](ProtocolVersion2, (*PayloadE2EHandshake).validate),
+	MessageTypeE2EMessage:             payloadSpec[PayloadSealed](ProtocolVersion2, (*PayloadSealed).validate),
+	MessageTypeE2EMessageAccepted:     payloadSpec[PayloadE2EMessageAccepted](ProtocolVersion2, nil),
+	MessageTypeSessionRejected:        payloadSpec[PayloadSessionEnd](ProtocolVersion2, nil),
+	MessageTypeSessionCancelled:       payloadSpec[PayloadSessionEnd](ProtocolVersion2, nil),
+	MessageTypeNumberMatch:            payloadSpec[PayloadNumberMatch](ProtocolVersion2, nil),
+	MessageTypeNumberMatchResponse:    payloadSpec[PayloadNumberMatchResponse](ProtocolVersion2, (*PayloadNumberMatchResponse).validate),
+}
+
+// negotiateVersion returns the version the server speaks with a client that
+// sent the given version. Messages without version are version 1, clients
+// newer than the server are answered with the latest version.
+func negotiateVersion(requested int) (int, error) {
+	switch {
+	case requested == 0:
+		return ProtocolVersion1, nil
+	case requested < ProtocolVersionMin:
+		return 0, slyerrors.BadRequest(slyerrors.ErrCodeSessionUnsupportedVersion, "protocol version %d is not supported", requested)
+	case requested > ProtocolVersionLatest:
+		return ProtocolVersionLatest, nil
+	default:
+		return requested, nil
+	}
+}
+
+// decodePayload replaces the raw payload of the message with its typed and
+// validated payload
+func (wm *WebsocketMessage) decodePayload(version int) error {
+	spec, ok := messageRegistry[wm.MessageType]
+	if !ok {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionMessageTypeUnknown, "type %s is not known", wm.MessageType)
+	}
+	if version < spec.minVersion {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionUnsupportedVersion, "type %s requires protocol version %d", wm.MessageType, spec.minVersion)
+	}
+
+	payload, err := spec.decode(wm.Payload)
+	if err != nil {
+		return err
+	}
+	wm.Payload = payload
+	return nil
+}
+
+// parsePayload returns the payload of the message as T, decoding and
+// validating it according to the registry if it is not typed yet
+func parsePayload[T any](wm *WebsocketMessage) (*T, error) {
+	if p, ok := wm.Payload.(*T); ok {
+		return p, nil
+	}
+
+	spec, ok := messageRegistry[wm.MessageType]
+	if !ok {
+		spec = payloadSpec[T](ProtocolVersionMin, nil)
+	}
+
+	payload, err := spec.decode(wm.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := payload.(*T)
+	if !ok {
+		return nil, fmt.Errorf("payload is not according to message type")
+	}
+	return p, nil
+}
+
+// stampReply sets the envelope fields of a reply for the negotiated version.
+// Version 1 clients get the envelope they know.
+func (wm *WebsocketMessage) stampReply(request *WebsocketMessage, version int) {
+	if version < ProtocolVersion2 {
+		wm.Version = 0
+		wm.Id = ""
+		wm.ReplyTo = ""
+		return
+	}
+	wm.Version = version
+	wm.Id = uuid.NewString()
+	wm.ReplyTo = request.Id
+}
+
+// newMessage creates a message with the envelope of the latest protocol version
+func newMessage(messageType string, sessionId string, payload interface{}) *WebsocketMessage {
+	return &WebsocketMessage{
+		Version:     ProtocolVersionLatest,
+		Id:          uuid.NewString(),
+		MessageType: messageType,
+		SessionId:   sessionId,
+		Payload:     payload,
+	}
+}
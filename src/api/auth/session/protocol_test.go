@@ -0,0 +1,75 @@
+package session
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yip/src/httpx"
+	"yip/src/slyerrors"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	v, err := negotiateVersion(0)
+	assert.NoError(t, err)
+	assert.Equal(t, ProtocolVersion1, v)
+
+	v, err = negotiateVersion(ProtocolVersion2)
+	assert.NoError(t, err)
+	assert.Equal(t, ProtocolVersion2, v)
+
+	v, err = negotiateVersion(ProtocolVersionLatest + 1)
+	assert.NoError(t, err)
+	assert.Equal(t, ProtocolVersionLatest, v)
+
+	_, err = negotiateVersion(-1)
+	assert.Equal(t, slyerrors.ErrCodeSessionUnsupportedVersion, slyerrors.Cause(err).Code)
+}
+
+func TestDecodePayload(t *testing.T) {
+	// a version 1 client sends the raw envelope without v and id
+	wm := &WebsocketMessage{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"messageType":"connect_with_account","sessionId":"s","payload":{"eoa":"0x0000000000000000000000000000000000000001","chainId":"31337"}}`), wm))
+	assert.NoError(t, wm.decodePayload(ProtocolVersion1))
+
+	p, err := wm.ParseAccountsResponse()
+	assert.NoError(t, err)
+	assert.Equal(t, "31337", p.ChainID)
+
+	wm = &WebsocketMessage{MessageType: MessageTypeConnectWithAccount, Payload: map[string]interface{}{"eoa": "no address"}}
+	assert.Error(t, wm.decodePayload(ProtocolVersion1))
+
+	wm = &WebsocketMessage{MessageType: "unknown"}
+	assert.Equal(t, slyerrors.ErrCodeSessionMessageTypeUnknown, slyerrors.Cause(wm.decodePayload(ProtocolVersion2)).Code)
+
+	// sign request messages need version 2
+	wm = CreateSubmitSignRequest("s", "0x01")
+	assert.Equal(t, slyerrors.ErrCodeSessionUnsupportedVersion, slyerrors.Cause(wm.decodePayload(ProtocolVersion1)).Code)
+	assert.NoError(t, wm.decodePayload(wm.Version))
+}
+
+func TestStampReply(t *testing.T) {
+	request := CreatePingRequest("s")
+	reply := CreatePingResponse("s", FlowStatePending, nil)
+
+	reply.stampReply(request, ProtocolVersion2)
+	assert.Equal(t, request.Id, reply.ReplyTo)
+	assert.NotEmpty(t, reply.Id)
+
+	reply.stampReply(request, ProtocolVersion1)
+	b, _ := json.Marshal(reply)
+	assert.NotContains(t, string(b), "replyTo")
+}
+
+func TestFlowErrorResponse(t *testing.T) {
+	r := flowErrorResponse(slyerrors.BadRequest(slyerrors.ErrCodeSessionEnded, "session rejected"), "sid")
+	assert.Equal(t, 200, r.StatusCode)
+
+	w := httptest.NewRecorder()
+	err := slyerrors.TooManyRequests(slyerrors.ErrCodePushRateLimited, "too many requests").WithRetryAfter(90 * time.Second)
+	httpx.RespondWithJSON(w, flowErrorResponse(err, "sid"))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,24 @@
+package session
+
+import (
+	"fmt"
+	"yip/src/config"
+	"yip/src/providers"
+)
+
+// PushDataLoginRequest is the type of the push sent for a pushed auth session
+const PushDataLoginRequest = "login_request"
+
+// loginPush carries the QR code content, the wallet app handles it as if the
+// user scanned it and signs in with SIWE
+func loginPush(cl *config.Client, created *PayloadSessionCreatedResponse) providers.PushNotification {
+	return providers.PushNotification{
+		Title: fmt.Sprintf("Sign in to %s", cl.Label),
+		Body:  fmt.Sprintf("Approve the sign in on %s", cl.Domain),
+		Data: map[string]string{
+			"type":          PushDataLoginRequest,
+			"sessionId":     created.SessionId,
+			"qrCodeContent": created.QRCodeContent,
+		},
+	}
+}
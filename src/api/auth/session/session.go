@@ -3,35 +3,129 @@ package session
 import (
 	"fmt"
 	"github.com/google/uuid"
+	"net/http"
+	"strconv"
 	"sync"
+	"time"
 	"yip/src/httpx"
 	"yip/src/slyerrors"
 )
 
 const (
-	SessionTypeAuth = "auth_session"
+	SessionTypeAuth        = "auth_session"
+	SessionTypeSignRequest = "sign_request_session"
+	SessionTypeLinkDevice  = "link_device_session"
 )
 
-var sessionTypes = []string{SessionTypeAuth}
+var sessionTypes = []string{SessionTypeAuth, SessionTypeSignRequest, SessionTypeLinkDevice}
+
+// the two parties of a session
+const (
+	PartyDApp   = "dapp"
+	PartyWallet = "wallet"
+)
+
+var sessionParties = []string{PartyDApp, PartyWallet}
+
+// defaultHandoffExpirationInSec is the lifetime of the signed QR code content
+// if jwt.handoff_expiration_in_sec is not configured
+const defaultHandoffExpirationInSec = 300
 
 type Session struct {
-	connector   *MConnector
-	SessionId   uuid.UUID
-	clients     map[*SessionClient]*SessionClient
-	mutex       *sync.Mutex
-	isClosed    bool
-	SessionType string
-	AuthFlow    *AuthFlow
+	connector       *MConnector
+	SessionId       uuid.UUID
+	clients         map[*SessionClient]*SessionClient
+	mutex           *sync.Mutex
+	isClosed        bool
+	SessionType     string
+	AuthFlow        *AuthFlow
+	SignRequestFlow *SignRequestFlow
+	LinkDeviceFlow  *LinkDeviceFlow
+	events          *eventLog
+	e2e             *e2eChannel
+	clientId        string
+	createdAt       time.Time
 }
 
-func newAuthSession(connector *MConnector) *Session {
+// newSessionClients returns the clients of a session created by the dApp of
+// clientId
+func newSessionClients(connector *MConnector, clientId string) map[*SessionClient]*SessionClient {
+	return map[*SessionClient]*SessionClient{newSessionClient(clientId, connector, nil): nil}
+}
+
+// newAuthSession and the other constructors set everything the admin
+// endpoints read before the session is registered, later changes are made
+// under the session lock
+func newAuthSession(connector *MConnector, clientId string) *Session {
 	s := &Session{
 		connector:   connector,
 		SessionId:   uuid.New(),
+		clients:     newSessionClients(connector, clientId),
+		clientId:    clientId,
+		createdAt:   time.Now(),
 		mutex:       &sync.Mutex{},
 		SessionType: SessionTypeAuth,
 		AuthFlow:    NewAuthFlow(),
+		events:      newEventLog(),
+	}
+	s.publishAuthState()
+
+	connector.registerNewSession(s)
+
+	return s
+}
+
+func newSignRequestSession(connector *MConnector, clientId string, flow *SignRequestFlow) *Session {
+	s := &Session{
+		connector:       connector,
+		SessionId:       uuid.New(),
+		clients:         newSessionClients(connector, clientId),
+		clientId:        clientId,
+		createdAt:       time.Now(),
+		mutex:           &sync.Mutex{},
+		SessionType:     SessionTypeSignRequest,
+		SignRequestFlow: flow,
+		events:          newEventLog(),
+	}
+	s.mutex.Lock()
+	s.publishSignRequestStateLocked()
+	s.mutex.Unlock()
+
+	// the flow expires on its own, so waiting dApps are notified
+	time.AfterFunc(time.Until(flow.expiresAt), func() {
+		_ = s.updateSignRequest(func(f *SignRequestFlow) error {
+			return f.expire()
+		})
+	})
+
+	connector.registerNewSession(s)
+
+	return s
+}
+
+func newLinkDeviceSession(connector *MConnector, clientId string, flow *LinkDeviceFlow) *Session {
+	s := &Session{
+		connector:      connector,
+		SessionId:      uuid.New(),
+		clients:        newSessionClients(connector, clientId),
+		clientId:       clientId,
+		createdAt:      time.Now(),
+		mutex:          &sync.Mutex{},
+		SessionType:    SessionTypeLinkDevice,
+		LinkDeviceFlow: flow,
+		events:         newEventLog(),
 	}
+	flow.message = linkDeviceMessage(s.SessionId.String(), flow.request)
+
+	s.mutex.Lock()
+	s.publishLinkDeviceStateLocked()
+	s.mutex.Unlock()
+
+	time.AfterFunc(time.Until(flow.expiresAt), func() {
+		_ = s.updateLinkDevice(func(f *LinkDeviceFlow) error {
+			return f.expire()
+		})
+	})
 
 	connector.registerNewSession(s)
 
@@ -42,8 +136,10 @@ func newSessionAsync(connector *MConnector, sessionType string) *Session {
 	s := &Session{
 		connector:   connector,
 		SessionId:   uuid.New(),
+		createdAt:   time.Now(),
 		mutex:       &sync.Mutex{},
 		SessionType: sessionType,
+		events:      newEventLog(),
 	}
 
 	connector.registerSession <- s
@@ -51,6 +147,10 @@ func newSessionAsync(connector *MConnector, sessionType string) *Session {
 	return s
 }
 
+func (s *Session) hasFlow() bool {
+	return s.AuthFlow != nil || s.SignRequestFlow != nil || s.LinkDeviceFlow != nil
+}
+
 func (s *Session) passMessage(client *SessionClient, msg *WebsocketMessage) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -65,6 +165,35 @@ func (s *Session) passMessage(client *SessionClient, msg *WebsocketMessage) erro
 	return nil
 }
 
+// notifyLocked pushes a message to the parties connected via websocket
+func (s *Session) notifyLocked(msg *WebsocketMessage) {
+	for c := range s.clients {
+		if c.CommunicationType == CommunicationTypeWebsocket {
+			c.sendMessage(msg)
+		}
+	}
+}
+
+// endAuthFlow rejects or cancels the auth flow and publishes the new state
+func (s *Session) endAuthFlow(state int, party string, reason string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.AuthFlow.end(state, party, reason); err != nil {
+		return err
+	}
+	s.publishAuthStateLocked()
+	return nil
+}
+
+// pingEndedResponse answers ping_token of an ended auth flow
+func (s *Session) pingEndedResponse() *WebsocketMessage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return CreatePingEndedResponse(s.SessionId.String(), s.AuthFlow)
+}
+
 func (s *Session) isConnected() bool {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -140,10 +269,69 @@ func (s *Session) CreateAccountsRequest(onlyPrimary bool) *WebsocketMessage {
 	}
 }
 
+// connectAccount moves the created auth flow to connected with the account of
+// the wallet and starts the number match if the client requires one, which is
+// returned. Checking the state and connecting happen under one lock, so only
+// one wallet can connect.
+func (s *Session) connectAccount(payload *PayloadAccountsResponse) (*PayloadNumberMatch, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.AuthFlow.isEnded() {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeSessionEnded, "session %s", s.AuthFlow.StateName())
+	}
+	if !s.AuthFlow.isCreatedState() {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeSessionDifferentMessageTypeExpected, "not expecting this message")
+	}
+
+	var match *PayloadNumberMatch
+	if s.AuthFlow.numberMatching != "" {
+		if err := s.AuthFlow.startNumberMatch(); err != nil {
+			return nil, slyerrors.Unexpected(slyerrors.ErrCodeUnknown, "cant create number match: %s", err)
+		}
+		p := s.AuthFlow.numberMatchPayload()
+		match = &p
+	}
+	s.AuthFlow.setPayload(payload)
+	s.publishAuthStateLocked()
+	return match, nil
+}
+
+// verifyAuthFlow marks the auth flow verified unless the session was closed
+// or the flow ended while the signature was checked
+func (s *Session) verifyAuthFlow(accountId string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isClosed {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionClosed, "session closed")
+	}
+	if s.AuthFlow.isEnded() {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionEnded, "session %s", s.AuthFlow.StateName())
+	}
+	s.AuthFlow.setVerified(accountId)
+	s.publishAuthStateLocked()
+	return nil
+}
+
+// claimToken reserves the token of the verified auth flow. Only the first
+// caller, via ping_token or the events stream, receives it.
+func (s *Session) claimToken() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.AuthFlow.tokenIssued {
+		return slyerrors.BadRequest(slyerrors.ErrCodeSessionTokenIssued, "token already issued")
+	}
+	s.AuthFlow.tokenIssued = true
+	return nil
+}
+
 func (s *Session) close() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	s.isClosed = true
+	s.publishAuthStateLocked()
 }
 
 func jsonErrorResponse(status int, code string, msg string, details string, sessionId string) *httpx.Response {
@@ -166,6 +354,22 @@ func createErrorResponse(code string, msg string, details string, sessionId stri
 	}
 }
 
+// flowErrorResponse maps an error returned by a flow transition. Session
+// errors are answered with 200, throttled requests with 429 and Retry-After
+// like httpx.MapServiceError does.
+func flowErrorResponse(err error, sessionId string) *httpx.Response {
+	e := slyerrors.Cause(err)
+	if e.Kind != slyerrors.KindTooManyRequests {
+		return jsonErrorResponse(200, e.Code, e.Details, "", sessionId)
+	}
+
+	response := jsonErrorResponse(http.StatusTooManyRequests, e.Code, e.Details, "", sessionId)
+	if e.RetryAfter > 0 {
+		response.AddHeader("Retry-After", strconv.Itoa(e.RetryAfter))
+	}
+	return response
+}
+
 func createJSONErrorResponse(status int, wm *WebsocketMessage) *httpx.Response {
 	return &httpx.Response{
 		Payload:    wm,
@@ -180,6 +384,9 @@ func (s *Session) verifyNotConnectedYet() *httpx.Response {
 	return nil
 }
 func (s *Session) verifyNotClosedYet() *httpx.Response {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	if s.isClosed {
 		return jsonErrorResponse(200, slyerrors.ErrCodeSessionClosed, "session closed", "", s.SessionId.String())
 	}
@@ -208,12 +415,13 @@ func (mc *MConnector) getSessionFromMessageAndVerifyStatus(wm *WebsocketMessage)
 		return nil, jsonErrorResponse(200, slyerrors.ErrCodeSessionNotFound, err.Error(), "", "")
 	}
 
-	errorResponse = session.verifyNotConnectedYet()
+	// a session force-closed by an admin accepts no message anymore
+	errorResponse = session.verifyNotClosedYet()
 	if errorResponse != nil {
 		return nil, errorResponse
 	}
 
-	errorResponse = session.verifyNotClosedYet()
+	errorResponse = session.verifyNotConnectedYet()
 	if errorResponse != nil {
 		return nil, errorResponse
 	}
@@ -10,19 +10,22 @@ import (
 	"yip/src/api/services/dto"
 	"yip/src/config"
 	"yip/src/httpx"
+	"yip/src/repositories/repo"
 )
 
 type Controller struct {
-	service     *services.SIWEService
-	userService *services.UserService
-	config      *config.Config
+	service      *services.SIWEService
+	userService  *services.UserService
+	auditService *services.SessionAuditService
+	config       *config.Config
 }
 
-func NewController(c *config.Config, service *services.SIWEService, userService *services.UserService) Controller {
+func NewController(c *config.Config, service *services.SIWEService, userService *services.UserService, auditService *services.SessionAuditService) Controller {
 	return Controller{
-		service:     service,
-		userService: userService,
-		config:      c,
+		service:      service,
+		userService:  userService,
+		auditService: auditService,
+		config:       c,
 	}
 }
 
@@ -172,6 +175,7 @@ func (a Controller) Submit(w http.ResponseWriter, r *http.Request) {
 		httpx.RespondWithJSON(w, httpx.MapServiceError(httpx.MapAuthError(err)))
 		return
 	}
+	a.auditService.RecordSignIn(r.Context(), ecdsa.AccountId, repo.SignInMethodSIWE, ecdsa.Address)
 
 	httpx.RespondWithJSON(w, httpx.OK(token))
 }
@@ -56,10 +56,11 @@ func (a Controller) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := a.tokenService.RefreshToken(data.RefreshToken)
+	result, err := a.tokenService.RefreshToken(r.Context(), data.RefreshToken)
 
 	if err != nil {
 		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
 	}
 
 	httpx.RespondWithJSON(w, httpx.OK(result))
@@ -85,7 +86,7 @@ func (a Controller) VerifyToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := a.tokenService.VerifyToken(data.Token)
+	token, err := a.tokenService.VerifyToken(r.Context(), data.Token)
 
 	if err != nil {
 		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
@@ -1,8 +1,14 @@
 package verifier
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 	"yip/src/config"
+	"yip/src/slyerrors"
 )
 
 func getVerifier(t *testing.T) Verifier {
@@ -29,3 +35,102 @@ func TestVerifierEnvironment(t *testing.T) {
 		})
 
 }
+
+func TestHandoff(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	v := Verifier{
+		config: config.JWTTokenConfig{Issuer: "issuer"},
+		certs:  Certs{VerifyKey: &key.PublicKey, SignKey: key},
+	}
+
+	token, err := v.SignHandoff(&HandoffClaims{SessionId: "sid", ClientId: "cid", Label: "Label"}, 60)
+	assert.NoError(t, err)
+
+	c, err := v.VerifyHandoff(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "sid", c.SessionId)
+	assert.Equal(t, "Label", c.Label)
+
+	_, err = ParseHandoff(token, &key.PublicKey, "other")
+	assert.Error(t, err)
+
+	expired, err := v.SignHandoff(&HandoffClaims{SessionId: "sid"}, -60)
+	assert.NoError(t, err)
+	_, err = v.VerifyHandoff(expired)
+	assert.Equal(t, slyerrors.ErrCodeTokenExpired, slyerrors.Cause(err).Code)
+
+	// a handoff token is no access or refresh token
+	_, err = v.VerifyToken(context.Background(), token)
+	assert.Equal(t, slyerrors.ErrCodeWrongTokenType, slyerrors.Cause(err).Code)
+	_, err = v.RefreshToken(token)
+	assert.Error(t, err)
+
+	access, err := v.CreateToken([]string{"aud"}, "account", "0x1", "0x2", "user")
+	assert.NoError(t, err)
+	_, err = v.VerifyToken(context.Background(), access.IdToken)
+	assert.NoError(t, err)
+	_, err = v.VerifyHandoff(access.IdToken)
+	assert.Error(t, err)
+}
+
+func TestMagicLink(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	v := Verifier{
+		config: config.JWTTokenConfig{Issuer: "issuer"},
+		certs:  Certs{VerifyKey: &key.PublicKey, SignKey: key},
+	}
+
+	token, err := v.SignMagicLink(&MagicLinkClaims{Nonce: "n", ECDSAPubKey: "0x1"}, 60)
+	assert.NoError(t, err)
+
+	c, err := v.VerifyMagicLink(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "n", c.Nonce)
+	assert.Equal(t, "0x1", c.ECDSAPubKey)
+
+	// a handoff token is no magic link
+	handoff, err := v.SignHandoff(&HandoffClaims{SessionId: "sid"}, 60)
+	assert.NoError(t, err)
+	_, err = v.VerifyMagicLink(handoff)
+	assert.Error(t, err)
+
+	expired, err := v.SignMagicLink(&MagicLinkClaims{Nonce: "n"}, -60)
+	assert.NoError(t, err)
+	_, err = v.VerifyMagicLink(expired)
+	assert.Equal(t, slyerrors.ErrCodeTokenExpired, slyerrors.Cause(err).Code)
+
+	// a magic link is no bearer token
+	_, err = v.VerifyToken(context.Background(), token)
+	assert.Equal(t, slyerrors.ErrCodeWrongTokenType, slyerrors.Cause(err).Code)
+	_, err = v.RefreshToken(token)
+	assert.Error(t, err)
+}
+
+func TestAuthTime(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	v := Verifier{
+		config: config.JWTTokenConfig{Issuer: "issuer", TokenExpirationInSec: 60, RefreshTokenExpirationInSec: 600},
+		certs:  Certs{VerifyKey: &key.PublicKey, SignKey: key},
+	}
+
+	signedIn := time.Now().Add(-time.Hour).Unix()
+	token, err := v.createToken([]string{"aud"}, "account", "0x1", "0x2", RoleBasic, nil, signedIn)
+	assert.NoError(t, err)
+
+	// a refreshed token keeps the time of the sign in
+	refreshed, err := v.RefreshToken(token.RefreshToken)
+	assert.NoError(t, err)
+	p, err := v.VerifyToken(context.Background(), refreshed.IdToken)
+	assert.NoError(t, err)
+	assert.Equal(t, signedIn, p.AuthTime.Unix())
+	assert.False(t, p.AuthenticatedWithin(5*time.Minute))
+
+	token, err = v.CreateToken([]string{"aud"}, "account", "0x1", "0x2", RoleBasic)
+	assert.NoError(t, err)
+	p, err = v.VerifyToken(context.Background(), token.IdToken)
+	assert.NoError(t, err)
+	assert.True(t, p.AuthenticatedWithin(5*time.Minute))
+}
@@ -0,0 +1,71 @@
+package verifier
+
+import (
+	"crypto/rsa"
+	"github.com/dgrijalva/jwt-go"
+	"time"
+	"yip/src/slyerrors"
+)
+
+// HandoffClaims are signed into the QR code and deep link of a session, so the
+// wallet can verify that the session was created by this YIP for the client
+// it shows to the user
+type HandoffClaims struct {
+	Type         string `json:"typ"`
+	SessionId    string `json:"sid"`
+	ClientId     string `json:"cid"`
+	SessionType  string `json:"flow"`
+	ChainId      string `json:"chainId"`
+	E2EPublicKey string `json:"pk,omitempty"`
+	Label        string `json:"label"`
+	Domain       string `json:"domain"`
+	jwt.StandardClaims
+}
+
+// SignHandoff signs the claims with the token signing key, the token expires
+// after expirationTimeInSec
+func (a Verifier) SignHandoff(c *HandoffClaims, expirationTimeInSec int64) (string, error) {
+	now := time.Now()
+	c.Type = TokenTypeHandoff
+	c.Issuer = a.config.Issuer
+	c.IssuedAt = now.Unix()
+	c.ExpiresAt = now.Add(time.Duration(expirationTimeInSec) * time.Second).Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, c)
+	signedToken, err := token.SignedString(a.certs.SignKey)
+	if err != nil {
+		return "", slyerrors.Unexpected("could not sign handoff", err.Error(), err)
+	}
+	return signedToken, nil
+}
+
+// VerifyHandoff verifies a handoff token signed by this YIP
+func (a Verifier) VerifyHandoff(tokenString string) (*HandoffClaims, error) {
+	return ParseHandoff(tokenString, a.certs.VerifyKey, a.config.Issuer)
+}
+
+// ParseHandoff verifies a handoff token with the public key of the JWKS of
+// issuer. Wallets use it to verify the QR code content.
+func ParseHandoff(tokenString string, key *rsa.PublicKey, issuer string) (*HandoffClaims, error) {
+	c := &HandoffClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, c, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, slyerrors.Unauthorized(slyerrors.ErrCodeMalformedToken, "unexpected signing method %s", token.Header["alg"])
+		}
+		return key, nil
+	})
+	if err != nil {
+		if vErr, ok := err.(*jwt.ValidationError); ok && vErr.Errors == jwt.ValidationErrorExpired {
+			return nil, slyerrors.Unauthorized(slyerrors.ErrCodeTokenExpired, vErr.Error())
+		}
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeUnknownTokenVerificationError, err.Error())
+	}
+
+	if c.Type != TokenTypeHandoff {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongTokenType, "not a handoff token")
+	}
+	if c.Issuer != issuer {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeUnknownTokenVerificationError, "handoff issued by %s", c.Issuer)
+	}
+	return c, nil
+}
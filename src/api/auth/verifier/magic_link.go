@@ -0,0 +1,60 @@
+package verifier
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"time"
+	"yip/src/slyerrors"
+)
+
+// MagicLinkClaims are signed into the magic link of a pin mail. The link is
+// bound to the device key the pin was requested for, the nonce identifies the
+// pin, so redeeming the link consumes the pin as well. The link names no
+// account, the account is resolved from the pin on redemption.
+type MagicLinkClaims struct {
+	Type        string `json:"typ"`
+	Nonce       string `json:"nonce"`
+	ECDSAPubKey string `json:"key"`
+	jwt.StandardClaims
+}
+
+// SignMagicLink signs the claims with the token signing key, the token expires
+// after expirationTimeInSec
+func (a Verifier) SignMagicLink(c *MagicLinkClaims, expirationTimeInSec int64) (string, error) {
+	now := time.Now()
+	c.Type = TokenTypeMagicLink
+	c.Issuer = a.config.Issuer
+	c.IssuedAt = now.Unix()
+	c.ExpiresAt = now.Add(time.Duration(expirationTimeInSec) * time.Second).Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, c)
+	signedToken, err := token.SignedString(a.certs.SignKey)
+	if err != nil {
+		return "", slyerrors.Unexpected("could not sign magic link", err.Error(), err)
+	}
+	return signedToken, nil
+}
+
+// VerifyMagicLink verifies a magic link token signed by this YIP
+func (a Verifier) VerifyMagicLink(tokenString string) (*MagicLinkClaims, error) {
+	c := &MagicLinkClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, c, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, slyerrors.Unauthorized(slyerrors.ErrCodeMalformedToken, "unexpected signing method %s", token.Header["alg"])
+		}
+		return a.certs.VerifyKey, nil
+	})
+	if err != nil {
+		if vErr, ok := err.(*jwt.ValidationError); ok && vErr.Errors == jwt.ValidationErrorExpired {
+			return nil, slyerrors.Unauthorized(slyerrors.ErrCodeTokenExpired, vErr.Error())
+		}
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeUnknownTokenVerificationError, err.Error())
+	}
+
+	if c.Type != TokenTypeMagicLink {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongTokenType, "not a magic link")
+	}
+	if c.Issuer != a.config.Issuer || c.Nonce == "" {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeUnknownTokenVerificationError, "not a magic link of this issuer")
+	}
+	return c, nil
+}
@@ -42,6 +42,8 @@ func (v TokenVerifierMiddleware) PrincipalCtx(next http.Handler) http.Handler {
 				httpx.RespondWithJSON(w, httpx.BadRequest(e.Details))
 			case slyerrors.KindUnauthorized:
 				httpx.RespondWithJSON(w, httpx.Unauthorized(e.Details))
+			default:
+				httpx.RespondWithJSON(w, httpx.MapServiceError(err))
 			}
 
 			return
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 )
 
 const (
@@ -20,12 +21,22 @@ type Principal struct {
 	Role             string
 	Scopes           []string
 	Audiences        []string
+	AMR              []string
+	IssuedAt         time.Time
+	// AuthTime is the time the principal signed in, refreshing the token
+	// doesn't change it
+	AuthTime time.Time
 }
 
 func (p Principal) IsAdmin() bool {
 	return p.Role == RoleAdmin
 }
 
+// AuthenticatedWithin is true if the principal signed in less than maxAge ago
+func (p Principal) AuthenticatedWithin(maxAge time.Duration) bool {
+	return time.Since(p.AuthTime) < maxAge
+}
+
 // ParseAuthorizationBearer expects a header Authorization to contain Bearer <token>
 // <token> is returned if parsing is correct
 // a BadRequest is returned if parsing is not possible
@@ -13,6 +13,15 @@ import (
 
 const (
 	BearerTokenType = "bearer"
+
+	// AMRHardwareKey is the authentication method reference (RFC 8176) of a WebAuthn passkey login
+	AMRHardwareKey = "hwk"
+
+	// TokenTypeAccess is the typ claim of access and refresh tokens, tokens
+	// signed with the same key for other purposes carry their own typ
+	TokenTypeAccess    = "access"
+	TokenTypeHandoff   = "handoff"
+	TokenTypeMagicLink = "magic_link"
 )
 
 // swagger:model Token
@@ -24,11 +33,15 @@ type Token struct {
 }
 
 type Claims struct {
+	Type   string   `json:"typ,omitempty"`
 	Scopes []string `json:"scopes"`
 	Aud    []string `json:"aud"`
 	Role   string   `json:"role"`
 	ECDSA  string   `json:"ecdsa"`
 	SLY    string   `json:"sly"`
+	AMR    []string `json:"amr,omitempty"`
+	// AuthTime is the time of the sign in, refreshed tokens keep it
+	AuthTime int64 `json:"auth_time,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -66,6 +79,10 @@ func (a Verifier) VerifyToken(ctx context.Context, tokenString string) (*Princip
 		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeUnknownTokenVerificationError, err.Error())
 	}
 
+	if !sc.isAccessToken() {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongTokenType, "not an access token")
+	}
+
 	return &Principal{
 		ID:               sc.Subject,
 		SLYWalletAddress: sc.SLY,
@@ -73,6 +90,9 @@ func (a Verifier) VerifyToken(ctx context.Context, tokenString string) (*Princip
 		Scopes:           sc.Scopes,
 		Role:             sc.Role,
 		Audiences:        sc.Aud,
+		AMR:              sc.AMR,
+		IssuedAt:         time.Unix(sc.IssuedAt, 0),
+		AuthTime:         time.Unix(sc.authTime(), 0),
 	}, nil
 }
 
@@ -81,6 +101,7 @@ func (a Verifier) NewClaims(audience []string, accountId string, ecdsaAddress st
 	expirationTime := time.Now().Add(time.Duration(expirationTimeInSec) * time.Second)
 
 	return &Claims{
+		Type:   TokenTypeAccess,
 		ECDSA:  ecdsaAddress,
 		SLY:    slyWalletAddress,
 		Scopes: []string{},
@@ -97,6 +118,21 @@ func (a Verifier) NewClaims(audience []string, accountId string, ecdsaAddress st
 	}
 }
 
+// authTime falls back to the issue time for tokens issued before the
+// auth_time claim was introduced
+func (c *Claims) authTime() int64 {
+	if c.AuthTime == 0 {
+		return c.IssuedAt
+	}
+	return c.AuthTime
+}
+
+// isAccessToken is false for tokens of other purposes signed with the token
+// key, tokens issued before the typ claim was introduced have none
+func (c *Claims) isAccessToken() bool {
+	return c.Type == "" || c.Type == TokenTypeAccess
+}
+
 func (a Verifier) parseClaimsToken(tokenString string, sc *Claims) (*jwt.Token, error) {
 	if a.certs.VerifyKey == nil {
 		return nil, slyerrors.Unexpected("cannot validate token", "secret key is empty", nil)
@@ -120,13 +156,28 @@ func (a Verifier) SignClaimsToken(c *Claims) (string, error) {
 // CreateToken returns a signed JWT token for the userId.
 // The token is anonymous and has no scope, so can be used only for endpoints usable by anonymous users
 func (a Verifier) CreateToken(audience []string, accountId string, ecdsaAddress string, slyWalletAddress string, role string) (*Token, error) {
-	token, err := a.SignClaimsToken(a.NewClaims(audience, accountId, ecdsaAddress, slyWalletAddress, role, a.config.TokenExpirationInSec))
+	return a.CreateTokenWithAMR(audience, accountId, ecdsaAddress, slyWalletAddress, role, nil)
+}
+
+// CreateTokenWithAMR is CreateToken with the authentication methods used to sign in recorded in the amr claim
+func (a Verifier) CreateTokenWithAMR(audience []string, accountId string, ecdsaAddress string, slyWalletAddress string, role string, amr []string) (*Token, error) {
+	return a.createToken(audience, accountId, ecdsaAddress, slyWalletAddress, role, amr, time.Now().Unix())
+}
+
+func (a Verifier) createToken(audience []string, accountId string, ecdsaAddress string, slyWalletAddress string, role string, amr []string, authTime int64) (*Token, error) {
+	claims := a.NewClaims(audience, accountId, ecdsaAddress, slyWalletAddress, role, a.config.TokenExpirationInSec)
+	claims.AMR = amr
+	claims.AuthTime = authTime
+	token, err := a.SignClaimsToken(claims)
 
 	if err != nil {
 		return nil, slyerrors.Unexpected("could not create token", "SignatureHex creation failed", err)
 	}
 
-	refreshToken, err := a.SignClaimsToken(a.NewClaims(audience, accountId, ecdsaAddress, slyWalletAddress, role, a.config.RefreshTokenExpirationInSec))
+	refreshClaims := a.NewClaims(audience, accountId, ecdsaAddress, slyWalletAddress, role, a.config.RefreshTokenExpirationInSec)
+	refreshClaims.AMR = amr
+	refreshClaims.AuthTime = authTime
+	refreshToken, err := a.SignClaimsToken(refreshClaims)
 
 	if err != nil {
 		return nil, slyerrors.Unexpected("could not create refresh token", "SignatureHex creation failed", err)
@@ -159,7 +210,11 @@ func (a Verifier) RefreshToken(refreshToken string) (*Token, error) {
 		return nil, slyerrors.Unauthorized("could not validate token", err.Error(), err)
 	}
 
-	token, err := a.CreateToken(claims.Aud, claims.Subject, claims.ECDSA, claims.SLY, claims.Role)
+	if !claims.isAccessToken() {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongTokenType, "not a refresh token")
+	}
+
+	token, err := a.createToken(claims.Aud, claims.Subject, claims.ECDSA, claims.SLY, claims.Role, claims.AMR, claims.authTime())
 	if err != nil {
 		return &Token{}, slyerrors.Unexpected("could not update token", "Refresh token creation failed", err)
 	}
@@ -0,0 +1,200 @@
+package webauthn
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services"
+	"yip/src/api/services/dto"
+	"yip/src/common"
+	"yip/src/httpx"
+)
+
+type Controller struct {
+	webAuthnService *services.WebAuthnService
+	tokenMiddleware verifier.TokenVerifierMiddleware
+}
+
+func NewController(service *services.WebAuthnService, tokenMiddleware *verifier.TokenVerifierMiddleware) Controller {
+	return Controller{
+		webAuthnService: service,
+		tokenMiddleware: *tokenMiddleware,
+	}
+}
+
+func (c Controller) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Post("/login/begin", c.BeginLogin)
+		r.Post("/login/finish", c.FinishLogin)
+
+		r.Group(func(r chi.Router) {
+			r.Use(c.tokenMiddleware.PrincipalCtx)
+			r.Post("/register/begin", c.BeginRegistration)
+			r.Post("/register/finish", c.FinishRegistration)
+			r.Get("/credentials", c.GetCredentials)
+			r.Delete("/credentials/{credentialId}", c.DeleteCredential)
+		})
+	}
+}
+
+// swagger:parameters beginWebAuthnLogin
+type beginWebAuthnLogin struct {
+	// in:body
+	Body dto.WebAuthnLoginBeginDTO
+}
+
+// swagger:route POST /auth/webauthn/login/begin WebAuthn beginWebAuthnLogin
+// Starts a passkey login, the options are passed to navigator.credentials.get()
+//
+// Responses:
+//
+//	200: WebAuthnRequestOptions
+func (a Controller) BeginLogin(w http.ResponseWriter, r *http.Request) {
+	data := &dto.WebAuthnLoginBeginDTO{}
+	if err := common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	options, err := a.webAuthnService.BeginLogin(r.Context(), data)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(options))
+}
+
+// swagger:parameters finishWebAuthnLogin
+type finishWebAuthnLogin struct {
+	// in:body
+	Body dto.WebAuthnLoginDTO
+}
+
+// swagger:route POST /auth/webauthn/login/finish WebAuthn finishWebAuthnLogin
+// Verifies the passkey assertion and responds with a token carrying amr ["hwk"]
+//
+// Responses:
+//
+//	200: Token
+func (a Controller) FinishLogin(w http.ResponseWriter, r *http.Request) {
+	data := &dto.WebAuthnLoginDTO{}
+	if err := common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	token, err := a.webAuthnService.FinishLogin(r.Context(), data)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(token))
+}
+
+// swagger:route POST /auth/webauthn/register/begin WebAuthn beginWebAuthnRegistration
+// Starts the registration of a passkey, the options are passed to navigator.credentials.create()
+//
+// Responses:
+//
+//	200: WebAuthnCreationOptions
+func (a Controller) BeginRegistration(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	options, err := a.webAuthnService.BeginRegistration(r.Context(), &principal)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(options))
+}
+
+// swagger:parameters finishWebAuthnRegistration
+type finishWebAuthnRegistration struct {
+	// in:body
+	Body dto.WebAuthnRegistrationDTO
+}
+
+// swagger:route POST /auth/webauthn/register/finish WebAuthn finishWebAuthnRegistration
+// Verifies the new passkey and stores it for the account
+//
+// Responses:
+//
+//	200: WebAuthnCredentialResponse
+func (a Controller) FinishRegistration(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	data := &dto.WebAuthnRegistrationDTO{}
+	if err = common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	credential, err := a.webAuthnService.FinishRegistration(r.Context(), &principal, data)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(credential))
+}
+
+// swagger:route GET /auth/webauthn/credentials WebAuthn getWebAuthnCredentials
+// Returns the passkeys of the account
+//
+// Responses:
+//
+//	200: []WebAuthnCredentialResponse
+func (a Controller) GetCredentials(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	credentials, err := a.webAuthnService.ListCredentials(r.Context(), &principal)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(credentials))
+}
+
+// swagger:route DELETE /auth/webauthn/credentials/{credentialId} WebAuthn deleteWebAuthnCredential
+// Removes a passkey of the account
+//
+// Responses:
+//
+//	204: noContent
+func (a Controller) DeleteCredential(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	credentialId, err := uuid.Parse(chi.URLParam(r, "credentialId"))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest("credentialId not uuid"))
+		return
+	}
+
+	if err = a.webAuthnService.DeleteCredential(r.Context(), &principal, credentialId); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.NoContent())
+}
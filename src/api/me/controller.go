@@ -0,0 +1,155 @@
+package me
+
+import (
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/middleware"
+	"yip/src/api/services"
+	"yip/src/api/services/dto"
+	"yip/src/common"
+	"yip/src/httpx"
+	"yip/src/repositories/repo"
+)
+
+type ProfileController struct {
+	profileService  *services.ProfileService
+	tokenMiddleware verifier.TokenVerifierMiddleware
+	meMiddleware    middleware.MeMiddleware[*repo.AccountModel]
+}
+
+func NewProfileController(
+	service *services.ProfileService,
+	tokenMiddleware *verifier.TokenVerifierMiddleware,
+	meMiddleware middleware.MeMiddleware[*repo.AccountModel],
+) ProfileController {
+	return ProfileController{
+		profileService:  service,
+		tokenMiddleware: *tokenMiddleware,
+		meMiddleware:    meMiddleware,
+	}
+}
+
+func (c ProfileController) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(c.tokenMiddleware.PrincipalCtx)
+			r.Use(c.meMiddleware.EntityContext)
+
+			r.Get("/", c.GetProfile)
+			r.Patch("/", c.PatchProfile)
+			r.Get("/devices", c.ListDevices)
+			r.Delete("/devices/{address}", c.RemoveDevice)
+			r.Get("/wallets", c.ListWallets)
+			r.Delete("/wallets/{address}", c.RemoveWallet)
+		})
+	}
+}
+
+// swagger:route GET /me Me getProfile
+// Returns the account of the token
+//
+// Responses:
+//
+//	200: AccountModel
+func (c ProfileController) GetProfile(w http.ResponseWriter, r *http.Request) {
+	httpx.RespondWithJSON(w, httpx.OK(c.meMiddleware.EntityFromCtx(r)))
+}
+
+// swagger:parameters patchProfile
+type patchProfile struct {
+	// in:body
+	Body dto.ProfilePatchDTO
+}
+
+// swagger:route PATCH /me Me patchProfile
+// Updates the first name, last name or phone of the account of the token
+//
+// Responses:
+//
+//	200: AccountModel
+func (c ProfileController) PatchProfile(w http.ResponseWriter, r *http.Request) {
+	data := &dto.ProfilePatchDTO{}
+	if err := common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	account, err := c.profileService.UpdateProfile(r.Context(), c.meMiddleware.EntityFromCtx(r), data)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(account))
+}
+
+// swagger:route GET /me/devices Me listDevices
+// Lists the devices (ecdsa keys) of the account of the token
+//
+// Responses:
+//
+//	200: []EcdsaModel
+func (c ProfileController) ListDevices(w http.ResponseWriter, r *http.Request) {
+	devices, err := c.profileService.ListDevices(r.Context(), c.meMiddleware.EntityFromCtx(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(devices))
+}
+
+// swagger:route DELETE /me/devices/{address} Me removeDevice
+// Removes a device of the account with its push tokens, the device of the token can't be removed
+//
+// Responses:
+//
+//	204: noContent
+func (c ProfileController) RemoveDevice(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	err = c.profileService.RemoveDevice(r.Context(), c.meMiddleware.EntityFromCtx(r), &principal, chi.URLParam(r, "address"))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.NoContent())
+}
+
+// swagger:route GET /me/wallets Me listWallets
+// Lists the SLYWallets of the account of the token
+//
+// Responses:
+//
+//	200: []SlyWalletModel
+func (c ProfileController) ListWallets(w http.ResponseWriter, r *http.Request) {
+	wallets, err := c.profileService.ListWallets(r.Context(), c.meMiddleware.EntityFromCtx(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(wallets))
+}
+
+// swagger:route DELETE /me/wallets/{address} Me removeWallet
+// Unlinks a SLYWallet from the account of the token, the wallet on chain is not touched
+//
+// Responses:
+//
+//	204: noContent
+func (c ProfileController) RemoveWallet(w http.ResponseWriter, r *http.Request) {
+	err := c.profileService.RemoveWallet(r.Context(), c.meMiddleware.EntityFromCtx(r), chi.URLParam(r, "address"))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.NoContent())
+}
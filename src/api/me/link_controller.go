@@ -0,0 +1,128 @@
+package me
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/middleware"
+	"yip/src/api/services"
+	"yip/src/api/services/dto"
+	"yip/src/common"
+	"yip/src/httpx"
+	"yip/src/repositories/repo"
+)
+
+type LinkController struct {
+	accountLinkService *services.AccountLinkService
+	tokenMiddleware    verifier.TokenVerifierMiddleware
+	meMiddleware       middleware.MeMiddleware[*repo.AccountModel]
+}
+
+func NewLinkController(
+	service *services.AccountLinkService,
+	tokenMiddleware *verifier.TokenVerifierMiddleware,
+	meMiddleware middleware.MeMiddleware[*repo.AccountModel],
+) LinkController {
+	return LinkController{
+		accountLinkService: service,
+		tokenMiddleware:    *tokenMiddleware,
+		meMiddleware:       meMiddleware,
+	}
+}
+
+func (c LinkController) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(c.tokenMiddleware.PrincipalCtx)
+			r.Use(c.meMiddleware.EntityContext)
+
+			r.Get("/links", c.ListLinks)
+			r.Post("/links", c.CreateLink)
+			r.Post("/links/confirm", c.ConfirmLink)
+			r.Delete("/links/{linkId}", c.CancelLink)
+		})
+	}
+}
+
+// swagger:route POST /me/links Me createAccountLink
+// Starts a link of the account of the token to another account, the other account confirms the returned code
+//
+// Responses:
+//
+//	200: AccountLinkResponse
+func (c LinkController) CreateLink(w http.ResponseWriter, r *http.Request) {
+	response, err := c.accountLinkService.CreateLink(r.Context(), c.meMiddleware.EntityFromCtx(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(response))
+}
+
+// swagger:parameters confirmAccountLink
+type confirmAccountLink struct {
+	// in:body
+	Body dto.AccountLinkConfirmDTO
+}
+
+// swagger:route POST /me/links/confirm Me confirmAccountLink
+// Confirms the link of another account with its code, the accounts can be merged by an admin then
+//
+// Responses:
+//
+//	200: AccountLinkModel
+func (c LinkController) ConfirmLink(w http.ResponseWriter, r *http.Request) {
+	data := &dto.AccountLinkConfirmDTO{}
+	if err := common.ReadAndValidate(data, r); err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	link, err := c.accountLinkService.ConfirmLink(r.Context(), c.meMiddleware.EntityFromCtx(r), data)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(link))
+}
+
+// swagger:route GET /me/links Me listAccountLinks
+// Lists the links of the account of the token, the latest first
+//
+// Responses:
+//
+//	200: []AccountLinkModel
+func (c LinkController) ListLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := c.accountLinkService.ListLinks(r.Context(), c.meMiddleware.EntityFromCtx(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(links))
+}
+
+// swagger:route DELETE /me/links/{linkId} Me cancelAccountLink
+// Cancels a pending or confirmed link of the account of the token
+//
+// Responses:
+//
+//	204: noContent
+func (c LinkController) CancelLink(w http.ResponseWriter, r *http.Request) {
+	linkId, err := uuid.Parse(chi.URLParam(r, "linkId"))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest("link id is no uuid"))
+		return
+	}
+
+	err = c.accountLinkService.CancelLink(r.Context(), c.meMiddleware.EntityFromCtx(r), linkId)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.NoContent())
+}
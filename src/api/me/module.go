@@ -0,0 +1,37 @@
+package me
+
+import (
+	"github.com/go-chi/chi/v5"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/middleware"
+	"yip/src/api/services"
+)
+
+const accountContextKey = "me.account"
+
+type Module struct {
+	ProfileController ProfileController
+	PrivacyController PrivacyController
+	LinkController    LinkController
+}
+
+func NewModule(
+	services *services.Services,
+	tokenMiddleware *verifier.TokenVerifierMiddleware,
+) Module {
+	meMiddleware := middleware.NewMeMiddleWare(services.ProfileService.GetAccount, accountContextKey)
+
+	return Module{
+		ProfileController: NewProfileController(&services.ProfileService, tokenMiddleware, meMiddleware),
+		PrivacyController: NewPrivacyController(&services.PrivacyService, tokenMiddleware, meMiddleware),
+		LinkController:    NewLinkController(&services.AccountLinkService, tokenMiddleware, meMiddleware),
+	}
+}
+
+func (a Module) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		a.ProfileController.Routes()(r)
+		a.PrivacyController.Routes()(r)
+		a.LinkController.Routes()(r)
+	}
+}
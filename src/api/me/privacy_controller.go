@@ -0,0 +1,81 @@
+package me
+
+import (
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/middleware"
+	"yip/src/api/services"
+	"yip/src/httpx"
+	"yip/src/repositories/repo"
+)
+
+type PrivacyController struct {
+	privacyService  *services.PrivacyService
+	tokenMiddleware verifier.TokenVerifierMiddleware
+	meMiddleware    middleware.MeMiddleware[*repo.AccountModel]
+}
+
+func NewPrivacyController(
+	service *services.PrivacyService,
+	tokenMiddleware *verifier.TokenVerifierMiddleware,
+	meMiddleware middleware.MeMiddleware[*repo.AccountModel],
+) PrivacyController {
+	return PrivacyController{
+		privacyService:  service,
+		tokenMiddleware: *tokenMiddleware,
+		meMiddleware:    meMiddleware,
+	}
+}
+
+func (c PrivacyController) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(c.tokenMiddleware.PrincipalCtx)
+			r.Use(c.meMiddleware.EntityContext)
+
+			r.Get("/export", c.Export)
+			r.Delete("/", c.DeleteAccount)
+		})
+	}
+}
+
+// swagger:route GET /me/export Me exportAccount
+// Exports the data stored about the account of the token as JSON
+//
+// Responses:
+//
+//	200: AccountExport
+func (c PrivacyController) Export(w http.ResponseWriter, r *http.Request) {
+	export, err := c.privacyService.Export(r.Context(), c.meMiddleware.EntityFromCtx(r))
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="account.json"`)
+	httpx.RespondWithJSON(w, httpx.OK(export))
+}
+
+// swagger:route DELETE /me Me deleteAccount
+// Deletes the account of the token. It is anonymized and its tokens are revoked right away, it is purged after the grace period.
+// The token must be of a recent sign in.
+//
+// Responses:
+//
+//	200: AccountDeletionModel
+func (c PrivacyController) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	principal, err := verifier.GetPrincipal(r.Context())
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.BadRequest(err.Error()))
+		return
+	}
+
+	deletion, err := c.privacyService.DeleteAccount(r.Context(), c.meMiddleware.EntityFromCtx(r), &principal)
+	if err != nil {
+		httpx.RespondWithJSON(w, httpx.MapServiceError(err))
+		return
+	}
+
+	httpx.RespondWithJSON(w, httpx.OK(deletion))
+}
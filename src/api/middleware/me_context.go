@@ -30,12 +30,14 @@ func (a MeMiddleware[T]) EntityContext(next http.Handler) http.Handler {
 		p, err := verifier.GetPrincipal(r.Context())
 		if err != nil {
 			httpx.RespondWithError(w, 401, "unauthenticated", err.Error())
+			return
 		}
 
 		entity, err := a.fetcher(r.Context(), p.ID)
 		if err != nil {
 			if slyerrors.IsNoRowsError(err) {
 				httpx.RespondWithJSON(w, httpx.NotFound(fmt.Sprintf("could not find profile slywallet: for context %s", a.keyContext)))
+				return
 			}
 			httpx.RespondWithJSON(w, httpx.MapServiceError(err))
 			return
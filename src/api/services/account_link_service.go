@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services/dto"
+	"yip/src/config"
+	"yip/src/cryptox"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+
+	"github.com/google/uuid"
+)
+
+const defaultAccountLinkExpirationInMin = 30
+
+// AccountLinkStore keeps the account links, it is implemented by
+// repo.AccountLinkRepository
+type AccountLinkStore interface {
+	Create(ctx context.Context, link *repo.AccountLinkModel) (*repo.AccountLinkModel, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*repo.AccountLinkModel, error)
+	GetByCodeHash(ctx context.Context, codeHash string) (*repo.AccountLinkModel, error)
+	Confirm(ctx context.Context, id uuid.UUID, linkedAccountId uuid.UUID, now time.Time) (*repo.AccountLinkModel, error)
+	Cancel(ctx context.Context, id uuid.UUID) error
+	ListByAccount(ctx context.Context, accountId uuid.UUID) ([]repo.AccountLinkModel, error)
+	ListByStatus(ctx context.Context, status string) ([]repo.AccountLinkModel, error)
+	Merge(ctx context.Context, link *repo.AccountLinkModel, targetId uuid.UUID, sourceId uuid.UUID, mergedBy string, now time.Time) (*repo.AccountModel, error)
+}
+
+// AccountLinkService links two accounts of one person, e.g. one signed in
+// with SIWE and one with an email pin. One account starts a link and gets a
+// code, the other one confirms it while signed in, so both sides are proven.
+// Admins merge confirmed links into one account.
+type AccountLinkService struct {
+	links      AccountLinkStore
+	hashSecret []byte
+	expiration time.Duration
+}
+
+func NewAccountLinkService(config *config.Config, repos *repo.Repositories) AccountLinkService {
+	expirationInMin := config.AccountLink.ExpirationInMin
+	if expirationInMin <= 0 {
+		expirationInMin = defaultAccountLinkExpirationInMin
+	}
+
+	return AccountLinkService{
+		links:      repos.AccountLinkRepo,
+		hashSecret: []byte(config.Pin.HashSecret),
+		expiration: time.Duration(expirationInMin) * time.Minute,
+	}
+}
+
+// CreateLink starts a link of the account, the code is confirmed by the other account
+func (s AccountLinkService) CreateLink(ctx context.Context, account *repo.AccountModel) (*dto.AccountLinkResponse, error) {
+	code, err := cryptox.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := s.links.Create(ctx, &repo.AccountLinkModel{
+		AccountID: account.ID,
+		CodeHash:  cryptox.HashCode(s.hashSecret, code),
+		ExpiresAt: time.Now().Add(s.expiration),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.AccountLinkResponse{
+		ID:        link.ID,
+		Code:      code,
+		ExpiresAt: link.ExpiresAt,
+	}, nil
+}
+
+// ConfirmLink links the account to the one that started the link of the code
+func (s AccountLinkService) ConfirmLink(ctx context.Context, account *repo.AccountModel, data *dto.AccountLinkConfirmDTO) (*repo.AccountLinkModel, error) {
+	link, err := s.links.GetByCodeHash(ctx, cryptox.HashCode(s.hashSecret, data.Code))
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeAccountLinkNotFound, "no pending account link")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if link.AccountID == account.ID {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeAccountLinkSelf, "an account can't be linked to itself")
+	}
+
+	link, err = s.links.Confirm(ctx, link.ID, account.ID, time.Now())
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeAccountLinkNotFound, "no pending account link")
+	}
+	return link, err
+}
+
+// ListLinks returns the links of the account, the latest first
+func (s AccountLinkService) ListLinks(ctx context.Context, account *repo.AccountModel) ([]repo.AccountLinkModel, error) {
+	return s.links.ListByAccount(ctx, account.ID)
+}
+
+// CancelLink cancels a pending or confirmed link of the account
+func (s AccountLinkService) CancelLink(ctx context.Context, account *repo.AccountModel, linkId uuid.UUID) error {
+	link, err := s.links.GetByID(ctx, linkId)
+	if err != nil && !errors.Is(err, repo.DBItemNotFound) {
+		return err
+	}
+	if link == nil || !isLinkParty(link, account.ID) {
+		return slyerrors.NotFound(slyerrors.ErrCodeAccountLinkNotFound, "account link not found")
+	}
+
+	err = s.links.Cancel(ctx, link.ID)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return slyerrors.NotFound(slyerrors.ErrCodeAccountLinkNotFound, "account link is merged or cancelled already")
+	}
+	return err
+}
+
+// ListLinksByStatus returns the links with a status for admins, e.g. the confirmed ones to merge
+func (s AccountLinkService) ListLinksByStatus(ctx context.Context, status string) ([]repo.AccountLinkModel, error) {
+	return s.links.ListByStatus(ctx, status)
+}
+
+// Merge moves the keys, wallets and invitation code of the other account of
+// a confirmed link into the target account. The other account is anonymized,
+// its tokens are revoked and it is purged with the next purge.
+func (s AccountLinkService) Merge(ctx context.Context, admin *verifier.Principal, linkId uuid.UUID, data *dto.AccountMergeDTO) (*repo.AccountModel, error) {
+	link, err := s.links.GetByID(ctx, linkId)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeAccountLinkNotFound, "account link not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if link.Status != repo.AccountLinkConfirmed || link.LinkedAccountID == nil {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeAccountLinkNotConfirmed, "account link is %s", link.Status)
+	}
+
+	targetId := uuid.MustParse(data.TargetAccountID)
+	if !isLinkParty(link, targetId) {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeMergeTargetNotLinked, "target is not an account of the link")
+	}
+	sourceId := link.AccountID
+	if sourceId == targetId {
+		sourceId = *link.LinkedAccountID
+	}
+
+	account, err := s.links.Merge(ctx, link, targetId, sourceId, admin.ID, time.Now())
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeAccountLinkNotConfirmed, "account link is not confirmed anymore")
+	}
+	if errors.Is(err, repo.ErrAccountDeleted) {
+		return nil, slyerrors.Conflict(slyerrors.ErrCodeAccountDeleted, "an account of the link is deleted")
+	}
+	return account, err
+}
+
+func isLinkParty(link *repo.AccountLinkModel, accountId uuid.UUID) bool {
+	return link.AccountID == accountId || (link.LinkedAccountID != nil && *link.LinkedAccountID == accountId)
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services/dto"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+
+	"github.com/google/uuid"
+)
+
+// accountLinkPool is an in-memory AccountLinkStore with the status
+// transitions of repo.AccountLinkRepository
+type accountLinkPool struct {
+	links map[uuid.UUID]repo.AccountLinkModel
+	mutex sync.Mutex
+}
+
+func newAccountLinkPool() *accountLinkPool {
+	return &accountLinkPool{links: make(map[uuid.UUID]repo.AccountLinkModel)}
+}
+
+func (p *accountLinkPool) Create(_ context.Context, link *repo.AccountLinkModel) (*repo.AccountLinkModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	l := *link
+	l.ID = uuid.New()
+	l.Status = repo.AccountLinkPending
+	l.CreatedAt = time.Now()
+	p.links[l.ID] = l
+	return &l, nil
+}
+
+func (p *accountLinkPool) GetByID(_ context.Context, id uuid.UUID) (*repo.AccountLinkModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	l, ok := p.links[id]
+	if !ok {
+		return nil, repo.DBItemNotFound
+	}
+	return &l, nil
+}
+
+func (p *accountLinkPool) GetByCodeHash(_ context.Context, codeHash string) (*repo.AccountLinkModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, l := range p.links {
+		if l.CodeHash == codeHash {
+			return &l, nil
+		}
+	}
+	return nil, repo.DBItemNotFound
+}
+
+func (p *accountLinkPool) Confirm(_ context.Context, id uuid.UUID, linkedAccountId uuid.UUID, now time.Time) (*repo.AccountLinkModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	l, ok := p.links[id]
+	if !ok || l.Status != repo.AccountLinkPending || !l.ExpiresAt.After(now) {
+		return nil, repo.DBItemNotFound
+	}
+	l.LinkedAccountID = &linkedAccountId
+	l.Status = repo.AccountLinkConfirmed
+	l.ConfirmedAt = &now
+	p.links[id] = l
+	return &l, nil
+}
+
+func (p *accountLinkPool) Cancel(_ context.Context, id uuid.UUID) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	l, ok := p.links[id]
+	if !ok || (l.Status != repo.AccountLinkPending && l.Status != repo.AccountLinkConfirmed) {
+		return repo.DBItemNotFound
+	}
+	l.Status = repo.AccountLinkCancelled
+	p.links[id] = l
+	return nil
+}
+
+func (p *accountLinkPool) ListByAccount(_ context.Context, accountId uuid.UUID) ([]repo.AccountLinkModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	links := make([]repo.AccountLinkModel, 0)
+	for _, l := range p.links {
+		if isLinkParty(&l, accountId) {
+			links = append(links, l)
+		}
+	}
+	return links, nil
+}
+
+func (p *accountLinkPool) ListByStatus(_ context.Context, status string) ([]repo.AccountLinkModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	links := make([]repo.AccountLinkModel, 0)
+	for _, l := range p.links {
+		if l.Status == status {
+			links = append(links, l)
+		}
+	}
+	return links, nil
+}
+
+func (p *accountLinkPool) Merge(_ context.Context, link *repo.AccountLinkModel, targetId uuid.UUID, _ uuid.UUID, mergedBy string, now time.Time) (*repo.AccountModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	l, ok := p.links[link.ID]
+	if !ok || l.Status != repo.AccountLinkConfirmed {
+		return nil, repo.DBItemNotFound
+	}
+	l.Status = repo.AccountLinkMerged
+	l.MergedInto = &targetId
+	l.MergedBy = mergedBy
+	l.MergedAt = &now
+	p.links[link.ID] = l
+	return &repo.AccountModel{ID: targetId}, nil
+}
+
+func testAccountLinkService() AccountLinkService {
+	return AccountLinkService{
+		links:      newAccountLinkPool(),
+		hashSecret: []byte("secret"),
+		expiration: time.Minute,
+	}
+}
+
+func TestAccountLinkConfirm(t *testing.T) {
+	s := testAccountLinkService()
+	ctx := context.Background()
+	first, second := &repo.AccountModel{ID: uuid.New()}, &repo.AccountModel{ID: uuid.New()}
+
+	created, err := s.CreateLink(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the account that started the link can't confirm it
+	_, err = s.ConfirmLink(ctx, first, &dto.AccountLinkConfirmDTO{Code: created.Code})
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeAccountLinkSelf {
+		t.Errorf("expected self link, got %v", err)
+	}
+	_, err = s.ConfirmLink(ctx, second, &dto.AccountLinkConfirmDTO{Code: "wrong"})
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeAccountLinkNotFound {
+		t.Errorf("expected unknown code, got %v", err)
+	}
+
+	link, err := s.ConfirmLink(ctx, second, &dto.AccountLinkConfirmDTO{Code: created.Code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Status != repo.AccountLinkConfirmed || *link.LinkedAccountID != second.ID {
+		t.Errorf("link not confirmed: %+v", link)
+	}
+
+	// a code confirms once
+	third := &repo.AccountModel{ID: uuid.New()}
+	_, err = s.ConfirmLink(ctx, third, &dto.AccountLinkConfirmDTO{Code: created.Code})
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeAccountLinkNotFound {
+		t.Errorf("expected confirmed link, got %v", err)
+	}
+}
+
+func TestAccountLinkExpired(t *testing.T) {
+	s := testAccountLinkService()
+	s.expiration = -time.Second
+	ctx := context.Background()
+
+	created, err := s.CreateLink(ctx, &repo.AccountModel{ID: uuid.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.ConfirmLink(ctx, &repo.AccountModel{ID: uuid.New()}, &dto.AccountLinkConfirmDTO{Code: created.Code})
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeAccountLinkNotFound {
+		t.Errorf("expected expired link, got %v", err)
+	}
+}
+
+func TestAccountLinkCancel(t *testing.T) {
+	s := testAccountLinkService()
+	ctx := context.Background()
+	first, second := &repo.AccountModel{ID: uuid.New()}, &repo.AccountModel{ID: uuid.New()}
+
+	created, err := s.CreateLink(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.ConfirmLink(ctx, second, &dto.AccountLinkConfirmDTO{Code: created.Code}); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the accounts of the link cancel it
+	err = s.CancelLink(ctx, &repo.AccountModel{ID: uuid.New()}, created.ID)
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeAccountLinkNotFound {
+		t.Errorf("expected foreign link, got %v", err)
+	}
+	if err = s.CancelLink(ctx, second, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.CancelLink(ctx, first, created.ID); slyerrors.Cause(err).Code != slyerrors.ErrCodeAccountLinkNotFound {
+		t.Errorf("expected cancelled link, got %v", err)
+	}
+
+	// a cancelled link is neither confirmed nor merged
+	_, err = s.ConfirmLink(ctx, &repo.AccountModel{ID: uuid.New()}, &dto.AccountLinkConfirmDTO{Code: created.Code})
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeAccountLinkNotFound {
+		t.Errorf("expected cancelled link, got %v", err)
+	}
+	admin := &verifier.Principal{ID: uuid.NewString(), Role: verifier.RoleAdmin}
+	_, err = s.Merge(ctx, admin, created.ID, &dto.AccountMergeDTO{TargetAccountID: first.ID.String()})
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeAccountLinkNotConfirmed {
+		t.Errorf("expected unconfirmed link, got %v", err)
+	}
+}
+
+func TestAccountLinkMerge(t *testing.T) {
+	s := testAccountLinkService()
+	ctx := context.Background()
+	first, second := &repo.AccountModel{ID: uuid.New()}, &repo.AccountModel{ID: uuid.New()}
+	admin := &verifier.Principal{ID: uuid.NewString(), Role: verifier.RoleAdmin}
+
+	created, err := s.CreateLink(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a pending link is not merged
+	_, err = s.Merge(ctx, admin, created.ID, &dto.AccountMergeDTO{TargetAccountID: first.ID.String()})
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeAccountLinkNotConfirmed {
+		t.Errorf("expected pending link, got %v", err)
+	}
+
+	if _, err = s.ConfirmLink(ctx, second, &dto.AccountLinkConfirmDTO{Code: created.Code}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Merge(ctx, admin, created.ID, &dto.AccountMergeDTO{TargetAccountID: uuid.NewString()})
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeMergeTargetNotLinked {
+		t.Errorf("expected foreign target, got %v", err)
+	}
+
+	account, err := s.Merge(ctx, admin, created.ID, &dto.AccountMergeDTO{TargetAccountID: second.ID.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.ID != second.ID {
+		t.Errorf("merged into %s, expected %s", account.ID, second.ID)
+	}
+
+	// a merged link is final
+	if err = s.CancelLink(ctx, first, created.ID); slyerrors.Cause(err).Code != slyerrors.ErrCodeAccountLinkNotFound {
+		t.Errorf("expected merged link, got %v", err)
+	}
+}
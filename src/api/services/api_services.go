@@ -15,19 +15,38 @@ type Services struct {
 	SLYWalletService      *SLYWalletService
 	Repos                 *repo.Repositories
 	InvitationCodeService InvitationCodeService
+	SessionAuditService   SessionAuditService
+	PushService           PushService
+	EmailChangeService    EmailChangeService
+	ProfileService        ProfileService
+	PrivacyService        PrivacyService
+	AccountLinkService    AccountLinkService
+	PasswordService       PasswordService
+	MFAService            MFAService
+	WebAuthnService       WebAuthnService
 }
 
 func GenerateApiServices(app *app.App) Services {
 	repos := repo.NewRepositories(app.DB)
+	mfaService := NewMFAService(app.Config, app.Verifier, repos)
 
 	return Services{
-		PinService:            pin.NewService(app.Config, app.Verifier, app.UserDB, &app.EmailProvider, repos),
-		UserService:           NewUserService(app.Config, app.Verifier, app.UserDB, repos),
-		TokenService:          NewTokenService(app.Config, app.Verifier, app.UserDB, app.EthProvider),
+		PinService:            pin.NewService(app.Config, app.Verifier, app.UserDB, &app.EmailProvider, app.SMSProvider, repos),
+		UserService:           NewUserService(app.Config, app.Verifier, app.UserDB, repos, &mfaService),
+		TokenService:          NewTokenService(app.Config, app.Verifier, app.UserDB, app.EthProvider, repos),
 		SIWEService:           NewSIWEService(app.Config, app.Verifier, app.UserDB, app.EthProvider, app.SLYWalletManager),
 		AccountService:        NewAccountService(repos),
 		InvitationCodeService: NewInvitationCodeService(repos),
 		SLYWalletService:      NewSLYWalletService(app.Config, app.SLYWalletManager, repos),
+		SessionAuditService:   NewSessionAuditService(repos),
+		PushService:           NewPushService(app.Config, repos, app.PushProvider),
+		EmailChangeService:    NewEmailChangeService(app.Config, repos, &app.EmailProvider),
+		ProfileService:        NewProfileService(repos),
+		PrivacyService:        NewPrivacyService(app.Config, repos),
+		AccountLinkService:    NewAccountLinkService(app.Config, repos),
+		PasswordService:       NewPasswordService(app.Config, app.Verifier, repos, &app.EmailProvider),
+		MFAService:            mfaService,
+		WebAuthnService:       NewWebAuthnService(app.Config, app.Verifier, repos),
 		Repos:                 repos,
 	}
 }
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"time"
+	"yip/src/slyerrors"
+
+	"github.com/google/uuid"
+)
+
+// swagger:model AccountLinkResponse
+type AccountLinkResponse struct {
+	ID uuid.UUID `json:"id"`
+	// Code is confirmed by the other account, it is only returned once
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// swagger:model AccountLinkConfirmRequest
+type AccountLinkConfirmDTO struct {
+	Code string `json:"code"`
+}
+
+func (a *AccountLinkConfirmDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("code", a.Code).
+		Error()
+}
+
+// swagger:model AccountMergeRequest
+type AccountMergeDTO struct {
+	// TargetAccountID is the account of the link that is kept, the other one is merged into it
+	TargetAccountID string `json:"targetAccountId"`
+}
+
+func (a *AccountMergeDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateUUID("targetAccountId", a.TargetAccountID).
+		Error()
+}
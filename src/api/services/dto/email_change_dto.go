@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"time"
+	"yip/src/slyerrors"
+)
+
+// swagger:model EmailChangeRequest
+type EmailChangeRequestDTO struct {
+	NewEmail string `json:"newEmail"`
+	// Language of the mails, e.g. "de", the default language if empty
+	Language string `json:"language,omitempty"`
+}
+
+func (a *EmailChangeRequestDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateEmail("newEmail", a.NewEmail).
+		Error()
+}
+
+// swagger:model EmailChangeResponse
+type EmailChangeResponse struct {
+	NewEmail  string    `json:"newEmail"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Code and Link are only returned in test mode
+	Code string `json:"code,omitempty"`
+	Link string `json:"link,omitempty"`
+}
+
+// swagger:model EmailChangeConfirmRequest
+type EmailChangeConfirmDTO struct {
+	Code string `json:"code"`
+}
+
+func (a *EmailChangeConfirmDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("code", a.Code).
+		Error()
+}
+
+// swagger:model EmailChangeConfirmLinkRequest
+type EmailChangeConfirmLinkDTO struct {
+	// Token is the t parameter of the link
+	Token string `json:"token"`
+}
+
+func (a *EmailChangeConfirmLinkDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("token", a.Token).
+		Error()
+}
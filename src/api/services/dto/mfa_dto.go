@@ -0,0 +1,114 @@
+package dto
+
+import (
+	"time"
+	"yip/src/api/auth/verifier"
+	"yip/src/slyerrors"
+)
+
+const (
+	MFAMethodTOTP         = "totp"
+	MFAMethodRecoveryCode = "recovery_code"
+)
+
+// SignInResponse carries the token of a sign-in, or the challenge of the
+// second factor if one is required
+//
+// swagger:model SignInResponse
+type SignInResponse struct {
+	*verifier.Token
+	MFA *MFAChallengeResponse `json:"mfa,omitempty"`
+}
+
+// swagger:model MFAChallengeResponse
+type MFAChallengeResponse struct {
+	Challenge string    `json:"challenge"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Methods verifying the challenge, "totp" and "recovery_code"
+	Methods []string `json:"methods"`
+	// EnrollmentRequired is set if the role requires a second factor the
+	// account has not enrolled yet, it is enrolled with the challenge
+	EnrollmentRequired bool `json:"enrollmentRequired"`
+}
+
+// swagger:model MFAChallengeRequest
+type MFAChallengeDTO struct {
+	Challenge string `json:"challenge"`
+}
+
+func (a *MFAChallengeDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("challenge", a.Challenge).
+		Error()
+}
+
+// swagger:model MFAVerifyRequest
+type MFAVerifyDTO struct {
+	Challenge string `json:"challenge"`
+	MFACodeDTO
+}
+
+func (a *MFAVerifyDTO) Validate() error {
+	return a.MFACodeDTO.validate(slyerrors.NewValidation("400").ValidateNotEmpty("challenge", a.Challenge)).
+		Error()
+}
+
+// MFACodeDTO proves the second factor with either a TOTP code or a recovery
+// code
+//
+// swagger:model MFACodeRequest
+type MFACodeDTO struct {
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recoveryCode,omitempty"`
+}
+
+func (a *MFACodeDTO) Validate() error {
+	return a.validate(slyerrors.NewValidation("400")).Error()
+}
+
+func (a *MFACodeDTO) validate(v *slyerrors.Validation) *slyerrors.Validation {
+	if (a.Code == "") == (a.RecoveryCode == "") {
+		v.Add("code", slyerrors.ValidationCodeUnexpectedValue, "either code or recoveryCode is required")
+	}
+	return v
+}
+
+// swagger:model TOTPCodeRequest
+type TOTPCodeDTO struct {
+	Code string `json:"code"`
+}
+
+func (a *TOTPCodeDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("code", a.Code).
+		Error()
+}
+
+// MFAVerifyResponse carries the token of a verified challenge, and the
+// recovery codes if the factor was enrolled with it
+//
+// swagger:model MFAVerifyResponse
+type MFAVerifyResponse struct {
+	*verifier.Token
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// swagger:model TOTPEnrollmentResponse
+type TOTPEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth URI to show as QR code
+	URI string `json:"uri"`
+}
+
+// swagger:model MFAStatusResponse
+type MFAStatusResponse struct {
+	Enrolled bool `json:"enrolled"`
+	// Required is set if the role requires a second factor
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+// swagger:model RecoveryCodesResponse
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
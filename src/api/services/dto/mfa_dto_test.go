@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+	"yip/src/api/auth/verifier"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMFACodeValidate(t *testing.T) {
+	assert.NoError(t, (&MFACodeDTO{Code: "123456"}).Validate())
+	assert.NoError(t, (&MFACodeDTO{RecoveryCode: "ABCDE-12345"}).Validate())
+	assert.Error(t, (&MFACodeDTO{}).Validate())
+	assert.Error(t, (&MFACodeDTO{Code: "123456", RecoveryCode: "ABCDE-12345"}).Validate())
+
+	assert.NoError(t, (&MFAVerifyDTO{Challenge: "abc", MFACodeDTO: MFACodeDTO{Code: "123456"}}).Validate())
+	assert.Error(t, (&MFAVerifyDTO{MFACodeDTO: MFACodeDTO{Code: "123456"}}).Validate())
+}
+
+func TestSignInResponseJSON(t *testing.T) {
+	b, err := json.Marshal(SignInResponse{Token: &verifier.Token{IdToken: "id"}})
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"token":"id"`)
+	assert.NotContains(t, string(b), `"mfa"`)
+
+	b, err = json.Marshal(SignInResponse{MFA: &MFAChallengeResponse{Challenge: "c"}})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), `"token"`)
+	assert.Contains(t, string(b), `"challenge":"c"`)
+}
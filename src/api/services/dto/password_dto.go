@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"yip/src/slyerrors"
+)
+
+const (
+	passwordMinLength = 8
+	// bcrypt uses the first 72 bytes only
+	passwordMaxLength = 72
+)
+
+// swagger:model PasswordForgotRequest
+type PasswordForgotDTO struct {
+	Email string `json:"email"`
+	// Language of the mail, e.g. "de", the default language if empty
+	Language string `json:"language,omitempty"`
+}
+
+func (a *PasswordForgotDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateEmail("email", a.Email).
+		Error()
+}
+
+// swagger:model PasswordForgotResponse
+type PasswordForgotResponse struct {
+	// Token and Link are only returned in test mode if the email belongs to a
+	// credential account
+	Token string `json:"token,omitempty"`
+	Link  string `json:"link,omitempty"`
+}
+
+// swagger:model PasswordResetRequest
+type PasswordResetDTO struct {
+	// Token is the t parameter of the link resp. the token of the mail
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
+
+func (a *PasswordResetDTO) Validate() error {
+	return validatePassword(slyerrors.NewValidation("400").ValidateNotEmpty("token", a.Token), "newPassword", a.NewPassword).
+		Error()
+}
+
+// swagger:model PasswordChangeRequest
+type PasswordChangeDTO struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+func (a *PasswordChangeDTO) Validate() error {
+	return validatePassword(slyerrors.NewValidation("400").ValidateNotEmpty("currentPassword", a.CurrentPassword), "newPassword", a.NewPassword).
+		Error()
+}
+
+func validatePassword(v *slyerrors.Validation, field, password string) *slyerrors.Validation {
+	return v.ValidateMinLength(field, password, passwordMinLength).
+		ValidateMaxLength(field, password, passwordMaxLength)
+}
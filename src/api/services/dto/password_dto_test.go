@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetValidate(t *testing.T) {
+	assert.NoError(t, (&PasswordResetDTO{Token: "abc", NewPassword: "s3cret-pass"}).Validate())
+	assert.Error(t, (&PasswordResetDTO{NewPassword: "s3cret-pass"}).Validate())
+	assert.Error(t, (&PasswordResetDTO{Token: "abc", NewPassword: "short"}).Validate())
+	assert.Error(t, (&PasswordResetDTO{Token: "abc", NewPassword: strings.Repeat("a", passwordMaxLength+1)}).Validate())
+}
+
+func TestPasswordChangeValidate(t *testing.T) {
+	assert.NoError(t, (&PasswordChangeDTO{CurrentPassword: "old", NewPassword: "s3cret-pass"}).Validate())
+	assert.Error(t, (&PasswordChangeDTO{NewPassword: "s3cret-pass"}).Validate())
+	assert.Error(t, (&PasswordChangeDTO{CurrentPassword: "old", NewPassword: ""}).Validate())
+}
@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"time"
+	"yip/src/repositories/repo"
+)
+
+// AccountExport bundles the data stored about an account
+// swagger:model AccountExport
+type AccountExport struct {
+	ExportedAt time.Time          `json:"exportedAt"`
+	Account    *repo.AccountModel `json:"account"`
+	// Devices are the ECDSA keys of the account
+	Devices    []repo.EcdsaModel     `json:"devices"`
+	SLYWallets []repo.SlyWalletModel `json:"slyWallets"`
+	// WalletLinks connect the devices and SLYWallets of the account to keys and wallets
+	WalletLinks     []repo.EcdsaSlyWalletModel `json:"walletLinks"`
+	InvitationCodes []repo.InvitationCodeModel `json:"invitationCodes"`
+	PushTokens      []repo.PushTokenModel      `json:"pushTokens"`
+	EmailChanges    []repo.EmailChangeModel    `json:"emailChanges"`
+	AccountLinks    []repo.AccountLinkModel    `json:"accountLinks"`
+	// Passkeys are the WebAuthn credentials of the account
+	Passkeys []*repo.WebAuthnCredentialModel `json:"passkeys"`
+	// LoginHistory are the recorded QR sessions of the devices
+	LoginHistory []repo.SessionAuditModel `json:"loginHistory"`
+	// SignIns are the sign-ins with pin, SIWE, password and passkey
+	SignIns []repo.SignInModel `json:"signIns"`
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+)
+
+const maxNameLength = 255
+
+// swagger:model ProfilePatchRequest
+type ProfilePatchDTO struct {
+	// Fields left out are not changed
+	FirstName *string `json:"firstName,omitempty"`
+	LastName  *string `json:"lastName,omitempty"`
+	// E.164 phone number, an empty string removes the phone. A changed phone is
+	// not verified anymore.
+	Phone *string `json:"phone,omitempty"`
+}
+
+func (a *ProfilePatchDTO) Validate() error {
+	v := slyerrors.NewValidation("400")
+	if a.FirstName != nil {
+		v.ValidateMaxLength("firstName", *a.FirstName, maxNameLength)
+	}
+	if a.LastName != nil {
+		v.ValidateMaxLength("lastName", *a.LastName, maxNameLength)
+	}
+	if a.Phone != nil && *a.Phone != "" {
+		v.ValidatePhone("phone", *a.Phone)
+	}
+	return v.Error()
+}
+
+// Apply sets the given fields on account and reports whether the phone changed
+func (a *ProfilePatchDTO) Apply(account *repo.AccountModel) (phoneChanged bool) {
+	if a.FirstName != nil {
+		account.FirstName = *a.FirstName
+	}
+	if a.LastName != nil {
+		account.LastName = *a.LastName
+	}
+	if a.Phone != nil && *a.Phone != account.Phone {
+		account.Phone = *a.Phone
+		phoneChanged = true
+	}
+	return phoneChanged
+}
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+	"yip/src/repositories/repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfilePatchValidate(t *testing.T) {
+	first, phone, empty := "Ada", "+4915112345678", ""
+	assert.NoError(t, (&ProfilePatchDTO{}).Validate())
+	assert.NoError(t, (&ProfilePatchDTO{FirstName: &first, Phone: &phone}).Validate())
+	assert.NoError(t, (&ProfilePatchDTO{Phone: &empty}).Validate())
+
+	badPhone := "015112345678"
+	assert.Error(t, (&ProfilePatchDTO{Phone: &badPhone}).Validate())
+	long := strings.Repeat("a", maxNameLength+1)
+	assert.Error(t, (&ProfilePatchDTO{LastName: &long}).Validate())
+}
+
+func TestProfilePatchApply(t *testing.T) {
+	account := &repo.AccountModel{FirstName: "Ada", LastName: "Lovelace", Phone: "+4915112345678"}
+
+	last := "King"
+	assert.False(t, (&ProfilePatchDTO{LastName: &last}).Apply(account))
+	assert.Equal(t, "Ada", account.FirstName)
+	assert.Equal(t, "King", account.LastName)
+
+	samePhone := "+4915112345678"
+	assert.False(t, (&ProfilePatchDTO{Phone: &samePhone}).Apply(account))
+
+	empty := ""
+	assert.True(t, (&ProfilePatchDTO{Phone: &empty}).Apply(account))
+	assert.Equal(t, "", account.Phone)
+}
@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"yip/src/slyerrors"
+)
+
+const (
+	PushPlatformIOS     = "ios"
+	PushPlatformAndroid = "android"
+	PushPlatformWeb     = "web"
+)
+
+// swagger:model PushTokenRequest
+type PushTokenRequestDTO struct {
+	Token    string `json:"token"`
+	Platform string `json:"platform"`
+}
+
+func (a *PushTokenRequestDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("token", a.Token).
+		ValidateInList("platform", a.Platform, []string{PushPlatformIOS, PushPlatformAndroid, PushPlatformWeb}).
+		Error()
+}
@@ -3,7 +3,10 @@ package dto
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"math/big"
 	"net/http"
+	"yip/src/cryptox"
 	"yip/src/httpx"
 	"yip/src/slyerrors"
 )
@@ -128,7 +131,63 @@ func (a *CreateSLYWalletRequest) ReadAndValidate(r *http.Request) error {
 		return slyerrors.NewValidation("400").Add("json is not readable", slyerrors.ValidationCodeCannotValidate, err.Error()).Error()
 	}
 
-	return slyerrors.NewValidation("400").
-		ValidateNotEmpty("invitationCode", a.InvitationCode).
-		Error()
+	// users may type the code in groups
+	a.InvitationCode = cryptox.InvitationCodes.Normalize(a.InvitationCode)
+
+	val := slyerrors.NewValidation("400").ValidateNotEmpty("invitationCode", a.InvitationCode)
+	// the check digit catches mistyped codes before they are looked up
+	if a.InvitationCode != "" && !cryptox.InvitationCodes.Verify(a.InvitationCode) {
+		val.Add("invitationCode", slyerrors.ValidationCodeUnexpectedValue, "not a valid invitation code")
+	}
+	return val.Error()
+}
+
+// ExecuteCall is a call the SLYWallet executes on behalf of one of its controller keys
+type ExecuteCall struct {
+	SLYWalletAddress string `json:"slyWalletAddress"`
+	To               string `json:"to"`
+	Value            string `json:"value"` // in wei
+	Data             string `json:"data"`  // hex encoded call data
+}
+
+func (e *ExecuteCall) Validate() error {
+	v := slyerrors.NewValidation("400").
+		ValidateEthAddress("slyWalletAddress", e.SLYWalletAddress).
+		ValidateEthAddress("to", e.To)
+
+	if _, err := e.ValueWei(); err != nil {
+		v.Add("value", slyerrors.ValidationCodeCannotValidate, err.Error())
+	}
+	if _, err := e.CallData(); err != nil {
+		v.Add("data", slyerrors.ValidationCodeCannotValidate, err.Error())
+	}
+
+	return v.Error()
+}
+
+func (e *ExecuteCall) ValueWei() (*big.Int, error) {
+	if e.Value == "" {
+		return big.NewInt(0), nil
+	}
+	value, ok := new(big.Int).SetString(e.Value, 10)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("value is not a positive integer: %s", e.Value)
+	}
+	return value, nil
+}
+
+func (e *ExecuteCall) CallData() ([]byte, error) {
+	if e.Data == "" || e.Data == "0x" {
+		return []byte{}, nil
+	}
+	return hexutil.Decode(e.Data)
+}
+
+// PreparedExecute is an ExecuteCall bound to a signer and its current nonce.
+// Digest is the EIP-712 hash the signer has to sign for ExecuteWithSignature.
+type PreparedExecute struct {
+	ExecuteCall
+	Signer string `json:"signer"`
+	Nonce  string `json:"nonce"`
+	Digest string `json:"digest"`
 }
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"yip/src/cryptox"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSLYWalletRequestValidate(t *testing.T) {
+	code, err := cryptox.InvitationCodes.Generate()
+	assert.NoError(t, err)
+
+	read := func(code string) error {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(`{"invitationCode":"`+code+`"}`))
+		return (&CreateSLYWalletRequest{}).ReadAndValidate(r)
+	}
+
+	assert.NoError(t, read(code))
+	assert.NoError(t, read(code[:4]+" "+code[4:8]+"-"+code[8:]))
+	assert.Error(t, read(""))
+	assert.Error(t, read(code[:15]))
+
+	// a mistyped digit fails the check digit
+	mistyped := []byte(code)
+	mistyped[3] = '0' + (mistyped[3]-'0'+1)%10
+	assert.Error(t, read(string(mistyped)))
+}
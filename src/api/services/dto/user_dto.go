@@ -57,7 +57,7 @@ func (a *SetEmailRequest) ReadAndValidate(r *http.Request) error {
 	}
 
 	return slyerrors.NewValidation("400").
-		ValidateNotEmpty("email", a.UserID).
-		ValidateNotEmpty("password", a.Email).
+		ValidateNotEmpty("userId", a.UserID).
+		ValidateEmail("email", a.Email).
 		Error()
 }
@@ -0,0 +1,148 @@
+package dto
+
+import (
+	"time"
+	"yip/src/slyerrors"
+)
+
+const (
+	WebAuthnCredentialType = "public-key"
+)
+
+// WebAuthnRelyingParty names the relying party in the creation options
+type WebAuthnRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// WebAuthnUser is the account a passkey is created for, ID is the base64url
+// encoded account id
+type WebAuthnUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// WebAuthnCredentialParameter is an algorithm YIP accepts, a COSE algorithm
+// identifier
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// WebAuthnCredentialDescriptor is a passkey known to YIP, ID is the base64url
+// encoded credential id
+type WebAuthnCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// WebAuthnCreationOptions are the publicKey options of
+// navigator.credentials.create(), binary values base64url encoded
+//
+// swagger:model WebAuthnCreationOptions
+type WebAuthnCreationOptions struct {
+	Challenge              string                         `json:"challenge"`
+	RP                     WebAuthnRelyingParty           `json:"rp"`
+	User                   WebAuthnUser                   `json:"user"`
+	PubKeyCredParams       []WebAuthnCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                            `json:"timeout"`
+	Attestation            string                         `json:"attestation"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+}
+
+// WebAuthnRequestOptions are the publicKey options of
+// navigator.credentials.get(), binary values base64url encoded. Without
+// AllowCredentials the browser offers the discoverable passkeys of the RP.
+//
+// swagger:model WebAuthnRequestOptions
+type WebAuthnRequestOptions struct {
+	Challenge        string                         `json:"challenge"`
+	RPID             string                         `json:"rpId"`
+	Timeout          int                            `json:"timeout"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                         `json:"userVerification"`
+}
+
+// WebAuthnRegistrationDTO is the response of navigator.credentials.create(),
+// binary values base64url encoded. Label names the passkey in the list of the
+// account.
+//
+// swagger:model WebAuthnRegistrationRequest
+type WebAuthnRegistrationDTO struct {
+	ID                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"`
+	Label             string `json:"label"`
+}
+
+func (a *WebAuthnRegistrationDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("id", a.ID).
+		ValidateNotEmpty("clientDataJSON", a.ClientDataJSON).
+		ValidateNotEmpty("attestationObject", a.AttestationObject).
+		ValidateMaxLength("label", a.Label, 255).
+		Error()
+}
+
+// WebAuthnLoginBeginDTO starts a passkey login for the audiences. With Email
+// the passkeys of the account are offered, the discoverable ones otherwise.
+//
+// swagger:model WebAuthnLoginBeginRequest
+type WebAuthnLoginBeginDTO struct {
+	Audiences []string `json:"audiences"`
+	Email     string   `json:"email,omitempty"`
+}
+
+func (a *WebAuthnLoginBeginDTO) Validate() error {
+	v := slyerrors.NewValidation("400").
+		ValidateAtLeastOneElement("audiences", a.Audiences)
+	if a.Email != "" {
+		v.ValidateEmail("email", a.Email)
+	}
+	return v.Error()
+}
+
+// WebAuthnLoginDTO is the response of navigator.credentials.get(), binary
+// values base64url encoded. UserHandle is set for discoverable passkeys.
+//
+// swagger:model WebAuthnLoginRequest
+type WebAuthnLoginDTO struct {
+	ID                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
+
+func (a *WebAuthnLoginDTO) Validate() error {
+	return slyerrors.NewValidation("400").
+		ValidateNotEmpty("id", a.ID).
+		ValidateNotEmpty("clientDataJSON", a.ClientDataJSON).
+		ValidateNotEmpty("authenticatorData", a.AuthenticatorData).
+		ValidateNotEmpty("signature", a.Signature).
+		Error()
+}
+
+// WebAuthnCredentialResponse is a passkey of the account. PublicKeyX and
+// PublicKeyY are the hex coordinates of ES256 (secp256r1) passkeys, e.g. to
+// add the passkey as authenticator key of a SLYWallet.
+//
+// swagger:model WebAuthnCredentialResponse
+type WebAuthnCredentialResponse struct {
+	ID           string     `json:"id"`
+	CredentialID string     `json:"credentialId"`
+	Label        string     `json:"label"`
+	Algorithm    int        `json:"algorithm"`
+	AAGUID       string     `json:"aaguid"`
+	PublicKeyX   string     `json:"publicKeyX,omitempty"`
+	PublicKeyY   string     `json:"publicKeyY,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	LastUsedAt   *time.Time `json:"lastUsedAt,omitempty"`
+}
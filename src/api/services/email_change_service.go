@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services/dto"
+	"yip/src/config"
+	"yip/src/cryptox"
+	"yip/src/providers"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultEmailChangeExpirationInMin = 60
+	defaultEmailChangeMaxAttempts     = 5
+	defaultEmailChangeMaxPerAccount   = 5
+	defaultEmailChangeMaxPerEmail     = 3
+)
+
+// EmailChangeStore keeps the email changes, it is implemented by
+// repo.EmailChangeRepository
+type EmailChangeStore interface {
+	Create(ctx context.Context, change *repo.EmailChangeModel) (*repo.EmailChangeModel, error)
+	GetPending(ctx context.Context, accountID uuid.UUID, now time.Time) (*repo.EmailChangeModel, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*repo.EmailChangeModel, error)
+	IncrementFailedAttempts(ctx context.Context, id uuid.UUID) (int, error)
+	Expire(ctx context.Context, id uuid.UUID, at time.Time) error
+	ExpirePending(ctx context.Context, accountID uuid.UUID, at time.Time) error
+	Apply(ctx context.Context, change *repo.EmailChangeModel, at time.Time) (*repo.AccountModel, error)
+	ListByAccount(ctx context.Context, accountID uuid.UUID) ([]repo.EmailChangeModel, error)
+}
+
+// EmailChangeService changes the email of an account. Users confirm the new
+// address with a code or link sent to it, the old address is notified. Admins
+// change it right away, the change records the admin.
+type EmailChangeService struct {
+	config        *config.Config
+	repos         *repo.Repositories
+	changes       EmailChangeStore
+	ep            *providers.EmailProvider
+	hashSecret    []byte
+	expiration    time.Duration
+	maxAttempts   int
+	maxAuthAge    time.Duration
+	limiter       RequestLimiter
+	maxPerAccount int
+	maxPerEmail   int
+}
+
+func NewEmailChangeService(config *config.Config, repos *repo.Repositories, ep *providers.EmailProvider) EmailChangeService {
+	expirationInMin := config.EmailChange.ExpirationInMin
+	if expirationInMin <= 0 {
+		expirationInMin = defaultEmailChangeExpirationInMin
+	}
+	maxAttempts := config.EmailChange.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultEmailChangeMaxAttempts
+	}
+	maxAuthAgeInSec := config.EmailChange.MaxAuthAgeInSec
+	if maxAuthAgeInSec <= 0 {
+		maxAuthAgeInSec = defaultMaxAuthAgeInSec
+	}
+
+	return EmailChangeService{
+		config:        config,
+		repos:         repos,
+		changes:       repos.EmailChangeRepo,
+		ep:            ep,
+		hashSecret:    []byte(config.Pin.HashSecret),
+		expiration:    time.Duration(expirationInMin) * time.Minute,
+		maxAttempts:   maxAttempts,
+		maxAuthAge:    time.Duration(maxAuthAgeInSec) * time.Second,
+		limiter:       NewRequestLimiter(config, repos),
+		maxPerAccount: limitOrDefault(config.EmailChange.MaxRequestsPerAccount, defaultEmailChangeMaxPerAccount),
+		maxPerEmail:   limitOrDefault(config.EmailChange.MaxRequestsPerEmail, defaultEmailChangeMaxPerEmail),
+	}
+}
+
+// throttle applies the account limit first, so requests it rejects do not
+// use up the quota of someone else's inbox
+func (s EmailChangeService) throttle(ctx context.Context, account *repo.AccountModel, newEmail string) error {
+	if err := s.limiter.Throttle(ctx, slyerrors.ErrCodeEmailChangeRateLimited, "email_change:"+account.ID.String(), s.maxPerAccount); err != nil {
+		return err
+	}
+	return s.limiter.Throttle(ctx, slyerrors.ErrCodeEmailChangeRateLimited, "email_change_to:"+strings.ToLower(newEmail), s.maxPerEmail)
+}
+
+// RequestChange sends a code, and a link if enabled, to the new email and a
+// notice to the old one. A former pending change of the account is dropped.
+// Requests are limited per account and per new email, so the mails can't be
+// used to spam an address. The principal must have signed in recently, a
+// leaked or long-lived token can't move the account to another address.
+func (s EmailChangeService) RequestChange(ctx context.Context, principal *verifier.Principal, data *dto.EmailChangeRequestDTO, ip string) (*dto.EmailChangeResponse, error) {
+	if !principal.AuthenticatedWithin(s.maxAuthAge) {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeReauthenticationRequired, "sign in again to change the email")
+	}
+
+	account, err := s.accountOf(ctx, principal.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.throttle(ctx, account, data.NewEmail); err != nil {
+		return nil, err
+	}
+	if err = s.checkAvailable(ctx, account, data.NewEmail); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err = s.changes.ExpirePending(ctx, account.ID, now); err != nil {
+		return nil, err
+	}
+
+	code, err := cryptox.PinCodes.Generate()
+	if err != nil {
+		return nil, err
+	}
+	change := &repo.EmailChangeModel{
+		AccountID: account.ID,
+		OldEmail:  account.Email,
+		NewEmail:  data.NewEmail,
+		CodeHash:  cryptox.HashCode(s.hashSecret, code),
+		ExpiresAt: now.Add(s.expiration),
+	}
+
+	var link string
+	if s.config.EmailChange.ConfirmUrl != "" {
+		token, err := cryptox.RandomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		change.TokenHash = cryptox.HashCode(s.hashSecret, token)
+		link = fmt.Sprintf("%s?t=%s", s.config.EmailChange.ConfirmUrl, url.QueryEscape(token))
+	}
+
+	change, err = s.changes.Create(ctx, change)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.EmailChangeResponse{
+		NewEmail:  change.NewEmail,
+		ExpiresAt: change.ExpiresAt,
+	}
+	if s.config.Test.On {
+		response.Code = code
+		response.Link = link
+		return response, nil
+	}
+
+	err = s.ep.SendEmailChangeMail(ctx, change.NewEmail, data.Language, providers.EmailChangeMail{
+		NewEmail:  change.NewEmail,
+		Code:      code,
+		Link:      link,
+		ExpiresAt: change.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.alert(ctx, change.OldEmail, data.Language, fmt.Sprintf("A change of your email address to %s was requested.", change.NewEmail), ip)
+
+	return response, nil
+}
+
+// ConfirmChange applies the pending change of the principal's account if the
+// code matches. After too many wrong codes the change is dropped.
+func (s EmailChangeService) ConfirmChange(ctx context.Context, principal *verifier.Principal, data *dto.EmailChangeConfirmDTO, ip string) (*repo.AccountModel, error) {
+	accountId, err := uuid.Parse(principal.ID)
+	if err != nil {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeParsingUUID, err.Error())
+	}
+
+	now := time.Now()
+	change, err := s.changes.GetPending(ctx, accountId, now)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeEmailChangeNotFound, "no pending email change")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal([]byte(cryptox.HashCode(s.hashSecret, data.Code)), []byte(change.CodeHash)) {
+		attempts, err := s.changes.IncrementFailedAttempts(ctx, change.ID)
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= s.maxAttempts {
+			if err = s.changes.Expire(ctx, change.ID, now); err != nil {
+				return nil, err
+			}
+		}
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongEmailChangeCode, "wrong code")
+	}
+
+	return s.apply(ctx, change, ip)
+}
+
+// ConfirmChangeLink applies the change of a confirmation link. The link alone
+// authorizes the change, it is only sent to the new email.
+func (s EmailChangeService) ConfirmChangeLink(ctx context.Context, data *dto.EmailChangeConfirmLinkDTO, ip string) (*repo.AccountModel, error) {
+	change, err := s.changes.GetByTokenHash(ctx, cryptox.HashCode(s.hashSecret, data.Token))
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeEmailChangeNotFound, "no pending email change")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if change.ConfirmedAt != nil || !change.ExpiresAt.After(time.Now()) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeEmailChangeNotFound, "no pending email change")
+	}
+
+	return s.apply(ctx, change, ip)
+}
+
+// AdminSetEmail sets the email of an account without confirmation. The change
+// records the acting admin and the old email is notified.
+func (s EmailChangeService) AdminSetEmail(ctx context.Context, admin *verifier.Principal, accountId uuid.UUID, email string, ip string) (*repo.AccountModel, error) {
+	account, err := s.accountOf(ctx, accountId.String())
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkAvailable(ctx, account, email); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err = s.changes.ExpirePending(ctx, account.ID, now); err != nil {
+		return nil, err
+	}
+
+	change, err := s.changes.Create(ctx, &repo.EmailChangeModel{
+		AccountID: account.ID,
+		OldEmail:  account.Email,
+		NewEmail:  email,
+		ExpiresAt: now,
+		ChangedBy: admin.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.apply(ctx, change, ip)
+}
+
+// ListChanges returns the email changes of an account, the latest first
+func (s EmailChangeService) ListChanges(ctx context.Context, accountId uuid.UUID) ([]repo.EmailChangeModel, error) {
+	return s.changes.ListByAccount(ctx, accountId)
+}
+
+// apply sets the new email of the change and notifies the old one
+func (s EmailChangeService) apply(ctx context.Context, change *repo.EmailChangeModel, ip string) (*repo.AccountModel, error) {
+	account, err := s.changes.Apply(ctx, change, time.Now())
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeEmailChangeNotFound, "no pending email change")
+	}
+	if slyerrors.IsUniqueViolation(err) {
+		return nil, slyerrors.Conflict(slyerrors.ErrCodeEmailTaken, "email is taken by another account")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event := fmt.Sprintf("The email address of your account was changed to %s.", change.NewEmail)
+	if change.ChangedBy != "" {
+		event = fmt.Sprintf("The email address of your account was changed to %s by an administrator.", change.NewEmail)
+	}
+	s.alert(ctx, change.OldEmail, "", event, ip)
+
+	return account, nil
+}
+
+func (s EmailChangeService) accountOf(ctx context.Context, id string) (*repo.AccountModel, error) {
+	accountId, err := uuid.Parse(id)
+	if err != nil {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeParsingUUID, err.Error())
+	}
+	account, err := s.repos.AccountRepo.GetByID(ctx, accountId)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeCantCreateOrGetAccount, "account not found")
+	}
+	return account, err
+}
+
+// checkAvailable fails if email is the account's email already or belongs to
+// another account
+func (s EmailChangeService) checkAvailable(ctx context.Context, account *repo.AccountModel, email string) error {
+	if strings.EqualFold(account.Email, email) {
+		return slyerrors.BadRequest(slyerrors.ErrCodeEmailUnchanged, "email is the email of the account already")
+	}
+
+	other, err := s.repos.AccountRepo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repo.DBItemNotFound) {
+		return err
+	}
+	if other != nil {
+		return slyerrors.Conflict(slyerrors.ErrCodeEmailTaken, "email is taken by another account")
+	}
+	return nil
+}
+
+// alert sends a security alert to email, failures are logged only
+func (s EmailChangeService) alert(ctx context.Context, email string, lang string, event string, ip string) {
+	sendSecurityAlert(ctx, s.config, s.ep, email, lang, event, ip)
+}
+
+// sendSecurityAlert sends a security alert to email unless in test mode,
+// failures are logged only
+func sendSecurityAlert(ctx context.Context, config *config.Config, ep *providers.EmailProvider, email string, lang string, event string, ip string) {
+	if email == "" || config.Test.On {
+		return
+	}
+	err := ep.SendSecurityAlertMail(ctx, email, lang, providers.SecurityAlertMail{Event: event, IP: ip, Time: time.Now()})
+	if err != nil {
+		log.Println("could not send security alert:", err)
+	}
+}
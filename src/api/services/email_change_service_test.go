@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services/dto"
+	"yip/src/config"
+	"yip/src/cryptox"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+
+	"github.com/google/uuid"
+)
+
+// emailChangePool is an in-memory EmailChangeStore
+type emailChangePool struct {
+	changes map[uuid.UUID]repo.EmailChangeModel
+	mutex   sync.Mutex
+}
+
+func newEmailChangePool() *emailChangePool {
+	return &emailChangePool{changes: make(map[uuid.UUID]repo.EmailChangeModel)}
+}
+
+func (p *emailChangePool) Create(_ context.Context, change *repo.EmailChangeModel) (*repo.EmailChangeModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	c := *change
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now()
+	p.changes[c.ID] = c
+	return &c, nil
+}
+
+func (p *emailChangePool) GetPending(_ context.Context, accountID uuid.UUID, now time.Time) (*repo.EmailChangeModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, c := range p.changes {
+		if c.AccountID == accountID && c.ConfirmedAt == nil && c.ExpiresAt.After(now) {
+			return &c, nil
+		}
+	}
+	return nil, repo.DBItemNotFound
+}
+
+func (p *emailChangePool) GetByTokenHash(_ context.Context, tokenHash string) (*repo.EmailChangeModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, c := range p.changes {
+		if c.TokenHash != "" && c.TokenHash == tokenHash {
+			return &c, nil
+		}
+	}
+	return nil, repo.DBItemNotFound
+}
+
+func (p *emailChangePool) IncrementFailedAttempts(_ context.Context, id uuid.UUID) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	c := p.changes[id]
+	c.FailedAttempts++
+	p.changes[id] = c
+	return c.FailedAttempts, nil
+}
+
+func (p *emailChangePool) Expire(_ context.Context, id uuid.UUID, at time.Time) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	c := p.changes[id]
+	if c.ConfirmedAt == nil {
+		c.ExpiresAt = at
+		p.changes[id] = c
+	}
+	return nil
+}
+
+func (p *emailChangePool) ExpirePending(ctx context.Context, accountID uuid.UUID, at time.Time) error {
+	for {
+		c, err := p.GetPending(ctx, accountID, at)
+		if err != nil {
+			return nil
+		}
+		_ = p.Expire(ctx, c.ID, at)
+	}
+}
+
+func (p *emailChangePool) Apply(_ context.Context, change *repo.EmailChangeModel, at time.Time) (*repo.AccountModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	c := p.changes[change.ID]
+	if c.ConfirmedAt != nil {
+		return nil, repo.DBItemNotFound
+	}
+	c.ConfirmedAt = &at
+	p.changes[change.ID] = c
+	return &repo.AccountModel{ID: c.AccountID, Email: c.NewEmail, IsEmailVerified: true}, nil
+}
+
+func (p *emailChangePool) ListByAccount(_ context.Context, accountID uuid.UUID) ([]repo.EmailChangeModel, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	changes := make([]repo.EmailChangeModel, 0)
+	for _, c := range p.changes {
+		if c.AccountID == accountID {
+			changes = append(changes, c)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].CreatedAt.After(changes[j].CreatedAt) })
+	return changes, nil
+}
+
+const (
+	testChangeCode  = "123456"
+	testChangeToken = "link-token"
+)
+
+func testEmailChangeService(store EmailChangeStore) EmailChangeService {
+	return EmailChangeService{
+		config:      &config.Config{Test: config.Test{On: true}},
+		changes:     store,
+		hashSecret:  []byte("secret"),
+		expiration:  time.Hour,
+		maxAttempts: 3,
+		maxAuthAge:  5 * time.Minute,
+	}
+}
+
+func createTestChange(t *testing.T, s EmailChangeService, accountId uuid.UUID, expiresAt time.Time) *repo.EmailChangeModel {
+	change, err := s.changes.Create(context.Background(), &repo.EmailChangeModel{
+		AccountID: accountId,
+		OldEmail:  "old@email.com",
+		NewEmail:  "new@email.com",
+		CodeHash:  cryptox.HashCode(s.hashSecret, testChangeCode),
+		TokenHash: cryptox.HashCode(s.hashSecret, testChangeToken),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return change
+}
+
+func TestEmailChangeReauthentication(t *testing.T) {
+	s := testEmailChangeService(newEmailChangePool())
+	principal := &verifier.Principal{ID: uuid.NewString(), AuthTime: time.Now().Add(-time.Hour)}
+
+	_, err := s.RequestChange(context.Background(), principal, &dto.EmailChangeRequestDTO{NewEmail: "new@email.com"}, "203.0.113.7")
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeReauthenticationRequired {
+		t.Errorf("expected reauthentication, got %v", err)
+	}
+}
+
+func TestEmailChangeAttempts(t *testing.T) {
+	s := testEmailChangeService(newEmailChangePool())
+	ctx := context.Background()
+	accountId := uuid.New()
+	principal := &verifier.Principal{ID: accountId.String(), AuthTime: time.Now()}
+	createTestChange(t, s, accountId, time.Now().Add(time.Hour))
+
+	for i := 0; i < s.maxAttempts; i++ {
+		_, err := s.ConfirmChange(ctx, principal, &dto.EmailChangeConfirmDTO{Code: "000000"}, "203.0.113.7")
+		if slyerrors.Cause(err).Code != slyerrors.ErrCodeWrongEmailChangeCode {
+			t.Fatalf("attempt %d: expected wrong code, got %v", i, err)
+		}
+	}
+
+	// the change is dropped, the right code comes too late
+	_, err := s.ConfirmChange(ctx, principal, &dto.EmailChangeConfirmDTO{Code: testChangeCode}, "203.0.113.7")
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeEmailChangeNotFound {
+		t.Errorf("expected dropped change, got %v", err)
+	}
+	_, err = s.ConfirmChangeLink(ctx, &dto.EmailChangeConfirmLinkDTO{Token: testChangeToken}, "203.0.113.7")
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeEmailChangeNotFound {
+		t.Errorf("expected dropped link, got %v", err)
+	}
+}
+
+func TestEmailChangeConfirm(t *testing.T) {
+	s := testEmailChangeService(newEmailChangePool())
+	ctx := context.Background()
+	accountId := uuid.New()
+	principal := &verifier.Principal{ID: accountId.String(), AuthTime: time.Now()}
+	createTestChange(t, s, accountId, time.Now().Add(time.Hour))
+
+	// a wrong code below the limit keeps the change
+	if _, err := s.ConfirmChange(ctx, principal, &dto.EmailChangeConfirmDTO{Code: "000000"}, "203.0.113.7"); err == nil {
+		t.Fatal("wrong code accepted")
+	}
+	account, err := s.ConfirmChange(ctx, principal, &dto.EmailChangeConfirmDTO{Code: testChangeCode}, "203.0.113.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Email != "new@email.com" {
+		t.Errorf("email not changed: %s", account.Email)
+	}
+
+	// the link of a confirmed change is used up
+	_, err = s.ConfirmChangeLink(ctx, &dto.EmailChangeConfirmLinkDTO{Token: testChangeToken}, "203.0.113.7")
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeEmailChangeNotFound {
+		t.Errorf("expected used link, got %v", err)
+	}
+}
+
+func TestEmailChangeExpiry(t *testing.T) {
+	s := testEmailChangeService(newEmailChangePool())
+	ctx := context.Background()
+	accountId := uuid.New()
+	principal := &verifier.Principal{ID: accountId.String(), AuthTime: time.Now()}
+	createTestChange(t, s, accountId, time.Now().Add(-time.Second))
+
+	_, err := s.ConfirmChange(ctx, principal, &dto.EmailChangeConfirmDTO{Code: testChangeCode}, "203.0.113.7")
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeEmailChangeNotFound {
+		t.Errorf("expected expired change, got %v", err)
+	}
+	_, err = s.ConfirmChangeLink(ctx, &dto.EmailChangeConfirmLinkDTO{Token: testChangeToken}, "203.0.113.7")
+	if slyerrors.Cause(err).Code != slyerrors.ErrCodeEmailChangeNotFound {
+		t.Errorf("expected expired link, got %v", err)
+	}
+}
@@ -7,10 +7,15 @@ import (
 	"yip/src/common"
 	"yip/src/cryptox"
 	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
 )
 
+// CodeLength of invitation codes including the check digit
 const CodeLength = 16
 
+// attempts to find a code no other invitation code has
+const maxCodeAttempts = 5
+
 type InvitationCodeService struct {
 	repos *repo.Repositories
 }
@@ -36,9 +41,15 @@ func (s InvitationCodeService) GetAllCodes(ctx context.Context) (*repo.Paginated
 		log.Println("need to add invitation codes...")
 		for i := 0; i <= 20; i++ {
 
-			_, err := s.repos.InvitationCodeRepo.Create(ctx, &repo.InvitationCodeModel{
-				Code:      cryptox.GenerateNumberCode(CodeLength),
-				ExpiresAt: time.Now().Add(100000 * time.Hour),
+			_, err := cryptox.Allocate(cryptox.InvitationCodes, maxCodeAttempts, func(code string) error {
+				_, err := s.repos.InvitationCodeRepo.Create(ctx, &repo.InvitationCodeModel{
+					Code:      code,
+					ExpiresAt: time.Now().Add(100000 * time.Hour),
+				})
+				if slyerrors.IsUniqueViolation(err) {
+					return cryptox.ErrCodeTaken
+				}
+				return err
 			})
 			if err != nil {
 				return nil, err
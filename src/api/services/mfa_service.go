@@ -0,0 +1,492 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services/dto"
+	"yip/src/config"
+	"yip/src/cryptox"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMFAIssuer                   = "YIP"
+	defaultMFAChallengeExpirationInSec = 300
+	defaultMFAMaxAttempts              = 5
+	defaultMFAMaxFailures              = 10
+	defaultMFARecoveryCodes            = 10
+	// totpSkew accepts the codes of one step before and after the current one
+	totpSkew = 1
+)
+
+// MFAService handles the TOTP second factor of password sign-ins. Subjects
+// are account ids, or the id of the YIP admin, who has no account. Enrolled
+// subjects and the ones of a required role get a challenge at sign-in, the
+// token is issued once the challenge is verified with a TOTP or recovery
+// code. Wrong codes are counted per subject across challenges, at maxFailures
+// the subject is locked until the end of the request window.
+type MFAService struct {
+	config              *config.Config
+	verifier            *verifier.Verifier
+	repos               *repo.Repositories
+	hashSecret          []byte
+	sealKey             []byte
+	issuer              string
+	challengeExpiration time.Duration
+	maxAttempts         int
+	recoveryCodes       int
+	limiter             RequestLimiter
+	maxFailures         int
+}
+
+func NewMFAService(config *config.Config, verifier *verifier.Verifier, repos *repo.Repositories) MFAService {
+	mfa := config.MFA
+	issuer := mfa.Issuer
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+	expirationInSec := mfa.ChallengeExpirationInSec
+	if expirationInSec <= 0 {
+		expirationInSec = defaultMFAChallengeExpirationInSec
+	}
+	maxAttempts := mfa.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMFAMaxAttempts
+	}
+	recoveryCodes := mfa.RecoveryCodes
+	if recoveryCodes <= 0 {
+		recoveryCodes = defaultMFARecoveryCodes
+	}
+
+	return MFAService{
+		config:              config,
+		verifier:            verifier,
+		repos:               repos,
+		hashSecret:          []byte(config.Pin.HashSecret),
+		sealKey:             []byte(mfa.EncryptionKey),
+		issuer:              issuer,
+		challengeExpiration: time.Duration(expirationInSec) * time.Second,
+		maxAttempts:         maxAttempts,
+		recoveryCodes:       recoveryCodes,
+		limiter:             NewRequestLimiter(config, repos),
+		maxFailures:         limitOrDefault(mfa.MaxFailures, defaultMFAMaxFailures),
+	}
+}
+
+// IsRequired tells if the role requires a second factor
+func (s MFAService) IsRequired(role string) bool {
+	return slices.Contains(s.config.MFA.RequiredRoles, role)
+}
+
+// SignIn completes a sign-in whose password was checked. It returns the token
+// right away if the subject has no factor and its role requires none, the
+// challenge of the second factor otherwise.
+func (s MFAService) SignIn(ctx context.Context, subject string, role string, audiences []string) (*dto.SignInResponse, error) {
+	factor, err := s.confirmedFactor(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	if factor == nil && !s.IsRequired(role) {
+		token, err := s.verifier.CreateToken(audiences, subject, "", "", role)
+		if err != nil {
+			return nil, err
+		}
+		s.recordSignIn(ctx, subject)
+		return &dto.SignInResponse{Token: token}, nil
+	}
+	// a locked subject gets no new challenges to guess codes with
+	if err = s.checkLocked(ctx, subject); err != nil {
+		return nil, err
+	}
+
+	token, err := cryptox.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := s.repos.MFARepo.CreateChallenge(ctx, &repo.MFAChallengeModel{
+		Subject:   subject,
+		TokenHash: cryptox.HashCode(s.hashSecret, token),
+		Role:      role,
+		Audiences: audiences,
+		ExpiresAt: time.Now().Add(s.challengeExpiration),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.MFAChallengeResponse{
+		Challenge:          token,
+		ExpiresAt:          challenge.ExpiresAt,
+		Methods:            []string{dto.MFAMethodTOTP, dto.MFAMethodRecoveryCode},
+		EnrollmentRequired: factor == nil,
+	}
+	if factor == nil {
+		response.Methods = []string{dto.MFAMethodTOTP}
+	}
+	return &dto.SignInResponse{MFA: response}, nil
+}
+
+// EnrollChallenge starts the enrollment of the subject of a challenge whose
+// role requires a second factor it has not enrolled yet
+func (s MFAService) EnrollChallenge(ctx context.Context, data *dto.MFAChallengeDTO) (*dto.TOTPEnrollmentResponse, error) {
+	challenge, err := s.pendingChallenge(ctx, data.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	return s.startEnrollment(ctx, challenge.Subject)
+}
+
+// VerifyChallenge verifies the second factor of a sign-in and returns the
+// token. If the factor is enrolled with the challenge, it is confirmed with
+// the code and the response carries the new recovery codes. After too many
+// wrong codes the challenge is dropped.
+func (s MFAService) VerifyChallenge(ctx context.Context, data *dto.MFAVerifyDTO) (*dto.MFAVerifyResponse, error) {
+	challenge, err := s.pendingChallenge(ctx, data.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	response := &dto.MFAVerifyResponse{}
+	factor, err := s.repos.MFARepo.GetTOTP(ctx, challenge.Subject)
+	if err != nil && !errors.Is(err, repo.DBItemNotFound) {
+		return nil, err
+	}
+
+	var ok bool
+	switch {
+	case factor == nil:
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeMFANotEnrolled, "enroll a second factor with the challenge first")
+	case factor.ConfirmedAt == nil:
+		if data.Code == "" {
+			return nil, slyerrors.BadRequest(slyerrors.ErrCodeMFANotEnrolled, "confirm the enrollment with a code")
+		}
+		response.RecoveryCodes, ok, err = s.confirmEnrollment(ctx, factor, data.Code, now)
+	default:
+		ok, err = s.verifyFactor(ctx, factor, &data.MFACodeDTO, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		attempts, err := s.repos.MFARepo.IncrementChallengeAttempts(ctx, challenge.ID)
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= s.maxAttempts {
+			if err = s.repos.MFARepo.UseChallenge(ctx, challenge.ID, now); err != nil && !errors.Is(err, repo.DBItemNotFound) {
+				return nil, err
+			}
+		}
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongMFACode, "wrong code")
+	}
+
+	err = s.repos.MFARepo.UseChallenge(ctx, challenge.ID, now)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeMFAChallengeNotFound, "no pending challenge")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response.Token, err = s.verifier.CreateToken(challenge.Audiences, challenge.Subject, "", "", challenge.Role)
+	if err != nil {
+		return nil, err
+	}
+	s.recordSignIn(ctx, challenge.Subject)
+	return response, nil
+}
+
+// recordSignIn records a password sign-in, the YIP admin has no account to
+// record it for
+func (s MFAService) recordSignIn(ctx context.Context, subject string) {
+	if subject != yipAdminID {
+		recordSignIn(ctx, s.repos, subject, repo.SignInMethodPassword, "")
+	}
+}
+
+// Status returns whether the principal enrolled a second factor
+func (s MFAService) Status(ctx context.Context, principal *verifier.Principal) (*dto.MFAStatusResponse, error) {
+	factor, err := s.confirmedFactor(ctx, principal.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &dto.MFAStatusResponse{
+		Enrolled: factor != nil,
+		Required: s.IsRequired(principal.Role),
+	}
+	if factor != nil {
+		if status.RecoveryCodesLeft, err = s.repos.MFARepo.CountRecoveryCodes(ctx, principal.ID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// StartEnrollment creates a new TOTP secret for the principal, it counts once
+// confirmed with a code
+func (s MFAService) StartEnrollment(ctx context.Context, principal *verifier.Principal) (*dto.TOTPEnrollmentResponse, error) {
+	return s.startEnrollment(ctx, principal.ID)
+}
+
+// ConfirmEnrollment confirms the TOTP factor of the principal with a code and
+// returns the recovery codes, they are shown this once only
+func (s MFAService) ConfirmEnrollment(ctx context.Context, principal *verifier.Principal, data *dto.TOTPCodeDTO) (*dto.RecoveryCodesResponse, error) {
+	factor, err := s.repos.MFARepo.GetTOTP(ctx, principal.ID)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeMFANotEnrolled, "no pending enrollment")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if factor.ConfirmedAt != nil {
+		return nil, slyerrors.Conflict(slyerrors.ErrCodeMFAAlreadyEnrolled, "second factor is enrolled already")
+	}
+
+	codes, ok, err := s.confirmEnrollment(ctx, factor, data.Code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongMFACode, "wrong code")
+	}
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the principal, a
+// TOTP code proves the factor
+func (s MFAService) RegenerateRecoveryCodes(ctx context.Context, principal *verifier.Principal, data *dto.TOTPCodeDTO) (*dto.RecoveryCodesResponse, error) {
+	factor, err := s.requireFactor(ctx, principal.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ok, err := s.verifyFactor(ctx, factor, &dto.MFACodeDTO{Code: data.Code}, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongMFACode, "wrong code")
+	}
+
+	codes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repos.MFARepo.ReplaceRecoveryCodes(ctx, principal.ID, hashes, now); err != nil {
+		return nil, err
+	}
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable removes the second factor of the principal, proven with a TOTP or
+// recovery code. Roles requiring a second factor can't disable it.
+func (s MFAService) Disable(ctx context.Context, principal *verifier.Principal, data *dto.MFACodeDTO) error {
+	if s.IsRequired(principal.Role) {
+		return slyerrors.BadRequest(slyerrors.ErrCodeMFARequired, "the role %s requires a second factor", principal.Role)
+	}
+	factor, err := s.requireFactor(ctx, principal.ID)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.verifyFactor(ctx, factor, data, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return slyerrors.Unauthorized(slyerrors.ErrCodeWrongMFACode, "wrong code")
+	}
+	return s.repos.MFARepo.DeleteTOTP(ctx, principal.ID)
+}
+
+// Reset removes the second factor of an account for admins, e.g. when its
+// device and recovery codes are lost. Accounts of a required role enroll again
+// at the next sign-in.
+func (s MFAService) Reset(ctx context.Context, accountId uuid.UUID) error {
+	return s.repos.MFARepo.DeleteTOTP(ctx, accountId.String())
+}
+
+func (s MFAService) startEnrollment(ctx context.Context, subject string) (*dto.TOTPEnrollmentResponse, error) {
+	secret, err := cryptox.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := cryptox.SealString(s.sealKey, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.repos.MFARepo.SaveTOTP(ctx, subject, sealed, time.Now())
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.Conflict(slyerrors.ErrCodeMFAAlreadyEnrolled, "second factor is enrolled already")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TOTPEnrollmentResponse{
+		Secret: secret,
+		URI:    cryptox.TOTPProvisioningURI(s.issuer, s.accountName(ctx, subject), secret),
+	}, nil
+}
+
+// confirmEnrollment confirms an unconfirmed factor if code is valid and
+// returns the new recovery codes
+func (s MFAService) confirmEnrollment(ctx context.Context, factor *repo.TOTPFactorModel, code string, now time.Time) ([]string, bool, error) {
+	if err := s.checkLocked(ctx, factor.Subject); err != nil {
+		return nil, false, err
+	}
+	secret, err := cryptox.OpenString(s.sealKey, factor.Secret)
+	if err != nil {
+		return nil, false, err
+	}
+	step, ok := cryptox.ValidateTOTP(secret, code, now, totpSkew)
+	if !ok {
+		s.fail(ctx, factor.Subject)
+		return nil, false, nil
+	}
+
+	codes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, false, err
+	}
+	err = s.repos.MFARepo.ConfirmTOTP(ctx, factor.Subject, step, hashes, now)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return codes, true, nil
+}
+
+// verifyFactor checks a TOTP code, or uses up a recovery code, of a confirmed
+// factor. Wrong codes count towards the lock of the subject.
+func (s MFAService) verifyFactor(ctx context.Context, factor *repo.TOTPFactorModel, data *dto.MFACodeDTO, now time.Time) (bool, error) {
+	if err := s.checkLocked(ctx, factor.Subject); err != nil {
+		return false, err
+	}
+	ok, err := s.checkFactor(ctx, factor, data, now)
+	if err == nil && !ok {
+		s.fail(ctx, factor.Subject)
+	}
+	return ok, err
+}
+
+// checkFactor checks the code of verifyFactor. Each TOTP code is accepted
+// once.
+func (s MFAService) checkFactor(ctx context.Context, factor *repo.TOTPFactorModel, data *dto.MFACodeDTO, now time.Time) (bool, error) {
+	var err error
+	if data.RecoveryCode != "" {
+		codeHash := cryptox.HashCode(s.hashSecret, cryptox.RecoveryCodes.Normalize(data.RecoveryCode))
+		err = s.repos.MFARepo.UseRecoveryCode(ctx, factor.Subject, codeHash, now)
+	} else {
+		var secret string
+		if secret, err = cryptox.OpenString(s.sealKey, factor.Secret); err != nil {
+			return false, err
+		}
+		step, ok := cryptox.ValidateTOTP(secret, data.Code, now, totpSkew)
+		if !ok {
+			return false, nil
+		}
+		err = s.repos.MFARepo.UseTOTPStep(ctx, factor.Subject, step, now)
+	}
+	if errors.Is(err, repo.DBItemNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// checkLocked returns ErrCodeMFALocked while the subject has maxFailures
+// wrong codes in the current window
+func (s MFAService) checkLocked(ctx context.Context, subject string) error {
+	return s.limiter.Check(ctx, slyerrors.ErrCodeMFALocked, "mfa_failure:"+subject, s.maxFailures)
+}
+
+// fail counts a wrong code of the subject
+func (s MFAService) fail(ctx context.Context, subject string) {
+	if s.maxFailures > 0 {
+		s.limiter.Count(ctx, "mfa_failure:"+subject)
+	}
+}
+
+// generateRecoveryCodes returns new recovery codes, grouped for display, and
+// their hashes
+func (s MFAService) generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, s.recoveryCodes)
+	hashes := make([]string, s.recoveryCodes)
+	for i := range codes {
+		code, err := cryptox.RecoveryCodes.Generate()
+		if err != nil {
+			return nil, nil, err
+		}
+		half := len(code) / 2
+		codes[i] = code[:half] + "-" + code[half:]
+		hashes[i] = cryptox.HashCode(s.hashSecret, code)
+	}
+	return codes, hashes, nil
+}
+
+func (s MFAService) pendingChallenge(ctx context.Context, token string) (*repo.MFAChallengeModel, error) {
+	challenge, err := s.repos.MFARepo.GetChallengeByTokenHash(ctx, cryptox.HashCode(s.hashSecret, token))
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeMFAChallengeNotFound, "no pending challenge")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UsedAt != nil || !challenge.ExpiresAt.After(time.Now()) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeMFAChallengeNotFound, "no pending challenge")
+	}
+	return challenge, nil
+}
+
+// confirmedFactor returns the confirmed factor of the subject, nil if it has none
+func (s MFAService) confirmedFactor(ctx context.Context, subject string) (*repo.TOTPFactorModel, error) {
+	factor, err := s.repos.MFARepo.GetTOTP(ctx, subject)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if factor.ConfirmedAt == nil {
+		return nil, nil
+	}
+	return factor, nil
+}
+
+func (s MFAService) requireFactor(ctx context.Context, subject string) (*repo.TOTPFactorModel, error) {
+	factor, err := s.confirmedFactor(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	if factor == nil {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeMFANotEnrolled, "no second factor enrolled")
+	}
+	return factor, nil
+}
+
+// accountName labels the factor in authenticator apps, the email of the
+// account or the username of the YIP admin
+func (s MFAService) accountName(ctx context.Context, subject string) string {
+	if subject == yipAdminID {
+		return s.config.API.Admin.Username
+	}
+	if accountId, err := uuid.Parse(subject); err == nil {
+		if account, err := s.repos.AccountRepo.GetByID(ctx, accountId); err == nil && account.Email != "" {
+			return account.Email
+		}
+	}
+	return fmt.Sprintf("account %s", subject)
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services/dto"
+	"yip/src/config"
+	"yip/src/cryptox"
+	"yip/src/providers"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPasswordResetExpirationInMin = 30
+	defaultPasswordResetMaxPerEmail     = 3
+	defaultPasswordResetMaxPerIp        = 20
+	passwordResetMailTimeout            = 30 * time.Second
+)
+
+// PasswordService resets and changes the password of credential accounts,
+// the accounts signing in with email and password. Both revoke the tokens
+// issued before.
+type PasswordService struct {
+	config      *config.Config
+	verifier    *verifier.Verifier
+	repos       *repo.Repositories
+	ep          *providers.EmailProvider
+	hashSecret  []byte
+	expiration  time.Duration
+	limiter     RequestLimiter
+	maxPerEmail int
+	maxPerIp    int
+}
+
+func NewPasswordService(config *config.Config, verifier *verifier.Verifier, repos *repo.Repositories, ep *providers.EmailProvider) PasswordService {
+	expirationInMin := config.Password.ResetExpirationInMin
+	if expirationInMin <= 0 {
+		expirationInMin = defaultPasswordResetExpirationInMin
+	}
+
+	return PasswordService{
+		config:      config,
+		verifier:    verifier,
+		repos:       repos,
+		ep:          ep,
+		hashSecret:  []byte(config.Pin.HashSecret),
+		expiration:  time.Duration(expirationInMin) * time.Minute,
+		limiter:     NewRequestLimiter(config, repos),
+		maxPerEmail: limitOrDefault(config.Password.MaxRequestsPerEmail, defaultPasswordResetMaxPerEmail),
+		maxPerIp:    limitOrDefault(config.Password.MaxRequestsPerIp, defaultPasswordResetMaxPerIp),
+	}
+}
+
+// ForgotPassword mails a single-use reset token to the email if it belongs to
+// a credential account. The response doesn't tell whether it does: the reset
+// is started after responding, so both cases take the same time. Requests are
+// limited per IP and per email, a former pending reset of the account is
+// dropped. In test mode the reset is started right away and the response
+// carries the token.
+func (s PasswordService) ForgotPassword(ctx context.Context, data *dto.PasswordForgotDTO, ip string) (*dto.PasswordForgotResponse, error) {
+	if err := s.limiter.Throttle(ctx, slyerrors.ErrCodePasswordResetRateLimited, "password_reset_ip:"+ip, s.maxPerIp); err != nil {
+		return nil, err
+	}
+	if err := s.limiter.Throttle(ctx, slyerrors.ErrCodePasswordResetRateLimited, "password_reset:"+strings.ToLower(data.Email), s.maxPerEmail); err != nil {
+		return nil, err
+	}
+
+	if s.config.Test.On {
+		return s.startReset(ctx, data, ip)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), passwordResetMailTimeout)
+		defer cancel()
+
+		if _, err := s.startReset(ctx, data, ip); err != nil {
+			log.Println("password reset:", err.Error())
+		}
+	}()
+
+	return &dto.PasswordForgotResponse{}, nil
+}
+
+// startReset creates the reset of the account of the email and mails the
+// token, it does nothing if the email doesn't belong to a credential account
+func (s PasswordService) startReset(ctx context.Context, data *dto.PasswordForgotDTO, ip string) (*dto.PasswordForgotResponse, error) {
+	response := &dto.PasswordForgotResponse{}
+
+	account, err := s.repos.AccountRepo.GetByEmail(ctx, data.Email)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return response, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if account.PasswordHashed == "" || account.DeletedAt != nil {
+		return response, nil
+	}
+
+	now := time.Now()
+	if err = s.repos.PasswordResetRepo.ExpirePending(ctx, account.ID, now); err != nil {
+		return nil, err
+	}
+
+	token, err := cryptox.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	reset, err := s.repos.PasswordResetRepo.Create(ctx, &repo.PasswordResetModel{
+		AccountID:   account.ID,
+		TokenHash:   cryptox.HashCode(s.hashSecret, token),
+		ExpiresAt:   now.Add(s.expiration),
+		RequestedIP: ip,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var link string
+	if s.config.Password.ResetUrl != "" {
+		link = fmt.Sprintf("%s?t=%s", s.config.Password.ResetUrl, url.QueryEscape(token))
+	}
+
+	if s.config.Test.On {
+		response.Token = token
+		response.Link = link
+		return response, nil
+	}
+
+	err = s.ep.SendPasswordResetMail(ctx, data.Email, data.Language, providers.PasswordResetMail{
+		Token:     token,
+		Link:      link,
+		ExpiresAt: reset.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ResetPassword sets the new password of the account of a reset token. The
+// token is used up and the tokens of the account are revoked.
+func (s PasswordService) ResetPassword(ctx context.Context, data *dto.PasswordResetDTO, ip string) error {
+	reset, err := s.repos.PasswordResetRepo.GetByTokenHash(ctx, cryptox.HashCode(s.hashSecret, data.Token))
+	if errors.Is(err, repo.DBItemNotFound) {
+		return slyerrors.NotFound(slyerrors.ErrCodePasswordResetNotFound, "no pending password reset")
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if reset.UsedAt != nil || !reset.ExpiresAt.After(now) {
+		return slyerrors.NotFound(slyerrors.ErrCodePasswordResetNotFound, "no pending password reset")
+	}
+
+	passwordHash, err := cryptox.HashPassword(data.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	err = s.repos.PasswordResetRepo.Redeem(ctx, reset, passwordHash, now)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return slyerrors.NotFound(slyerrors.ErrCodePasswordResetNotFound, "no pending password reset")
+	}
+	if err != nil {
+		return err
+	}
+
+	s.alert(ctx, reset.AccountID, "The password of your account was reset.", ip)
+	return nil
+}
+
+// ChangePassword sets the new password of the principal's account if the
+// current one matches. The tokens of the account are revoked, the returned
+// token replaces the principal's one.
+func (s PasswordService) ChangePassword(ctx context.Context, principal *verifier.Principal, data *dto.PasswordChangeDTO, ip string) (*verifier.Token, error) {
+	accountId, err := uuid.Parse(principal.ID)
+	if err != nil {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeParsingUUID, err.Error())
+	}
+	account, err := s.repos.AccountRepo.GetByID(ctx, accountId)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeCantCreateOrGetAccount, "account not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if account.PasswordHashed == "" {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeNoPassword, "account has no password")
+	}
+	if !cryptox.CheckPasswordHash(data.CurrentPassword, account.PasswordHashed) {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWrongPassword, "current password is incorrect")
+	}
+
+	passwordHash, err := cryptox.HashPassword(data.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.repos.AccountRepo.ChangePassword(ctx, account.ID, passwordHash, now)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeCantCreateOrGetAccount, "account not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repos.PasswordResetRepo.ExpirePending(ctx, account.ID, now); err != nil {
+		return nil, err
+	}
+
+	s.alert(ctx, account.ID, "The password of your account was changed.", ip)
+
+	return s.verifier.CreateToken(principal.Audiences, principal.ID, principal.ECDSAAddress, principal.SLYWalletAddress, principal.Role)
+}
+
+// alert sends a security alert to the email of the account, failures are
+// logged only
+func (s PasswordService) alert(ctx context.Context, accountId uuid.UUID, event string, ip string) {
+	account, err := s.repos.AccountRepo.GetByID(ctx, accountId)
+	if err != nil {
+		return
+	}
+	sendSecurityAlert(ctx, s.config, s.ep, account.Email, "", event, ip)
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services/dto"
+	"yip/src/config"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+)
+
+const (
+	defaultGracePeriodInDays  = 30
+	defaultPurgeIntervalInMin = 60
+	defaultMaxAuthAgeInSec    = 5 * 60
+)
+
+// PrivacyService exports the data of an account and deletes accounts. A
+// deleted account is anonymized right away, its keys are detached and its
+// tokens revoked. After the grace period the account is purged, the deletion
+// record and the session audits are kept.
+type PrivacyService struct {
+	repos         *repo.Repositories
+	gracePeriod   time.Duration
+	purgeInterval time.Duration
+	maxAuthAge    time.Duration
+}
+
+func NewPrivacyService(config *config.Config, repos *repo.Repositories) PrivacyService {
+	gracePeriodInDays := config.Deletion.GracePeriodInDays
+	if gracePeriodInDays <= 0 {
+		gracePeriodInDays = defaultGracePeriodInDays
+	}
+	purgeIntervalInMin := config.Deletion.PurgeIntervalInMin
+	if purgeIntervalInMin == 0 {
+		purgeIntervalInMin = defaultPurgeIntervalInMin
+	}
+	maxAuthAgeInSec := config.Deletion.MaxAuthAgeInSec
+	if maxAuthAgeInSec <= 0 {
+		maxAuthAgeInSec = defaultMaxAuthAgeInSec
+	}
+
+	return PrivacyService{
+		repos:         repos,
+		gracePeriod:   time.Duration(gracePeriodInDays) * 24 * time.Hour,
+		purgeInterval: time.Duration(purgeIntervalInMin) * time.Minute,
+		maxAuthAge:    time.Duration(maxAuthAgeInSec) * time.Second,
+	}
+}
+
+// Export bundles the data stored about the account
+func (s PrivacyService) Export(ctx context.Context, account *repo.AccountModel) (*dto.AccountExport, error) {
+	export := &dto.AccountExport{
+		ExportedAt: time.Now(),
+		Account:    account,
+	}
+
+	var err error
+	if export.Devices, err = s.repos.EcdsaRepo.GetByAccountID(ctx, account.ID); err != nil {
+		return nil, err
+	}
+	if export.SLYWallets, err = s.repos.SlyWalletRepo.GetByAccountID(ctx, account.ID); err != nil {
+		return nil, err
+	}
+	if export.WalletLinks, err = s.walletLinks(ctx, export.Devices, export.SLYWallets); err != nil {
+		return nil, err
+	}
+	if export.InvitationCodes, err = s.invitationCodes(ctx, account, export.SLYWallets); err != nil {
+		return nil, err
+	}
+	if export.PushTokens, err = s.repos.PushTokenRepo.ListByAccount(ctx, account.ID); err != nil {
+		return nil, err
+	}
+	if export.EmailChanges, err = s.repos.EmailChangeRepo.ListByAccount(ctx, account.ID); err != nil {
+		return nil, err
+	}
+	if export.AccountLinks, err = s.repos.AccountLinkRepo.ListByAccount(ctx, account.ID); err != nil {
+		return nil, err
+	}
+	if export.Passkeys, err = s.repos.WebAuthnRepo.GetCredentialsByAccountId(ctx, account.ID); err != nil {
+		return nil, err
+	}
+
+	eoas := make([]string, len(export.Devices))
+	for i, device := range export.Devices {
+		eoas[i] = device.Address
+	}
+	if export.LoginHistory, err = s.repos.SessionAuditRepo.ListByEOAs(ctx, eoas); err != nil {
+		return nil, err
+	}
+	if export.SignIns, err = s.repos.SignInRepo.ListByAccount(ctx, account.ID); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// DeleteAccount anonymizes the account, detaches its keys and revokes its
+// tokens. The account is purged after the grace period. The principal must
+// have signed in recently, a leaked or long-lived token can't delete the
+// account.
+func (s PrivacyService) DeleteAccount(ctx context.Context, account *repo.AccountModel, principal *verifier.Principal) (*repo.AccountDeletionModel, error) {
+	if !principal.AuthenticatedWithin(s.maxAuthAge) {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeReauthenticationRequired, "sign in again to delete the account")
+	}
+
+	now := time.Now()
+	deletion, err := s.repos.AccountDeletionRepo.Anonymize(ctx, &repo.AccountDeletionModel{
+		AccountID:   account.ID,
+		RequestedBy: principal.ID,
+		RequestedAt: now,
+		PurgeAfter:  now.Add(s.gracePeriod),
+	})
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.Conflict(slyerrors.ErrCodeAccountDeleted, "account is deleted already")
+	}
+	return deletion, err
+}
+
+// PurgeDue deletes the accounts whose grace period ended before now and
+// returns how many were purged. A failed purge is logged and retried on the
+// next run, it doesn't hold up the other accounts.
+func (s PrivacyService) PurgeDue(ctx context.Context, now time.Time) (int, error) {
+	deletions, err := s.repos.AccountDeletionRepo.ListDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	var errs []error
+	for _, deletion := range deletions {
+		if err = s.purge(ctx, deletion, now); err != nil {
+			log.Printf("could not purge deleted account %s: %v", deletion.AccountID, err)
+			errs = append(errs, err)
+			continue
+		}
+		purged++
+	}
+	if len(errs) > 0 {
+		return purged, fmt.Errorf("%d of %d accounts not purged: %w", len(errs), len(deletions), errors.Join(errs...))
+	}
+	return purged, nil
+}
+
+func (s PrivacyService) purge(ctx context.Context, deletion repo.AccountDeletionModel, now time.Time) error {
+	err := s.repos.AccountRepo.Delete(ctx, deletion.AccountID)
+	if err != nil && !errors.Is(err, repo.DBItemNotFound) {
+		return err
+	}
+	return s.repos.AccountDeletionRepo.MarkPurged(ctx, deletion.ID, now)
+}
+
+// RunPurger purges due accounts every purge interval until ctx is done. A
+// negative purge_interval_in_min disables it.
+func (s PrivacyService) RunPurger(ctx context.Context) {
+	if s.purgeInterval < 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			purged, err := s.PurgeDue(ctx, now)
+			if err != nil {
+				log.Println("could not purge deleted accounts:", err)
+			}
+			if purged > 0 {
+				log.Println("purged deleted accounts:", purged)
+			}
+		}
+	}
+}
+
+// walletLinks returns the connections of the devices and the wallets, each once
+func (s PrivacyService) walletLinks(ctx context.Context, devices []repo.EcdsaModel, wallets []repo.SlyWalletModel) ([]repo.EcdsaSlyWalletModel, error) {
+	links := []repo.EcdsaSlyWalletModel{}
+	seen := map[[2]string]bool{}
+	add := func(connections []repo.EcdsaSlyWalletModel) {
+		for _, c := range connections {
+			key := [2]string{c.EcdsaAddress, c.OnChainAccountAddress}
+			if !seen[key] {
+				seen[key] = true
+				links = append(links, c)
+			}
+		}
+	}
+
+	for _, device := range devices {
+		connections, err := s.repos.EcdsaSlyWalletRepo.GetByEcdsaAddress(ctx, device.Address)
+		if err != nil {
+			return nil, err
+		}
+		add(connections)
+	}
+	for _, wallet := range wallets {
+		connections, err := s.repos.EcdsaSlyWalletRepo.GetBySlyWalletAddress(ctx, wallet.Address)
+		if err != nil {
+			return nil, err
+		}
+		add(connections)
+	}
+	return links, nil
+}
+
+// invitationCodes returns the codes the account and its wallets were invited with
+func (s PrivacyService) invitationCodes(ctx context.Context, account *repo.AccountModel, wallets []repo.SlyWalletModel) ([]repo.InvitationCodeModel, error) {
+	codes := []string{account.InvitationCode}
+	for _, wallet := range wallets {
+		codes = append(codes, wallet.InvitationCode)
+	}
+
+	invitationCodes := []repo.InvitationCodeModel{}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		invitationCode, err := s.repos.InvitationCodeRepo.GetByCode(ctx, code)
+		if errors.Is(err, repo.DBItemNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		invitationCodes = append(invitationCodes, *invitationCode)
+	}
+	return invitationCodes, nil
+}
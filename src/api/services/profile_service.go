@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services/dto"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+
+	"github.com/google/uuid"
+)
+
+// ProfileService serves the /me resource, the account of the principal with
+// its devices (ecdsa keys) and SLYWallets
+type ProfileService struct {
+	repos *repo.Repositories
+}
+
+func NewProfileService(repos *repo.Repositories) ProfileService {
+	return ProfileService{
+		repos: repos,
+	}
+}
+
+// GetAccount returns the account with id, it fetches the entity of the me middleware
+func (s ProfileService) GetAccount(ctx context.Context, id string) (*repo.AccountModel, error) {
+	accountId, err := uuid.Parse(id)
+	if err != nil {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeParsingUUID, err.Error())
+	}
+	account, err := s.repos.AccountRepo.GetByID(ctx, accountId)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeCantCreateOrGetAccount, "account not found")
+	}
+	return account, err
+}
+
+// UpdateProfile sets the given profile fields, the rest of the account is not
+// written, so concurrent changes of e.g. the email are kept. A changed phone
+// has to be verified again.
+func (s ProfileService) UpdateProfile(ctx context.Context, account *repo.AccountModel, data *dto.ProfilePatchDTO) (*repo.AccountModel, error) {
+	updated := *account
+	data.Apply(&updated)
+	return s.repos.AccountRepo.UpdateProfile(ctx, &updated)
+}
+
+// ListDevices returns the ecdsa keys of the account
+func (s ProfileService) ListDevices(ctx context.Context, account *repo.AccountModel) ([]repo.EcdsaModel, error) {
+	return s.repos.EcdsaRepo.GetByAccountID(ctx, account.ID)
+}
+
+// RemoveDevice deletes an ecdsa key of the account with its push tokens and
+// wallet connections. The key of the principal's token can't be removed.
+func (s ProfileService) RemoveDevice(ctx context.Context, account *repo.AccountModel, principal *verifier.Principal, address string) error {
+	if strings.EqualFold(address, principal.ECDSAAddress) {
+		return slyerrors.BadRequest(slyerrors.ErrCodeCurrentDevice, "the device of the current token can't be removed")
+	}
+
+	devices, err := s.ListDevices(ctx, account)
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if strings.EqualFold(device.Address, address) {
+			return s.repos.EcdsaRepo.Delete(ctx, device.Address)
+		}
+	}
+	return slyerrors.Forbidden(slyerrors.ErrCodeDeviceNotOfAccount, "device is not of account")
+}
+
+// ListWallets returns the SLYWallets of the account
+func (s ProfileService) ListWallets(ctx context.Context, account *repo.AccountModel) ([]repo.SlyWalletModel, error) {
+	return s.repos.SlyWalletRepo.GetByAccountID(ctx, account.ID)
+}
+
+// RemoveWallet unlinks a SLYWallet from the account, the wallet on chain is
+// not touched
+func (s ProfileService) RemoveWallet(ctx context.Context, account *repo.AccountModel, address string) error {
+	wallets, err := s.ListWallets(ctx, account)
+	if err != nil {
+		return err
+	}
+	for _, wallet := range wallets {
+		if !strings.EqualFold(wallet.Address, address) {
+			continue
+		}
+		if err = s.repos.SlyWalletRepo.Delete(ctx, wallet.Address); err != nil {
+			return err
+		}
+		if strings.EqualFold(account.LastUsedSlyWallet, wallet.Address) {
+			updated := *account
+			updated.LastUsedSlyWallet = ""
+			_, err = s.repos.AccountRepo.Update(ctx, &updated)
+		}
+		return err
+	}
+	return slyerrors.Forbidden(slyerrors.ErrCodeWalletNotOfAccount, "wallet is not of account")
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services/dto"
+	"yip/src/config"
+	"yip/src/providers"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMaxPushesPerAddress = 5
+	defaultMaxPushesPerIp      = 20
+)
+
+type PushService struct {
+	repos    *repo.Repositories
+	provider providers.PushProvider
+	limiter  RequestLimiter
+	// login pushes per request window, a limit of zero or less is disabled
+	maxPerAddress int
+	maxPerIp      int
+}
+
+func NewPushService(config *config.Config, repos *repo.Repositories, provider providers.PushProvider) PushService {
+	return PushService{
+		repos:         repos,
+		provider:      provider,
+		limiter:       NewRequestLimiter(config, repos),
+		maxPerAddress: limitOrDefault(config.Push.MaxPerAddress, defaultMaxPushesPerAddress),
+		maxPerIp:      limitOrDefault(config.Push.MaxPerIp, defaultMaxPushesPerIp),
+	}
+}
+
+// RegisterToken stores the push token of the device key the principal signed
+// in with
+func (s PushService) RegisterToken(ctx context.Context, principal *verifier.Principal, data *dto.PushTokenRequestDTO) error {
+	accountId, err := uuid.Parse(principal.ID)
+	if err != nil {
+		return slyerrors.BadRequest(slyerrors.ErrCodeParsingUUID, err.Error())
+	}
+
+	key, err := s.repos.EcdsaRepo.GetByAddress(ctx, principal.ECDSAAddress)
+	if err != nil {
+		if errors.Is(err, repo.DBItemNotFound) {
+			return slyerrors.Forbidden(slyerrors.ErrCodeDeviceNotOfAccount, "device key not found")
+		}
+		return err
+	}
+	if key.AccountID != accountId {
+		return slyerrors.Forbidden(slyerrors.ErrCodeDeviceNotOfAccount, "device key does not belong to account")
+	}
+
+	_, err = s.repos.PushTokenRepo.Upsert(ctx, &repo.PushTokenModel{
+		Token:        data.Token,
+		EcdsaAddress: key.Address,
+		AccountID:    key.AccountID,
+		Platform:     data.Platform,
+	})
+	return err
+}
+
+// UnregisterToken removes the push token of the device key the principal
+// signed in with
+func (s PushService) UnregisterToken(ctx context.Context, principal *verifier.Principal, token string) error {
+	return s.repos.PushTokenRepo.Delete(ctx, token, principal.ECDSAAddress)
+}
+
+// ThrottleLogin counts a login push to the device key address requested by
+// ip, the ip is limited first, so its requests do not use up the quota of the
+// address
+func (s PushService) ThrottleLogin(ctx context.Context, address string, ip string) error {
+	if err := s.limiter.Throttle(ctx, slyerrors.ErrCodePushRateLimited, "push_ip:"+ip, s.maxPerIp); err != nil {
+		return err
+	}
+	return s.limiter.Throttle(ctx, slyerrors.ErrCodePushRateLimited, "push:"+strings.ToLower(address), s.maxPerAddress)
+}
+
+// NotifyLogin sends the login request to all devices of the account the
+// device key address belongs to. It returns the number of devices notified,
+// 0 if the address is unknown or no device registered a push token. The
+// caller must not pass the number on, it tells which addresses have devices.
+func (s PushService) NotifyLogin(ctx context.Context, address string, n providers.PushNotification) (int, error) {
+	key, err := s.repos.EcdsaRepo.GetByAddress(ctx, address)
+	if err != nil {
+		if errors.Is(err, repo.DBItemNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	tokens, err := s.repos.PushTokenRepo.ListByAccount(ctx, key.AccountID)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, t := range tokens {
+		if err = s.provider.Send(ctx, t.Token, t.Platform, n); err != nil {
+			log.Printf("push to device %s failed: %v", t.EcdsaAddress, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
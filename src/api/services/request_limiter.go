@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"yip/src/config"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+)
+
+const defaultRequestWindowInSec = 60 * 60
+
+// RequestLimiter counts requests per subject in slyip.request_limit, so all
+// replicas apply the limits together. It uses the windows of the pin request
+// limits, which share the table. Subjects are prefixed with the request they
+// count, e.g. "push:0x..." or "push_ip:...".
+type RequestLimiter struct {
+	limitRepo *repo.RequestLimitRepository
+	window    time.Duration
+}
+
+func NewRequestLimiter(config *config.Config, repos *repo.Repositories) RequestLimiter {
+	windowInSec := config.Pin.RequestWindowInSec
+	if windowInSec <= 0 {
+		windowInSec = defaultRequestWindowInSec
+	}
+
+	return RequestLimiter{
+		limitRepo: repos.RequestLimitRepo,
+		window:    time.Duration(windowInSec) * time.Second,
+	}
+}
+
+// Throttle counts a request of the subject and returns a TooManyRequests
+// error of code if the subject made more than max requests in the current
+// window. A max of zero or less disables the limit.
+func (l RequestLimiter) Throttle(ctx context.Context, code string, subject string, max int) error {
+	if max <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	windowStart := now.Truncate(l.window)
+	if err := l.limitRepo.DeleteBefore(ctx, windowStart); err != nil {
+		log.Println("could not delete request limits:", err)
+	}
+
+	requests, err := l.limitRepo.Increment(ctx, subject, windowStart)
+	if err != nil {
+		return err
+	}
+	if requests > max {
+		return slyerrors.TooManyRequests(code, "too many requests, retry after %s", windowStart.Add(l.window).Format(time.RFC3339)).
+			WithRetryAfter(windowStart.Add(l.window).Sub(now))
+	}
+	return nil
+}
+
+// Check returns a TooManyRequests error of code if the subject was counted
+// max times or more in the current window, without counting it. Together
+// with Count it limits failures, e.g. wrong codes, rather than requests.
+func (l RequestLimiter) Check(ctx context.Context, code string, subject string, max int) error {
+	if max <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	windowStart := now.Truncate(l.window)
+	counted, err := l.limitRepo.Get(ctx, subject, windowStart)
+	if err != nil {
+		return err
+	}
+	if counted >= max {
+		return slyerrors.TooManyRequests(code, "too many failed attempts, retry after %s", windowStart.Add(l.window).Format(time.RFC3339)).
+			WithRetryAfter(windowStart.Add(l.window).Sub(now))
+	}
+	return nil
+}
+
+// Count counts the subject in the current window, failures are logged only
+func (l RequestLimiter) Count(ctx context.Context, subject string) {
+	windowStart := time.Now().Truncate(l.window)
+	if _, err := l.limitRepo.Increment(ctx, subject, windowStart); err != nil {
+		log.Printf("could not count %s: %s", subject, err)
+	}
+}
+
+// limitOrDefault is the configured limit, or def if it is not configured
+func limitOrDefault(limit int, def int) int {
+	if limit == 0 {
+		return def
+	}
+	return limit
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"log"
+	"yip/src/repositories/repo"
+
+	"github.com/google/uuid"
+)
+
+type SessionAuditService struct {
+	repos *repo.Repositories
+}
+
+func NewSessionAuditService(repos *repo.Repositories) SessionAuditService {
+	return SessionAuditService{
+		repos: repos,
+	}
+}
+
+// Record stores the outcome of a session. A failing audit does not fail the
+// session flow, so errors are only logged.
+func (s SessionAuditService) Record(ctx context.Context, audit *repo.SessionAuditModel) {
+	_, err := s.repos.SessionAuditRepo.Create(ctx, audit)
+	if err != nil {
+		log.Printf("session %s: could not record outcome %s: %v", audit.SessionID, audit.Outcome, err)
+	}
+}
+
+// RecordSignIn stores a sign-in of an account other than by QR session. A
+// failing record does not fail the sign-in, so errors are only logged.
+func (s SessionAuditService) RecordSignIn(ctx context.Context, accountId string, method string, eoa string) {
+	recordSignIn(ctx, s.repos, accountId, method, eoa)
+}
+
+func recordSignIn(ctx context.Context, repos *repo.Repositories, accountId string, method string, eoa string) {
+	uu, err := uuid.Parse(accountId)
+	if err == nil {
+		_, err = repos.SignInRepo.Create(ctx, &repo.SignInModel{AccountID: uu, Method: method, EOA: eoa})
+	}
+	if err != nil {
+		log.Printf("account %s: could not record %s sign-in: %v", accountId, method, err)
+	}
+}
@@ -99,6 +99,11 @@ func (s SIWEService) CreateToken(audiences []string, accountId string, ecdsaAddr
 	return s.verifier.CreateToken(audiences, accountId, ecdsaAddress, slyWalletAddress, role)
 }
 
+// SignHandoff signs the QR code content of a session, wallets verify it with the JWKS
+func (s SIWEService) SignHandoff(claims *verifier.HandoffClaims, expirationTimeInSec int64) (string, error) {
+	return s.verifier.SignHandoff(claims, expirationTimeInSec)
+}
+
 func (s SIWEService) GetOrCreateAccount(context context.Context, address string) (repositories.ECDSAKey, error) {
 	return s.userDB.GetOrCreateECDSAKey(context, address)
 }
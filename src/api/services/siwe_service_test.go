@@ -13,7 +13,7 @@ var SepoliaWalletPrivateKey = "a5ec116d46f3ec04c7337be7af353a3c20eb567ba76430402
 var userWallet = GetWallet()
 
 func TestSIWE(t *testing.T) {
-	s := NewSIWEService(nil, nil, nil, nil)
+	s := NewSIWEService(nil, nil, nil, nil, nil)
 	domain := "http://localhost:3000"
 	c, err := s.Challenge(&dto.ChallengeRequestDTO{
 		ChainId: "111155551111",
@@ -2,10 +2,15 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/google/uuid"
 	"log"
+	"math/big"
+	"time"
 	"yip/src/api/middleware"
 	"yip/src/api/services/dto"
 	"yip/src/config"
@@ -13,12 +18,20 @@ import (
 	"yip/src/httpx"
 	"yip/src/providers"
 	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+)
+
+const (
+	receiptPollPeriod = 3 * time.Second
+
+	defaultMaxRelaysPerClient = 100
 )
 
 type SLYWalletService struct {
 	slyWalletManager *contracts.WalletManager
 	ByIdMiddleware   middleware.EntityMiddleware[*dto.SLYBase]
 	repos            *repo.Repositories
+	limiter          RequestLimiter
 	Config           *config.Config
 }
 
@@ -30,6 +43,7 @@ func NewSLYWalletService(
 	s := SLYWalletService{
 		slyWalletManager: slyWalletManager,
 		repos:            repos,
+		limiter:          NewRequestLimiter(config, repos),
 		Config:           config,
 	}
 
@@ -193,3 +207,181 @@ func (s SLYWalletService) IsControllerKeyOf(controllerKey common.Address, slyWal
 		return nil
 	}
 }
+
+func (s SLYWalletService) GetKeyRole(ctx context.Context, slyWalletAddress common.Address, key common.Address) (contracts.Role, error) {
+	return s.slyWalletManager.GetKeyRole(ctx, slyWalletAddress, key)
+}
+
+// PrepareExecute binds the call to the signer's current nonce and calculates the digest to sign
+func (s SLYWalletService) PrepareExecute(ctx context.Context, call *dto.ExecuteCall, signer common.Address) (*dto.PreparedExecute, error) {
+	value, err := call.ValueWei()
+	if err != nil {
+		return nil, err
+	}
+	data, err := call.CallData()
+	if err != nil {
+		return nil, err
+	}
+
+	walletAddress := common.HexToAddress(call.SLYWalletAddress)
+	nonce, err := s.slyWalletManager.GetNonce(ctx, walletAddress, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := s.slyWalletManager.ExecuteDigest(ctx, walletAddress, common.HexToAddress(call.To), value, data, signer, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PreparedExecute{
+		ExecuteCall: *call,
+		Signer:      signer.Hex(),
+		Nonce:       nonce.String(),
+		Digest:      hexutil.Encode(digest[:]),
+	}, nil
+}
+
+// ThrottleRelay counts a relay for the client and fails once the client
+// relayed more than its MaxRelays in the current window
+func (s SLYWalletService) ThrottleRelay(ctx context.Context, clientId string) error {
+	max := defaultMaxRelaysPerClient
+	if c := s.Config.ClientById(clientId); c != nil {
+		max = limitOrDefault(c.MaxRelays, defaultMaxRelaysPerClient)
+	}
+	return s.limiter.Throttle(ctx, slyerrors.ErrCodeRelayRateLimited, "relay:"+clientId, max)
+}
+
+// RelayExecute submits a prepared call signed by its signer as meta-transaction
+func (s SLYWalletService) RelayExecute(ctx context.Context, prepared *dto.PreparedExecute, signature []byte) (*providers.TransactionTicket, error) {
+	value, err := prepared.ValueWei()
+	if err != nil {
+		return nil, err
+	}
+	data, err := prepared.CallData()
+	if err != nil {
+		return nil, err
+	}
+	nonce, ok := new(big.Int).SetString(prepared.Nonce, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid nonce: %s", prepared.Nonce)
+	}
+
+	tx, err := s.slyWalletManager.RelayExecuteWithSignature(
+		ctx,
+		common.HexToAddress(prepared.SLYWalletAddress),
+		common.HexToAddress(prepared.To),
+		value,
+		data,
+		common.HexToAddress(prepared.Signer),
+		nonce,
+		signature,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &providers.TransactionTicket{
+		TransactionType: contracts.TransactionTypeExecuteWithSignature,
+		TransactionHash: tx.Hash().Hex(),
+	}, nil
+}
+
+// AddControllerKey relays AddKey, the key is registered with WaitForControllerKey once mined
+func (s SLYWalletService) AddControllerKey(ctx context.Context, slyWalletAddress common.Address, key common.Address, role contracts.Role) (*providers.TransactionTicket, error) {
+	tx, err := s.slyWalletManager.AddKey(ctx, slyWalletAddress, key, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &providers.TransactionTicket{
+		TransactionType: contracts.TransactionTypeAddKey,
+		TransactionHash: tx.Hash().Hex(),
+	}, nil
+}
+
+// WaitForControllerKey polls the receipt of an AddKey transaction until it is mined
+// or ctx is done. On success the key is added to the wallet's account.
+func (s SLYWalletService) WaitForControllerKey(
+	ctx context.Context,
+	transactionHash string,
+	slyWalletAddress common.Address,
+	key common.Address,
+	role contracts.Role,
+) (string, error) {
+	h := common.HexToHash(transactionHash)
+	ticker := time.NewTicker(receiptPollPeriod)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := s.slyWalletManager.GetTransactionReceipt(h)
+		if err == nil {
+			if receipt.Status == types.ReceiptStatusFailed {
+				return providers.TransactionStatusFailed, nil
+			}
+			return providers.TransactionStatusSuccess, s.registerControllerKey(ctx, slyWalletAddress, key, role)
+		}
+		if slyerrors.Cause(err).Kind != slyerrors.KindNotFound {
+			return "", err
+		}
+
+		select {
+		case <-ctx.Done():
+			return providers.TransactionStatusPending, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckControllerKey returns ErrCodeKeyOfOtherAccount if the key is registered
+// to another account than the one of the SLYWallet. Such a key is not moved
+// silently, the accounts have to be linked and merged instead.
+func (s SLYWalletService) CheckControllerKey(ctx context.Context, slyWalletAddress common.Address, key common.Address) error {
+	_, _, err := s.controllerKey(ctx, slyWalletAddress, key)
+	return err
+}
+
+// controllerKey returns the SLYWallet and the registered key, the key is nil
+// if it is not registered yet
+func (s SLYWalletService) controllerKey(ctx context.Context, slyWalletAddress common.Address, key common.Address) (*repo.SlyWalletModel, *repo.EcdsaModel, error) {
+	slyWallet, err := s.repos.SlyWalletRepo.GetByAddress(ctx, slyWalletAddress.Hex())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get SlyWallet by address: %w", err)
+	}
+
+	existing, err := s.repos.EcdsaRepo.GetByAddress(ctx, key.Hex())
+	if errors.Is(err, repo.DBItemNotFound) {
+		return slyWallet, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing.AccountID != slyWallet.AccountID {
+		return nil, nil, slyerrors.Conflict(slyerrors.ErrCodeKeyOfOtherAccount, "the key belongs to another account, link the accounts to use it")
+	}
+	return slyWallet, existing, nil
+}
+
+func (s SLYWalletService) registerControllerKey(ctx context.Context, slyWalletAddress common.Address, key common.Address, role contracts.Role) error {
+	slyWallet, existing, err := s.controllerKey(ctx, slyWalletAddress, key)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		_, err = s.repos.EcdsaRepo.Create(ctx, &repo.EcdsaModel{
+			Address:   key.Hex(),
+			AccountID: slyWallet.AccountID,
+		})
+		if err != nil {
+			return fmt.Errorf("could not add ecdsa key : %w", err)
+		}
+	}
+
+	_, err = s.repos.EcdsaSlyWalletRepo.AddECDSAToSlyWallet(ctx, key.Hex(), slyWallet.Address, int32(role))
+	if err != nil {
+		return fmt.Errorf("could not add ecdsa key : %w", err)
+	}
+
+	return nil
+}
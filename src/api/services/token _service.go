@@ -2,10 +2,16 @@ package services
 
 import (
 	"context"
+	"errors"
+	"time"
 	"yip/src/api/auth/verifier"
 	"yip/src/config"
 	"yip/src/providers"
 	"yip/src/repositories"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+
+	"github.com/google/uuid"
 )
 
 type TokenService struct {
@@ -13,6 +19,7 @@ type TokenService struct {
 	verifier    *verifier.Verifier
 	db          repositories.Database
 	ethProvider *providers.EthProvider
+	repos       *repo.Repositories
 }
 
 func NewTokenService(
@@ -20,19 +27,57 @@ func NewTokenService(
 	verifier *verifier.Verifier,
 	db repositories.Database,
 	ethProvider *providers.EthProvider,
+	repos *repo.Repositories,
 ) TokenService {
 	return TokenService{
 		config:      config,
 		verifier:    verifier,
 		db:          db,
 		ethProvider: ethProvider,
+		repos:       repos,
 	}
 }
 
-func (s TokenService) RefreshToken(token string) (*verifier.Token, error) {
+func (s TokenService) RefreshToken(ctx context.Context, token string) (*verifier.Token, error) {
+	if _, err := s.VerifyToken(ctx, token); err != nil {
+		return nil, err
+	}
 	return s.verifier.RefreshToken(token)
 }
 
-func (s TokenService) VerifyToken(token string) (*verifier.Principal, error) {
-	return s.verifier.VerifyToken(context.Background(), token)
+func (s TokenService) VerifyToken(ctx context.Context, token string) (*verifier.Principal, error) {
+	principal, err := s.verifier.VerifyToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.CheckRevoked(ctx, principal); err != nil {
+		return nil, err
+	}
+	return principal, nil
+}
+
+// CheckRevoked fails if the account of the principal is deleted or revoked its
+// tokens after the token was issued. Tokens without account, as the ones of
+// the YIP admin, are not checked.
+func (s TokenService) CheckRevoked(ctx context.Context, principal *verifier.Principal) error {
+	accountId, err := uuid.Parse(principal.ID)
+	if err != nil {
+		return nil
+	}
+
+	account, err := s.repos.AccountRepo.GetByID(ctx, accountId)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return slyerrors.Unauthorized(slyerrors.ErrCodeAccountDeleted, "account does not exist")
+	}
+	if err != nil {
+		return err
+	}
+	if account.DeletedAt != nil {
+		return slyerrors.Unauthorized(slyerrors.ErrCodeAccountDeleted, "account is deleted")
+	}
+	// iat has seconds only, tokens issued within the second of the revocation stay valid
+	if account.TokensRevokedAt != nil && principal.IssuedAt.Before(account.TokensRevokedAt.Truncate(time.Second)) {
+		return slyerrors.Unauthorized(slyerrors.ErrCodeTokenRevoked, "token is revoked")
+	}
+	return nil
 }
@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"fmt"
 	"github.com/google/uuid"
 	"yip/src/api/auth/verifier"
 	"yip/src/api/services/dto"
@@ -14,11 +13,15 @@ import (
 	"yip/src/slyerrors"
 )
 
+// yipAdminID is the subject of the tokens of the YIP admin, who has no account
+const yipAdminID = "0000-0000-0000"
+
 type UserService struct {
 	Config   *config.Config
 	verifier *verifier.Verifier
 	userDB   repositories.Database
 	repos    *repo.Repositories
+	mfa      *MFAService
 }
 
 func NewUserService(
@@ -26,38 +29,42 @@ func NewUserService(
 	verifier *verifier.Verifier,
 	useDB repositories.Database,
 	repos *repo.Repositories,
+	mfa *MFAService,
 ) UserService {
 	return UserService{
 		Config:   config,
 		verifier: verifier,
 		userDB:   useDB,
 		repos:    repos,
+		mfa:      mfa,
 	}
 }
 
-func (s UserService) SignInYIPAdmin(context context.Context, data *dto.SignInRequest) (*verifier.Token, error) {
+// SignInYIPAdmin checks the password of the YIP admin, the response carries the
+// token or the challenge of the second factor
+func (s UserService) SignInYIPAdmin(context context.Context, data *dto.SignInRequest) (*dto.SignInResponse, error) {
 	admin := s.Config.API.Admin
 	if data.Email != admin.Username {
 		return nil, slyerrors.Forbidden("403", "user is not allowed")
 	}
 
-	fmt.Println("body password", data.Password)
-	fmt.Println("hashed", admin.PasswordHashed)
 	if cryptox.CheckPasswordHash(data.Password, admin.PasswordHashed) {
-		return s.verifier.CreateToken(data.Audiences, "0000-0000-0000", "", "", verifier.RoleAdmin)
+		return s.mfa.SignIn(context, yipAdminID, verifier.RoleAdmin, data.Audiences)
 	}
 
 	return nil, slyerrors.BadRequest("400", "password is incorrect")
 }
 
-func (s UserService) SignInUser(context context.Context, data *dto.SignInRequest) (*verifier.Token, error) {
+// SignInUser checks the password of an account, the response carries the token
+// or the challenge of the second factor
+func (s UserService) SignInUser(context context.Context, data *dto.SignInRequest) (*dto.SignInResponse, error) {
 	user, err := s.userDB.GetAccountByEmail(context, data.Email)
 	if err != nil {
 		return nil, err
 	}
 
 	if cryptox.CheckPasswordHash(data.Password, user.PasswordHashed) {
-		return s.verifier.CreateToken(data.Audiences, user.ID, "", "", user.Role)
+		return s.mfa.SignIn(context, user.ID, user.Role, data.Audiences)
 	}
 
 	return nil, slyerrors.BadRequest("400", "password is incorrect")
@@ -112,7 +119,3 @@ func (s UserService) SetRole(context context.Context, id uuid.UUID, role string)
 
 	return s.userDB.SetRole(context, id, role)
 }
-
-func (s UserService) SetEmail(context context.Context, id uuid.UUID, email string) (repositories.UserAccount, error) {
-	return s.userDB.SetEmail(context, id, email)
-}
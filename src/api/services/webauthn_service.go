@@ -0,0 +1,461 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"yip/src/api/auth/verifier"
+	"yip/src/api/services/dto"
+	"yip/src/config"
+	"yip/src/cryptox"
+	"yip/src/repositories/repo"
+	"yip/src/slyerrors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultWebAuthnTimeoutInSec = 300
+	defaultWebAuthnPasskeyLabel = "Passkey"
+	webAuthnChallengeBytes      = 32
+)
+
+// WebAuthnService handles the passkeys of accounts. A passkey is registered by
+// a signed in account and signs in the account afterwards, the token carries
+// amr ["hwk"]. Passkeys are disabled if no relying party is configured.
+type WebAuthnService struct {
+	config     *config.Config
+	verifier   *verifier.Verifier
+	repos      *repo.Repositories
+	hashSecret []byte
+	rp         cryptox.WebAuthnRelyingParty
+	timeout    time.Duration
+	maxAuthAge time.Duration
+}
+
+func NewWebAuthnService(config *config.Config, verifier *verifier.Verifier, repos *repo.Repositories) WebAuthnService {
+	timeoutInSec := config.WebAuthn.TimeoutInSec
+	if timeoutInSec <= 0 {
+		timeoutInSec = defaultWebAuthnTimeoutInSec
+	}
+	maxAuthAgeInSec := config.WebAuthn.MaxAuthAgeInSec
+	if maxAuthAgeInSec <= 0 {
+		maxAuthAgeInSec = defaultMaxAuthAgeInSec
+	}
+
+	return WebAuthnService{
+		config:     config,
+		verifier:   verifier,
+		repos:      repos,
+		hashSecret: []byte(config.Pin.HashSecret),
+		rp: cryptox.WebAuthnRelyingParty{
+			ID:                      config.WebAuthn.RPID,
+			Origins:                 config.WebAuthn.Origins,
+			RequireUserVerification: config.WebAuthn.RequireUserVerification,
+		},
+		timeout:    time.Duration(timeoutInSec) * time.Second,
+		maxAuthAge: time.Duration(maxAuthAgeInSec) * time.Second,
+	}
+}
+
+// BeginRegistration creates the options to register a new passkey of the
+// principal with navigator.credentials.create(). The principal must have
+// signed in recently, a leaked or long-lived token can't add a passkey that
+// signs in to the account.
+func (s WebAuthnService) BeginRegistration(ctx context.Context, principal *verifier.Principal) (*dto.WebAuthnCreationOptions, error) {
+	account, err := s.account(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+	if !principal.AuthenticatedWithin(s.maxAuthAge) {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeReauthenticationRequired, "sign in again to register a passkey")
+	}
+	credentials, err := s.repos.WebAuthnRepo.GetCredentialsByAccountId(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := s.newChallenge(ctx, repo.WebAuthnRegistration, &account.ID, principal.Audiences)
+	if err != nil {
+		return nil, err
+	}
+
+	name := account.Email
+	if name == "" {
+		name = account.Phone
+	}
+	if name == "" {
+		name = account.ID.String()
+	}
+	displayName := strings.TrimSpace(account.FirstName + " " + account.LastName)
+	if displayName == "" {
+		displayName = name
+	}
+
+	return &dto.WebAuthnCreationOptions{
+		Challenge: challenge,
+		RP: dto.WebAuthnRelyingParty{
+			ID:   s.rp.ID,
+			Name: s.rpName(),
+		},
+		User: dto.WebAuthnUser{
+			ID:          cryptox.WebAuthnChallengeEncoding.EncodeToString(account.ID[:]),
+			Name:        name,
+			DisplayName: displayName,
+		},
+		PubKeyCredParams: []dto.WebAuthnCredentialParameter{
+			{Type: dto.WebAuthnCredentialType, Alg: cryptox.COSEAlgES256},
+			{Type: dto.WebAuthnCredentialType, Alg: cryptox.COSEAlgRS256},
+		},
+		Timeout:            int(s.timeout.Milliseconds()),
+		Attestation:        "none",
+		ExcludeCredentials: credentialDescriptors(credentials),
+		AuthenticatorSelection: dto.WebAuthnAuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: s.userVerification(),
+		},
+	}, nil
+}
+
+// FinishRegistration verifies the response of navigator.credentials.create()
+// to a registration of the principal and stores the passkey
+func (s WebAuthnService) FinishRegistration(ctx context.Context, principal *verifier.Principal, data *dto.WebAuthnRegistrationDTO) (*dto.WebAuthnCredentialResponse, error) {
+	account, err := s.account(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+	clientDataJSON, err := decodeWebAuthnField("clientDataJSON", data.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	attestationObject, err := decodeWebAuthnField("attestationObject", data.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.useChallenge(ctx, clientDataJSON, repo.WebAuthnRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.AccountID == nil || *challenge.AccountID != account.ID {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeWebAuthnChallengeNotFound, "no pending registration")
+	}
+
+	credential, err := s.rp.VerifyRegistration(clientDataJSON, attestationObject, challengeOf(clientDataJSON))
+	if err != nil {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWebAuthnVerificationFailed, err.Error())
+	}
+	credentialID := cryptox.WebAuthnChallengeEncoding.EncodeToString(credential.ID)
+	if credentialID != strings.TrimRight(data.ID, "=") {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeWebAuthnVerificationFailed, "id is not the id of the attested credential")
+	}
+
+	_, err = s.repos.WebAuthnRepo.GetCredentialByCredentialID(ctx, credentialID)
+	if err == nil {
+		return nil, slyerrors.Conflict(slyerrors.ErrCodeWebAuthnCredentialExists, "passkey is registered already")
+	}
+	if !errors.Is(err, repo.DBItemNotFound) {
+		return nil, err
+	}
+
+	aaguid, err := uuid.FromBytes(credential.AAGUID)
+	if err != nil {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeWebAuthnVerificationFailed, "malformed aaguid")
+	}
+	label := data.Label
+	if label == "" {
+		label = defaultWebAuthnPasskeyLabel
+	}
+
+	stored, err := s.repos.WebAuthnRepo.CreateCredential(ctx, &repo.WebAuthnCredentialModel{
+		AccountID:    account.ID,
+		CredentialID: credentialID,
+		PublicKey:    credential.PublicKey,
+		Algorithm:    int(credential.Algorithm),
+		SignCount:    credential.SignCount,
+		AAGUID:       aaguid.String(),
+		Label:        label,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mapWebAuthnCredential(stored), nil
+}
+
+// BeginLogin creates the options to sign in with a passkey with
+// navigator.credentials.get(). The passkeys of the account of the email are
+// offered if given, an unknown email offers the discoverable ones as well to
+// not reveal which emails have an account.
+func (s WebAuthnService) BeginLogin(ctx context.Context, data *dto.WebAuthnLoginBeginDTO) (*dto.WebAuthnRequestOptions, error) {
+	if err := s.enabled(); err != nil {
+		return nil, err
+	}
+	if !s.config.VerifyAudiencesExist(data.Audiences) {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeAudienceDoesntExist, "audience(s) dont exist")
+	}
+
+	var credentials []*repo.WebAuthnCredentialModel
+	if data.Email != "" {
+		account, err := s.repos.AccountRepo.GetByEmail(ctx, data.Email)
+		if err != nil && !errors.Is(err, repo.DBItemNotFound) {
+			return nil, err
+		}
+		if account != nil && account.DeletedAt == nil {
+			if credentials, err = s.repos.WebAuthnRepo.GetCredentialsByAccountId(ctx, account.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	challenge, err := s.newChallenge(ctx, repo.WebAuthnLogin, nil, data.Audiences)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.WebAuthnRequestOptions{
+		Challenge:        challenge,
+		RPID:             s.rp.ID,
+		Timeout:          int(s.timeout.Milliseconds()),
+		AllowCredentials: credentialDescriptors(credentials),
+		UserVerification: s.userVerification(),
+	}, nil
+}
+
+// FinishLogin verifies the response of navigator.credentials.get() to a login
+// and returns the token of the account of the passkey
+func (s WebAuthnService) FinishLogin(ctx context.Context, data *dto.WebAuthnLoginDTO) (*verifier.Token, error) {
+	if err := s.enabled(); err != nil {
+		return nil, err
+	}
+	clientDataJSON, err := decodeWebAuthnField("clientDataJSON", data.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	authenticatorData, err := decodeWebAuthnField("authenticatorData", data.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := decodeWebAuthnField("signature", data.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.useChallenge(ctx, clientDataJSON, repo.WebAuthnLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.repos.WebAuthnRepo.GetCredentialByCredentialID(ctx, strings.TrimRight(data.ID, "="))
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWebAuthnCredentialNotFound, "unknown passkey")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data.UserHandle != "" {
+		userHandle, err := decodeWebAuthnField("userHandle", data.UserHandle)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(userHandle, credential.AccountID[:]) {
+			return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWebAuthnVerificationFailed, "passkey is not of the user")
+		}
+	}
+
+	authData, err := s.rp.VerifyAssertion(clientDataJSON, authenticatorData, signature, challengeOf(clientDataJSON), credential.PublicKey, credential.SignCount)
+	if err != nil {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWebAuthnVerificationFailed, err.Error())
+	}
+	err = s.repos.WebAuthnRepo.UseCredential(ctx, credential.ID, credential.SignCount, authData.SignCount, time.Now())
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeWebAuthnVerificationFailed, "passkey was used concurrently")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.repos.AccountRepo.GetByID(ctx, credential.AccountID)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeAccountDeleted, "account does not exist")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if account.DeletedAt != nil {
+		return nil, slyerrors.Unauthorized(slyerrors.ErrCodeAccountDeleted, "account is deleted")
+	}
+
+	token, err := s.verifier.CreateTokenWithAMR(challenge.Audiences, account.ID.String(), "", account.LastUsedSlyWallet, verifier.RoleBasic, []string{verifier.AMRHardwareKey})
+	if err != nil {
+		return nil, err
+	}
+	recordSignIn(ctx, s.repos, account.ID.String(), repo.SignInMethodPasskey, "")
+	return token, nil
+}
+
+// ListCredentials returns the passkeys of the principal
+func (s WebAuthnService) ListCredentials(ctx context.Context, principal *verifier.Principal) ([]*dto.WebAuthnCredentialResponse, error) {
+	accountId, err := webAuthnAccountId(principal)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := s.repos.WebAuthnRepo.GetCredentialsByAccountId(ctx, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*dto.WebAuthnCredentialResponse, len(credentials))
+	for i, credential := range credentials {
+		response[i] = mapWebAuthnCredential(credential)
+	}
+	return response, nil
+}
+
+// DeleteCredential removes a passkey of the principal
+func (s WebAuthnService) DeleteCredential(ctx context.Context, principal *verifier.Principal, id uuid.UUID) error {
+	accountId, err := webAuthnAccountId(principal)
+	if err != nil {
+		return err
+	}
+
+	err = s.repos.WebAuthnRepo.DeleteCredential(ctx, accountId, id)
+	if errors.Is(err, repo.DBItemNotFound) {
+		return slyerrors.NotFound(slyerrors.ErrCodeWebAuthnCredentialNotFound, "passkey not found")
+	}
+	return err
+}
+
+func (s WebAuthnService) enabled() error {
+	if s.rp.ID == "" {
+		return slyerrors.BadRequest(slyerrors.ErrCodeWebAuthnNotConfigured, "passkeys are not configured")
+	}
+	return nil
+}
+
+// account returns the account of the principal, passkeys are registered for
+// accounts only
+func (s WebAuthnService) account(ctx context.Context, principal *verifier.Principal) (*repo.AccountModel, error) {
+	if err := s.enabled(); err != nil {
+		return nil, err
+	}
+	accountId, err := webAuthnAccountId(principal)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.repos.AccountRepo.GetByID(ctx, accountId)
+	if err != nil {
+		return nil, err
+	}
+	if account.DeletedAt != nil {
+		return nil, slyerrors.Conflict(slyerrors.ErrCodeAccountDeleted, "account is deleted")
+	}
+	return account, nil
+}
+
+func (s WebAuthnService) newChallenge(ctx context.Context, purpose string, accountId *uuid.UUID, audiences []string) (string, error) {
+	b := make([]byte, webAuthnChallengeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	challenge := cryptox.WebAuthnChallengeEncoding.EncodeToString(b)
+
+	_, err := s.repos.WebAuthnRepo.CreateChallenge(ctx, &repo.WebAuthnChallengeModel{
+		ChallengeHash: cryptox.HashCode(s.hashSecret, challenge),
+		AccountID:     accountId,
+		Purpose:       purpose,
+		Audiences:     audiences,
+		ExpiresAt:     time.Now().Add(s.timeout),
+	})
+	if err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// useChallenge uses up the challenge the client data of a ceremony was
+// created for. The client data is verified against it afterwards.
+func (s WebAuthnService) useChallenge(ctx context.Context, clientDataJSON []byte, purpose string) (*repo.WebAuthnChallengeModel, error) {
+	challenge := challengeOf(clientDataJSON)
+	if challenge == "" {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeWebAuthnVerificationFailed, "client data has no challenge")
+	}
+
+	pending, err := s.repos.WebAuthnRepo.UseChallenge(ctx, cryptox.HashCode(s.hashSecret, challenge), purpose, time.Now())
+	if errors.Is(err, repo.DBItemNotFound) {
+		return nil, slyerrors.NotFound(slyerrors.ErrCodeWebAuthnChallengeNotFound, "no pending challenge")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+func (s WebAuthnService) rpName() string {
+	if s.config.WebAuthn.RPName != "" {
+		return s.config.WebAuthn.RPName
+	}
+	return s.rp.ID
+}
+
+func (s WebAuthnService) userVerification() string {
+	if s.rp.RequireUserVerification {
+		return "required"
+	}
+	return "preferred"
+}
+
+func webAuthnAccountId(principal *verifier.Principal) (uuid.UUID, error) {
+	accountId, err := uuid.Parse(principal.ID)
+	if err != nil {
+		return uuid.Nil, slyerrors.Forbidden("403", "passkeys are available to accounts only")
+	}
+	return accountId, nil
+}
+
+// challengeOf returns the challenge of the client data, empty if it is not
+// parsable
+func challengeOf(clientDataJSON []byte) string {
+	clientData, err := cryptox.ParseWebAuthnClientData(clientDataJSON)
+	if err != nil {
+		return ""
+	}
+	return clientData.Challenge
+}
+
+func decodeWebAuthnField(field string, value string) ([]byte, error) {
+	b, err := cryptox.WebAuthnChallengeEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, slyerrors.BadRequest(slyerrors.ErrCodeWebAuthnVerificationFailed, "%s is not base64url", field)
+	}
+	return b, nil
+}
+
+func credentialDescriptors(credentials []*repo.WebAuthnCredentialModel) []dto.WebAuthnCredentialDescriptor {
+	descriptors := make([]dto.WebAuthnCredentialDescriptor, len(credentials))
+	for i, credential := range credentials {
+		descriptors[i] = dto.WebAuthnCredentialDescriptor{
+			Type: dto.WebAuthnCredentialType,
+			ID:   credential.CredentialID,
+		}
+	}
+	return descriptors
+}
+
+func mapWebAuthnCredential(credential *repo.WebAuthnCredentialModel) *dto.WebAuthnCredentialResponse {
+	response := &dto.WebAuthnCredentialResponse{
+		ID:           credential.ID.String(),
+		CredentialID: credential.CredentialID,
+		Label:        credential.Label,
+		Algorithm:    credential.Algorithm,
+		AAGUID:       credential.AAGUID,
+		CreatedAt:    credential.CreatedAt,
+		LastUsedAt:   credential.LastUsedAt,
+	}
+	if x, y, ok := cryptox.WebAuthnP256Coordinates(credential.PublicKey); ok {
+		response.PublicKeyX = "0x" + hex.EncodeToString(x)
+		response.PublicKeyY = "0x" + hex.EncodeToString(y)
+	}
+	return response
+}
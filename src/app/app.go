@@ -18,6 +18,8 @@ type App struct {
 	DB               *sql.DB
 	UserDB           repositories.Database
 	EmailProvider    providers.EmailProvider
+	PushProvider     providers.PushProvider
+	SMSProvider      providers.SMSProvider
 	EthProvider      *providers.EthProvider
 	SLYWalletManager *contracts.WalletManager
 }
@@ -37,6 +39,16 @@ func InitApp(c *config.Config) (*App, error) {
 		return nil, err
 	}
 
+	pp, err := providers.InitPushProvider(&c.Push)
+	if err != nil {
+		return nil, err
+	}
+
+	sp, err := providers.InitSMSProvider(&c.SMS)
+	if err != nil {
+		return nil, err
+	}
+
 	ethProvider, err := providers.InitEthProvider(&c.EthConfig)
 	if err != nil {
 		return nil, err
@@ -52,6 +64,8 @@ func InitApp(c *config.Config) (*App, error) {
 		db,
 		repositories.NewDatabase(db),
 		ep,
+		pp,
+		sp,
 		&ethProvider,
 		wm,
 	}, nil
@@ -11,26 +11,174 @@ const (
 )
 
 type Config struct {
-	DB        SqlDBInfo      `json:"db"`
-	JWT       JWTTokenConfig `json:"jwt"`
-	Audiences []Audience     `json:"audiences"`
-	Clients   []Client       `json:"clients"`
-	API       API            `json:"api"`
-	Test      Test           `json:"test"`
-	Email     EmailConfig    `json:"email"`
-	EthConfig EthConfig      `json:"eth"`
+	DB          SqlDBInfo         `json:"db"`
+	JWT         JWTTokenConfig    `json:"jwt"`
+	Audiences   []Audience        `json:"audiences"`
+	Clients     []Client          `json:"clients"`
+	API         API               `json:"api"`
+	Test        Test              `json:"test"`
+	Email       EmailConfig       `json:"email"`
+	EthConfig   EthConfig         `json:"eth"`
+	Push        PushConfig        `json:"push"`
+	Pin         PinConfig         `json:"pin"`
+	SMS         SMSConfig         `json:"sms"`
+	EmailChange EmailChangeConfig `json:"email_change"`
+	Deletion    DeletionConfig    `json:"account_deletion"`
+	AccountLink AccountLinkConfig `json:"account_link"`
+	Password    PasswordConfig    `json:"password"`
+	MFA         MFAConfig         `json:"mfa"`
+	WebAuthn    WebAuthnConfig    `json:"webauthn"`
 }
 
+// WebAuthnConfig configures passkey sign-ins. RPID is the domain the passkeys
+// are scoped to, passkeys are disabled if empty, and Origins the pages allowed
+// to run the ceremonies. A ceremony has to finish within TimeoutInSec.
+// RequireUserVerification rejects authenticators that did not verify the user
+// with a PIN or biometric. Users register a passkey with a token of a sign in
+// at most MaxAuthAgeInSec ago.
+type WebAuthnConfig struct {
+	RPID                    string   `json:"rp_id"`
+	RPName                  string   `json:"rp_name"`
+	Origins                 []string `json:"origins"`
+	TimeoutInSec            int      `json:"timeout_in_sec"`
+	RequireUserVerification bool     `json:"require_user_verification"`
+	MaxAuthAgeInSec         int      `json:"max_auth_age_in_sec"`
+}
+
+// MFAConfig configures the TOTP second factor of password sign-ins. Sign-ins
+// of the RequiredRoles, and of everyone enrolled, return a challenge instead
+// of a token, valid for ChallengeExpirationInSec and MaxAttempts wrong codes.
+// MaxFailures wrong codes per pin request window, across challenges, lock the
+// subject until the window ends. Users of a required role without factor
+// enroll within the challenge. EncryptionKey seals the stored TOTP secrets,
+// YIP refuses to start without one of at least 32 characters. Issuer names
+// YIP in authenticator apps.
+type MFAConfig struct {
+	Issuer                   string   `json:"issuer"`
+	RequiredRoles            []string `json:"required_roles"`
+	EncryptionKey            string   `json:"encryption_key"`
+	ChallengeExpirationInSec int      `json:"challenge_expiration_in_sec"`
+	MaxAttempts              int      `json:"max_attempts"`
+	MaxFailures              int      `json:"max_failures"`
+	RecoveryCodes            int      `json:"recovery_codes"`
+}
+
+// PasswordConfig configures the password resets of credential accounts. The
+// reset mail carries a link to ResetUrl if set, the token otherwise, valid
+// for ResetExpirationInMin. Requests are limited per pin request window to
+// MaxRequestsPerEmail per email and MaxRequestsPerIp per IP.
+type PasswordConfig struct {
+	ResetExpirationInMin int    `json:"reset_expiration_in_min"`
+	ResetUrl             string `json:"reset_url"`
+
+	MaxRequestsPerEmail int `json:"max_requests_per_email"`
+	MaxRequestsPerIp    int `json:"max_requests_per_ip"`
+}
+
+// AccountLinkConfig configures how long the code of an account link can be
+// confirmed by the other account
+type AccountLinkConfig struct {
+	ExpirationInMin int `json:"expiration_in_min"`
+}
+
+// DeletionConfig configures the deletion of accounts. Deleted accounts are
+// anonymized right away and purged after GracePeriodInDays, due accounts are
+// purged every PurgeIntervalInMin. Users delete their account with a token of
+// a sign in at most MaxAuthAgeInSec ago.
+type DeletionConfig struct {
+	GracePeriodInDays  int `json:"grace_period_in_days"`
+	PurgeIntervalInMin int `json:"purge_interval_in_min"`
+	MaxAuthAgeInSec    int `json:"max_auth_age_in_sec"`
+}
+
+// EmailChangeConfig configures the confirmation of email changes. The new
+// address gets a code, and a link to ConfirmUrl if set, valid for
+// ExpirationInMin. After MaxAttempts wrong codes the change is dropped.
+// Requests are limited per pin request window to MaxRequestsPerAccount per
+// account and MaxRequestsPerEmail per new email. Users request a change with
+// a token of a sign in at most MaxAuthAgeInSec ago.
+type EmailChangeConfig struct {
+	ExpirationInMin int    `json:"expiration_in_min"`
+	ConfirmUrl      string `json:"confirm_url"`
+	MaxAttempts     int    `json:"max_attempts"`
+	MaxAuthAgeInSec int    `json:"max_auth_age_in_sec"`
+
+	MaxRequestsPerAccount int `json:"max_requests_per_account"`
+	MaxRequestsPerEmail   int `json:"max_requests_per_email"`
+}
+
+// SMSConfig selects the provider sending the phone pins, "log" appends them
+// to File or writes them to the log
+type SMSConfig struct {
+	Provider string `json:"provider"`
+	File     string `json:"file"`
+}
+
+// PinConfig configures the email pins. HashSecret keys the hash pins are
+// stored with, and the hashes of the other codes and tokens YIP sends. YIP
+// does not start without it. After MaxAttempts failed redemptions a pin is invalidated and
+// the ip resp. account is locked for LockoutInSec, doubled with each further
+// lock up to MaxLockoutInSec. If MagicLinkUrl is set, the pin mail carries a
+// link to it as well, valid for MagicLinkExpirationInMin.
+//
+// Pin requests are limited per RequestWindowInSec to MaxRequests in total,
+// MaxRequestsPerIp per ip and MaxRequestsPerRecipient per email resp. phone.
+// Within ResendCooldownInSec a request reuses the active pin.
+type PinConfig struct {
+	ExpirationInMin int    `json:"expiration_in_min"`
+	HashSecret      string `json:"hash_secret"`
+	MaxAttempts     int    `json:"max_attempts"`
+	LockoutInSec    int    `json:"lockout_in_sec"`
+	MaxLockoutInSec int    `json:"max_lockout_in_sec"`
+
+	MagicLinkUrl             string `json:"magic_link_url"`
+	MagicLinkExpirationInMin int    `json:"magic_link_expiration_in_min"`
+
+	ResendCooldownInSec     int `json:"resend_cooldown_in_sec"`
+	RequestWindowInSec      int `json:"request_window_in_sec"`
+	MaxRequests             int `json:"max_requests"`
+	MaxRequestsPerIp        int `json:"max_requests_per_ip"`
+	MaxRequestsPerRecipient int `json:"max_requests_per_recipient"`
+}
+
+// PushConfig selects the push provider, "log" writes the notifications to File.
+// Login pushes are limited per pin request window to MaxPerAddress per device
+// key address and MaxPerIp per ip.
+type PushConfig struct {
+	Provider string `json:"provider"`
+	File     string `json:"file"`
+
+	MaxPerAddress int `json:"max_per_address"`
+	MaxPerIp      int `json:"max_per_ip"`
+}
+
+// EmailConfig selects the provider sending the mails: "mailjet" (default),
+// "smtp" or "log". The log provider appends the mails to Outbox, or writes
+// them to the log. Mails are rendered of the built-in templates unless
+// TemplateDir holds templates, one directory per language.
 type EmailConfig struct {
-	SenderName  string        `json:"senderName"`
-	SenderEmail string        `json:"senderEmail"`
-	MailJet     MailJetConfig `json:"mailjet"`
+	Provider        string        `json:"provider"`
+	SenderName      string        `json:"senderName"`
+	SenderEmail     string        `json:"senderEmail"`
+	MailJet         MailJetConfig `json:"mailjet"`
+	SMTP            SMTPConfig    `json:"smtp"`
+	Outbox          string        `json:"outbox"`
+	TemplateDir     string        `json:"templateDir"`
+	DefaultLanguage string        `json:"defaultLanguage"`
 }
 
 type MailJetConfig struct {
 	PublicKey  string `json:"publicKey"`
 	PrivateKey string `json:"privateKey"`
-	TemplateId int    `json:"templateId"`
+}
+
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// ImplicitTLS connects with TLS right away instead of STARTTLS
+	ImplicitTLS bool `json:"implicitTLS"`
 }
 
 func ReadConfig() (Config, error) {
@@ -44,6 +192,9 @@ func ReadConfig() (Config, error) {
 	return c, err
 }
 
+// minSecretLength is the minimum length of the configured secrets
+const minSecretLength = 32
+
 func (c Config) verifyConfig() error {
 	_, err := url.Parse(c.JWT.Issuer)
 	if err != nil {
@@ -63,6 +214,24 @@ func (c Config) verifyConfig() error {
 		}
 	}
 
+	// the secret keys the hashes of pins, magic links, email change, password
+	// reset, account link, MFA challenge and passkey ceremony codes
+	if len(c.Pin.HashSecret) < minSecretLength {
+		return fmt.Errorf("pin.hash_secret must be a random secret of at least %d characters", minSecretLength)
+	}
+	// the key seals the stored TOTP secrets
+	if len(c.MFA.EncryptionKey) < minSecretLength {
+		return fmt.Errorf("mfa.encryption_key must be a random secret of at least %d characters", minSecretLength)
+	}
+
+	for _, cl := range c.Clients {
+		switch cl.NumberMatching {
+		case NumberMatchingOff, NumberMatchingConfirm, NumberMatchingChoose:
+		default:
+			return fmt.Errorf("unknown number matching mode for client %s: %s", cl.ID, cl.NumberMatching)
+		}
+	}
+
 	return nil
 }
 
@@ -78,6 +247,11 @@ type API struct {
 	Port      string `json:"port"`
 	SwaggerOn bool   `json:"swagger_on"`
 	Admin     Admin  `json:"admin"`
+	// UniversalLink is the base of the deep link that opens the wallet app, e.g. https://wallet.singularry.xyz/session
+	UniversalLink string `json:"universal_link"`
+	// TrustProxy takes the client ip from the X-Forwarded-For resp. X-Real-IP
+	// header, only set it behind a proxy that overwrites them
+	TrustProxy bool `json:"trust_proxy"`
 }
 
 type Admin struct {
@@ -91,6 +265,8 @@ type JWTTokenConfig struct {
 	CertificatePrivate          string `json:"certificate_private"`
 	CertificatePublic           string `json:"certificate_public"`
 	Issuer                      string `json:"issuer"`
+	// HandoffExpirationInSec is the lifetime of the signed QR code content of a session
+	HandoffExpirationInSec int64 `json:"handoff_expiration_in_sec"`
 }
 
 type Audience struct {
@@ -100,11 +276,24 @@ type Audience struct {
 	Scopes  []string `json:"scopes"`
 }
 
+// number matching modes of the QR login
+const (
+	NumberMatchingOff     = ""
+	NumberMatchingConfirm = "confirm" // the wallet shows the code, the user confirms it matches
+	NumberMatchingChoose  = "choose"  // the user picks the code from three choices
+)
+
 type Client struct {
 	ID        string   `json:"id"`
 	Domain    string   `json:"domain"`
 	Label     string   `json:"label"`
 	Audiences []string `json:"audiences"`
+	// NumberMatching requires the wallet user to match a code shown by the
+	// dApp before the SIWE signature of a session is accepted
+	NumberMatching string `json:"numberMatching"`
+	// MaxRelays limits the meta-transactions relayed for the sign requests of
+	// the client per pin request window, 0 uses the default, negative disables
+	MaxRelays int `json:"maxRelays"`
 }
 
 func (c Config) AudiencesByClient(clientId string) []string {
@@ -136,6 +325,16 @@ func (pi SqlDBInfo) String() string {
 	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", pi.Host, pi.Port, pi.User, pi.Password, pi.Database)
 }
 
+// ClientById returns the client of the id, or nil if there is none
+func (c Config) ClientById(clientId string) *Client {
+	for i := range c.Clients {
+		if c.Clients[i].ID == clientId {
+			return &c.Clients[i]
+		}
+	}
+	return nil
+}
+
 func (c Config) VerifyAudiencesExist(audience []string) bool {
 	exist := false
 	for _, a := range audience {
@@ -19,7 +19,11 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
-const TransactionTypeSpawnSLYWallet = "SpawnSLYWallet"
+const (
+	TransactionTypeSpawnSLYWallet       = "SpawnSLYWallet"
+	TransactionTypeExecuteWithSignature = "ExecuteWithSignature"
+	TransactionTypeAddKey               = "AddKey"
+)
 
 // WalletManager handles creating and managing SLY smart wallets
 type WalletManager struct {
@@ -335,6 +339,54 @@ func (m *WalletManager) ExecuteWithSignature(
 	return tx, nil
 }
 
+// RelayExecuteWithSignature submits a meta-transaction that was signed by a
+// controller key of the wallet, e.g. on a mobile device. The relayer pays the gas.
+func (m *WalletManager) RelayExecuteWithSignature(
+	ctx context.Context,
+	walletAddress common.Address,
+	to common.Address,
+	value *big.Int,
+	data []byte,
+	signer common.Address,
+	nonce *big.Int,
+	signature []byte,
+) (*types.Transaction, error) {
+	wallet, err := m.GetWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	relayer, err := m.ethProvider.DefaultSigner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := wallet.ExecuteWithSignature(relayer, to, value, data, signer, nonce, signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to relay execute with signature: %w", err)
+	}
+
+	return tx, nil
+}
+
+// ExecuteDigest returns the EIP-712 digest a controller key has to sign for ExecuteWithSignature
+func (m *WalletManager) ExecuteDigest(
+	ctx context.Context,
+	walletAddress common.Address,
+	to common.Address,
+	value *big.Int,
+	data []byte,
+	signer common.Address,
+	nonce *big.Int,
+) ([32]byte, error) {
+	chainID, err := m.ethProvider.Client.ChainID(ctx)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	return m.calculateExecuteDigest(walletAddress, to, value, data, signer, nonce, chainID)
+}
+
 func (m *WalletManager) createMetaTxSignature(
 	ctx context.Context,
 	walletAddress common.Address,
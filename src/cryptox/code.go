@@ -0,0 +1,151 @@
+package cryptox
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// AlphabetNumeric is for codes typed on a number pad, e.g. pins
+	AlphabetNumeric = "0123456789"
+	// AlphabetCrockford is the Crockford base32 alphabet, it leaves out I, L, O
+	// and U so codes can be read out and typed without mix-ups
+	AlphabetCrockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+// ErrCodeTaken is returned by the claim function of Allocate if the code is
+// used already, Allocate tries another code then
+var ErrCodeTaken = errors.New("code taken")
+
+// CodeGenerator generates random codes with crypto/rand
+type CodeGenerator struct {
+	Alphabet string
+	// Length of the code without check digit
+	Length int
+	// CheckDigit appends a Luhn mod N check digit, so typos are detected
+	// before the code is looked up
+	CheckDigit bool
+}
+
+var (
+	// PinCodes are the 6 digit pins sent by email
+	PinCodes = CodeGenerator{Alphabet: AlphabetNumeric, Length: 6}
+	// InvitationCodes are 16 digits, the last one is a check digit
+	InvitationCodes = CodeGenerator{Alphabet: AlphabetNumeric, Length: 15, CheckDigit: true}
+	// RecoveryCodes replace the second factor once each, 10 Crockford characters
+	RecoveryCodes = CodeGenerator{Alphabet: AlphabetCrockford, Length: 10}
+)
+
+// Generate returns a new code, each character is drawn uniformly from the
+// alphabet
+func (g CodeGenerator) Generate() (string, error) {
+	if len(g.Alphabet) < 2 || g.Length <= 0 {
+		return "", fmt.Errorf("invalid code generator")
+	}
+
+	max := big.NewInt(int64(len(g.Alphabet)))
+	b := make([]byte, g.Length, g.Length+1)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = g.Alphabet[n.Int64()]
+	}
+
+	if g.CheckDigit {
+		b = append(b, g.checkDigit(string(b)))
+	}
+	return string(b), nil
+}
+
+// Normalize removes spaces and hyphens users type to group a code. Crockford
+// codes are upper cased and the letters left out of the alphabet are mapped to
+// the digits they are mistaken for.
+func (g CodeGenerator) Normalize(code string) string {
+	code = strings.NewReplacer(" ", "", "-", "").Replace(code)
+	if g.Alphabet == AlphabetCrockford {
+		code = strings.NewReplacer("O", "0", "I", "1", "L", "1").Replace(strings.ToUpper(code))
+	}
+	return code
+}
+
+// Verify checks that a normalized code has the length and alphabet of the
+// generator and, if enabled, its check digit
+func (g CodeGenerator) Verify(code string) bool {
+	length := g.Length
+	if g.CheckDigit {
+		length++
+	}
+	if len(code) != length {
+		return false
+	}
+	for i := range code {
+		if strings.IndexByte(g.Alphabet, code[i]) < 0 {
+			return false
+		}
+	}
+	return !g.CheckDigit || g.checkDigit(code[:g.Length]) == code[g.Length]
+}
+
+// checkDigit calculates the Luhn mod N check digit of code
+func (g CodeGenerator) checkDigit(code string) byte {
+	n := len(g.Alphabet)
+	sum := 0
+	factor := 2
+	for i := len(code) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(g.Alphabet, code[i])
+		sum += addend/n + addend%n
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+	}
+	return g.Alphabet[(n-sum%n)%n]
+}
+
+// Allocate generates codes until claim accepts one. claim stores the code and
+// returns ErrCodeTaken if it is used already, e.g. on a unique violation, so
+// concurrent allocations never hand out the same code.
+func Allocate(g CodeGenerator, attempts int, claim func(code string) error) (string, error) {
+	for i := 0; i < attempts; i++ {
+		code, err := g.Generate()
+		if err != nil {
+			return "", err
+		}
+
+		err = claim(code)
+		if errors.Is(err, ErrCodeTaken) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return code, nil
+	}
+	return "", fmt.Errorf("no free code found after %d attempts", attempts)
+}
+
+// HashCode keys the hash of a code with secret, a short code could be
+// recovered from a plain hash by trying all codes
+func HashCode(secret []byte, code string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RandomToken returns n random bytes hex encoded, e.g. for the token of a link
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,75 @@
+package cryptox
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCodeGenerator(t *testing.T) {
+	for _, g := range []CodeGenerator{PinCodes, InvitationCodes, {Alphabet: AlphabetCrockford, Length: 10, CheckDigit: true}} {
+		code, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g.Verify(code) {
+			t.Errorf("generated code %s does not verify", code)
+		}
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	g := InvitationCodes
+	code, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a single mistyped digit is detected
+	for i := range code {
+		b := []byte(code)
+		b[i] = '0' + (b[i]-'0'+1)%10
+		if g.Verify(string(b)) {
+			t.Errorf("typo at %d of %s not detected", i, code)
+		}
+	}
+
+	if !g.Verify(g.Normalize(code[:4] + " " + code[4:8] + "-" + code[8:])) {
+		t.Errorf("grouped code %s does not verify", code)
+	}
+}
+
+func TestCrockfordNormalize(t *testing.T) {
+	g := CodeGenerator{Alphabet: AlphabetCrockford, Length: 4}
+	if n := g.Normalize("o1-il"); n != "0111" {
+		t.Errorf("expected 0111, got %s", n)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	claims := 0
+	code, err := Allocate(PinCodes, 3, func(code string) error {
+		claims++
+		if claims < 3 {
+			return ErrCodeTaken
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims != 3 || len(code) != PinCodes.Length {
+		t.Errorf("unexpected allocation %s after %d claims", code, claims)
+	}
+
+	_, err = Allocate(PinCodes, 2, func(code string) error {
+		return ErrCodeTaken
+	})
+	if err == nil {
+		t.Error("allocated a taken code")
+	}
+
+	fail := errors.New("db down")
+	if _, err = Allocate(PinCodes, 2, func(code string) error { return fail }); !errors.Is(err, fail) {
+		t.Errorf("expected claim error, got %v", err)
+	}
+}
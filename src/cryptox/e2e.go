@@ -0,0 +1,104 @@
+package cryptox
+
+import (
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+	"io"
+)
+
+// e2eInfo binds derived keys to the session channel
+const e2eInfo = "yip-session-e2e"
+
+// GenerateE2EKey creates an X25519 key pair for an end-to-end encrypted session
+func GenerateE2EKey() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// EncodeE2EPublicKey encodes a public key as unpadded base64url, short enough
+// for QR codes and query strings
+func EncodeE2EPublicKey(key *ecdh.PublicKey) string {
+	return base64.RawURLEncoding.EncodeToString(key.Bytes())
+}
+
+func ParseE2EPublicKey(key string) (*ecdh.PublicKey, error) {
+	b, err := base64.RawURLEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("public key is not base64url: %w", err)
+	}
+	return ecdh.X25519().NewPublicKey(b)
+}
+
+// GenerateE2ESecret creates the secret the dApp adds to the QR code as hs. It
+// authenticates the wallet's handshake, YIP never learns it.
+func GenerateE2ESecret() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// E2EHandshakeMAC is the HMAC-SHA256 of the wallet's public key and the
+// session id, keyed with the secret of the QR code
+func E2EHandshakeMAC(secret string, sessionId string, publicKey string) (string, error) {
+	key, err := base64.RawURLEncoding.DecodeString(secret)
+	if err != nil {
+		return "", fmt.Errorf("secret is not base64url: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(sessionId + ":" + publicKey))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyE2EHandshakeMAC is used by the dApp to accept only the wallet key of
+// a handshake made by someone who scanned its QR code
+func VerifyE2EHandshakeMAC(secret string, sessionId string, publicKey string, mac string) bool {
+	expected, err := E2EHandshakeMAC(secret, sessionId, publicKey)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(mac))
+}
+
+// E2ESharedKey derives the ChaCha20-Poly1305 key both parties of a session share.
+// The session id is used as salt, so keys are never reused across sessions.
+func E2ESharedKey(key *ecdh.PrivateKey, peer *ecdh.PublicKey, sessionId string) ([]byte, error) {
+	secret, err := key.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := make([]byte, chacha20poly1305.KeySize)
+	if _, err = io.ReadFull(hkdf.New(sha256.New, secret, []byte(sessionId), []byte(e2eInfo)), shared); err != nil {
+		return nil, err
+	}
+	return shared, nil
+}
+
+// E2ESeal encrypts plaintext with a random nonce. ad is authenticated but not encrypted.
+func E2ESeal(key []byte, plaintext []byte, ad []byte) (nonce []byte, ciphertext []byte, err error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, aead.Seal(nil, nonce, plaintext, ad), nil
+}
+
+func E2EOpen(key []byte, nonce []byte, ciphertext []byte, ad []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, nonce, ciphertext, ad)
+}
@@ -0,0 +1,57 @@
+package cryptox
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestE2ESealAndOpen(t *testing.T) {
+	dApp, err := GenerateE2EKey()
+	assert.NoError(t, err)
+	wallet, err := GenerateE2EKey()
+	assert.NoError(t, err)
+
+	// the public keys are exchanged in their encoded form
+	walletPub, err := ParseE2EPublicKey(EncodeE2EPublicKey(wallet.PublicKey()))
+	assert.NoError(t, err)
+	dAppPub, err := ParseE2EPublicKey(EncodeE2EPublicKey(dApp.PublicKey()))
+	assert.NoError(t, err)
+
+	dAppKey, err := E2ESharedKey(dApp, walletPub, "session")
+	assert.NoError(t, err)
+	walletKey, err := E2ESharedKey(wallet, dAppPub, "session")
+	assert.NoError(t, err)
+	assert.Equal(t, dAppKey, walletKey)
+
+	nonce, ciphertext, err := E2ESeal(dAppKey, []byte("hello"), []byte("session:dapp"))
+	assert.NoError(t, err)
+
+	plaintext, err := E2EOpen(walletKey, nonce, ciphertext, []byte("session:dapp"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(plaintext))
+
+	_, err = E2EOpen(walletKey, nonce, ciphertext, []byte("session:wallet"))
+	assert.Error(t, err)
+
+	otherKey, _ := E2ESharedKey(wallet, dAppPub, "other session")
+	_, err = E2EOpen(otherKey, nonce, ciphertext, []byte("session:dapp"))
+	assert.Error(t, err)
+
+	_, err = ParseE2EPublicKey("not a key")
+	assert.Error(t, err)
+}
+
+func TestE2EHandshakeMAC(t *testing.T) {
+	secret, err := GenerateE2ESecret()
+	assert.NoError(t, err)
+
+	mac, err := E2EHandshakeMAC(secret, "session", "key")
+	assert.NoError(t, err)
+	assert.True(t, VerifyE2EHandshakeMAC(secret, "session", "key", mac))
+	// a key substituted by the relay does not verify
+	assert.False(t, VerifyE2EHandshakeMAC(secret, "session", "other key", mac))
+	assert.False(t, VerifyE2EHandshakeMAC(secret, "other session", "key", mac))
+
+	other, _ := GenerateE2ESecret()
+	assert.False(t, VerifyE2EHandshakeMAC(other, "session", "key", mac))
+}
@@ -1,8 +1,8 @@
 package cryptox
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
+	"math/big"
 )
 
 const (
@@ -11,10 +11,6 @@ const (
 	numBytes     = "0123456789"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 func GenerateNumberCode(length int) string {
 	return GeneratePin(length, false, false, true)
 }
@@ -23,26 +19,26 @@ func GeneratePassword(length int, useLetters bool, useSpecial bool, useNum bool)
 	b := make([]byte, length)
 	for i := range b {
 		if useLetters {
-			b[i] = letterBytes[rand.Intn(len(letterBytes))]
+			b[i] = pick(letterBytes)
 		} else if useSpecial {
-			b[i] = specialBytes[rand.Intn(len(specialBytes))]
+			b[i] = pick(specialBytes)
 		} else if useNum {
-			b[i] = numBytes[rand.Intn(len(numBytes))]
+			b[i] = pick(numBytes)
 		}
 	}
 	return string(b)
 }
 
 func GeneratePin(length int, useLetters bool, useSpecial bool, useNum bool) string {
-	b := make([]byte, length)
-	for i := range b {
-		if useLetters {
-			b[i] = letterBytes[rand.Intn(len(letterBytes))]
-		} else if useSpecial {
-			b[i] = specialBytes[rand.Intn(len(specialBytes))]
-		} else if useNum {
-			b[i] = numBytes[rand.Intn(len(numBytes))]
-		}
+	return GeneratePassword(length, useLetters, useSpecial, useNum)
+}
+
+// pick draws a character of alphabet with crypto/rand. Without randomness no
+// secret can be generated, so it panics if crypto/rand fails.
+func pick(alphabet string) byte {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+	if err != nil {
+		panic("crypto/rand failed: " + err.Error())
 	}
-	return string(b)
+	return alphabet[n.Int64()]
 }
@@ -22,6 +22,44 @@ import (
 //	  	SignTypeWeb3JS: sig[65] = 27|28
 //	  	SignTypeGo: 	sig[65] = 0|1
 func Recover(msg string, sigHex string, method SignMethod, web3JSStrict bool) (*common.Address, error) {
+	sig, err := decodeSignature(sigHex, web3JSStrict)
+	if err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case SignMethodGoDefault:
+		return RecoverGoDefault(msg, sig)
+	case SignMethodEthereumPrefix:
+		return RecoverPrefix(msg, sig)
+	default:
+		return nil, fmt.Errorf("could not recover: signing method not found: %d", method)
+	}
+}
+
+// RecoverHash recovers the signer of an already hashed message, e.g. an EIP-712 digest
+func RecoverHash(hash []byte, sigHex string) (*common.Address, error) {
+	sig, err := decodeSignature(sigHex, false)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKeyBytes, err := crypto.Ecrecover(hash, sig)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey, err := crypto.UnmarshalPubkey(pubKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	address := crypto.PubkeyToAddress(*pubKey)
+
+	return &address, nil
+}
+
+// decodeSignature decodes a hex signature and normalizes V to 0|1
+func decodeSignature(sigHex string, web3JSStrict bool) ([]byte, error) {
 	preparedSignature := sigHex
 	if strings.Contains(sigHex, "0x") {
 		preparedSignature = sigHex[2:]
@@ -39,15 +77,7 @@ func Recover(msg string, sigHex string, method SignMethod, web3JSStrict bool) (*
 	} else {
 		sig[64] -= 27
 	}
-
-	switch method {
-	case SignMethodGoDefault:
-		return RecoverGoDefault(msg, sig)
-	case SignMethodEthereumPrefix:
-		return RecoverPrefix(msg, sig)
-	default:
-		return nil, fmt.Errorf("could not recover: signing method not found: %d", method)
-	}
+	return sig, nil
 }
 
 func RecoverGoDefault(msg string, sig []byte) (*common.Address, error) {
@@ -0,0 +1,50 @@
+package cryptox
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// SealString encrypts plaintext with XChaCha20-Poly1305 under a key derived
+// of secret, e.g. to store TOTP secrets that have to be read back. The result
+// is the nonce and ciphertext, base64 encoded.
+func SealString(secret []byte, plaintext string) (string, error) {
+	key := sha256.Sum256(secret)
+	aead, err := chacha20poly1305.NewX(key[:])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+// OpenString decrypts a value of SealString
+func OpenString(secret []byte, sealed string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", fmt.Errorf("sealed value is not base64: %w", err)
+	}
+
+	key := sha256.Sum256(secret)
+	aead, err := chacha20poly1305.NewX(key[:])
+	if err != nil {
+		return "", err
+	}
+	if len(b) < aead.NonceSize() {
+		return "", fmt.Errorf("sealed value is too short")
+	}
+
+	plaintext, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("could not open sealed value: %w", err)
+	}
+	return string(plaintext), nil
+}
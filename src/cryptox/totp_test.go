@@ -0,0 +1,60 @@
+package cryptox
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// TestHOTP checks the SHA1 test vectors of RFC 6238, appendix B
+func TestHOTP(t *testing.T) {
+	key := []byte("12345678901234567890")
+	for _, v := range []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	} {
+		if code := hotp(key, uint64(TOTPStep(time.Unix(v.unix, 0))), 8); code != v.code {
+			t.Errorf("code at %d is %s, want %s", v.unix, code, v.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+
+	code, err := TOTPCode(secret, TOTPStep(now)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step, ok := ValidateTOTP(secret, code, now, 1); !ok || step != TOTPStep(now)-1 {
+		t.Errorf("code of the previous step is not accepted with skew 1")
+	}
+	if _, ok := ValidateTOTP(secret, code, now, 0); ok {
+		t.Errorf("code of the previous step is accepted without skew")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now, 1); ok {
+		t.Errorf("short code is accepted")
+	}
+}
+
+func TestSealString(t *testing.T) {
+	sealed, err := SealString([]byte("secret"), "JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := OpenString([]byte("secret"), sealed)
+	if err != nil || plaintext != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("opened %q, %v", plaintext, err)
+	}
+	if _, err = OpenString([]byte("other"), sealed); err == nil {
+		t.Errorf("opened with another secret")
+	}
+}
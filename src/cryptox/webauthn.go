@@ -0,0 +1,293 @@
+package cryptox
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+)
+
+// COSE algorithms of the WebAuthn credentials YIP accepts
+const (
+	COSEAlgES256 int64 = -7
+	COSEAlgRS256 int64 = -257
+)
+
+const (
+	webAuthnTypeCreate = "webauthn.create"
+	webAuthnTypeGet    = "webauthn.get"
+
+	authDataFlagUP = 0x01
+	authDataFlagUV = 0x04
+	authDataFlagAT = 0x40
+)
+
+// WebAuthnChallengeEncoding encodes challenges and credential ids as the
+// browser APIs do
+var WebAuthnChallengeEncoding = base64.RawURLEncoding
+
+// ErrWebAuthnSignCount is returned by VerifyAssertion if the signature counter
+// did not increase, the authenticator may be cloned
+var ErrWebAuthnSignCount = errors.New("webauthn: signature counter did not increase")
+
+// WebAuthnClientData is the part of the clientDataJSON of a ceremony YIP checks
+type WebAuthnClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// ParseWebAuthnClientData parses the clientDataJSON of a ceremony, e.g. to
+// look up its challenge before the ceremony is verified
+func ParseWebAuthnClientData(clientDataJSON []byte) (*WebAuthnClientData, error) {
+	clientData := &WebAuthnClientData{}
+	if err := json.Unmarshal(clientDataJSON, clientData); err != nil {
+		return nil, fmt.Errorf("webauthn: client data is not json: %w", err)
+	}
+	return clientData, nil
+}
+
+// WebAuthnAuthData is the authenticator data of a ceremony. Registrations
+// carry the attested credential as well.
+type WebAuthnAuthData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	// PublicKey is the COSE key of the credential
+	PublicKey []byte
+}
+
+func (a WebAuthnAuthData) UserPresent() bool {
+	return a.Flags&authDataFlagUP != 0
+}
+
+func (a WebAuthnAuthData) UserVerified() bool {
+	return a.Flags&authDataFlagUV != 0
+}
+
+// WebAuthnCredential is the credential of a verified registration
+type WebAuthnCredential struct {
+	ID []byte
+	// PublicKey is the COSE key of the credential
+	PublicKey    []byte
+	Algorithm    int64
+	SignCount    uint32
+	AAGUID       []byte
+	UserVerified bool
+}
+
+// WebAuthnRelyingParty verifies the ceremonies of the relying party ID, the
+// domain the credentials are bound to, started on one of Origins
+type WebAuthnRelyingParty struct {
+	ID                      string
+	Origins                 []string
+	RequireUserVerification bool
+}
+
+// VerifyRegistration verifies the response of navigator.credentials.create()
+// for challenge and returns the new credential. The attestation statement is
+// not verified, YIP requests none and trusts the credential on first use.
+func (rp WebAuthnRelyingParty) VerifyRegistration(clientDataJSON []byte, attestationObject []byte, challenge string) (*WebAuthnCredential, error) {
+	if err := rp.verifyClientData(clientDataJSON, webAuthnTypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, rest, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, err
+	}
+	attestation, ok := decoded.(map[interface{}]interface{})
+	if !ok || len(rest) > 0 {
+		return nil, fmt.Errorf("webauthn: malformed attestation object")
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("webauthn: attestation object has no authData")
+	}
+
+	authData, err := rp.verifyAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.Flags&authDataFlagAT == 0 || len(authData.PublicKey) == 0 {
+		return nil, fmt.Errorf("webauthn: no attested credential")
+	}
+
+	_, alg, err := ParseCOSEKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnCredential{
+		ID:           authData.CredentialID,
+		PublicKey:    authData.PublicKey,
+		Algorithm:    alg,
+		SignCount:    authData.SignCount,
+		AAGUID:       authData.AAGUID,
+		UserVerified: authData.UserVerified(),
+	}, nil
+}
+
+// VerifyAssertion verifies the response of navigator.credentials.get() for
+// challenge with the COSE public key of the credential. signCount is the
+// counter stored with the credential, the new one is returned.
+func (rp WebAuthnRelyingParty) VerifyAssertion(clientDataJSON []byte, authenticatorData []byte, signature []byte, challenge string, publicKey []byte, signCount uint32) (*WebAuthnAuthData, error) {
+	if err := rp.verifyClientData(clientDataJSON, webAuthnTypeGet, challenge); err != nil {
+		return nil, err
+	}
+	authData, err := rp.verifyAuthData(authenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	key, alg, err := ParseCOSEKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(slices.Clone(authenticatorData), clientDataHash[:]...))
+
+	switch alg {
+	case COSEAlgES256:
+		if !ecdsa.VerifyASN1(key.(*ecdsa.PublicKey), digest[:], signature) {
+			return nil, fmt.Errorf("webauthn: invalid signature")
+		}
+	case COSEAlgRS256:
+		if err = rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature); err != nil {
+			return nil, fmt.Errorf("webauthn: invalid signature")
+		}
+	}
+
+	// authenticators without counter, e.g. synced passkeys, always send 0
+	if (authData.SignCount != 0 || signCount != 0) && authData.SignCount <= signCount {
+		return nil, ErrWebAuthnSignCount
+	}
+	return authData, nil
+}
+
+func (rp WebAuthnRelyingParty) verifyClientData(clientDataJSON []byte, ceremony string, challenge string) error {
+	clientData, err := ParseWebAuthnClientData(clientDataJSON)
+	if err != nil {
+		return err
+	}
+	if clientData.Type != ceremony {
+		return fmt.Errorf("webauthn: client data is of %s, not %s", clientData.Type, ceremony)
+	}
+	if subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return fmt.Errorf("webauthn: challenge mismatch")
+	}
+	if !slices.Contains(rp.Origins, clientData.Origin) {
+		return fmt.Errorf("webauthn: origin %s is not allowed", clientData.Origin)
+	}
+	return nil
+}
+
+func (rp WebAuthnRelyingParty) verifyAuthData(raw []byte) (*WebAuthnAuthData, error) {
+	authData, err := parseWebAuthnAuthData(raw)
+	if err != nil {
+		return nil, err
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(authData.RPIDHash, rpIDHash[:]) != 1 {
+		return nil, fmt.Errorf("webauthn: credential is of another relying party")
+	}
+	if !authData.UserPresent() {
+		return nil, fmt.Errorf("webauthn: user not present")
+	}
+	if rp.RequireUserVerification && !authData.UserVerified() {
+		return nil, fmt.Errorf("webauthn: user not verified")
+	}
+	return authData, nil
+}
+
+func parseWebAuthnAuthData(b []byte) (*WebAuthnAuthData, error) {
+	if len(b) < 37 {
+		return nil, fmt.Errorf("webauthn: authenticator data too short")
+	}
+	authData := &WebAuthnAuthData{
+		RPIDHash:  b[:32],
+		Flags:     b[32],
+		SignCount: binary.BigEndian.Uint32(b[33:37]),
+	}
+	if authData.Flags&authDataFlagAT == 0 {
+		return authData, nil
+	}
+
+	b = b[37:]
+	if len(b) < 18 {
+		return nil, fmt.Errorf("webauthn: attested credential data too short")
+	}
+	authData.AAGUID = b[:16]
+	idLength := int(binary.BigEndian.Uint16(b[16:18]))
+	b = b[18:]
+	if len(b) < idLength {
+		return nil, fmt.Errorf("webauthn: credential id exceeds authenticator data")
+	}
+	authData.CredentialID = b[:idLength]
+
+	_, rest, err := decodeCBOR(b[idLength:])
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: malformed credential public key: %w", err)
+	}
+	authData.PublicKey = b[idLength : len(b)-len(rest)]
+	return authData, nil
+}
+
+// ParseCOSEKey parses a COSE public key of an ES256 or RS256 credential
+func ParseCOSEKey(b []byte) (crypto.PublicKey, int64, error) {
+	decoded, rest, err := decodeCBOR(b)
+	if err != nil {
+		return nil, 0, err
+	}
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok || len(rest) > 0 {
+		return nil, 0, fmt.Errorf("cose: malformed key")
+	}
+
+	alg, _ := key[int64(3)].(int64)
+	switch alg {
+	case COSEAlgES256:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if key[int64(1)] != int64(2) || crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, fmt.Errorf("cose: malformed P-256 key")
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, 0, fmt.Errorf("cose: point is not on P-256")
+		}
+		return pub, alg, nil
+	case COSEAlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if key[int64(1)] != int64(3) || len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, fmt.Errorf("cose: malformed RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, alg, nil
+	default:
+		return nil, 0, fmt.Errorf("cose: unsupported algorithm %d", alg)
+	}
+}
+
+// WebAuthnP256Coordinates returns the coordinates of the P-256 key of an ES256
+// credential, e.g. for a secp256r1 verifier on chain
+func WebAuthnP256Coordinates(publicKey []byte) (x []byte, y []byte, ok bool) {
+	key, alg, err := ParseCOSEKey(publicKey)
+	if err != nil || alg != COSEAlgES256 {
+		return nil, nil, false
+	}
+	pub := key.(*ecdsa.PublicKey)
+	return pub.X.FillBytes(make([]byte, 32)), pub.Y.FillBytes(make([]byte, 32)), true
+}
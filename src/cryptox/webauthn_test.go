@@ -0,0 +1,186 @@
+package cryptox
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// cborPair is an entry of a CBOR map in encoding order
+type cborPair struct {
+	key   interface{}
+	value interface{}
+}
+
+func encodeCBORHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n < 1<<8:
+		return []byte{major<<5 | 24, byte(n)}
+	case n < 1<<16:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	}
+}
+
+func encodeCBOR(v interface{}) []byte {
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return encodeCBORHead(1, uint64(-1-v))
+		}
+		return encodeCBORHead(0, uint64(v))
+	case []byte:
+		return append(encodeCBORHead(2, uint64(len(v))), v...)
+	case string:
+		return append(encodeCBORHead(3, uint64(len(v))), v...)
+	case []cborPair:
+		b := encodeCBORHead(5, uint64(len(v)))
+		for _, p := range v {
+			b = append(b, encodeCBOR(p.key)...)
+			b = append(b, encodeCBOR(p.value)...)
+		}
+		return b
+	}
+	panic("unsupported cbor value")
+}
+
+// softAuthenticator is a software WebAuthn authenticator with an ES256 credential
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{key: key, credentialID: []byte("soft-credential-1")}
+}
+
+func (a *softAuthenticator) authData(rpID string, flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	b := append(rpIDHash[:], flags)
+	b = binary.BigEndian.AppendUint32(b, a.signCount)
+	if attested {
+		b = append(b, make([]byte, 16)...)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(a.credentialID)))
+		b = append(b, a.credentialID...)
+		b = append(b, encodeCBOR([]cborPair{
+			{1, 2},
+			{3, -7},
+			{-1, 1},
+			{-2, a.key.X.FillBytes(make([]byte, 32))},
+			{-3, a.key.Y.FillBytes(make([]byte, 32))},
+		})...)
+	}
+	return b
+}
+
+func clientDataJSON(t *testing.T, ceremony, challenge, origin string) []byte {
+	b, err := json.Marshal(WebAuthnClientData{Type: ceremony, Challenge: challenge, Origin: origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func (a *softAuthenticator) create(t *testing.T, rpID, challenge, origin string) ([]byte, []byte) {
+	attestationObject := encodeCBOR([]cborPair{
+		{"fmt", "none"},
+		{"attStmt", []cborPair{}},
+		{"authData", a.authData(rpID, authDataFlagUP|authDataFlagUV|authDataFlagAT, true)},
+	})
+	return clientDataJSON(t, webAuthnTypeCreate, challenge, origin), attestationObject
+}
+
+func (a *softAuthenticator) get(t *testing.T, rpID, challenge, origin string) ([]byte, []byte, []byte) {
+	a.signCount++
+	clientData := clientDataJSON(t, webAuthnTypeGet, challenge, origin)
+	authData := a.authData(rpID, authDataFlagUP|authDataFlagUV, false)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clientData, authData, signature
+}
+
+func TestWebAuthnRegistrationAndAssertion(t *testing.T) {
+	rp := WebAuthnRelyingParty{ID: "auth.example.com", Origins: []string{"https://auth.example.com"}, RequireUserVerification: true}
+	authenticator := newSoftAuthenticator(t)
+
+	clientData, attestationObject := authenticator.create(t, rp.ID, "register-challenge", "https://auth.example.com")
+	credential, err := rp.VerifyRegistration(clientData, attestationObject, "register-challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(credential.ID) != string(authenticator.credentialID) || credential.Algorithm != COSEAlgES256 || !credential.UserVerified {
+		t.Errorf("unexpected credential %+v", credential)
+	}
+	x, y, ok := WebAuthnP256Coordinates(credential.PublicKey)
+	if !ok || string(x) != string(authenticator.key.X.FillBytes(make([]byte, 32))) || string(y) != string(authenticator.key.Y.FillBytes(make([]byte, 32))) {
+		t.Errorf("P-256 coordinates do not match the key")
+	}
+
+	if _, err = rp.VerifyRegistration(clientData, attestationObject, "other-challenge"); err == nil {
+		t.Errorf("registration of another challenge verified")
+	}
+	clientData, attestationObject = authenticator.create(t, rp.ID, "register-challenge", "https://evil.example.com")
+	if _, err = rp.VerifyRegistration(clientData, attestationObject, "register-challenge"); err == nil {
+		t.Errorf("registration of another origin verified")
+	}
+	clientData, attestationObject = authenticator.create(t, "evil.example.com", "register-challenge", "https://auth.example.com")
+	if _, err = rp.VerifyRegistration(clientData, attestationObject, "register-challenge"); err == nil {
+		t.Errorf("registration of another relying party verified")
+	}
+
+	clientData, authData, signature := authenticator.get(t, rp.ID, "login-challenge", "https://auth.example.com")
+	asserted, err := rp.VerifyAssertion(clientData, authData, signature, "login-challenge", credential.PublicKey, credential.SignCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asserted.SignCount != 1 {
+		t.Errorf("sign count is %d, want 1", asserted.SignCount)
+	}
+
+	// a replayed assertion has the same counter
+	if _, err = rp.VerifyAssertion(clientData, authData, signature, "login-challenge", credential.PublicKey, asserted.SignCount); !errors.Is(err, ErrWebAuthnSignCount) {
+		t.Errorf("replayed assertion: %v", err)
+	}
+
+	signature[len(signature)-1] ^= 0xff
+	if _, err = rp.VerifyAssertion(clientData, authData, signature, "login-challenge", credential.PublicKey, credential.SignCount); err == nil {
+		t.Errorf("tampered signature verified")
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	b := encodeCBOR([]cborPair{{"a", 1}, {-2, []byte{1, 2}}, {3, -300}})
+	decoded, rest, err := decodeCBOR(append(b, 0xff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := decoded.(map[interface{}]interface{})
+	if m["a"] != int64(1) || string(m[int64(-2)].([]byte)) != "\x01\x02" || m[int64(3)] != int64(-300) || len(rest) != 1 {
+		t.Errorf("decoded %v, rest %v", m, rest)
+	}
+
+	if _, _, err = decodeCBOR([]byte{0x5f}); err == nil {
+		t.Errorf("indefinite length decoded")
+	}
+	if _, _, err = decodeCBOR([]byte{0x44, 1, 2}); err == nil {
+		t.Errorf("truncated string decoded")
+	}
+}
@@ -2,6 +2,7 @@ package httpx
 
 import (
 	"net/http"
+	"strconv"
 	"yip/src/slyerrors"
 )
 
@@ -45,6 +46,12 @@ func MapServiceError(err error) *Response {
 	case slyerrors.KindUnauthorized:
 		response.StatusCode = http.StatusUnauthorized
 
+	case slyerrors.KindTooManyRequests:
+		response.StatusCode = http.StatusTooManyRequests
+		if rootErr.RetryAfter > 0 {
+			response.AddHeader("Retry-After", strconv.Itoa(rootErr.RetryAfter))
+		}
+
 		// TODO: handle 502 and 503 slyerrors with dedicated kinds
 
 	default:
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 )
 
@@ -263,3 +264,13 @@ func PrintRequestBody(r *http.Request) {
 	// Important: Restore the body for later use
 	r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 }
+
+// ClientIP returns the ip of the peer. Behind a proxy api.trust_proxy sets
+// the remote address to the client ip with middleware.RealIP.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -1,55 +1,230 @@
 package providers
 
 import (
+	"bytes"
+	"context"
+	"embed"
 	"fmt"
-	"github.com/mailjet/mailjet-apiv3-go"
+	htmltemplate "html/template"
+	"io/fs"
 	"log"
+	"net/mail"
+	"os"
+	"strings"
+	texttemplate "text/template"
+	"time"
 	"yip/src/config"
 )
 
+const defaultEmailLanguage = "en"
+
+// MailTemplate names the templates of a mail. Each language has the text
+// template <name>.txt, defining the "subject" too, and the html template
+// <name>.html.
+type MailTemplate string
+
+const (
+	MailPin           MailTemplate = "pin"
+	MailEmailChange   MailTemplate = "email_change"
+	MailSecurityAlert MailTemplate = "security_alert"
+	MailInvitation    MailTemplate = "invitation"
+	MailPasswordReset MailTemplate = "password_reset"
+)
+
+var mailTemplates = []MailTemplate{MailPin, MailEmailChange, MailSecurityAlert, MailInvitation, MailPasswordReset}
+
+// PinMail carries the pin a user redeems to sign in, and the magic link
+// doing the same if enabled
+type PinMail struct {
+	Pin       string
+	Link      string
+	ExpiresAt time.Time
+}
+
+// EmailChangeMail is sent to a new email address to confirm it
+type EmailChangeMail struct {
+	NewEmail  string
+	Code      string
+	Link      string
+	ExpiresAt time.Time
+}
+
+// SecurityAlertMail tells a user about a security relevant event of the account
+type SecurityAlertMail struct {
+	Event string
+	IP    string
+	Time  time.Time
+}
+
+// InvitationMail carries an invitation code
+type InvitationMail struct {
+	Code string
+	Link string
+}
+
+// PasswordResetMail carries the link, or the token if no reset url is
+// configured, to reset the password of an account
+type PasswordResetMail struct {
+	Token     string
+	Link      string
+	ExpiresAt time.Time
+}
+
+//go:embed templates/email
+var embeddedEmailTemplates embed.FS
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// EmailProvider renders mails of the local templates and sends them with the
+// EmailSender selected by config.EmailConfig
 type EmailProvider struct {
-	client *mailjet.Client
-	config *config.EmailConfig
+	sender          EmailSender
+	from            mail.Address
+	defaultLanguage string
+	// templates by language and name
+	templates map[string]map[MailTemplate]*emailTemplate
 }
 
 func InitEmailProvider(config *config.EmailConfig) (EmailProvider, error) {
-	//mailjetClient := NewMailjetClient(os.Getenv("MJ_APIKEY_PUBLIC"), os.Getenv("MJ_APIKEY_PRIVATE"))
-	client := mailjet.NewMailjetClient(config.MailJet.PublicKey, config.MailJet.PrivateKey)
+	sender, err := initEmailSender(config)
+	if err != nil {
+		return EmailProvider{}, err
+	}
+
+	var templateFS fs.FS
+	if config.TemplateDir != "" {
+		templateFS = os.DirFS(config.TemplateDir)
+	} else {
+		templateFS, err = fs.Sub(embeddedEmailTemplates, "templates/email")
+		if err != nil {
+			return EmailProvider{}, err
+		}
+	}
+
+	templates, err := loadEmailTemplates(templateFS)
+	if err != nil {
+		return EmailProvider{}, err
+	}
+
+	defaultLanguage := config.DefaultLanguage
+	if defaultLanguage == "" {
+		defaultLanguage = defaultEmailLanguage
+	}
+	for _, name := range mailTemplates {
+		if templates[defaultLanguage][name] == nil {
+			return EmailProvider{}, fmt.Errorf("email template %s missing for default language %s", name, defaultLanguage)
+		}
+	}
+
 	return EmailProvider{
-		client: client,
-		config: config,
+		sender:          sender,
+		from:            mail.Address{Name: config.SenderName, Address: config.SenderEmail},
+		defaultLanguage: defaultLanguage,
+		templates:       templates,
+	}, nil
+}
+
+// loadEmailTemplates parses the templates of fsys, one directory per language
+func loadEmailTemplates(fsys fs.FS) (map[string]map[MailTemplate]*emailTemplate, error) {
+	languages, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]map[MailTemplate]*emailTemplate)
+	for _, language := range languages {
+		if !language.IsDir() {
+			continue
+		}
+
+		lang := language.Name()
+		templates[lang] = make(map[MailTemplate]*emailTemplate)
+		for _, name := range mailTemplates {
+			text, err := texttemplate.ParseFS(fsys, fmt.Sprintf("%s/%s.txt", lang, name))
+			if err != nil {
+				// languages may translate a part of the mails only
+				continue
+			}
+			if text.Lookup("subject") == nil {
+				return nil, fmt.Errorf("email template %s/%s.txt defines no subject", lang, name)
+			}
+
+			html, err := htmltemplate.ParseFS(fsys, fmt.Sprintf("%s/%s.html", lang, name))
+			if err != nil {
+				return nil, err
+			}
+			templates[lang][name] = &emailTemplate{text: text, html: html}
+		}
+	}
+	return templates, nil
+}
+
+// Render renders a mail in the language, mails not translated to it are
+// rendered in the default language
+func (ep *EmailProvider) Render(toEmail string, lang string, name MailTemplate, data interface{}) (*Email, error) {
+	t := ep.templates[strings.ToLower(lang)][name]
+	if t == nil {
+		t = ep.templates[ep.defaultLanguage][name]
+	}
+	if t == nil {
+		return nil, fmt.Errorf("unknown email template %s", name)
+	}
+
+	subject := &bytes.Buffer{}
+	if err := t.text.ExecuteTemplate(subject, "subject", data); err != nil {
+		return nil, err
+	}
+	text := &bytes.Buffer{}
+	if err := t.text.Execute(text, data); err != nil {
+		return nil, err
+	}
+	html := &bytes.Buffer{}
+	if err := t.html.Execute(html, data); err != nil {
+		return nil, err
+	}
+
+	return &Email{
+		From:    ep.from,
+		To:      toEmail,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()),
+		HTML:    html.String(),
 	}, nil
 }
 
-func (ep EmailProvider) SendPinMail(toEmail string, pin string) error {
-	fmt.Println("Sending email to ", toEmail, "with Pin", pin)
-	messagesInfo := []mailjet.InfoMessagesV31{
-		mailjet.InfoMessagesV31{
-			From: &mailjet.RecipientV31{
-				Email: ep.config.SenderEmail,
-				Name:  ep.config.SenderName,
-			},
-			To: &mailjet.RecipientsV31{
-				mailjet.RecipientV31{
-					Email: toEmail,
-					Name:  "Client",
-				},
-			},
-			TemplateID:       ep.config.MailJet.TemplateId,
-			TemplateLanguage: true,
-			Subject:          "Your PIN",
-			Variables: map[string]interface{}{
-				"pin": pin,
-			},
-		},
-	}
-
-	messages := mailjet.MessagesV31{Info: messagesInfo}
-	res, err := ep.client.SendMailV31(&messages)
+// SendMail renders and sends a mail
+func (ep *EmailProvider) SendMail(ctx context.Context, toEmail string, lang string, name MailTemplate, data interface{}) error {
+	e, err := ep.Render(toEmail, lang, name, data)
 	if err != nil {
-		log.Println(err.Error())
 		return err
 	}
-	log.Println(res.ResultsV31)
+
+	if err = ep.sender.Send(ctx, *e); err != nil {
+		log.Printf("could not send %s mail: %s", name, err)
+		return err
+	}
 	return nil
 }
+
+func (ep *EmailProvider) SendPinMail(ctx context.Context, toEmail string, lang string, m PinMail) error {
+	return ep.SendMail(ctx, toEmail, lang, MailPin, m)
+}
+
+func (ep *EmailProvider) SendEmailChangeMail(ctx context.Context, toEmail string, lang string, m EmailChangeMail) error {
+	return ep.SendMail(ctx, toEmail, lang, MailEmailChange, m)
+}
+
+func (ep *EmailProvider) SendSecurityAlertMail(ctx context.Context, toEmail string, lang string, m SecurityAlertMail) error {
+	return ep.SendMail(ctx, toEmail, lang, MailSecurityAlert, m)
+}
+
+func (ep *EmailProvider) SendInvitationMail(ctx context.Context, toEmail string, lang string, m InvitationMail) error {
+	return ep.SendMail(ctx, toEmail, lang, MailInvitation, m)
+}
+
+func (ep *EmailProvider) SendPasswordResetMail(ctx context.Context, toEmail string, lang string, m PasswordResetMail) error {
+	return ep.SendMail(ctx, toEmail, lang, MailPasswordReset, m)
+}
@@ -0,0 +1,94 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"yip/src/config"
+)
+
+func TestLogEmailSender(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "outbox.log")
+	ep, err := InitEmailProvider(&config.EmailConfig{Provider: EmailProviderLog, Outbox: file, SenderEmail: "yip@yours.net"})
+	assert.NoError(t, err)
+
+	expiresAt := time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+	assert.NoError(t, ep.SendPinMail(context.Background(), "some@email.com", "de", PinMail{Pin: "123456", ExpiresAt: expiresAt}))
+
+	b, err := os.ReadFile(file)
+	assert.NoError(t, err)
+
+	logged := loggedEmail{}
+	assert.NoError(t, json.Unmarshal(b, &logged))
+	assert.Equal(t, "some@email.com", logged.To)
+	assert.Equal(t, "yip@yours.net", logged.From.Address)
+	assert.Equal(t, "Deine PIN", logged.Subject)
+	assert.Contains(t, logged.Text, "123456")
+	assert.Contains(t, logged.Text, "19.10.2026 15:00")
+	assert.Contains(t, logged.HTML, "<strong>123456</strong>")
+}
+
+func TestRenderEmail(t *testing.T) {
+	ep, err := InitEmailProvider(&config.EmailConfig{Provider: EmailProviderLog})
+	assert.NoError(t, err)
+
+	for _, lang := range []string{"en", "de"} {
+		for _, name := range mailTemplates {
+			_, err = ep.Render("some@email.com", lang, name, map[string]interface{}{"Time": time.Now(), "ExpiresAt": time.Now()})
+			assert.NoError(t, err, "%s/%s", lang, name)
+		}
+	}
+
+	// untranslated languages fall back to the default language, html is escaped
+	e, err := ep.Render("some@email.com", "fr", MailSecurityAlert, SecurityAlertMail{Event: "<b>New device</b>", Time: time.Now()})
+	assert.NoError(t, err)
+	assert.Equal(t, "Security alert for your account", e.Subject)
+	assert.Contains(t, e.HTML, "&lt;b&gt;New device&lt;/b&gt;")
+
+	_, err = InitEmailProvider(&config.EmailConfig{Provider: "sendgrid"})
+	assert.Error(t, err)
+	_, err = InitEmailProvider(&config.EmailConfig{Provider: EmailProviderSMTP})
+	assert.Error(t, err)
+}
+
+func TestBuildMIMEMessage(t *testing.T) {
+	msg, err := buildMIMEMessage(Email{
+		From:    mail.Address{Name: "YIP", Address: "yip@yours.net"},
+		To:      "some@email.com",
+		Subject: "Bestätige",
+		Text:    "text",
+		HTML:    "<p>html</p>",
+	})
+	assert.NoError(t, err)
+
+	m, err := mail.ReadMessage(bytes.NewReader(msg))
+	assert.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bestätige", subject)
+
+	_, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	r := multipart.NewReader(m.Body, params["boundary"])
+	var parts []string
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(p)
+		parts = append(parts, strings.TrimSpace(string(body)))
+	}
+	assert.Equal(t, []string{"text", "<p>html</p>"}, parts)
+}
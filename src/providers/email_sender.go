@@ -0,0 +1,247 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/mailjet/mailjet-apiv3-go"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"yip/src/config"
+)
+
+const (
+	EmailProviderMailjet = "mailjet"
+	EmailProviderSMTP    = "smtp"
+	EmailProviderLog     = "log"
+)
+
+// Email is a rendered mail
+type Email struct {
+	From    mail.Address `json:"from"`
+	To      string       `json:"to"`
+	Subject string       `json:"subject"`
+	Text    string       `json:"text"`
+	HTML    string       `json:"html"`
+}
+
+// EmailSender delivers rendered mails
+type EmailSender interface {
+	Send(ctx context.Context, e Email) error
+}
+
+func initEmailSender(config *config.EmailConfig) (EmailSender, error) {
+	switch config.Provider {
+	case "", EmailProviderMailjet:
+		return &MailjetSender{client: mailjet.NewMailjetClient(config.MailJet.PublicKey, config.MailJet.PrivateKey)}, nil
+	case EmailProviderSMTP:
+		if config.SMTP.Host == "" {
+			return nil, fmt.Errorf("email.smtp.host is not set")
+		}
+		return &SMTPSender{config: config.SMTP}, nil
+	case EmailProviderLog:
+		return &LogEmailSender{file: config.Outbox}, nil
+	default:
+		return nil, fmt.Errorf("unknown email provider: %s", config.Provider)
+	}
+}
+
+// MailjetSender sends mails with the send API of Mailjet
+type MailjetSender struct {
+	client *mailjet.Client
+}
+
+func (s *MailjetSender) Send(_ context.Context, e Email) error {
+	messages := mailjet.MessagesV31{Info: []mailjet.InfoMessagesV31{
+		{
+			From: &mailjet.RecipientV31{
+				Email: e.From.Address,
+				Name:  e.From.Name,
+			},
+			To: &mailjet.RecipientsV31{
+				mailjet.RecipientV31{
+					Email: e.To,
+				},
+			},
+			Subject:  e.Subject,
+			TextPart: e.Text,
+			HTMLPart: e.HTML,
+		},
+	}}
+
+	res, err := s.client.SendMailV31(&messages)
+	if err != nil {
+		return err
+	}
+	log.Println("mailjet:", res.ResultsV31)
+	return nil
+}
+
+// SMTPSender sends mails to an SMTP server. STARTTLS is used if the server
+// offers it, ImplicitTLS connects with TLS right away (port 465).
+type SMTPSender struct {
+	config config.SMTPConfig
+}
+
+func (s *SMTPSender) Send(ctx context.Context, e Email) error {
+	msg, err := buildMIMEMessage(e)
+	if err != nil {
+		return err
+	}
+
+	port := s.config.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	if !s.config.ImplicitTLS {
+		return smtp.SendMail(addr, auth, e.From.Address, []string{e.To}, msg)
+	}
+
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: s.config.Host}}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if auth != nil {
+		if err = c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(e.From.Address); err != nil {
+		return err
+	}
+	if err = c.Rcpt(e.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMIMEMessage builds a multipart/alternative mail of the text and html
+// part
+func buildMIMEMessage(e Email) ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+
+	fmt.Fprintf(buf, "From: %s\r\n", e.From.String())
+	fmt.Fprintf(buf, "To: %s\r\n", e.To)
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", e.Subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domainOf(e.From.Address))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", e.Text},
+		{"text/html; charset=utf-8", e.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err = qw.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err = qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func domainOf(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
+
+// LogEmailSender is an outbox for local and air-gapped use. Mails are appended
+// as json lines to file, or written to the log if no file is configured.
+type LogEmailSender struct {
+	file  string
+	mutex sync.Mutex
+}
+
+type loggedEmail struct {
+	SentAt time.Time `json:"sentAt"`
+	Email
+}
+
+func (s *LogEmailSender) Send(_ context.Context, e Email) error {
+	line, err := json.Marshal(loggedEmail{SentAt: time.Now(), Email: e})
+	if err != nil {
+		return err
+	}
+
+	if s.file == "" {
+		log.Println("email:", string(line))
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	f, err := os.OpenFile(s.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,73 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+	"yip/src/config"
+)
+
+const PushProviderLog = "log"
+
+// PushNotification is delivered to a device of a user
+type PushNotification struct {
+	Title string            `json:"title"`
+	Body  string            `json:"body"`
+	Data  map[string]string `json:"data"`
+}
+
+// PushProvider delivers push notifications to the push token of a device
+type PushProvider interface {
+	Send(ctx context.Context, token string, platform string, n PushNotification) error
+}
+
+func InitPushProvider(config *config.PushConfig) (PushProvider, error) {
+	switch config.Provider {
+	case "", PushProviderLog:
+		return &LogPushProvider{file: config.File}, nil
+	default:
+		return nil, fmt.Errorf("unknown push provider: %s", config.Provider)
+	}
+}
+
+// LogPushProvider is a fake for local use. Notifications are appended as json
+// lines to file, or written to the log if no file is configured.
+type LogPushProvider struct {
+	file  string
+	mutex sync.Mutex
+}
+
+type loggedPush struct {
+	Token    string           `json:"token"`
+	Platform string           `json:"platform"`
+	SentAt   time.Time        `json:"sentAt"`
+	Push     PushNotification `json:"push"`
+}
+
+func (p *LogPushProvider) Send(_ context.Context, token string, platform string, n PushNotification) error {
+	line, err := json.Marshal(loggedPush{Token: token, Platform: platform, SentAt: time.Now(), Push: n})
+	if err != nil {
+		return err
+	}
+
+	if p.file == "" {
+		log.Println("push:", string(line))
+		return nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	f, err := os.OpenFile(p.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,34 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yip/src/config"
+)
+
+func TestLogPushProvider(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "push.log")
+	p, err := InitPushProvider(&config.PushConfig{Provider: PushProviderLog, File: file})
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.Send(context.Background(), "t1", "ios", PushNotification{Title: "a"}))
+	assert.NoError(t, p.Send(context.Background(), "t2", "android", PushNotification{Title: "b", Data: map[string]string{"k": "v"}}))
+
+	b, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	assert.Len(t, lines, 2)
+
+	logged := loggedPush{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &logged))
+	assert.Equal(t, "t2", logged.Token)
+	assert.Equal(t, "v", logged.Push.Data["k"])
+
+	_, err = InitPushProvider(&config.PushConfig{Provider: "fcm"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+	"yip/src/config"
+)
+
+const SMSProviderLog = "log"
+
+// SMSProvider delivers text messages to an E.164 phone number
+type SMSProvider interface {
+	Send(ctx context.Context, phone string, text string) error
+}
+
+func InitSMSProvider(config *config.SMSConfig) (SMSProvider, error) {
+	switch config.Provider {
+	case "", SMSProviderLog:
+		return &LogSMSProvider{file: config.File}, nil
+	default:
+		return nil, fmt.Errorf("unknown sms provider: %s", config.Provider)
+	}
+}
+
+// LogSMSProvider is a fake for local use. Messages are appended as json lines
+// to file, or written to the log if no file is configured.
+type LogSMSProvider struct {
+	file  string
+	mutex sync.Mutex
+}
+
+type loggedSMS struct {
+	Phone  string    `json:"phone"`
+	SentAt time.Time `json:"sentAt"`
+	Text   string    `json:"text"`
+}
+
+func (p *LogSMSProvider) Send(_ context.Context, phone string, text string) error {
+	line, err := json.Marshal(loggedSMS{Phone: phone, SentAt: time.Now(), Text: text})
+	if err != nil {
+		return err
+	}
+
+	if p.file == "" {
+		log.Println("sms:", string(line))
+		return nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	f, err := os.OpenFile(p.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
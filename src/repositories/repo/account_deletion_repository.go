@@ -0,0 +1,226 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// AccountDeletionRepository handles all AccountDeletion related database operations
+type AccountDeletionRepository struct {
+	db *Database
+}
+
+// NewAccountDeletionRepository creates a new AccountDeletion repository
+func NewAccountDeletionRepository(db *Database) *AccountDeletionRepository {
+	return &AccountDeletionRepository{
+		db: db,
+	}
+}
+
+// Anonymize clears the personal data of the account, detaches its keys,
+// revokes its tokens and records the deletion in one transaction. It returns
+// DBItemNotFound if the account does not exist or is deleted already.
+func (r *AccountDeletionRepository) Anonymize(ctx context.Context, deletion *AccountDeletionModel) (*AccountDeletionModel, error) {
+	var created *AccountDeletionModel
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if err := anonymizeAccount(ctx, tx, deletion.AccountID, deletion.RequestedAt); err != nil {
+			return err
+		}
+
+		var err error
+		created, err = insertAccountDeletion(ctx, tx, deletion)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// anonymizeAccount clears the personal data of the account, detaches its
+// keys, cancels its open links and marks it deleted at
+func anonymizeAccount(ctx context.Context, tx *sql.Tx, accountID uuid.UUID, at time.Time) error {
+	accountStmt := table.Account.UPDATE().
+		SET(
+			table.Account.FirstName.SET(postgres.String("")),
+			table.Account.LastName.SET(postgres.String("")),
+			table.Account.Phone.SET(postgres.String("")),
+			table.Account.Email.SET(postgres.StringExp(postgres.NULL)),
+			table.Account.IsEmailVerified.SET(postgres.Bool(false)),
+			table.Account.IsPhoneVerified.SET(postgres.Bool(false)),
+			table.Account.PasswordHashed.SET(postgres.String("")),
+			table.Account.LastUsedSlyWallet.SET(postgres.String("")),
+			table.Account.TokensRevokedAt.SET(postgres.TimestampzT(at)),
+			table.Account.DeletedAt.SET(postgres.TimestampzT(at)),
+			table.Account.UpdatedAt.SET(postgres.TimestampzT(at)),
+		).WHERE(
+		table.Account.ID.EQ(postgres.UUID(accountID)).
+			AND(table.Account.DeletedAt.IS_NULL()),
+	)
+
+	result, err := accountStmt.ExecContext(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize account: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return DBItemNotFound
+	}
+
+	if err = detachAccountKeys(ctx, tx, accountID); err != nil {
+		return err
+	}
+
+	// email changes hold former addresses
+	changeStmt := table.EmailChange.DELETE().WHERE(
+		table.EmailChange.AccountID.EQ(postgres.UUID(accountID)),
+	)
+	if _, err = changeStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete account's email changes: %w", err)
+	}
+
+	resetStmt := table.PasswordReset.DELETE().WHERE(
+		table.PasswordReset.AccountID.EQ(postgres.UUID(accountID)),
+	)
+	if _, err = resetStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete account's password resets: %w", err)
+	}
+
+	if err = deleteMFA(ctx, tx, accountID.String()); err != nil {
+		return err
+	}
+
+	if err = deleteWebAuthn(ctx, tx, accountID); err != nil {
+		return err
+	}
+
+	linkStmt := table.AccountLink.UPDATE().
+		SET(
+			table.AccountLink.Status.SET(postgres.String(AccountLinkCancelled)),
+		).WHERE(
+		table.AccountLink.AccountID.EQ(postgres.UUID(accountID)).
+			OR(table.AccountLink.LinkedAccountID.EQ(postgres.UUID(accountID))).
+			AND(table.AccountLink.Status.IN(postgres.String(AccountLinkPending), postgres.String(AccountLinkConfirmed))),
+	)
+	if _, err = linkStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to cancel account's links: %w", err)
+	}
+
+	return nil
+}
+
+func insertAccountDeletion(ctx context.Context, tx *sql.Tx, deletion *AccountDeletionModel) (*AccountDeletionModel, error) {
+	stmt := table.AccountDeletion.INSERT(
+		table.AccountDeletion.AccountID,
+		table.AccountDeletion.RequestedBy,
+		table.AccountDeletion.RequestedAt,
+		table.AccountDeletion.PurgeAfter,
+	).VALUES(
+		postgres.UUID(deletion.AccountID),
+		deletion.RequestedBy,
+		postgres.TimestampzT(deletion.RequestedAt),
+		postgres.TimestampzT(deletion.PurgeAfter),
+	).RETURNING(
+		table.AccountDeletion.AllColumns,
+	)
+
+	var dbDeletion model.AccountDeletion
+	if err := stmt.QueryContext(ctx, tx, &dbDeletion); err != nil {
+		return nil, fmt.Errorf("failed to create AccountDeletion: %w", err)
+	}
+
+	return mapAccountDeletionToModel(dbDeletion), nil
+}
+
+// GetByAccount retrieves the deletion of an account
+func (r *AccountDeletionRepository) GetByAccount(ctx context.Context, accountId uuid.UUID) (*AccountDeletionModel, error) {
+	stmt := postgres.SELECT(
+		table.AccountDeletion.AllColumns,
+	).FROM(
+		table.AccountDeletion,
+	).WHERE(
+		table.AccountDeletion.AccountID.EQ(postgres.UUID(accountId)),
+	)
+
+	var dbDeletion model.AccountDeletion
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbDeletion)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get AccountDeletion: %w", err)
+	}
+
+	return mapAccountDeletionToModel(dbDeletion), nil
+}
+
+// ListDue retrieves the deletions whose grace period ended before now and
+// which are not purged yet
+func (r *AccountDeletionRepository) ListDue(ctx context.Context, now time.Time) ([]AccountDeletionModel, error) {
+	stmt := postgres.SELECT(
+		table.AccountDeletion.AllColumns,
+	).FROM(
+		table.AccountDeletion,
+	).WHERE(
+		table.AccountDeletion.PurgeAfter.LT_EQ(postgres.TimestampzT(now)).
+			AND(table.AccountDeletion.PurgedAt.IS_NULL()),
+	).ORDER_BY(
+		table.AccountDeletion.PurgeAfter.ASC(),
+	)
+
+	var dbDeletions []model.AccountDeletion
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbDeletions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due AccountDeletions: %w", err)
+	}
+
+	deletions := make([]AccountDeletionModel, len(dbDeletions))
+	for i, dbDeletion := range dbDeletions {
+		deletions[i] = *mapAccountDeletionToModel(dbDeletion)
+	}
+
+	return deletions, nil
+}
+
+// MarkPurged records that the account of the deletion is deleted
+func (r *AccountDeletionRepository) MarkPurged(ctx context.Context, id uuid.UUID, now time.Time) error {
+	stmt := table.AccountDeletion.UPDATE().
+		SET(
+			table.AccountDeletion.PurgedAt.SET(postgres.TimestampzT(now)),
+		).WHERE(
+		table.AccountDeletion.ID.EQ(postgres.UUID(id)),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to mark AccountDeletion purged: %w", err)
+	}
+
+	return nil
+}
+
+// Helper function to map AccountDeletion model to AccountDeletionModel
+func mapAccountDeletionToModel(deletion model.AccountDeletion) *AccountDeletionModel {
+	return &AccountDeletionModel{
+		ID:          deletion.ID,
+		AccountID:   deletion.AccountID,
+		RequestedBy: deletion.RequestedBy,
+		RequestedAt: deletion.RequestedAt,
+		PurgeAfter:  deletion.PurgeAfter,
+		PurgedAt:    deletion.PurgedAt,
+	}
+}
@@ -0,0 +1,355 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// ErrAccountDeleted is returned by Merge if one of the accounts is deleted
+var ErrAccountDeleted = errors.New("account is deleted")
+
+// AccountLinkRepository handles all AccountLink related database operations
+type AccountLinkRepository struct {
+	db *Database
+}
+
+// NewAccountLinkRepository creates a new AccountLink repository
+func NewAccountLinkRepository(db *Database) *AccountLinkRepository {
+	return &AccountLinkRepository{
+		db: db,
+	}
+}
+
+// Create stores a pending link
+func (r *AccountLinkRepository) Create(ctx context.Context, link *AccountLinkModel) (*AccountLinkModel, error) {
+	stmt := table.AccountLink.INSERT(
+		table.AccountLink.AccountID,
+		table.AccountLink.CodeHash,
+		table.AccountLink.Status,
+		table.AccountLink.ExpiresAt,
+	).VALUES(
+		postgres.UUID(link.AccountID),
+		link.CodeHash,
+		AccountLinkPending,
+		postgres.TimestampzT(link.ExpiresAt),
+	).RETURNING(
+		table.AccountLink.AllColumns,
+	)
+
+	var dbLink model.AccountLink
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbLink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AccountLink: %w", err)
+	}
+
+	return mapAccountLinkToModel(dbLink), nil
+}
+
+// GetByID retrieves a link
+func (r *AccountLinkRepository) GetByID(ctx context.Context, id uuid.UUID) (*AccountLinkModel, error) {
+	return r.get(ctx, table.AccountLink.ID.EQ(postgres.UUID(id)))
+}
+
+// GetByCodeHash retrieves the link of a code
+func (r *AccountLinkRepository) GetByCodeHash(ctx context.Context, codeHash string) (*AccountLinkModel, error) {
+	return r.get(ctx, table.AccountLink.CodeHash.EQ(postgres.String(codeHash)))
+}
+
+func (r *AccountLinkRepository) get(ctx context.Context, condition postgres.BoolExpression) (*AccountLinkModel, error) {
+	stmt := postgres.SELECT(
+		table.AccountLink.AllColumns,
+	).FROM(
+		table.AccountLink,
+	).WHERE(
+		condition,
+	)
+
+	var dbLink model.AccountLink
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbLink)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get AccountLink: %w", err)
+	}
+
+	return mapAccountLinkToModel(dbLink), nil
+}
+
+// Confirm links the pending link to linkedAccountId if it is not expired at now
+func (r *AccountLinkRepository) Confirm(ctx context.Context, id uuid.UUID, linkedAccountId uuid.UUID, now time.Time) (*AccountLinkModel, error) {
+	stmt := table.AccountLink.UPDATE().
+		SET(
+			table.AccountLink.LinkedAccountID.SET(postgres.UUID(linkedAccountId)),
+			table.AccountLink.Status.SET(postgres.String(AccountLinkConfirmed)),
+			table.AccountLink.ConfirmedAt.SET(postgres.TimestampzT(now)),
+		).WHERE(
+		table.AccountLink.ID.EQ(postgres.UUID(id)).
+			AND(table.AccountLink.Status.EQ(postgres.String(AccountLinkPending))).
+			AND(table.AccountLink.ExpiresAt.GT(postgres.TimestampzT(now))),
+	).RETURNING(
+		table.AccountLink.AllColumns,
+	)
+
+	var dbLink model.AccountLink
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbLink)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to confirm AccountLink: %w", err)
+	}
+
+	return mapAccountLinkToModel(dbLink), nil
+}
+
+// Cancel cancels a pending or confirmed link
+func (r *AccountLinkRepository) Cancel(ctx context.Context, id uuid.UUID) error {
+	stmt := table.AccountLink.UPDATE().
+		SET(
+			table.AccountLink.Status.SET(postgres.String(AccountLinkCancelled)),
+		).WHERE(
+		table.AccountLink.ID.EQ(postgres.UUID(id)).
+			AND(table.AccountLink.Status.IN(postgres.String(AccountLinkPending), postgres.String(AccountLinkConfirmed))),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to cancel AccountLink: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return DBItemNotFound
+	}
+
+	return nil
+}
+
+// ListByAccount retrieves the links started or confirmed by an account, the latest first
+func (r *AccountLinkRepository) ListByAccount(ctx context.Context, accountId uuid.UUID) ([]AccountLinkModel, error) {
+	return r.list(ctx, table.AccountLink.AccountID.EQ(postgres.UUID(accountId)).
+		OR(table.AccountLink.LinkedAccountID.EQ(postgres.UUID(accountId))))
+}
+
+// ListByStatus retrieves the links with a status, the latest first
+func (r *AccountLinkRepository) ListByStatus(ctx context.Context, status string) ([]AccountLinkModel, error) {
+	return r.list(ctx, table.AccountLink.Status.EQ(postgres.String(status)))
+}
+
+func (r *AccountLinkRepository) list(ctx context.Context, condition postgres.BoolExpression) ([]AccountLinkModel, error) {
+	stmt := postgres.SELECT(
+		table.AccountLink.AllColumns,
+	).FROM(
+		table.AccountLink,
+	).WHERE(
+		condition,
+	).ORDER_BY(
+		table.AccountLink.CreatedAt.DESC(),
+	)
+
+	var dbLinks []model.AccountLink
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbLinks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AccountLinks: %w", err)
+	}
+
+	links := make([]AccountLinkModel, len(dbLinks))
+	for i, dbLink := range dbLinks {
+		links[i] = *mapAccountLinkToModel(dbLink)
+	}
+
+	return links, nil
+}
+
+// Merge moves the keys, wallets, push tokens and invitation code of the source
+// account of a confirmed link into the target account in one transaction.
+// Profile fields the target lacks are taken from the source. The source is
+// anonymized and its deletion recorded for the purge. It returns
+// DBItemNotFound if the link is not confirmed and ErrAccountDeleted if one of
+// the accounts is deleted.
+func (r *AccountLinkRepository) Merge(ctx context.Context, link *AccountLinkModel, targetId uuid.UUID, sourceId uuid.UUID, mergedBy string, now time.Time) (*AccountModel, error) {
+	var merged model.Account
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		linkStmt := table.AccountLink.UPDATE().
+			SET(
+				table.AccountLink.Status.SET(postgres.String(AccountLinkMerged)),
+				table.AccountLink.MergedInto.SET(postgres.UUID(targetId)),
+				table.AccountLink.MergedBy.SET(postgres.String(mergedBy)),
+				table.AccountLink.MergedAt.SET(postgres.TimestampzT(now)),
+			).WHERE(
+			table.AccountLink.ID.EQ(postgres.UUID(link.ID)).
+				AND(table.AccountLink.Status.EQ(postgres.String(AccountLinkConfirmed))),
+		)
+		result, err := linkStmt.ExecContext(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to merge AccountLink: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return DBItemNotFound
+		}
+
+		var accounts []model.Account
+		accountsStmt := postgres.SELECT(
+			table.Account.AllColumns,
+		).FROM(
+			table.Account,
+		).WHERE(
+			table.Account.ID.IN(postgres.UUID(targetId), postgres.UUID(sourceId)),
+		).FOR(
+			postgres.UPDATE(),
+		)
+		if err = accountsStmt.QueryContext(ctx, tx, &accounts); err != nil {
+			return fmt.Errorf("failed to get accounts to merge: %w", err)
+		}
+		var target, source *model.Account
+		for i := range accounts {
+			if accounts[i].ID == targetId {
+				target = &accounts[i]
+			} else if accounts[i].ID == sourceId {
+				source = &accounts[i]
+			}
+		}
+		if target == nil || source == nil || target.DeletedAt != nil || source.DeletedAt != nil {
+			return ErrAccountDeleted
+		}
+
+		ecdsaStmt := table.Ecdsa.UPDATE().
+			SET(
+				table.Ecdsa.AccountID.SET(postgres.UUID(targetId)),
+				table.Ecdsa.UpdatedAt.SET(postgres.TimestampzT(now)),
+			).WHERE(
+			table.Ecdsa.AccountID.EQ(postgres.UUID(sourceId)),
+		)
+		if _, err = ecdsaStmt.ExecContext(ctx, tx); err != nil {
+			return fmt.Errorf("failed to move ECDSA keys: %w", err)
+		}
+
+		slyWalletStmt := table.SlyWallet.UPDATE().
+			SET(
+				table.SlyWallet.AccountID.SET(postgres.UUID(targetId)),
+				table.SlyWallet.UpdatedAt.SET(postgres.TimestampzT(now)),
+			).WHERE(
+			table.SlyWallet.AccountID.EQ(postgres.UUID(sourceId)),
+		)
+		if _, err = slyWalletStmt.ExecContext(ctx, tx); err != nil {
+			return fmt.Errorf("failed to move SlyWallets: %w", err)
+		}
+
+		pushStmt := table.PushToken.UPDATE().
+			SET(
+				table.PushToken.AccountID.SET(postgres.UUID(targetId)),
+			).WHERE(
+			table.PushToken.AccountID.EQ(postgres.UUID(sourceId)),
+		)
+		if _, err = pushStmt.ExecContext(ctx, tx); err != nil {
+			return fmt.Errorf("failed to move push tokens: %w", err)
+		}
+
+		credentialStmt := table.WebauthnCredential.UPDATE().
+			SET(
+				table.WebauthnCredential.AccountID.SET(postgres.UUID(targetId)),
+			).WHERE(
+			table.WebauthnCredential.AccountID.EQ(postgres.UUID(sourceId)),
+		)
+		if _, err = credentialStmt.ExecContext(ctx, tx); err != nil {
+			return fmt.Errorf("failed to move WebAuthn credentials: %w", err)
+		}
+
+		// the source is cleared before its email may move to the target
+		if err = anonymizeAccount(ctx, tx, sourceId, now); err != nil {
+			return err
+		}
+		_, err = insertAccountDeletion(ctx, tx, &AccountDeletionModel{
+			AccountID:   sourceId,
+			RequestedBy: mergedBy,
+			RequestedAt: now,
+			PurgeAfter:  now,
+		})
+		if err != nil {
+			return err
+		}
+
+		mergeAccountFields(target, source)
+		targetStmt := table.Account.UPDATE().
+			SET(
+				table.Account.FirstName.SET(postgres.String(target.FirstName)),
+				table.Account.LastName.SET(postgres.String(target.LastName)),
+				table.Account.Phone.SET(postgres.String(target.Phone)),
+				table.Account.Email.SET(postgres.StringExp(stringOrNull(stringOf(target.Email)))),
+				table.Account.IsEmailVerified.SET(postgres.Bool(target.IsEmailVerified)),
+				table.Account.IsPhoneVerified.SET(postgres.Bool(target.IsPhoneVerified)),
+				table.Account.InvitationCode.SET(postgres.String(target.InvitationCode)),
+				table.Account.LastUsedSlyWallet.SET(postgres.String(target.LastUsedSlyWallet)),
+				table.Account.UpdatedAt.SET(postgres.TimestampzT(now)),
+			).WHERE(
+			table.Account.ID.EQ(postgres.UUID(targetId)),
+		).RETURNING(
+			table.Account.AllColumns,
+		)
+		if err = targetStmt.QueryContext(ctx, tx, &merged); err != nil {
+			return fmt.Errorf("failed to update merged account: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAccountToModel(merged), nil
+}
+
+// mergeAccountFields fills the empty profile fields of target from source
+func mergeAccountFields(target *model.Account, source *model.Account) {
+	if target.FirstName == "" && target.LastName == "" {
+		target.FirstName, target.LastName = source.FirstName, source.LastName
+	}
+	if stringOf(target.Email) == "" && stringOf(source.Email) != "" {
+		target.Email, target.IsEmailVerified = source.Email, source.IsEmailVerified
+	}
+	if target.Phone == "" && source.Phone != "" {
+		target.Phone, target.IsPhoneVerified = source.Phone, source.IsPhoneVerified
+	}
+	if target.InvitationCode == "" {
+		target.InvitationCode = source.InvitationCode
+	}
+	if target.LastUsedSlyWallet == "" {
+		target.LastUsedSlyWallet = source.LastUsedSlyWallet
+	}
+}
+
+// Helper function to map AccountLink model to AccountLinkModel
+func mapAccountLinkToModel(link model.AccountLink) *AccountLinkModel {
+	return &AccountLinkModel{
+		ID:              link.ID,
+		AccountID:       link.AccountID,
+		LinkedAccountID: link.LinkedAccountID,
+		CodeHash:        link.CodeHash,
+		Status:          link.Status,
+		ExpiresAt:       link.ExpiresAt,
+		ConfirmedAt:     link.ConfirmedAt,
+		MergedInto:      link.MergedInto,
+		MergedBy:        link.MergedBy,
+		MergedAt:        link.MergedAt,
+		CreatedAt:       link.CreatedAt,
+	}
+}
@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"testing"
+	"yip/.gen/slyip/slyip/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeAccountFields(t *testing.T) {
+	targetEmail, sourceEmail := "", "source@email.com"
+	target := &model.Account{LastName: "Lovelace", Email: &targetEmail, LastUsedSlyWallet: "0xtarget"}
+	source := &model.Account{
+		FirstName:         "Grace",
+		LastName:          "Hopper",
+		Email:             &sourceEmail,
+		IsEmailVerified:   true,
+		Phone:             "+4915112345678",
+		IsPhoneVerified:   true,
+		InvitationCode:    "INVITE",
+		LastUsedSlyWallet: "0xsource",
+	}
+
+	mergeAccountFields(target, source)
+
+	// the names are kept together, the target has one already
+	assert.Equal(t, "", target.FirstName)
+	assert.Equal(t, "Lovelace", target.LastName)
+	// empty fields are taken with their verification
+	assert.Equal(t, "source@email.com", stringOf(target.Email))
+	assert.True(t, target.IsEmailVerified)
+	assert.Equal(t, "+4915112345678", target.Phone)
+	assert.True(t, target.IsPhoneVerified)
+	assert.Equal(t, "INVITE", target.InvitationCode)
+	assert.Equal(t, "0xtarget", target.LastUsedSlyWallet)
+}
+
+func TestMergeAccountFieldsKeepsTarget(t *testing.T) {
+	targetEmail, sourceEmail := "target@email.com", "source@email.com"
+	target := &model.Account{Email: &targetEmail, Phone: "+4915100000000", InvitationCode: "OWN"}
+	source := &model.Account{FirstName: "Grace", LastName: "Hopper", Email: &sourceEmail, IsEmailVerified: true, Phone: "+4915112345678", IsPhoneVerified: true, InvitationCode: "INVITE"}
+
+	mergeAccountFields(target, source)
+
+	assert.Equal(t, "Grace", target.FirstName)
+	assert.Equal(t, "Hopper", target.LastName)
+	assert.Equal(t, "target@email.com", stringOf(target.Email))
+	assert.False(t, target.IsEmailVerified)
+	assert.Equal(t, "+4915100000000", target.Phone)
+	assert.False(t, target.IsPhoneVerified)
+	assert.Equal(t, "OWN", target.InvitationCode)
+
+	// a target without email keeps none if the source has none either
+	target = &model.Account{}
+	mergeAccountFields(target, &model.Account{})
+	assert.Nil(t, target.Email)
+}
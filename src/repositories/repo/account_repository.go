@@ -79,6 +79,34 @@ func (r *AccountRepository) Create(ctx context.Context, account *AccountModel) (
 	return mapAccountToModel(dbAccount), nil
 }
 
+// CreateWithPhone creates an account of a phone number, the email is left
+// empty
+func (r *AccountRepository) CreateWithPhone(ctx context.Context, phone string) (*AccountModel, error) {
+	now := time.Now()
+
+	stmt := table.Account.INSERT(
+		table.Account.ID,
+		table.Account.Phone,
+		table.Account.CreatedAt,
+		table.Account.UpdatedAt,
+	).VALUES(
+		uuid.New(),
+		phone,
+		now,
+		now,
+	).RETURNING(
+		table.Account.AllColumns,
+	)
+
+	var dbAccount model.Account
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbAccount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create account with phone: %w", err)
+	}
+
+	return mapAccountToModel(dbAccount), nil
+}
+
 // GetByID retrieves an account by ID
 func (r *AccountRepository) GetByID(ctx context.Context, id uuid.UUID) (*AccountModel, error) {
 	stmt := postgres.SELECT(
@@ -123,14 +151,16 @@ func (r *AccountRepository) GetByEmail(ctx context.Context, email string) (*Acco
 	return mapAccountToModel(dbAccount), nil
 }
 
-// GetByPhone retrieves an account by phone
-func (r *AccountRepository) GetByPhone(ctx context.Context, phone string) (*AccountModel, error) {
+// GetByVerifiedPhone retrieves the account that verified the phone, a verified
+// phone belongs to one account at most
+func (r *AccountRepository) GetByVerifiedPhone(ctx context.Context, phone string) (*AccountModel, error) {
 	stmt := postgres.SELECT(
 		table.Account.AllColumns,
 	).FROM(
 		table.Account,
 	).WHERE(
-		table.Account.Phone.EQ(postgres.String(phone)),
+		table.Account.Phone.EQ(postgres.String(phone)).
+			AND(table.Account.IsPhoneVerified.IS_TRUE()),
 	)
 
 	var dbAccount model.Account
@@ -302,6 +332,36 @@ func (r *AccountRepository) Update(ctx context.Context, account *AccountModel) (
 	return mapAccountToModel(dbAccount), nil
 }
 
+// UpdateProfile sets the names and phone of an account, the other columns are
+// left untouched. A changed phone is no longer verified.
+func (r *AccountRepository) UpdateProfile(ctx context.Context, account *AccountModel) (*AccountModel, error) {
+	phone := postgres.String(account.Phone)
+
+	stmt := table.Account.UPDATE().
+		SET(
+			table.Account.FirstName.SET(postgres.String(account.FirstName)),
+			table.Account.LastName.SET(postgres.String(account.LastName)),
+			table.Account.Phone.SET(phone),
+			table.Account.IsPhoneVerified.SET(table.Account.IsPhoneVerified.AND(table.Account.Phone.EQ(phone))),
+			table.Account.UpdatedAt.SET(postgres.TimestampzT(time.Now())),
+		).WHERE(
+		table.Account.ID.EQ(postgres.UUID(account.ID)),
+	).RETURNING(
+		table.Account.AllColumns,
+	)
+
+	var dbAccount model.Account
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbAccount)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	return mapAccountToModel(dbAccount), nil
+}
+
 // SetEmailVerified sets email verified
 func (r *AccountRepository) SetEmailVerified(ctx context.Context, accountId uuid.UUID) (*AccountModel, error) {
 	stmt := table.Account.UPDATE().
@@ -326,6 +386,32 @@ func (r *AccountRepository) SetEmailVerified(ctx context.Context, accountId uuid
 	return mapAccountToModel(dbAccount), nil
 }
 
+// SetPhoneVerified marks the phone of an account as verified, unless the
+// account changed its phone in the meantime
+func (r *AccountRepository) SetPhoneVerified(ctx context.Context, accountId uuid.UUID, phone string) (*AccountModel, error) {
+	stmt := table.Account.UPDATE().
+		SET(
+			table.Account.IsPhoneVerified.SET(postgres.Bool(true)),
+			table.Account.UpdatedAt.SET(postgres.TimestampzT(time.Now())),
+		).WHERE(
+		table.Account.ID.EQ(postgres.UUID(accountId)).
+			AND(table.Account.Phone.EQ(postgres.String(phone))),
+	).RETURNING(
+		table.Account.AllColumns,
+	)
+
+	var dbAccount model.Account
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbAccount)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to update account: %w", err)
+	}
+
+	return mapAccountToModel(dbAccount), nil
+}
+
 // UpdatePassword updates an account's password
 func (r *AccountRepository) UpdatePassword(ctx context.Context, accountID uuid.UUID, hashedPassword string) error {
 	stmt := table.Account.UPDATE().
@@ -352,17 +438,31 @@ func (r *AccountRepository) UpdatePassword(ctx context.Context, accountID uuid.U
 	return nil
 }
 
+// ChangePassword sets an account's password and revokes the tokens issued
+// before at
+func (r *AccountRepository) ChangePassword(ctx context.Context, accountID uuid.UUID, hashedPassword string, at time.Time) error {
+	return setPassword(ctx, r.db.GetDB(), accountID, hashedPassword, at)
+}
+
 // Delete deletes an account and all associated data (can be used in a transaction)
 func (r *AccountRepository) Delete(ctx context.Context, accountID uuid.UUID) error {
 	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
-		// Delete associated ECDSA keys
-		ecdsaStmt := table.Ecdsa.DELETE().WHERE(
-			table.Ecdsa.AccountID.EQ(postgres.UUID(accountID)),
+		if err := detachAccountKeys(ctx, tx, accountID); err != nil {
+			return err
+		}
+
+		// Delete the connections of the SlyWallets to keys of other accounts
+		connStmt := table.EcdsaSlyWallet.DELETE().WHERE(
+			table.EcdsaSlyWallet.OnChainAccountAddress.IN(
+				postgres.SELECT(table.SlyWallet.Address).
+					FROM(table.SlyWallet).
+					WHERE(table.SlyWallet.AccountID.EQ(postgres.UUID(accountID))),
+			),
 		)
 
-		_, err := ecdsaStmt.ExecContext(ctx, tx)
+		_, err := connStmt.ExecContext(ctx, tx)
 		if err != nil {
-			return fmt.Errorf("failed to delete account's ECDSA keys: %w", err)
+			return fmt.Errorf("failed to delete account's SlyWallet connections: %w", err)
 		}
 
 		// Delete associated SlyWallets
@@ -375,7 +475,7 @@ func (r *AccountRepository) Delete(ctx context.Context, accountID uuid.UUID) err
 			return fmt.Errorf("failed to delete account's SlyWallets: %w", err)
 		}
 
-		// Delete the account
+		// Delete the account, its email changes are deleted by cascade
 		accountStmt := table.Account.DELETE().WHERE(
 			table.Account.ID.EQ(postgres.UUID(accountID)),
 		)
@@ -398,6 +498,99 @@ func (r *AccountRepository) Delete(ctx context.Context, accountID uuid.UUID) err
 	})
 }
 
+// RevokeTokens rejects all tokens of the account issued before at
+func (r *AccountRepository) RevokeTokens(ctx context.Context, accountID uuid.UUID, at time.Time) error {
+	stmt := table.Account.UPDATE().
+		SET(
+			table.Account.TokensRevokedAt.SET(postgres.TimestampzT(at)),
+		).WHERE(
+		table.Account.ID.EQ(postgres.UUID(accountID)),
+	)
+
+	result, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return DBItemNotFound
+	}
+
+	return nil
+}
+
+func setPassword(ctx context.Context, db qrm.Executable, accountID uuid.UUID, hashedPassword string, at time.Time) error {
+	stmt := table.Account.UPDATE().
+		SET(
+			table.Account.PasswordHashed.SET(postgres.String(hashedPassword)),
+			table.Account.TokensRevokedAt.SET(postgres.TimestampzT(at)),
+			table.Account.UpdatedAt.SET(postgres.TimestampzT(at)),
+		).WHERE(
+		table.Account.ID.EQ(postgres.UUID(accountID)).
+			AND(table.Account.DeletedAt.IS_NULL()),
+	)
+
+	result, err := stmt.ExecContext(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return DBItemNotFound
+	}
+
+	return nil
+}
+
+// detachAccountKeys deletes the ECDSA keys of an account with their push
+// tokens and SlyWallet connections, and the pins of the account
+func detachAccountKeys(ctx context.Context, tx *sql.Tx, accountID uuid.UUID) error {
+	accountEcdsas := postgres.SELECT(table.Ecdsa.Address).
+		FROM(table.Ecdsa).
+		WHERE(table.Ecdsa.AccountID.EQ(postgres.UUID(accountID)))
+
+	pushStmt := table.PushToken.DELETE().WHERE(
+		table.PushToken.AccountID.EQ(postgres.UUID(accountID)).
+			OR(table.PushToken.EcdsaAddress.IN(accountEcdsas)),
+	)
+	if _, err := pushStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete account's push tokens: %w", err)
+	}
+
+	connStmt := table.EcdsaSlyWallet.DELETE().WHERE(
+		table.EcdsaSlyWallet.EcdsaAddress.IN(accountEcdsas),
+	)
+	if _, err := connStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete account's ECDSA SlyWallet connections: %w", err)
+	}
+
+	ecdsaStmt := table.Ecdsa.DELETE().WHERE(
+		table.Ecdsa.AccountID.EQ(postgres.UUID(accountID)),
+	)
+	if _, err := ecdsaStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete account's ECDSA keys: %w", err)
+	}
+
+	pinStmt := table.Pin.DELETE().WHERE(
+		table.Pin.AccountID.EQ(postgres.UUID(accountID)),
+	)
+	if _, err := pinStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete account's pins: %w", err)
+	}
+
+	return nil
+}
+
 // GetWithEcdsas retrieves an account with its ECDSA keys
 func (r *AccountRepository) GetWithEcdsas(ctx context.Context, accountID uuid.UUID) (*AccountModel, error) {
 	// First get the account
@@ -539,5 +732,7 @@ func mapAccountToModel(account model.Account) *AccountModel {
 		LastUsedSlyWallet: account.LastUsedSlyWallet,
 		CreatedAt:         account.CreatedAt,
 		UpdatedAt:         account.UpdatedAt,
+		TokensRevokedAt:   account.TokensRevokedAt,
+		DeletedAt:         account.DeletedAt,
 	}
 }
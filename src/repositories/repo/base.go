@@ -3,11 +3,23 @@ package repo
 import "database/sql"
 
 type Repositories struct {
-	AccountRepo        *AccountRepository
-	EcdsaRepo          *EcdsaRepository
-	SlyWalletRepo      *SlyWalletRepository
-	InvitationCodeRepo *InvitationCodeRepository
-	EcdsaSlyWalletRepo *EcdsaSlyWalletRepository
+	AccountRepo         *AccountRepository
+	EcdsaRepo           *EcdsaRepository
+	SlyWalletRepo       *SlyWalletRepository
+	InvitationCodeRepo  *InvitationCodeRepository
+	EcdsaSlyWalletRepo  *EcdsaSlyWalletRepository
+	SessionAuditRepo    *SessionAuditRepository
+	PushTokenRepo       *PushTokenRepository
+	RequestLimitRepo    *RequestLimitRepository
+	PinRepo             *PinRepository
+	PinLockoutRepo      *PinLockoutRepository
+	EmailChangeRepo     *EmailChangeRepository
+	AccountDeletionRepo *AccountDeletionRepository
+	AccountLinkRepo     *AccountLinkRepository
+	PasswordResetRepo   *PasswordResetRepository
+	MFARepo             *MFARepository
+	WebAuthnRepo        *WebAuthnRepository
+	SignInRepo          *SignInRepository
 }
 
 func NewRepositories(database *sql.DB) *Repositories {
@@ -18,11 +30,35 @@ func NewRepositories(database *sql.DB) *Repositories {
 	slyWalletRepo := NewSlyWalletRepository(db)
 	invitationCodeRepo := NewInvitationCodeRepository(db)
 	ecdsaSlyWalletRepo := NewEcdsaSlyWalletRepository(db)
+	sessionAuditRepo := NewSessionAuditRepository(db)
+	pushTokenRepo := NewPushTokenRepository(db)
+	requestLimitRepo := NewRequestLimitRepository(db)
+	pinRepo := NewPinRepository(db)
+	pinLockoutRepo := NewPinLockoutRepository(db)
+	emailChangeRepo := NewEmailChangeRepository(db)
+	accountDeletionRepo := NewAccountDeletionRepository(db)
+	accountLinkRepo := NewAccountLinkRepository(db)
+	passwordResetRepo := NewPasswordResetRepository(db)
+	mfaRepo := NewMFARepository(db)
+	webAuthnRepo := NewWebAuthnRepository(db)
+	signInRepo := NewSignInRepository(db)
 	return &Repositories{
-		AccountRepo:        accountRepo,
-		EcdsaRepo:          ecdsaRepo,
-		SlyWalletRepo:      slyWalletRepo,
-		InvitationCodeRepo: invitationCodeRepo,
-		EcdsaSlyWalletRepo: ecdsaSlyWalletRepo,
+		AccountRepo:         accountRepo,
+		EcdsaRepo:           ecdsaRepo,
+		SlyWalletRepo:       slyWalletRepo,
+		InvitationCodeRepo:  invitationCodeRepo,
+		EcdsaSlyWalletRepo:  ecdsaSlyWalletRepo,
+		SessionAuditRepo:    sessionAuditRepo,
+		PushTokenRepo:       pushTokenRepo,
+		RequestLimitRepo:    requestLimitRepo,
+		PinRepo:             pinRepo,
+		PinLockoutRepo:      pinLockoutRepo,
+		EmailChangeRepo:     emailChangeRepo,
+		AccountDeletionRepo: accountDeletionRepo,
+		AccountLinkRepo:     accountLinkRepo,
+		PasswordResetRepo:   passwordResetRepo,
+		MFARepo:             mfaRepo,
+		WebAuthnRepo:        webAuthnRepo,
+		SignInRepo:          signInRepo,
 	}
 }
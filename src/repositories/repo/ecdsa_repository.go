@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"time"
@@ -328,38 +329,49 @@ func (r *EcdsaRepository) Update(ctx context.Context, ecdsa *EcdsaModel) (*Ecdsa
 	return mapEcdsaToModel(dbEcdsa), nil
 }
 
-// Delete deletes an ECDSA key
+// Delete deletes an ECDSA key with its push tokens and SlyWallet connections
 func (r *EcdsaRepository) Delete(ctx context.Context, address string) error {
-	// First delete any EcdsaSlyWallet connections
-	connStmt := table.EcdsaSlyWallet.DELETE().WHERE(
-		table.EcdsaSlyWallet.EcdsaAddress.EQ(postgres.String(address)),
-	)
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// First delete the push tokens and EcdsaSlyWallet connections
+		pushStmt := table.PushToken.DELETE().WHERE(
+			table.PushToken.EcdsaAddress.EQ(postgres.String(address)),
+		)
+
+		_, err := pushStmt.ExecContext(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to delete ECDSA push tokens: %w", err)
+		}
 
-	_, err := connStmt.ExecContext(ctx, r.db.GetDB())
-	if err != nil {
-		return fmt.Errorf("failed to delete ECDSA SlyWallet connections: %w", err)
-	}
+		connStmt := table.EcdsaSlyWallet.DELETE().WHERE(
+			table.EcdsaSlyWallet.EcdsaAddress.EQ(postgres.String(address)),
+		)
 
-	// Then delete the ECDSA key
-	stmt := table.Ecdsa.DELETE().WHERE(
-		table.Ecdsa.Address.EQ(postgres.String(address)),
-	)
+		_, err = connStmt.ExecContext(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to delete ECDSA SlyWallet connections: %w", err)
+		}
 
-	result, err := stmt.ExecContext(ctx, r.db.GetDB())
-	if err != nil {
-		return fmt.Errorf("failed to delete ECDSA key: %w", err)
-	}
+		// Then delete the ECDSA key
+		stmt := table.Ecdsa.DELETE().WHERE(
+			table.Ecdsa.Address.EQ(postgres.String(address)),
+		)
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
+		result, err := stmt.ExecContext(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to delete ECDSA key: %w", err)
+		}
 
-	if rowsAffected == 0 {
-		return DBItemNotFound
-	}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return DBItemNotFound
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // UpsertECDSA creates or updates an ECDSA key
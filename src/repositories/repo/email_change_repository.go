@@ -0,0 +1,294 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// EmailChangeRepository handles all EmailChange related database operations
+type EmailChangeRepository struct {
+	db *Database
+}
+
+// NewEmailChangeRepository creates a new EmailChange repository
+func NewEmailChangeRepository(db *Database) *EmailChangeRepository {
+	return &EmailChangeRepository{
+		db: db,
+	}
+}
+
+// Create stores an email change
+func (r *EmailChangeRepository) Create(ctx context.Context, change *EmailChangeModel) (*EmailChangeModel, error) {
+	change.ID = uuid.New()
+	change.CreatedAt = time.Now()
+
+	confirmedAt := postgres.Expression(postgres.NULL)
+	if change.ConfirmedAt != nil {
+		confirmedAt = postgres.TimestampzT(*change.ConfirmedAt)
+	}
+
+	stmt := table.EmailChange.INSERT(
+		table.EmailChange.ID,
+		table.EmailChange.AccountID,
+		table.EmailChange.OldEmail,
+		table.EmailChange.NewEmail,
+		table.EmailChange.CodeHash,
+		table.EmailChange.TokenHash,
+		table.EmailChange.ExpiresAt,
+		table.EmailChange.ConfirmedAt,
+		table.EmailChange.ChangedBy,
+		table.EmailChange.CreatedAt,
+	).VALUES(
+		postgres.UUID(change.ID),
+		postgres.UUID(change.AccountID),
+		stringOrNull(change.OldEmail),
+		postgres.String(change.NewEmail),
+		stringOrNull(change.CodeHash),
+		stringOrNull(change.TokenHash),
+		postgres.TimestampzT(change.ExpiresAt),
+		confirmedAt,
+		stringOrNull(change.ChangedBy),
+		postgres.TimestampzT(change.CreatedAt),
+	).RETURNING(
+		table.EmailChange.AllColumns,
+	)
+
+	var dbChange model.EmailChange
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbChange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create EmailChange: %w", err)
+	}
+
+	return mapEmailChangeToModel(dbChange), nil
+}
+
+// GetPending retrieves the latest unconfirmed, unexpired email change of an
+// account
+func (r *EmailChangeRepository) GetPending(ctx context.Context, accountID uuid.UUID, now time.Time) (*EmailChangeModel, error) {
+	stmt := postgres.SELECT(
+		table.EmailChange.AllColumns,
+	).FROM(
+		table.EmailChange,
+	).WHERE(
+		table.EmailChange.AccountID.EQ(postgres.UUID(accountID)).
+			AND(table.EmailChange.ConfirmedAt.IS_NULL()).
+			AND(table.EmailChange.ExpiresAt.GT(postgres.TimestampzT(now))),
+	).ORDER_BY(
+		table.EmailChange.CreatedAt.DESC(),
+	).LIMIT(1)
+
+	var dbChange model.EmailChange
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbChange)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get pending EmailChange: %w", err)
+	}
+
+	return mapEmailChangeToModel(dbChange), nil
+}
+
+// GetByTokenHash retrieves the email change of a confirmation link
+func (r *EmailChangeRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*EmailChangeModel, error) {
+	stmt := postgres.SELECT(
+		table.EmailChange.AllColumns,
+	).FROM(
+		table.EmailChange,
+	).WHERE(
+		table.EmailChange.TokenHash.EQ(postgres.String(tokenHash)),
+	)
+
+	var dbChange model.EmailChange
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbChange)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get EmailChange: %w", err)
+	}
+
+	return mapEmailChangeToModel(dbChange), nil
+}
+
+// IncrementFailedAttempts counts a wrong confirmation code and returns the
+// failed attempts of the email change
+func (r *EmailChangeRepository) IncrementFailedAttempts(ctx context.Context, id uuid.UUID) (int, error) {
+	stmt := table.EmailChange.UPDATE().
+		SET(
+			table.EmailChange.FailedAttempts.SET(table.EmailChange.FailedAttempts.ADD(postgres.Int(1))),
+		).WHERE(
+		table.EmailChange.ID.EQ(postgres.UUID(id)),
+	).RETURNING(
+		table.EmailChange.AllColumns,
+	)
+
+	var dbChange model.EmailChange
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbChange)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return 0, DBItemNotFound
+		}
+		return 0, fmt.Errorf("failed to increment failed attempts of EmailChange: %w", err)
+	}
+
+	return int(dbChange.FailedAttempts), nil
+}
+
+// Expire ends an unconfirmed email change, e.g. after too many wrong codes
+func (r *EmailChangeRepository) Expire(ctx context.Context, id uuid.UUID, at time.Time) error {
+	stmt := table.EmailChange.UPDATE().
+		SET(
+			table.EmailChange.ExpiresAt.SET(postgres.TimestampzT(at)),
+		).WHERE(
+		table.EmailChange.ID.EQ(postgres.UUID(id)).
+			AND(table.EmailChange.ConfirmedAt.IS_NULL()),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to expire EmailChange: %w", err)
+	}
+
+	return nil
+}
+
+// ExpirePending ends all unconfirmed email changes of an account
+func (r *EmailChangeRepository) ExpirePending(ctx context.Context, accountID uuid.UUID, at time.Time) error {
+	stmt := table.EmailChange.UPDATE().
+		SET(
+			table.EmailChange.ExpiresAt.SET(postgres.TimestampzT(at)),
+		).WHERE(
+		table.EmailChange.AccountID.EQ(postgres.UUID(accountID)).
+			AND(table.EmailChange.ConfirmedAt.IS_NULL()).
+			AND(table.EmailChange.ExpiresAt.GT(postgres.TimestampzT(at))),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to expire pending EmailChanges: %w", err)
+	}
+
+	return nil
+}
+
+// Apply confirms an email change and sets the new email of the account as
+// verified in one transaction. It returns DBItemNotFound if the change was
+// confirmed before.
+func (r *EmailChangeRepository) Apply(ctx context.Context, change *EmailChangeModel, at time.Time) (*AccountModel, error) {
+	var account *AccountModel
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		confirmStmt := table.EmailChange.UPDATE().
+			SET(
+				table.EmailChange.ConfirmedAt.SET(postgres.TimestampzT(at)),
+			).WHERE(
+			table.EmailChange.ID.EQ(postgres.UUID(change.ID)).
+				AND(table.EmailChange.ConfirmedAt.IS_NULL()),
+		)
+
+		result, err := confirmStmt.ExecContext(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to confirm EmailChange: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return DBItemNotFound
+		}
+
+		accountStmt := table.Account.UPDATE().
+			SET(
+				table.Account.Email.SET(postgres.String(change.NewEmail)),
+				table.Account.IsEmailVerified.SET(postgres.Bool(true)),
+				table.Account.UpdatedAt.SET(postgres.TimestampzT(at)),
+			).WHERE(
+			table.Account.ID.EQ(postgres.UUID(change.AccountID)),
+		).RETURNING(
+			table.Account.AllColumns,
+		)
+
+		var dbAccount model.Account
+		if err = accountStmt.QueryContext(ctx, tx, &dbAccount); err != nil {
+			if errors.Is(err, qrm.ErrNoRows) {
+				return DBItemNotFound
+			}
+			return fmt.Errorf("failed to set email of account: %w", err)
+		}
+		account = mapAccountToModel(dbAccount)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// ListByAccount retrieves the email changes of an account, the latest first
+func (r *EmailChangeRepository) ListByAccount(ctx context.Context, accountID uuid.UUID) ([]EmailChangeModel, error) {
+	stmt := postgres.SELECT(
+		table.EmailChange.AllColumns,
+	).FROM(
+		table.EmailChange,
+	).WHERE(
+		table.EmailChange.AccountID.EQ(postgres.UUID(accountID)),
+	).ORDER_BY(
+		table.EmailChange.CreatedAt.DESC(),
+	)
+
+	var dbChanges []model.EmailChange
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbChanges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list EmailChanges: %w", err)
+	}
+
+	changes := make([]EmailChangeModel, len(dbChanges))
+	for i, dbChange := range dbChanges {
+		changes[i] = *mapEmailChangeToModel(dbChange)
+	}
+
+	return changes, nil
+}
+
+func stringOrNull(s string) postgres.Expression {
+	if s == "" {
+		return postgres.NULL
+	}
+	return postgres.String(s)
+}
+
+func stringOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Helper function to map EmailChange model to EmailChangeModel
+func mapEmailChangeToModel(change model.EmailChange) *EmailChangeModel {
+	return &EmailChangeModel{
+		ID:             change.ID,
+		AccountID:      change.AccountID,
+		OldEmail:       stringOf(change.OldEmail),
+		NewEmail:       change.NewEmail,
+		CodeHash:       stringOf(change.CodeHash),
+		TokenHash:      stringOf(change.TokenHash),
+		FailedAttempts: int(change.FailedAttempts),
+		ExpiresAt:      change.ExpiresAt,
+		ConfirmedAt:    change.ConfirmedAt,
+		ChangedBy:      stringOf(change.ChangedBy),
+		CreatedAt:      change.CreatedAt,
+	}
+}
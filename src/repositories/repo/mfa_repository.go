@@ -0,0 +1,379 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// MFARepository handles the TOTP factors, recovery codes and sign-in
+// challenges of the second factor
+type MFARepository struct {
+	db *Database
+}
+
+// NewMFARepository creates a new MFA repository
+func NewMFARepository(db *Database) *MFARepository {
+	return &MFARepository{
+		db: db,
+	}
+}
+
+// GetTOTP retrieves the TOTP factor of a subject
+func (r *MFARepository) GetTOTP(ctx context.Context, subject string) (*TOTPFactorModel, error) {
+	stmt := postgres.SELECT(
+		table.MfaTotp.AllColumns,
+	).FROM(
+		table.MfaTotp,
+	).WHERE(
+		table.MfaTotp.Subject.EQ(postgres.String(subject)),
+	)
+
+	var dbFactor model.MfaTotp
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbFactor)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get MfaTotp: %w", err)
+	}
+
+	return mapTOTPFactorToModel(dbFactor), nil
+}
+
+// SaveTOTP stores an unconfirmed TOTP factor of a subject, replacing a former
+// unconfirmed one. It returns DBItemNotFound if the subject has a confirmed
+// factor.
+func (r *MFARepository) SaveTOTP(ctx context.Context, subject string, secret string, at time.Time) (*TOTPFactorModel, error) {
+	stmt := table.MfaTotp.INSERT(
+		table.MfaTotp.Subject,
+		table.MfaTotp.Secret,
+		table.MfaTotp.LastUsedStep,
+		table.MfaTotp.CreatedAt,
+		table.MfaTotp.UpdatedAt,
+	).VALUES(
+		postgres.String(subject),
+		postgres.String(secret),
+		postgres.Int(0),
+		postgres.TimestampzT(at),
+		postgres.TimestampzT(at),
+	).ON_CONFLICT(
+		table.MfaTotp.Subject,
+	).DO_UPDATE(postgres.SET(
+		table.MfaTotp.Secret.SET(table.MfaTotp.EXCLUDED.Secret),
+		table.MfaTotp.LastUsedStep.SET(postgres.Int(0)),
+		table.MfaTotp.CreatedAt.SET(table.MfaTotp.EXCLUDED.CreatedAt),
+		table.MfaTotp.UpdatedAt.SET(table.MfaTotp.EXCLUDED.UpdatedAt),
+	).WHERE(
+		table.MfaTotp.ConfirmedAt.IS_NULL(),
+	)).RETURNING(
+		table.MfaTotp.AllColumns,
+	)
+
+	var dbFactor model.MfaTotp
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbFactor)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to save MfaTotp: %w", err)
+	}
+
+	return mapTOTPFactorToModel(dbFactor), nil
+}
+
+// ConfirmTOTP confirms the TOTP factor of a subject with the step of a valid
+// code and replaces the recovery codes in one transaction. It returns
+// DBItemNotFound if the factor is confirmed already or the step was used.
+func (r *MFARepository) ConfirmTOTP(ctx context.Context, subject string, step int64, recoveryCodeHashes []string, at time.Time) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		stmt := table.MfaTotp.UPDATE().
+			SET(
+				table.MfaTotp.ConfirmedAt.SET(postgres.TimestampzT(at)),
+				table.MfaTotp.LastUsedStep.SET(postgres.Int(step)),
+				table.MfaTotp.UpdatedAt.SET(postgres.TimestampzT(at)),
+			).WHERE(
+			table.MfaTotp.Subject.EQ(postgres.String(subject)).
+				AND(table.MfaTotp.ConfirmedAt.IS_NULL()).
+				AND(table.MfaTotp.LastUsedStep.LT(postgres.Int(step))),
+		)
+
+		if err := execAffectingRows(ctx, tx, stmt, "failed to confirm MfaTotp"); err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(ctx, tx, subject, recoveryCodeHashes, at)
+	})
+}
+
+// UseTOTPStep records the step of a valid code of a confirmed factor. It
+// returns DBItemNotFound if the step or a later one was used before.
+func (r *MFARepository) UseTOTPStep(ctx context.Context, subject string, step int64, at time.Time) error {
+	stmt := table.MfaTotp.UPDATE().
+		SET(
+			table.MfaTotp.LastUsedStep.SET(postgres.Int(step)),
+			table.MfaTotp.UpdatedAt.SET(postgres.TimestampzT(at)),
+		).WHERE(
+		table.MfaTotp.Subject.EQ(postgres.String(subject)).
+			AND(table.MfaTotp.ConfirmedAt.IS_NOT_NULL()).
+			AND(table.MfaTotp.LastUsedStep.LT(postgres.Int(step))),
+	)
+
+	return execAffectingRows(ctx, r.db.GetDB(), stmt, "failed to use step of MfaTotp")
+}
+
+// DeleteTOTP removes the TOTP factor and the recovery codes of a subject
+func (r *MFARepository) DeleteTOTP(ctx context.Context, subject string) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return deleteMFA(ctx, tx, subject)
+	})
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of a subject
+func (r *MFARepository) ReplaceRecoveryCodes(ctx context.Context, subject string, codeHashes []string, at time.Time) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return replaceRecoveryCodes(ctx, tx, subject, codeHashes, at)
+	})
+}
+
+// UseRecoveryCode uses up a recovery code of a subject. It returns
+// DBItemNotFound if the subject has no such unused code.
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, subject string, codeHash string, at time.Time) error {
+	stmt := table.MfaRecoveryCode.UPDATE().
+		SET(
+			table.MfaRecoveryCode.UsedAt.SET(postgres.TimestampzT(at)),
+		).WHERE(
+		table.MfaRecoveryCode.Subject.EQ(postgres.String(subject)).
+			AND(table.MfaRecoveryCode.CodeHash.EQ(postgres.String(codeHash))).
+			AND(table.MfaRecoveryCode.UsedAt.IS_NULL()),
+	)
+
+	return execAffectingRows(ctx, r.db.GetDB(), stmt, "failed to use MfaRecoveryCode")
+}
+
+// CountRecoveryCodes counts the unused recovery codes of a subject
+func (r *MFARepository) CountRecoveryCodes(ctx context.Context, subject string) (int, error) {
+	stmt := postgres.SELECT(
+		postgres.COUNT(postgres.STAR).AS("total"),
+	).FROM(
+		table.MfaRecoveryCode,
+	).WHERE(
+		table.MfaRecoveryCode.Subject.EQ(postgres.String(subject)).
+			AND(table.MfaRecoveryCode.UsedAt.IS_NULL()),
+	)
+
+	var totalCount struct {
+		Total uint64 `sql:"total"`
+	}
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &totalCount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count MfaRecoveryCodes: %w", err)
+	}
+
+	return int(totalCount.Total), nil
+}
+
+// CreateChallenge stores the challenge of a sign-in
+func (r *MFARepository) CreateChallenge(ctx context.Context, challenge *MFAChallengeModel) (*MFAChallengeModel, error) {
+	challenge.ID = uuid.New()
+	challenge.CreatedAt = time.Now()
+
+	stmt := table.MfaChallenge.INSERT(
+		table.MfaChallenge.ID,
+		table.MfaChallenge.Subject,
+		table.MfaChallenge.TokenHash,
+		table.MfaChallenge.Role,
+		table.MfaChallenge.Audiences,
+		table.MfaChallenge.ExpiresAt,
+		table.MfaChallenge.CreatedAt,
+	).VALUES(
+		postgres.UUID(challenge.ID),
+		postgres.String(challenge.Subject),
+		postgres.String(challenge.TokenHash),
+		postgres.String(challenge.Role),
+		postgres.String(strings.Join(challenge.Audiences, " ")),
+		postgres.TimestampzT(challenge.ExpiresAt),
+		postgres.TimestampzT(challenge.CreatedAt),
+	).RETURNING(
+		table.MfaChallenge.AllColumns,
+	)
+
+	var dbChallenge model.MfaChallenge
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbChallenge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MfaChallenge: %w", err)
+	}
+
+	return mapMFAChallengeToModel(dbChallenge), nil
+}
+
+// GetChallengeByTokenHash retrieves the challenge of a token
+func (r *MFARepository) GetChallengeByTokenHash(ctx context.Context, tokenHash string) (*MFAChallengeModel, error) {
+	stmt := postgres.SELECT(
+		table.MfaChallenge.AllColumns,
+	).FROM(
+		table.MfaChallenge,
+	).WHERE(
+		table.MfaChallenge.TokenHash.EQ(postgres.String(tokenHash)),
+	)
+
+	var dbChallenge model.MfaChallenge
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbChallenge)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get MfaChallenge: %w", err)
+	}
+
+	return mapMFAChallengeToModel(dbChallenge), nil
+}
+
+// IncrementChallengeAttempts counts a wrong code and returns the failed
+// attempts of the challenge
+func (r *MFARepository) IncrementChallengeAttempts(ctx context.Context, id uuid.UUID) (int, error) {
+	stmt := table.MfaChallenge.UPDATE().
+		SET(
+			table.MfaChallenge.FailedAttempts.SET(table.MfaChallenge.FailedAttempts.ADD(postgres.Int(1))),
+		).WHERE(
+		table.MfaChallenge.ID.EQ(postgres.UUID(id)),
+	).RETURNING(
+		table.MfaChallenge.AllColumns,
+	)
+
+	var dbChallenge model.MfaChallenge
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbChallenge)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return 0, DBItemNotFound
+		}
+		return 0, fmt.Errorf("failed to increment failed attempts of MfaChallenge: %w", err)
+	}
+
+	return int(dbChallenge.FailedAttempts), nil
+}
+
+// UseChallenge uses up a challenge, after the second factor is verified or
+// too many wrong codes. It returns DBItemNotFound if the challenge was used
+// or has expired.
+func (r *MFARepository) UseChallenge(ctx context.Context, id uuid.UUID, at time.Time) error {
+	stmt := table.MfaChallenge.UPDATE().
+		SET(
+			table.MfaChallenge.UsedAt.SET(postgres.TimestampzT(at)),
+		).WHERE(
+		table.MfaChallenge.ID.EQ(postgres.UUID(id)).
+			AND(table.MfaChallenge.UsedAt.IS_NULL()).
+			AND(table.MfaChallenge.ExpiresAt.GT(postgres.TimestampzT(at))),
+	)
+
+	return execAffectingRows(ctx, r.db.GetDB(), stmt, "failed to use MfaChallenge")
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, subject string, codeHashes []string, at time.Time) error {
+	deleteStmt := table.MfaRecoveryCode.DELETE().WHERE(
+		table.MfaRecoveryCode.Subject.EQ(postgres.String(subject)),
+	)
+	if _, err := deleteStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete MfaRecoveryCodes: %w", err)
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	insertStmt := table.MfaRecoveryCode.INSERT(
+		table.MfaRecoveryCode.ID,
+		table.MfaRecoveryCode.Subject,
+		table.MfaRecoveryCode.CodeHash,
+		table.MfaRecoveryCode.CreatedAt,
+	)
+	for _, codeHash := range codeHashes {
+		insertStmt = insertStmt.VALUES(
+			postgres.UUID(uuid.New()),
+			postgres.String(subject),
+			postgres.String(codeHash),
+			postgres.TimestampzT(at),
+		)
+	}
+	if _, err := insertStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to create MfaRecoveryCodes: %w", err)
+	}
+	return nil
+}
+
+// deleteMFA removes the TOTP factor, recovery codes and challenges of a subject
+func deleteMFA(ctx context.Context, tx *sql.Tx, subject string) error {
+	totpStmt := table.MfaTotp.DELETE().WHERE(
+		table.MfaTotp.Subject.EQ(postgres.String(subject)),
+	)
+	if _, err := totpStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete MfaTotp: %w", err)
+	}
+
+	codeStmt := table.MfaRecoveryCode.DELETE().WHERE(
+		table.MfaRecoveryCode.Subject.EQ(postgres.String(subject)),
+	)
+	if _, err := codeStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete MfaRecoveryCodes: %w", err)
+	}
+
+	challengeStmt := table.MfaChallenge.DELETE().WHERE(
+		table.MfaChallenge.Subject.EQ(postgres.String(subject)),
+	)
+	if _, err := challengeStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete MfaChallenges: %w", err)
+	}
+	return nil
+}
+
+// execAffectingRows executes stmt and returns DBItemNotFound if no row was
+// affected
+func execAffectingRows(ctx context.Context, db qrm.Executable, stmt postgres.Statement, errMsg string) error {
+	result, err := stmt.ExecContext(ctx, db)
+	if err != nil {
+		return fmt.Errorf("%s: %w", errMsg, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return DBItemNotFound
+	}
+	return nil
+}
+
+// Helper function to map MfaTotp model to TOTPFactorModel
+func mapTOTPFactorToModel(factor model.MfaTotp) *TOTPFactorModel {
+	return &TOTPFactorModel{
+		Subject:      factor.Subject,
+		Secret:       factor.Secret,
+		ConfirmedAt:  factor.ConfirmedAt,
+		LastUsedStep: factor.LastUsedStep,
+		CreatedAt:    factor.CreatedAt,
+		UpdatedAt:    factor.UpdatedAt,
+	}
+}
+
+// Helper function to map MfaChallenge model to MFAChallengeModel
+func mapMFAChallengeToModel(challenge model.MfaChallenge) *MFAChallengeModel {
+	return &MFAChallengeModel{
+		ID:             challenge.ID,
+		Subject:        challenge.Subject,
+		TokenHash:      challenge.TokenHash,
+		Role:           challenge.Role,
+		Audiences:      strings.Fields(challenge.Audiences),
+		FailedAttempts: int(challenge.FailedAttempts),
+		ExpiresAt:      challenge.ExpiresAt,
+		UsedAt:         challenge.UsedAt,
+		CreatedAt:      challenge.CreatedAt,
+	}
+}
@@ -24,6 +24,9 @@ type AccountModel struct {
 	LastUsedSlyWallet string    `json:"lastUsedSlyWallet"`
 	CreatedAt         time.Time `json:"createdAt"`
 	UpdatedAt         time.Time `json:"updatedAt"`
+	// Tokens issued before TokensRevokedAt are not accepted anymore
+	TokensRevokedAt *time.Time `json:"tokensRevokedAt,omitempty"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty"`
 
 	// Relations
 	Ecdsas     []EcdsaModel     `json:"ecdsas,omitempty"`
@@ -84,6 +87,198 @@ type InvitationCodeModel struct {
 	SlyWallets []SlyWalletModel `json:"slyWallets,omitempty"`
 }
 
+// SessionAuditModel records how a session ended
+type SessionAuditModel struct {
+	ID          uuid.UUID `json:"id"`
+	SessionID   uuid.UUID `json:"sessionId"`
+	SessionType string    `json:"sessionType"`
+	Outcome     string    `json:"outcome"`
+	Party       string    `json:"party"`
+	Reason      string    `json:"reason"`
+	EOA         string    `json:"eoa"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Methods of a SignInModel
+const (
+	SignInMethodPin      = "pin"
+	SignInMethodSIWE     = "siwe"
+	SignInMethodPassword = "password"
+	SignInMethodPasskey  = "passkey"
+)
+
+// SignInModel records a sign-in of an account other than by QR session, those
+// are recorded by SessionAuditModel. EOA is the device key of the token, if any.
+type SignInModel struct {
+	ID        uuid.UUID `json:"id"`
+	AccountID uuid.UUID `json:"accountId"`
+	Method    string    `json:"method"`
+	EOA       string    `json:"eoa,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PushTokenModel is the push notification token of a device key
+type PushTokenModel struct {
+	Token        string    `json:"token"`
+	EcdsaAddress string    `json:"ecdsaAddress"`
+	AccountID    uuid.UUID `json:"accountId"`
+	Platform     string    `json:"platform"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// PinModel is an outstanding email pin, the pin itself is only stored as hash
+type PinModel struct {
+	PinHash string `json:"-"`
+	// AccountID is nil until the pin of a new account is redeemed
+	AccountID      *uuid.UUID `json:"accountId,omitempty"`
+	Email          string     `json:"email"`
+	EcdsaPubKey    string     `json:"ecdsaPubKey"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	FailedAttempts int        `json:"failedAttempts"`
+	// Channel the pin was sent by, "email" or "phone"
+	Channel string `json:"channel"`
+	Phone   string `json:"phone"`
+	// LinkHash is the hash of the nonce of the magic link sent with the pin
+	LinkHash string `json:"-"`
+}
+
+// PinLockoutModel counts the failed pin redemptions of a subject, e.g. an ip
+// or an account
+type PinLockoutModel struct {
+	Subject        string     `json:"subject"`
+	FailedAttempts int        `json:"failedAttempts"`
+	LockedUntil    *time.Time `json:"lockedUntil,omitempty"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// EmailChangeModel is a requested change of the email of an account. It is
+// applied when confirmed, changes by admins are confirmed right away and
+// record the admin in ChangedBy.
+type EmailChangeModel struct {
+	ID             uuid.UUID  `json:"id"`
+	AccountID      uuid.UUID  `json:"accountId"`
+	OldEmail       string     `json:"oldEmail"`
+	NewEmail       string     `json:"newEmail"`
+	CodeHash       string     `json:"-"`
+	TokenHash      string     `json:"-"`
+	FailedAttempts int        `json:"failedAttempts"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	ConfirmedAt    *time.Time `json:"confirmedAt,omitempty"`
+	ChangedBy      string     `json:"changedBy,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// PasswordResetModel is a single-use reset of the password of an account,
+// requested by email
+type PasswordResetModel struct {
+	ID          uuid.UUID  `json:"id"`
+	AccountID   uuid.UUID  `json:"accountId"`
+	TokenHash   string     `json:"-"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	UsedAt      *time.Time `json:"usedAt,omitempty"`
+	RequestedIP string     `json:"requestedIp,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// TOTPFactorModel is the TOTP second factor of a subject, the id of an
+// account or of the YIP admin. Secret is sealed, the factor counts once
+// ConfirmedAt is set. LastUsedStep rejects replays of a code.
+type TOTPFactorModel struct {
+	Subject      string     `json:"subject"`
+	Secret       string     `json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmedAt,omitempty"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// MFAChallengeModel is the pending second step of a sign-in. It keeps what
+// the token is issued with once the second factor is verified.
+type MFAChallengeModel struct {
+	ID             uuid.UUID  `json:"id"`
+	Subject        string     `json:"subject"`
+	TokenHash      string     `json:"-"`
+	Role           string     `json:"role"`
+	Audiences      []string   `json:"audiences"`
+	FailedAttempts int        `json:"failedAttempts"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	UsedAt         *time.Time `json:"usedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// Purposes of a WebAuthnChallengeModel
+const (
+	WebAuthnRegistration = "registration"
+	WebAuthnLogin        = "login"
+)
+
+// WebAuthnCredentialModel is a passkey of an account. CredentialID is the
+// base64url credential id the authenticator returns, PublicKey the COSE key
+// of Algorithm. SignCount is the last signature counter of the authenticator.
+type WebAuthnCredentialModel struct {
+	ID           uuid.UUID  `json:"id"`
+	AccountID    uuid.UUID  `json:"accountId"`
+	CredentialID string     `json:"credentialId"`
+	PublicKey    []byte     `json:"-"`
+	Algorithm    int        `json:"algorithm"`
+	SignCount    uint32     `json:"signCount"`
+	AAGUID       string     `json:"aaguid"`
+	Label        string     `json:"label"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	LastUsedAt   *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// WebAuthnChallengeModel is a pending registration or login ceremony. AccountID
+// is set for registrations, a login keeps the audiences of its token.
+type WebAuthnChallengeModel struct {
+	ID            uuid.UUID  `json:"id"`
+	ChallengeHash string     `json:"-"`
+	AccountID     *uuid.UUID `json:"accountId,omitempty"`
+	Purpose       string     `json:"purpose"`
+	Audiences     []string   `json:"audiences"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	UsedAt        *time.Time `json:"usedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// AccountDeletionModel is the audit trail of a deleted account. The account is
+// anonymized right away and purged after PurgeAfter, this record is kept.
+type AccountDeletionModel struct {
+	ID          uuid.UUID  `json:"id"`
+	AccountID   uuid.UUID  `json:"accountId"`
+	RequestedBy string     `json:"requestedBy"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	PurgeAfter  time.Time  `json:"purgeAfter"`
+	PurgedAt    *time.Time `json:"purgedAt,omitempty"`
+}
+
+// Statuses of an AccountLinkModel
+const (
+	AccountLinkPending   = "pending"
+	AccountLinkConfirmed = "confirmed"
+	AccountLinkMerged    = "merged"
+	AccountLinkCancelled = "cancelled"
+)
+
+// AccountLinkModel links two accounts of one person. AccountID started the
+// link, LinkedAccountID confirmed it with the code. Admins merge confirmed
+// links into one of the accounts.
+type AccountLinkModel struct {
+	ID              uuid.UUID  `json:"id"`
+	AccountID       uuid.UUID  `json:"accountId"`
+	LinkedAccountID *uuid.UUID `json:"linkedAccountId,omitempty"`
+	CodeHash        string     `json:"-"`
+	Status          string     `json:"status"`
+	ExpiresAt       time.Time  `json:"expiresAt"`
+	ConfirmedAt     *time.Time `json:"confirmedAt,omitempty"`
+	MergedInto      *uuid.UUID `json:"mergedInto,omitempty"`
+	MergedBy        string     `json:"mergedBy,omitempty"`
+	MergedAt        *time.Time `json:"mergedAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
 func (ic *InvitationCodeModel) IsValid() bool {
 	return len(ic.TransactionHash) == 0
 }
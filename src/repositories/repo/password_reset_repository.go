@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// PasswordResetRepository handles all PasswordReset related database operations
+type PasswordResetRepository struct {
+	db *Database
+}
+
+// NewPasswordResetRepository creates a new PasswordReset repository
+func NewPasswordResetRepository(db *Database) *PasswordResetRepository {
+	return &PasswordResetRepository{
+		db: db,
+	}
+}
+
+// Create stores a password reset
+func (r *PasswordResetRepository) Create(ctx context.Context, reset *PasswordResetModel) (*PasswordResetModel, error) {
+	reset.ID = uuid.New()
+	reset.CreatedAt = time.Now()
+
+	stmt := table.PasswordReset.INSERT(
+		table.PasswordReset.ID,
+		table.PasswordReset.AccountID,
+		table.PasswordReset.TokenHash,
+		table.PasswordReset.ExpiresAt,
+		table.PasswordReset.RequestedIP,
+		table.PasswordReset.CreatedAt,
+	).VALUES(
+		postgres.UUID(reset.ID),
+		postgres.UUID(reset.AccountID),
+		postgres.String(reset.TokenHash),
+		postgres.TimestampzT(reset.ExpiresAt),
+		stringOrNull(reset.RequestedIP),
+		postgres.TimestampzT(reset.CreatedAt),
+	).RETURNING(
+		table.PasswordReset.AllColumns,
+	)
+
+	var dbReset model.PasswordReset
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbReset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PasswordReset: %w", err)
+	}
+
+	return mapPasswordResetToModel(dbReset), nil
+}
+
+// GetByTokenHash retrieves the password reset of a token
+func (r *PasswordResetRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*PasswordResetModel, error) {
+	stmt := postgres.SELECT(
+		table.PasswordReset.AllColumns,
+	).FROM(
+		table.PasswordReset,
+	).WHERE(
+		table.PasswordReset.TokenHash.EQ(postgres.String(tokenHash)),
+	)
+
+	var dbReset model.PasswordReset
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbReset)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get PasswordReset: %w", err)
+	}
+
+	return mapPasswordResetToModel(dbReset), nil
+}
+
+// ExpirePending ends all unused password resets of an account
+func (r *PasswordResetRepository) ExpirePending(ctx context.Context, accountID uuid.UUID, at time.Time) error {
+	stmt := table.PasswordReset.UPDATE().
+		SET(
+			table.PasswordReset.ExpiresAt.SET(postgres.TimestampzT(at)),
+		).WHERE(
+		table.PasswordReset.AccountID.EQ(postgres.UUID(accountID)).
+			AND(table.PasswordReset.UsedAt.IS_NULL()).
+			AND(table.PasswordReset.ExpiresAt.GT(postgres.TimestampzT(at))),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to expire pending PasswordResets: %w", err)
+	}
+
+	return nil
+}
+
+// Redeem uses a password reset, sets the new password of the account and
+// revokes its tokens in one transaction. Other pending resets of the account
+// are expired. It returns DBItemNotFound if the reset was used or has expired.
+func (r *PasswordResetRepository) Redeem(ctx context.Context, reset *PasswordResetModel, hashedPassword string, at time.Time) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		useStmt := table.PasswordReset.UPDATE().
+			SET(
+				table.PasswordReset.UsedAt.SET(postgres.TimestampzT(at)),
+			).WHERE(
+			table.PasswordReset.ID.EQ(postgres.UUID(reset.ID)).
+				AND(table.PasswordReset.UsedAt.IS_NULL()).
+				AND(table.PasswordReset.ExpiresAt.GT(postgres.TimestampzT(at))),
+		)
+
+		result, err := useStmt.ExecContext(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to use PasswordReset: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return DBItemNotFound
+		}
+
+		expireStmt := table.PasswordReset.UPDATE().
+			SET(
+				table.PasswordReset.ExpiresAt.SET(postgres.TimestampzT(at)),
+			).WHERE(
+			table.PasswordReset.AccountID.EQ(postgres.UUID(reset.AccountID)).
+				AND(table.PasswordReset.UsedAt.IS_NULL()).
+				AND(table.PasswordReset.ExpiresAt.GT(postgres.TimestampzT(at))),
+		)
+		if _, err = expireStmt.ExecContext(ctx, tx); err != nil {
+			return fmt.Errorf("failed to expire pending PasswordResets: %w", err)
+		}
+
+		return setPassword(ctx, tx, reset.AccountID, hashedPassword, at)
+	})
+}
+
+// Helper function to map PasswordReset model to PasswordResetModel
+func mapPasswordResetToModel(reset model.PasswordReset) *PasswordResetModel {
+	return &PasswordResetModel{
+		ID:          reset.ID,
+		AccountID:   reset.AccountID,
+		TokenHash:   reset.TokenHash,
+		ExpiresAt:   reset.ExpiresAt,
+		UsedAt:      reset.UsedAt,
+		RequestedIP: stringOf(reset.RequestedIP),
+		CreatedAt:   reset.CreatedAt,
+	}
+}
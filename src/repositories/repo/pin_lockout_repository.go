@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// PinLockoutRepository handles all PinLockout related database operations
+type PinLockoutRepository struct {
+	db *Database
+}
+
+// NewPinLockoutRepository creates a new PinLockout repository
+func NewPinLockoutRepository(db *Database) *PinLockoutRepository {
+	return &PinLockoutRepository{
+		db: db,
+	}
+}
+
+// Get retrieves the lockout of a subject
+func (r *PinLockoutRepository) Get(ctx context.Context, subject string) (*PinLockoutModel, error) {
+	stmt := postgres.SELECT(
+		table.PinLockout.AllColumns,
+	).FROM(
+		table.PinLockout,
+	).WHERE(
+		table.PinLockout.Subject.EQ(postgres.String(subject)),
+	)
+
+	var dbLockout model.PinLockout
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbLockout)
+	if err != nil {
+		if err == qrm.ErrNoRows {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get PinLockout: %w", err)
+	}
+
+	return mapPinLockoutToModel(dbLockout), nil
+}
+
+// IncrementFailedAttempts counts a failed redemption of a subject, concurrent
+// failures are all counted
+func (r *PinLockoutRepository) IncrementFailedAttempts(ctx context.Context, subject string) (*PinLockoutModel, error) {
+	now := time.Now()
+
+	stmt := table.PinLockout.INSERT(
+		table.PinLockout.Subject,
+		table.PinLockout.FailedAttempts,
+		table.PinLockout.UpdatedAt,
+	).VALUES(
+		subject,
+		1,
+		now,
+	).ON_CONFLICT(
+		table.PinLockout.Subject,
+	).DO_UPDATE(postgres.SET(
+		table.PinLockout.FailedAttempts.SET(table.PinLockout.FailedAttempts.ADD(postgres.Int(1))),
+		table.PinLockout.UpdatedAt.SET(table.PinLockout.EXCLUDED.UpdatedAt),
+	)).RETURNING(
+		table.PinLockout.AllColumns,
+	)
+
+	var dbLockout model.PinLockout
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbLockout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to increment failed attempts of PinLockout: %w", err)
+	}
+
+	return mapPinLockoutToModel(dbLockout), nil
+}
+
+// Lock locks a subject until the given time
+func (r *PinLockoutRepository) Lock(ctx context.Context, subject string, until time.Time) error {
+	stmt := table.PinLockout.UPDATE(
+		table.PinLockout.LockedUntil,
+	).SET(
+		until,
+	).WHERE(
+		table.PinLockout.Subject.EQ(postgres.String(subject)),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to lock PinLockout: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the lockout of a subject. It returns false if there was none.
+func (r *PinLockoutRepository) Delete(ctx context.Context, subject string) (bool, error) {
+	stmt := table.PinLockout.DELETE().WHERE(
+		table.PinLockout.Subject.EQ(postgres.String(subject)),
+	)
+
+	res, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return false, fmt.Errorf("failed to delete PinLockout: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete PinLockout: %w", err)
+	}
+
+	return n == 1, nil
+}
+
+// List retrieves all lockouts, the most recent failures first
+func (r *PinLockoutRepository) List(ctx context.Context) ([]PinLockoutModel, error) {
+	stmt := postgres.SELECT(
+		table.PinLockout.AllColumns,
+	).FROM(
+		table.PinLockout,
+	).ORDER_BY(
+		table.PinLockout.UpdatedAt.DESC(),
+	)
+
+	var dbLockouts []model.PinLockout
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbLockouts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PinLockouts: %w", err)
+	}
+
+	lockouts := make([]PinLockoutModel, len(dbLockouts))
+	for i, dbLockout := range dbLockouts {
+		lockouts[i] = *mapPinLockoutToModel(dbLockout)
+	}
+
+	return lockouts, nil
+}
+
+// Helper function to map PinLockout model to PinLockoutModel
+func mapPinLockoutToModel(lockout model.PinLockout) *PinLockoutModel {
+	return &PinLockoutModel{
+		Subject:        lockout.Subject,
+		FailedAttempts: int(lockout.FailedAttempts),
+		LockedUntil:    lockout.LockedUntil,
+		UpdatedAt:      lockout.UpdatedAt,
+	}
+}
@@ -0,0 +1,229 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// PinRepository handles all Pin related database operations. Pins are only
+// stored as hash.
+type PinRepository struct {
+	db *Database
+}
+
+// NewPinRepository creates a new Pin repository
+func NewPinRepository(db *Database) *PinRepository {
+	return &PinRepository{
+		db: db,
+	}
+}
+
+// Create stores a pin, it fails if the hash is taken by another pin
+func (r *PinRepository) Create(ctx context.Context, pin *PinModel) error {
+	accountId := postgres.Expression(postgres.NULL)
+	if pin.AccountID != nil {
+		accountId = postgres.UUID(*pin.AccountID)
+	}
+
+	stmt := table.Pin.INSERT(
+		table.Pin.PinHash,
+		table.Pin.AccountID,
+		table.Pin.Email,
+		table.Pin.EcdsaPubKey,
+		table.Pin.ExpiresAt,
+		table.Pin.Channel,
+		table.Pin.Phone,
+		table.Pin.LinkHash,
+	).VALUES(
+		pin.PinHash,
+		accountId,
+		pin.Email,
+		pin.EcdsaPubKey,
+		pin.ExpiresAt,
+		pin.Channel,
+		pin.Phone,
+		pin.LinkHash,
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to create Pin: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHash retrieves a pin by its hash
+func (r *PinRepository) GetByHash(ctx context.Context, pinHash string) (*PinModel, error) {
+	stmt := postgres.SELECT(
+		table.Pin.AllColumns,
+	).FROM(
+		table.Pin,
+	).WHERE(
+		table.Pin.PinHash.EQ(postgres.String(pinHash)),
+	)
+
+	var dbPin model.Pin
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbPin)
+	if err != nil {
+		if err == qrm.ErrNoRows {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get Pin by hash: %w", err)
+	}
+
+	return mapPinToModel(dbPin), nil
+}
+
+// GetByLinkHash retrieves a pin by the hash of its magic link
+func (r *PinRepository) GetByLinkHash(ctx context.Context, linkHash string) (*PinModel, error) {
+	stmt := postgres.SELECT(
+		table.Pin.AllColumns,
+	).FROM(
+		table.Pin,
+	).WHERE(
+		table.Pin.LinkHash.EQ(postgres.String(linkHash)).
+			AND(table.Pin.LinkHash.NOT_EQ(postgres.String(""))),
+	)
+
+	var dbPin model.Pin
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbPin)
+	if err != nil {
+		if err == qrm.ErrNoRows {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get Pin by link hash: %w", err)
+	}
+
+	return mapPinToModel(dbPin), nil
+}
+
+// ListByEcdsaPubKey retrieves the outstanding pins requested for a device key
+func (r *PinRepository) ListByEcdsaPubKey(ctx context.Context, ecdsaPubKey string) ([]PinModel, error) {
+	stmt := postgres.SELECT(
+		table.Pin.AllColumns,
+	).FROM(
+		table.Pin,
+	).WHERE(
+		table.Pin.EcdsaPubKey.EQ(postgres.String(ecdsaPubKey)),
+	)
+
+	var dbPins []model.Pin
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbPins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Pins by ecdsa pub key: %w", err)
+	}
+
+	pins := make([]PinModel, len(dbPins))
+	for i, dbPin := range dbPins {
+		pins[i] = *mapPinToModel(dbPin)
+	}
+
+	return pins, nil
+}
+
+// IncrementFailedAttempts counts a failed redemption of a pin and returns the
+// failed attempts so far
+func (r *PinRepository) IncrementFailedAttempts(ctx context.Context, pinHash string) (int, error) {
+	stmt := table.Pin.UPDATE(
+		table.Pin.FailedAttempts,
+	).SET(
+		table.Pin.FailedAttempts.ADD(postgres.Int(1)),
+	).WHERE(
+		table.Pin.PinHash.EQ(postgres.String(pinHash)),
+	).RETURNING(
+		table.Pin.AllColumns,
+	)
+
+	var dbPin model.Pin
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbPin)
+	if err != nil {
+		if err == qrm.ErrNoRows {
+			return 0, DBItemNotFound
+		}
+		return 0, fmt.Errorf("failed to increment failed attempts of Pin: %w", err)
+	}
+
+	return int(dbPin.FailedAttempts), nil
+}
+
+// DeleteByHash removes a pin. It returns false if the pin was already
+// removed, so only one of concurrent redemptions succeeds.
+func (r *PinRepository) DeleteByHash(ctx context.Context, pinHash string) (bool, error) {
+	stmt := table.Pin.DELETE().WHERE(
+		table.Pin.PinHash.EQ(postgres.String(pinHash)),
+	)
+
+	res, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return false, fmt.Errorf("failed to delete Pin: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete Pin: %w", err)
+	}
+
+	return n == 1, nil
+}
+
+// DeleteExpired removes all pins expired before now
+func (r *PinRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	stmt := table.Pin.DELETE().WHERE(
+		table.Pin.ExpiresAt.LT(postgres.TimestampzT(now)),
+	)
+
+	res, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired Pins: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// List retrieves all outstanding pins
+func (r *PinRepository) List(ctx context.Context) ([]PinModel, error) {
+	stmt := postgres.SELECT(
+		table.Pin.AllColumns,
+	).FROM(
+		table.Pin,
+	).ORDER_BY(
+		table.Pin.CreatedAt.ASC(),
+	)
+
+	var dbPins []model.Pin
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbPins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Pins: %w", err)
+	}
+
+	pins := make([]PinModel, len(dbPins))
+	for i, dbPin := range dbPins {
+		pins[i] = *mapPinToModel(dbPin)
+	}
+
+	return pins, nil
+}
+
+// Helper function to map Pin model to PinModel
+func mapPinToModel(pin model.Pin) *PinModel {
+	return &PinModel{
+		PinHash:        pin.PinHash,
+		AccountID:      pin.AccountID,
+		Email:          pin.Email,
+		EcdsaPubKey:    pin.EcdsaPubKey,
+		ExpiresAt:      pin.ExpiresAt,
+		CreatedAt:      pin.CreatedAt,
+		FailedAttempts: int(pin.FailedAttempts),
+		Channel:        pin.Channel,
+		Phone:          pin.Phone,
+		LinkHash:       pin.LinkHash,
+	}
+}
@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/google/uuid"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// PushTokenRepository handles all PushToken related database operations
+type PushTokenRepository struct {
+	db *Database
+}
+
+// NewPushTokenRepository creates a new PushToken repository
+func NewPushTokenRepository(db *Database) *PushTokenRepository {
+	return &PushTokenRepository{
+		db: db,
+	}
+}
+
+// Upsert registers a push token. A token registered before moves to the given
+// device key, e.g. after the app was reinstalled with another key.
+func (r *PushTokenRepository) Upsert(ctx context.Context, pushToken *PushTokenModel) (*PushTokenModel, error) {
+	now := time.Now()
+	pushToken.CreatedAt = now
+	pushToken.UpdatedAt = now
+
+	stmt := table.PushToken.INSERT(
+		table.PushToken.Token,
+		table.PushToken.EcdsaAddress,
+		table.PushToken.AccountID,
+		table.PushToken.Platform,
+		table.PushToken.CreatedAt,
+		table.PushToken.UpdatedAt,
+	).VALUES(
+		pushToken.Token,
+		pushToken.EcdsaAddress,
+		pushToken.AccountID,
+		pushToken.Platform,
+		pushToken.CreatedAt,
+		pushToken.UpdatedAt,
+	).ON_CONFLICT(
+		table.PushToken.Token,
+	).DO_UPDATE(postgres.SET(
+		table.PushToken.EcdsaAddress.SET(table.PushToken.EXCLUDED.EcdsaAddress),
+		table.PushToken.AccountID.SET(table.PushToken.EXCLUDED.AccountID),
+		table.PushToken.Platform.SET(table.PushToken.EXCLUDED.Platform),
+		table.PushToken.UpdatedAt.SET(table.PushToken.EXCLUDED.UpdatedAt),
+	)).RETURNING(
+		table.PushToken.AllColumns,
+	)
+
+	var dbPushToken model.PushToken
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbPushToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert PushToken: %w", err)
+	}
+
+	return mapPushTokenToModel(dbPushToken), nil
+}
+
+// ListByAccount retrieves the push tokens of all devices of an account
+func (r *PushTokenRepository) ListByAccount(ctx context.Context, accountId uuid.UUID) ([]PushTokenModel, error) {
+	stmt := postgres.SELECT(
+		table.PushToken.AllColumns,
+	).FROM(
+		table.PushToken,
+	).WHERE(
+		table.PushToken.AccountID.EQ(postgres.UUID(accountId)),
+	)
+
+	var dbPushTokens []model.PushToken
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbPushTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PushTokens: %w", err)
+	}
+
+	pushTokens := make([]PushTokenModel, len(dbPushTokens))
+	for i, dbPushToken := range dbPushTokens {
+		pushTokens[i] = *mapPushTokenToModel(dbPushToken)
+	}
+
+	return pushTokens, nil
+}
+
+// Delete removes a push token of a device key
+func (r *PushTokenRepository) Delete(ctx context.Context, token string, ecdsaAddress string) error {
+	stmt := table.PushToken.DELETE().WHERE(
+		table.PushToken.Token.EQ(postgres.String(token)).
+			AND(table.PushToken.EcdsaAddress.EQ(postgres.String(ecdsaAddress))),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to delete PushToken: %w", err)
+	}
+
+	return nil
+}
+
+// Helper function to map PushToken model to PushTokenModel
+func mapPushTokenToModel(pushToken model.PushToken) *PushTokenModel {
+	return &PushTokenModel{
+		Token:        pushToken.Token,
+		EcdsaAddress: pushToken.EcdsaAddress,
+		AccountID:    pushToken.AccountID,
+		Platform:     pushToken.Platform,
+		CreatedAt:    pushToken.CreatedAt,
+		UpdatedAt:    pushToken.UpdatedAt,
+	}
+}
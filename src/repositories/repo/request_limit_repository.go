@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// RequestLimitRepository handles all RequestLimit related database operations
+type RequestLimitRepository struct {
+	db *Database
+}
+
+// NewRequestLimitRepository creates a new RequestLimit repository
+func NewRequestLimitRepository(db *Database) *RequestLimitRepository {
+	return &RequestLimitRepository{
+		db: db,
+	}
+}
+
+// Increment counts a request of a subject in the window starting at
+// windowStart and returns the requests of the window, concurrent requests are
+// all counted
+func (r *RequestLimitRepository) Increment(ctx context.Context, subject string, windowStart time.Time) (int, error) {
+	stmt := table.RequestLimit.INSERT(
+		table.RequestLimit.Subject,
+		table.RequestLimit.WindowStart,
+		table.RequestLimit.Requests,
+	).VALUES(
+		subject,
+		windowStart,
+		1,
+	).ON_CONFLICT(
+		table.RequestLimit.Subject,
+		table.RequestLimit.WindowStart,
+	).DO_UPDATE(postgres.SET(
+		table.RequestLimit.Requests.SET(table.RequestLimit.Requests.ADD(postgres.Int(1))),
+	)).RETURNING(
+		table.RequestLimit.AllColumns,
+	)
+
+	var dbLimit model.RequestLimit
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbLimit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment requests of RequestLimit: %w", err)
+	}
+
+	return int(dbLimit.Requests), nil
+}
+
+// Get returns the requests of a subject in the window starting at
+// windowStart, zero if there were none
+func (r *RequestLimitRepository) Get(ctx context.Context, subject string, windowStart time.Time) (int, error) {
+	stmt := postgres.SELECT(
+		table.RequestLimit.AllColumns,
+	).FROM(
+		table.RequestLimit,
+	).WHERE(
+		table.RequestLimit.Subject.EQ(postgres.String(subject)).
+			AND(table.RequestLimit.WindowStart.EQ(postgres.TimestampzT(windowStart))),
+	)
+
+	var dbLimit model.RequestLimit
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbLimit)
+	if errors.Is(err, qrm.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get RequestLimit: %w", err)
+	}
+
+	return int(dbLimit.Requests), nil
+}
+
+// DeleteBefore removes the windows starting before windowStart
+func (r *RequestLimitRepository) DeleteBefore(ctx context.Context, windowStart time.Time) error {
+	stmt := table.RequestLimit.DELETE().WHERE(
+		table.RequestLimit.WindowStart.LT(postgres.TimestampzT(windowStart)),
+	)
+
+	_, err := stmt.ExecContext(ctx, r.db.GetDB())
+	if err != nil {
+		return fmt.Errorf("failed to delete RequestLimits: %w", err)
+	}
+
+	return nil
+}
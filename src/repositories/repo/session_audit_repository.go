@@ -0,0 +1,127 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/google/uuid"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// SessionAuditRepository handles all SessionAudit related database operations
+type SessionAuditRepository struct {
+	db *Database
+}
+
+// NewSessionAuditRepository creates a new SessionAudit repository
+func NewSessionAuditRepository(db *Database) *SessionAuditRepository {
+	return &SessionAuditRepository{
+		db: db,
+	}
+}
+
+// Create records the outcome of a session
+func (r *SessionAuditRepository) Create(ctx context.Context, audit *SessionAuditModel) (*SessionAuditModel, error) {
+	stmt := table.SessionAudit.INSERT(
+		table.SessionAudit.SessionID,
+		table.SessionAudit.SessionType,
+		table.SessionAudit.Outcome,
+		table.SessionAudit.Party,
+		table.SessionAudit.Reason,
+		table.SessionAudit.Eoa,
+	).VALUES(
+		audit.SessionID,
+		audit.SessionType,
+		audit.Outcome,
+		audit.Party,
+		audit.Reason,
+		audit.EOA,
+	).RETURNING(
+		table.SessionAudit.AllColumns,
+	)
+
+	var dbAudit model.SessionAudit
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbAudit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SessionAudit: %w", err)
+	}
+
+	return mapSessionAuditToModel(dbAudit), nil
+}
+
+// ListBySession retrieves all recorded outcomes of a session
+func (r *SessionAuditRepository) ListBySession(ctx context.Context, sessionId uuid.UUID) ([]SessionAuditModel, error) {
+	stmt := postgres.SELECT(
+		table.SessionAudit.AllColumns,
+	).FROM(
+		table.SessionAudit,
+	).WHERE(
+		table.SessionAudit.SessionID.EQ(postgres.UUID(sessionId)),
+	).ORDER_BY(
+		table.SessionAudit.CreatedAt.ASC(),
+	)
+
+	var dbAudits []model.SessionAudit
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbAudits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SessionAudits: %w", err)
+	}
+
+	audits := make([]SessionAuditModel, len(dbAudits))
+	for i, dbAudit := range dbAudits {
+		audits[i] = *mapSessionAuditToModel(dbAudit)
+	}
+
+	return audits, nil
+}
+
+// ListByEOAs retrieves all recorded outcomes of sessions of the EOAs, the latest first
+func (r *SessionAuditRepository) ListByEOAs(ctx context.Context, eoas []string) ([]SessionAuditModel, error) {
+	if len(eoas) == 0 {
+		return []SessionAuditModel{}, nil
+	}
+
+	addresses := make([]postgres.Expression, len(eoas))
+	for i, eoa := range eoas {
+		addresses[i] = postgres.String(eoa)
+	}
+
+	stmt := postgres.SELECT(
+		table.SessionAudit.AllColumns,
+	).FROM(
+		table.SessionAudit,
+	).WHERE(
+		table.SessionAudit.Eoa.IN(addresses...),
+	).ORDER_BY(
+		table.SessionAudit.CreatedAt.DESC(),
+	)
+
+	var dbAudits []model.SessionAudit
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbAudits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SessionAudits by EOAs: %w", err)
+	}
+
+	audits := make([]SessionAuditModel, len(dbAudits))
+	for i, dbAudit := range dbAudits {
+		audits[i] = *mapSessionAuditToModel(dbAudit)
+	}
+
+	return audits, nil
+}
+
+// Helper function to map SessionAudit model to SessionAuditModel
+func mapSessionAuditToModel(audit model.SessionAudit) *SessionAuditModel {
+	return &SessionAuditModel{
+		ID:          audit.ID,
+		SessionID:   audit.SessionID,
+		SessionType: audit.SessionType,
+		Outcome:     audit.Outcome,
+		Party:       audit.Party,
+		Reason:      audit.Reason,
+		EOA:         audit.Eoa,
+		CreatedAt:   audit.CreatedAt,
+	}
+}
@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/google/uuid"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// SignInRepository handles all SignIn related database operations
+type SignInRepository struct {
+	db *Database
+}
+
+// NewSignInRepository creates a new SignIn repository
+func NewSignInRepository(db *Database) *SignInRepository {
+	return &SignInRepository{
+		db: db,
+	}
+}
+
+// Create records a sign-in
+func (r *SignInRepository) Create(ctx context.Context, signIn *SignInModel) (*SignInModel, error) {
+	stmt := table.SignIn.INSERT(
+		table.SignIn.AccountID,
+		table.SignIn.Method,
+		table.SignIn.Eoa,
+	).VALUES(
+		signIn.AccountID,
+		signIn.Method,
+		signIn.EOA,
+	).RETURNING(
+		table.SignIn.AllColumns,
+	)
+
+	var dbSignIn model.SignIn
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbSignIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SignIn: %w", err)
+	}
+
+	return mapSignInToModel(dbSignIn), nil
+}
+
+// ListByAccount retrieves the sign-ins of an account, the latest first
+func (r *SignInRepository) ListByAccount(ctx context.Context, accountID uuid.UUID) ([]SignInModel, error) {
+	stmt := postgres.SELECT(
+		table.SignIn.AllColumns,
+	).FROM(
+		table.SignIn,
+	).WHERE(
+		table.SignIn.AccountID.EQ(postgres.UUID(accountID)),
+	).ORDER_BY(
+		table.SignIn.CreatedAt.DESC(),
+	)
+
+	var dbSignIns []model.SignIn
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbSignIns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SignIns: %w", err)
+	}
+
+	signIns := make([]SignInModel, len(dbSignIns))
+	for i, dbSignIn := range dbSignIns {
+		signIns[i] = *mapSignInToModel(dbSignIn)
+	}
+
+	return signIns, nil
+}
+
+// Helper function to map SignIn model to SignInModel
+func mapSignInToModel(signIn model.SignIn) *SignInModel {
+	return &SignInModel{
+		ID:        signIn.ID,
+		AccountID: signIn.AccountID,
+		Method:    signIn.Method,
+		EOA:       signIn.Eoa,
+		CreatedAt: signIn.CreatedAt,
+	}
+}
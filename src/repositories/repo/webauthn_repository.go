@@ -0,0 +1,267 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"yip/.gen/slyip/slyip/model"
+	"yip/.gen/slyip/slyip/table"
+)
+
+// WebAuthnRepository handles the passkeys of accounts and their pending
+// registration and login ceremonies
+type WebAuthnRepository struct {
+	db *Database
+}
+
+// NewWebAuthnRepository creates a new WebAuthn repository
+func NewWebAuthnRepository(db *Database) *WebAuthnRepository {
+	return &WebAuthnRepository{
+		db: db,
+	}
+}
+
+// CreateCredential stores a registered passkey
+func (r *WebAuthnRepository) CreateCredential(ctx context.Context, credential *WebAuthnCredentialModel) (*WebAuthnCredentialModel, error) {
+	credential.ID = uuid.New()
+	credential.CreatedAt = time.Now()
+
+	stmt := table.WebauthnCredential.INSERT(
+		table.WebauthnCredential.ID,
+		table.WebauthnCredential.AccountID,
+		table.WebauthnCredential.CredentialID,
+		table.WebauthnCredential.PublicKey,
+		table.WebauthnCredential.Algorithm,
+		table.WebauthnCredential.SignCount,
+		table.WebauthnCredential.Aaguid,
+		table.WebauthnCredential.Label,
+		table.WebauthnCredential.CreatedAt,
+	).VALUES(
+		postgres.UUID(credential.ID),
+		postgres.UUID(credential.AccountID),
+		postgres.String(credential.CredentialID),
+		postgres.String(base64.RawURLEncoding.EncodeToString(credential.PublicKey)),
+		postgres.Int(int64(credential.Algorithm)),
+		postgres.Int(int64(credential.SignCount)),
+		postgres.String(credential.AAGUID),
+		postgres.String(credential.Label),
+		postgres.TimestampzT(credential.CreatedAt),
+	).RETURNING(
+		table.WebauthnCredential.AllColumns,
+	)
+
+	var dbCredential model.WebauthnCredential
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbCredential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WebauthnCredential: %w", err)
+	}
+
+	return mapWebAuthnCredentialToModel(dbCredential)
+}
+
+// GetCredentialByCredentialID retrieves the passkey of a credential id
+func (r *WebAuthnRepository) GetCredentialByCredentialID(ctx context.Context, credentialID string) (*WebAuthnCredentialModel, error) {
+	stmt := postgres.SELECT(
+		table.WebauthnCredential.AllColumns,
+	).FROM(
+		table.WebauthnCredential,
+	).WHERE(
+		table.WebauthnCredential.CredentialID.EQ(postgres.String(credentialID)),
+	)
+
+	var dbCredential model.WebauthnCredential
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbCredential)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get WebauthnCredential: %w", err)
+	}
+
+	return mapWebAuthnCredentialToModel(dbCredential)
+}
+
+// GetCredentialsByAccountId retrieves the passkeys of an account, oldest first
+func (r *WebAuthnRepository) GetCredentialsByAccountId(ctx context.Context, accountId uuid.UUID) ([]*WebAuthnCredentialModel, error) {
+	stmt := postgres.SELECT(
+		table.WebauthnCredential.AllColumns,
+	).FROM(
+		table.WebauthnCredential,
+	).WHERE(
+		table.WebauthnCredential.AccountID.EQ(postgres.UUID(accountId)),
+	).ORDER_BY(
+		table.WebauthnCredential.CreatedAt.ASC(),
+	)
+
+	var dbCredentials []model.WebauthnCredential
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbCredentials)
+	if err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get WebauthnCredentials: %w", err)
+	}
+
+	credentials := make([]*WebAuthnCredentialModel, 0, len(dbCredentials))
+	for _, dbCredential := range dbCredentials {
+		credential, err := mapWebAuthnCredentialToModel(dbCredential)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, nil
+}
+
+// UseCredential records a login with a passkey and the new signature counter
+// of its authenticator. It returns DBItemNotFound if the counter changed
+// since the passkey was read, a concurrent login used it.
+func (r *WebAuthnRepository) UseCredential(ctx context.Context, id uuid.UUID, previousSignCount uint32, signCount uint32, at time.Time) error {
+	stmt := table.WebauthnCredential.UPDATE().
+		SET(
+			table.WebauthnCredential.SignCount.SET(postgres.Int(int64(signCount))),
+			table.WebauthnCredential.LastUsedAt.SET(postgres.TimestampzT(at)),
+		).WHERE(
+		table.WebauthnCredential.ID.EQ(postgres.UUID(id)).
+			AND(table.WebauthnCredential.SignCount.EQ(postgres.Int(int64(previousSignCount)))),
+	)
+
+	return execAffectingRows(ctx, r.db.GetDB(), stmt, "failed to use WebauthnCredential")
+}
+
+// DeleteCredential removes a passkey of an account. It returns DBItemNotFound
+// if the account has no such passkey.
+func (r *WebAuthnRepository) DeleteCredential(ctx context.Context, accountId uuid.UUID, id uuid.UUID) error {
+	stmt := table.WebauthnCredential.DELETE().WHERE(
+		table.WebauthnCredential.ID.EQ(postgres.UUID(id)).
+			AND(table.WebauthnCredential.AccountID.EQ(postgres.UUID(accountId))),
+	)
+
+	return execAffectingRows(ctx, r.db.GetDB(), stmt, "failed to delete WebauthnCredential")
+}
+
+// CreateChallenge stores the challenge of a ceremony
+func (r *WebAuthnRepository) CreateChallenge(ctx context.Context, challenge *WebAuthnChallengeModel) (*WebAuthnChallengeModel, error) {
+	challenge.ID = uuid.New()
+	challenge.CreatedAt = time.Now()
+
+	accountId := postgres.Expression(postgres.NULL)
+	if challenge.AccountID != nil {
+		accountId = postgres.UUID(*challenge.AccountID)
+	}
+
+	stmt := table.WebauthnChallenge.INSERT(
+		table.WebauthnChallenge.ID,
+		table.WebauthnChallenge.ChallengeHash,
+		table.WebauthnChallenge.AccountID,
+		table.WebauthnChallenge.Purpose,
+		table.WebauthnChallenge.Audiences,
+		table.WebauthnChallenge.ExpiresAt,
+		table.WebauthnChallenge.CreatedAt,
+	).VALUES(
+		postgres.UUID(challenge.ID),
+		postgres.String(challenge.ChallengeHash),
+		accountId,
+		postgres.String(challenge.Purpose),
+		postgres.String(strings.Join(challenge.Audiences, " ")),
+		postgres.TimestampzT(challenge.ExpiresAt),
+		postgres.TimestampzT(challenge.CreatedAt),
+	).RETURNING(
+		table.WebauthnChallenge.AllColumns,
+	)
+
+	var dbChallenge model.WebauthnChallenge
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbChallenge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WebauthnChallenge: %w", err)
+	}
+
+	return mapWebAuthnChallengeToModel(dbChallenge), nil
+}
+
+// UseChallenge uses up the challenge of a ceremony and returns it. It returns
+// DBItemNotFound if there is no such challenge of purpose or it was used or
+// has expired.
+func (r *WebAuthnRepository) UseChallenge(ctx context.Context, challengeHash string, purpose string, at time.Time) (*WebAuthnChallengeModel, error) {
+	stmt := table.WebauthnChallenge.UPDATE().
+		SET(
+			table.WebauthnChallenge.UsedAt.SET(postgres.TimestampzT(at)),
+		).WHERE(
+		table.WebauthnChallenge.ChallengeHash.EQ(postgres.String(challengeHash)).
+			AND(table.WebauthnChallenge.Purpose.EQ(postgres.String(purpose))).
+			AND(table.WebauthnChallenge.UsedAt.IS_NULL()).
+			AND(table.WebauthnChallenge.ExpiresAt.GT(postgres.TimestampzT(at))),
+	).RETURNING(
+		table.WebauthnChallenge.AllColumns,
+	)
+
+	var dbChallenge model.WebauthnChallenge
+	err := stmt.QueryContext(ctx, r.db.GetDB(), &dbChallenge)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, DBItemNotFound
+		}
+		return nil, fmt.Errorf("failed to use WebauthnChallenge: %w", err)
+	}
+
+	return mapWebAuthnChallengeToModel(dbChallenge), nil
+}
+
+// deleteWebAuthn removes the passkeys and registration challenges of an account
+func deleteWebAuthn(ctx context.Context, tx *sql.Tx, accountID uuid.UUID) error {
+	credentialStmt := table.WebauthnCredential.DELETE().WHERE(
+		table.WebauthnCredential.AccountID.EQ(postgres.UUID(accountID)),
+	)
+	if _, err := credentialStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete WebauthnCredentials: %w", err)
+	}
+
+	challengeStmt := table.WebauthnChallenge.DELETE().WHERE(
+		table.WebauthnChallenge.AccountID.EQ(postgres.UUID(accountID)),
+	)
+	if _, err := challengeStmt.ExecContext(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete WebauthnChallenges: %w", err)
+	}
+	return nil
+}
+
+// Helper function to map WebauthnCredential model to WebAuthnCredentialModel
+func mapWebAuthnCredentialToModel(credential model.WebauthnCredential) (*WebAuthnCredentialModel, error) {
+	publicKey, err := base64.RawURLEncoding.DecodeString(credential.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key of WebauthnCredential: %w", err)
+	}
+
+	return &WebAuthnCredentialModel{
+		ID:           credential.ID,
+		AccountID:    credential.AccountID,
+		CredentialID: credential.CredentialID,
+		PublicKey:    publicKey,
+		Algorithm:    int(credential.Algorithm),
+		SignCount:    uint32(credential.SignCount),
+		AAGUID:       credential.Aaguid,
+		Label:        credential.Label,
+		CreatedAt:    credential.CreatedAt,
+		LastUsedAt:   credential.LastUsedAt,
+	}, nil
+}
+
+// Helper function to map WebauthnChallenge model to WebAuthnChallengeModel
+func mapWebAuthnChallengeToModel(challenge model.WebauthnChallenge) *WebAuthnChallengeModel {
+	return &WebAuthnChallengeModel{
+		ID:            challenge.ID,
+		ChallengeHash: challenge.ChallengeHash,
+		AccountID:     challenge.AccountID,
+		Purpose:       challenge.Purpose,
+		Audiences:     strings.Fields(challenge.Audiences),
+		ExpiresAt:     challenge.ExpiresAt,
+		UsedAt:        challenge.UsedAt,
+		CreatedAt:     challenge.CreatedAt,
+	}
+}
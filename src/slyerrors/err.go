@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Kind can be used to convert from lperr.Error to an HTTP error code for example.
@@ -53,6 +54,7 @@ const (
 	KindNotFound                        // NotFound
 	KindConflict                        // Conflict
 	KindUnprocessableEntity             // UnprocessabeEntity
+	KindTooManyRequests                 // TooManyRequests
 )
 
 // Error can be used as error or *Error, and supports JSON encoding.
@@ -62,6 +64,7 @@ type Error struct {
 	Message    string               `json:"message"`                 // contains the root message only
 	Details    string               `json:"details"`                 // contains the whole chain
 	Validation map[string][2]string `json:"invalidFields,omitempty"` // key is a field name, value is a validation code and a message
+	RetryAfter int                  `json:"retryAfter,omitempty"`    // seconds until a TooManyRequests error may be retried
 }
 
 // ValidationCodes are a middleware but basic collection of codes that you can use in most scenarios.
@@ -73,6 +76,8 @@ const (
 	ValidationCodeInvalidUUID                  = "invalidUUID"
 	ValidationCodeStringEmpty                  = "stringEmpty"
 	ValidationCodeNotEthAddress                = "notEthAddress"
+	ValidationCodeNotE164Phone                 = "notE164Phone"
+	ValidationCodeNotEmail                     = "notEmail"
 	ValidationCodeListEmpty                    = "listEmpty"
 	ValidationCodeStringNotInList              = "stringNotInList"
 	ValidationCodeStringTooLong                = "stringTooLong"
@@ -200,3 +205,16 @@ func NotFound(code string, msg string, args ...interface{}) *Error {
 func Conflict(code string, msg string, args ...interface{}) *Error {
 	return New(KindConflict, code, "conflict", msg, args...)
 }
+
+func TooManyRequests(code string, msg string, args ...interface{}) *Error {
+	return New(KindTooManyRequests, code, "too many requests", msg, args...)
+}
+
+// WithRetryAfter sets the seconds until the request may be retried, rounded up
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	e.RetryAfter = int((d + time.Second - 1) / time.Second)
+	if e.RetryAfter < 1 {
+		e.RetryAfter = 1
+	}
+	return e
+}
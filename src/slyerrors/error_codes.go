@@ -13,6 +13,42 @@ const (
 	ErrCodeCantCreateOrGetAccount              = "400010"
 	ErrCodeParsingUUID                         = "400011"
 	ErrCodeCantCreateToken                     = "400012"
+	ErrCodeDeviceNotOfAccount                  = "400013"
+	ErrCodePinLocked                           = "400014"
+	ErrCodePinRateLimited                      = "400015"
+	ErrCodeEmailTaken                          = "400016"
+	ErrCodeEmailUnchanged                      = "400017"
+	ErrCodeEmailChangeNotFound                 = "400018"
+	ErrCodeWrongEmailChangeCode                = "400019"
+	ErrCodeWalletNotOfAccount                  = "400020"
+	ErrCodeCurrentDevice                       = "400021"
+	ErrCodeTokenRevoked                        = "400022"
+	ErrCodeAccountDeleted                      = "400023"
+	ErrCodeKeyOfOtherAccount                   = "400024"
+	ErrCodeAccountLinkNotFound                 = "400025"
+	ErrCodeAccountLinkSelf                     = "400026"
+	ErrCodeAccountLinkNotConfirmed             = "400027"
+	ErrCodeMergeTargetNotLinked                = "400028"
+	ErrCodePasswordResetNotFound               = "400029"
+	ErrCodeWrongPassword                       = "400030"
+	ErrCodeNoPassword                          = "400031"
+	ErrCodeMFAChallengeNotFound                = "400032"
+	ErrCodeWrongMFACode                        = "400033"
+	ErrCodeMFAAlreadyEnrolled                  = "400034"
+	ErrCodeMFANotEnrolled                      = "400035"
+	ErrCodeMFARequired                         = "400036"
+	ErrCodeWebAuthnNotConfigured               = "400037"
+	ErrCodeWebAuthnChallengeNotFound           = "400038"
+	ErrCodeWebAuthnCredentialNotFound          = "400039"
+	ErrCodeWebAuthnVerificationFailed          = "400040"
+	ErrCodeWebAuthnCredentialExists            = "400041"
+	ErrCodeWrongTokenType                      = "400042"
+	ErrCodePushRateLimited                     = "400043"
+	ErrCodeReauthenticationRequired            = "400044"
+	ErrCodeEmailChangeRateLimited              = "400045"
+	ErrCodePasswordResetRateLimited            = "400046"
+	ErrCodeRelayRateLimited                    = "400047"
+	ErrCodeMFALocked                           = "400048"
 	ErrCodeCantCreateTransactor                = "500001"
 	ErrCodeCantEstimateGasPrice                = "500002"
 	ErrCodeCantDetermineNonce                  = "500003"
@@ -33,5 +69,18 @@ const (
 	ErrCodeSessionWrongSessionType             = "600007"
 	ErrCodeSessionCantCreateSIWEMessage        = "600008"
 	ErrCodeSessionMessageTypeUnknown           = "600009"
+	ErrCodeSignRequestInvalid                  = "600010"
+	ErrCodeSignRequestExpired                  = "600011"
+	ErrCodeSignRequestRejected                 = "600012"
+	ErrCodeSignRequestRelayFailed              = "600013"
+	ErrCodeSessionUnsupportedVersion           = "600014"
+	ErrCodeSessionE2ENotEnabled                = "600015"
+	ErrCodeSessionEnded                        = "600016"
+	ErrCodeSessionNumberMatchRequired          = "600017"
+	ErrCodeSessionNumberMismatch               = "600018"
+	ErrCodeSessionTokenIssued                  = "600019"
+	ErrCodeSignRequestWrongSigner              = "600020"
+	ErrCodeLinkDeviceNotProven                 = "600021"
+	ErrCodeSessionE2ELimitReached              = "600022"
 	ErrCodeUnknown                             = "unknown"
 )
@@ -1,8 +1,10 @@
 package slyerrors
 
 import (
+	"net/mail"
 	"regexp"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
@@ -98,6 +100,20 @@ func (v *Validation) ValidateNotEmpty(field, value string) *Validation {
 	return v
 }
 
+func (v *Validation) ValidateMaxLength(field, value string, max int) *Validation {
+	if utf8.RuneCountInString(value) > max {
+		v.Add(field, ValidationCodeStringTooLong, "at most %d characters", max)
+	}
+	return v
+}
+
+func (v *Validation) ValidateMinLength(field, value string, min int) *Validation {
+	if utf8.RuneCountInString(value) < min {
+		v.Add(field, ValidationCodeStringTooShort, "at least %d characters", min)
+	}
+	return v
+}
+
 func (v *Validation) ValidateAtLeastOneElement(field string, value []string) *Validation {
 	if len(value) == 0 {
 		v.Add(field, ValidationCodeStringEmpty, "")
@@ -112,6 +128,20 @@ func (v *Validation) ValidateEthAddress(field, value string) *Validation {
 	return v
 }
 
+func (v *Validation) ValidatePhone(field, value string) *Validation {
+	if !IsValidPhone(value) {
+		v.Add(field, ValidationCodeNotE164Phone, "")
+	}
+	return v
+}
+
+func (v *Validation) ValidateEmail(field, value string) *Validation {
+	if !IsValidEmail(value) {
+		v.Add(field, ValidationCodeNotEmail, "")
+	}
+	return v
+}
+
 func (v *Validation) ValidateInList(field, value string, list []string) *Validation {
 	isInList := false
 	for _, l := range list {
@@ -145,3 +175,15 @@ func IsValidEthAddress(addr string) bool {
 	re := regexp.MustCompile("^0x[0-9a-fA-F]{40}$")
 	return re.MatchString(addr)
 }
+
+// IsValidPhone checks that phone is an E.164 number, e.g. +4915112345678
+func IsValidPhone(phone string) bool {
+	re := regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+	return re.MatchString(phone)
+}
+
+// IsValidEmail checks that email is a bare address, e.g. some@email.com
+func IsValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
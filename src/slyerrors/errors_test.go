@@ -2,6 +2,7 @@ package slyerrors
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -19,3 +20,15 @@ func TestValidation(t *testing.T) {
 
 	assert.Equal(t, "error: kind Validation (4), code: stringEmpty, message: invalid request, invalid fields: [empty: code: stringEmpty | msg: ]|[subfield.empty2: code: stringEmpty | msg: ]", errstr)
 }
+
+func TestIsValidEmail(t *testing.T) {
+	assert.True(t, IsValidEmail("some@email.com"))
+	assert.False(t, IsValidEmail(""))
+	assert.False(t, IsValidEmail("some.email.com"))
+	assert.False(t, IsValidEmail("Some One <some@email.com>"))
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, 2, TooManyRequests("", "").WithRetryAfter(1500*time.Millisecond).RetryAfter)
+	assert.Equal(t, 1, TooManyRequests("", "").WithRetryAfter(0).RetryAfter)
+}
@@ -17,11 +17,12 @@ func _() {
 	_ = x[KindNotFound-6]
 	_ = x[KindConflict-7]
 	_ = x[KindUnprocessableEntity-8]
+	_ = x[KindTooManyRequests-9]
 }
 
-const _Kind_name = "UnknownUnexpectedUnauthorizedForbiddenValidationBadRequestNotFoundConflictUnprocessabeEntity"
+const _Kind_name = "UnknownUnexpectedUnauthorizedForbiddenValidationBadRequestNotFoundConflictUnprocessabeEntityTooManyRequests"
 
-var _Kind_index = [...]uint8{0, 7, 17, 29, 38, 48, 58, 66, 74, 92}
+var _Kind_index = [...]uint8{0, 7, 17, 29, 38, 48, 58, 66, 74, 92, 107}
 
 func (i Kind) String() string {
 	if i < 0 || i >= Kind(len(_Kind_index)-1) {
@@ -36,3 +36,9 @@ func MapJetError(err error, msg string) error {
 func IsNoRowsError(err error) bool {
 	return strings.Contains(err.Error(), "no rows in result set")
 }
+
+// IsUniqueViolation reports if err is caused by a violated unique constraint
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}